@@ -0,0 +1,224 @@
+// Package alerts alerts administrators about devices which have gone offline and about devices which have recovered.
+package alerts
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/mail"
+	"net/smtp"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/google/uuid"
+
+	"gitlab.com/timeterm/timeterm/backend/database"
+	"gitlab.com/timeterm/timeterm/backend/messages"
+)
+
+const (
+	// checkInterval is the interval in which devices are checked.
+	checkInterval = time.Minute
+	// notifyTimeout is the maximum time a webhook or mail server may take to accept a notification.
+	notifyTimeout = 10 * time.Second
+)
+
+type EventType string
+
+const (
+	EventTypeDeviceOffline   EventType = "device.offline"
+	EventTypeDeviceRecovered EventType = "device.recovered"
+)
+
+// Event is sent to the webhook of the organization.
+type Event struct {
+	Type           EventType `json:"type"`
+	OrganizationID uuid.UUID `json:"organizationId"`
+	DeviceID       uuid.UUID `json:"deviceId"`
+	DeviceName     string    `json:"deviceName"`
+	LastHeartbeat  time.Time `json:"lastHeartbeat"`
+}
+
+type smtpConfig struct {
+	addr     string
+	from     string
+	username string
+	password string
+}
+
+func smtpConfigFromEnv() smtpConfig {
+	return smtpConfig{
+		addr:     os.Getenv("SMTP_ADDR"),
+		from:     os.Getenv("SMTP_FROM"),
+		username: os.Getenv("SMTP_USERNAME"),
+		password: os.Getenv("SMTP_PASSWORD"),
+	}
+}
+
+type Wrapper struct {
+	log  logr.Logger
+	dbw  *database.Wrapper
+	msgw *messages.Wrapper
+	http *http.Client
+	smtp smtpConfig
+}
+
+// NewWrapper creates a new alerts wrapper. Emails are only sent if SMTP_ADDR and SMTP_FROM are set.
+func NewWrapper(log logr.Logger, dbw *database.Wrapper, msgw *messages.Wrapper) *Wrapper {
+	return &Wrapper{
+		log:  log.WithName("AlertsWrapper"),
+		dbw:  dbw,
+		msgw: msgw,
+		http: newWebhookClient(),
+		smtp: smtpConfigFromEnv(),
+	}
+}
+
+// Run checks for offline and recovered devices until the context is canceled.
+func (w *Wrapper) Run(ctx context.Context) error {
+	ticker := time.NewTicker(checkInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			w.check(ctx)
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func (w *Wrapper) check(ctx context.Context) {
+	offline, err := w.dbw.ClaimOfflineDeviceAlerts(ctx)
+	if err != nil {
+		w.log.Error(err, "could not get offline devices")
+	}
+	for _, alert := range offline {
+		w.msgw.Start(alert.OrganizationID).
+			Error().
+			Summaryf("Device %q is offline", alert.DeviceName).
+			Messagef("Device %q has not sent a heartbeat since %s.",
+				alert.DeviceName, alert.LastHeartbeat.Format(time.RFC1123),
+			).
+			WithField("deviceId", alert.DeviceID).
+			Log()
+
+		w.notify(ctx, EventTypeDeviceOffline, alert)
+	}
+
+	recovered, err := w.dbw.ClaimRecoveredDeviceAlerts(ctx)
+	if err != nil {
+		w.log.Error(err, "could not get recovered devices")
+	}
+	for _, alert := range recovered {
+		w.msgw.Start(alert.OrganizationID).
+			Info().
+			Summaryf("Device %q is back online", alert.DeviceName).
+			Messagef("Device %q has sent a heartbeat again.", alert.DeviceName).
+			WithField("deviceId", alert.DeviceID).
+			Log()
+
+		w.notify(ctx, EventTypeDeviceRecovered, alert)
+	}
+}
+
+// notify sends an alert to the webhook and email address of the organization, if they are configured.
+func (w *Wrapper) notify(ctx context.Context, typ EventType, alert database.DeviceAlert) {
+	log := w.log.WithValues("organizationId", alert.OrganizationID, "deviceId", alert.DeviceID, "type", typ)
+
+	org, err := w.dbw.GetOrganization(ctx, alert.OrganizationID)
+	if err != nil {
+		log.Error(err, "could not get organization")
+		return
+	}
+
+	ev := Event{
+		Type:           typ,
+		OrganizationID: alert.OrganizationID,
+		DeviceID:       alert.DeviceID,
+		DeviceName:     alert.DeviceName,
+		LastHeartbeat:  alert.LastHeartbeat,
+	}
+
+	if org.DeviceAlertWebhookURL.Valid {
+		if err = w.sendWebhook(ctx, org.DeviceAlertWebhookURL.String, ev); err != nil {
+			log.Error(err, "could not send alert to webhook")
+		}
+	}
+	if org.DeviceAlertEmail.Valid && w.smtp.addr != "" && w.smtp.from != "" {
+		if err = w.sendEmail(org.DeviceAlertEmail.String, ev); err != nil {
+			log.Error(err, "could not send alert by email")
+		}
+	}
+}
+
+func (w *Wrapper) sendWebhook(ctx context.Context, url string, ev Event) error {
+	body, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	rsp, err := w.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer rsp.Body.Close()
+
+	if rsp.StatusCode < 200 || rsp.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded with status %d", rsp.StatusCode)
+	}
+	return nil
+}
+
+func (w *Wrapper) sendEmail(to string, ev Event) error {
+	var subject, text string
+	switch ev.Type {
+	case EventTypeDeviceOffline:
+		subject = fmt.Sprintf("Device %q is offline", ev.DeviceName)
+		text = fmt.Sprintf("Device %q has not sent a heartbeat since %s.",
+			ev.DeviceName, ev.LastHeartbeat.Format(time.RFC1123),
+		)
+	case EventTypeDeviceRecovered:
+		subject = fmt.Sprintf("Device %q is back online", ev.DeviceName)
+		text = fmt.Sprintf("Device %q has sent a heartbeat again.", ev.DeviceName)
+	}
+
+	// Device names are chosen by administrators, so line breaks must not end up in the headers.
+	subject = strings.NewReplacer("\r", "", "\n", "").Replace(subject)
+
+	// The addresses may contain a display name, which can't be used for the SMTP envelope.
+	toAddr, err := mail.ParseAddress(to)
+	if err != nil {
+		return fmt.Errorf("invalid recipient address: %w", err)
+	}
+	fromAddr, err := mail.ParseAddress(w.smtp.from)
+	if err != nil {
+		return fmt.Errorf("invalid sender address: %w", err)
+	}
+
+	msg := fmt.Sprintf("From: %s\r\nTo: %s\r\nSubject: Timeterm: %s\r\n\r\n%s\r\n",
+		fromAddr, toAddr, subject, text,
+	)
+
+	var auth smtp.Auth
+	if w.smtp.username != "" {
+		host := w.smtp.addr
+		if i := strings.LastIndexByte(host, ':'); i != -1 {
+			host = host[:i]
+		}
+		auth = smtp.PlainAuth("", w.smtp.username, w.smtp.password, host)
+	}
+
+	return smtp.SendMail(w.smtp.addr, auth, fromAddr.Address, []string{toAddr.Address}, []byte(msg))
+}
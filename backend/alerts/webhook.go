@@ -0,0 +1,111 @@
+package alerts
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"syscall"
+	"time"
+)
+
+// blockedWebhookNets are the networks which webhooks can't be sent to, besides loopback, link-local and
+// unspecified addresses. Administrators choose the webhook URL, so they must not be able to reach
+// services in the network of the backend with it.
+var blockedWebhookNets = mustParseCIDRs(
+	"0.0.0.0/8",
+	"10.0.0.0/8",
+	"100.64.0.0/10",
+	"172.16.0.0/12",
+	"192.168.0.0/16",
+	"198.18.0.0/15",
+	"fc00::/7",
+)
+
+func mustParseCIDRs(cidrs ...string) []*net.IPNet {
+	nets := make([]*net.IPNet, len(cidrs))
+	for i, cidr := range cidrs {
+		_, n, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		nets[i] = n
+	}
+	return nets
+}
+
+var errWebhookAddressNotAllowed = errors.New("webhook address is not allowed")
+
+func checkWebhookIP(ip net.IP) error {
+	if ip.IsLoopback() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsMulticast() || ip.IsUnspecified() {
+		return errWebhookAddressNotAllowed
+	}
+	for _, n := range blockedWebhookNets {
+		if n.Contains(ip) {
+			return errWebhookAddressNotAllowed
+		}
+	}
+	return nil
+}
+
+// newWebhookClient creates an HTTP client which refuses to connect to addresses that checkWebhookIP rejects.
+// The address is checked when connecting instead of when resolving the host name, so the host name
+// can't resolve to another address in the meantime. This also applies to redirects.
+func newWebhookClient() *http.Client {
+	dialer := &net.Dialer{
+		Timeout: notifyTimeout,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			ip := net.ParseIP(host)
+			if ip == nil {
+				return fmt.Errorf("invalid address %q", address)
+			}
+			return checkWebhookIP(ip)
+		},
+	}
+
+	return &http.Client{
+		Timeout: notifyTimeout,
+		Transport: &http.Transport{
+			// A proxy would connect to the webhook instead of the dialer, so it is not used.
+			Proxy:               nil,
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: notifyTimeout,
+			MaxIdleConns:        10,
+			IdleConnTimeout:     90 * time.Second,
+		},
+	}
+}
+
+// CheckWebhookURL checks if alerts can be sent to a webhook URL. Sending alerts checks the address again,
+// as the host name may resolve to another address by then.
+func CheckWebhookURL(ctx context.Context, rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return err
+	}
+	if (u.Scheme != "https" && u.Scheme != "http") || u.Hostname() == "" {
+		return errors.New("webhook URL must be an absolute http(s) URL")
+	}
+
+	if ip := net.ParseIP(u.Hostname()); ip != nil {
+		return checkWebhookIP(ip)
+	}
+
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, u.Hostname())
+	if err != nil {
+		return fmt.Errorf("could not resolve webhook host: %w", err)
+	}
+	for _, addr := range addrs {
+		if err = checkWebhookIP(addr.IP); err != nil {
+			return err
+		}
+	}
+	return nil
+}
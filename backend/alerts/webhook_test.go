@@ -0,0 +1,44 @@
+package alerts
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckWebhookIP(t *testing.T) {
+	for _, ip := range []string{
+		"127.0.0.1", "::1", "10.1.2.3", "172.16.0.1", "192.168.1.1", "169.254.169.254",
+		"fe80::1", "fd00::1", "0.0.0.0", "::ffff:127.0.0.1",
+	} {
+		assert.Error(t, checkWebhookIP(net.ParseIP(ip)), ip)
+	}
+	for _, ip := range []string{"203.0.113.1", "2001:db8::1"} {
+		assert.NoError(t, checkWebhookIP(net.ParseIP(ip)), ip)
+	}
+}
+
+func TestCheckWebhookURL(t *testing.T) {
+	ctx := context.Background()
+
+	assert.NoError(t, CheckWebhookURL(ctx, "https://203.0.113.1/hook"))
+	assert.Error(t, CheckWebhookURL(ctx, "http://127.0.0.1:8200/v1/secret"))
+	assert.Error(t, CheckWebhookURL(ctx, "http://[::1]/"))
+	assert.Error(t, CheckWebhookURL(ctx, "ftp://203.0.113.1/"))
+	assert.Error(t, CheckWebhookURL(ctx, "/relative"))
+}
+
+func TestWebhookClient(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	// The test server listens on a loopback address.
+	_, err := newWebhookClient().Get(srv.URL)
+	assert.Error(t, err)
+}
@@ -0,0 +1,449 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo"
+
+	authn "gitlab.com/timeterm/timeterm/backend/auhtn"
+	"gitlab.com/timeterm/timeterm/backend/database"
+)
+
+// maxAnnouncementTextLength is the maximum length of the text of an announcement in characters.
+const maxAnnouncementTextLength = 2000
+
+// announcementFromRequest validates the request and converts it to an announcement in the organization.
+func (s *Server) announcementFromRequest(c echo.Context,
+	organizationID uuid.UUID,
+	req CreateAnnouncementRequest,
+) (database.Announcement, error) {
+	req.Text = strings.TrimSpace(req.Text)
+	if req.Text == "" {
+		return database.Announcement{}, echo.NewHTTPError(http.StatusBadRequest, "Text is required")
+	}
+	if utf8.RuneCountInString(req.Text) > maxAnnouncementTextLength {
+		return database.Announcement{}, echo.NewHTTPError(http.StatusBadRequest, "Text is too long")
+	}
+
+	severity, ok := AnnouncementSeverityToDB(req.Severity)
+	if !ok {
+		return database.Announcement{}, echo.NewHTTPError(http.StatusBadRequest, "Invalid severity")
+	}
+
+	startsAt := time.Now()
+	if req.StartsAt != nil {
+		startsAt = *req.StartsAt
+	}
+	if req.EndsAt != nil && !req.EndsAt.After(startsAt) {
+		return database.Announcement{}, echo.NewHTTPError(http.StatusBadRequest, "End must be after start")
+	}
+
+	if req.AllDevices {
+		req.DeviceGroupIDs = nil
+	} else if len(req.DeviceGroupIDs) == 0 {
+		return database.Announcement{}, echo.NewHTTPError(http.StatusBadRequest, "No device groups")
+	}
+	for _, groupID := range req.DeviceGroupIDs {
+		if err := s.checkDeviceGroup(c.Request().Context(), organizationID, groupID); err != nil {
+			return database.Announcement{}, err
+		}
+	}
+
+	return database.Announcement{
+		OrganizationID: organizationID,
+		Text:           req.Text,
+		Severity:       severity,
+		StartsAt:       startsAt,
+		EndsAt:         TimePtrToDB(req.EndsAt),
+		AllDevices:     req.AllDevices,
+		GroupIDs:       req.DeviceGroupIDs,
+	}, nil
+}
+
+func (s *Server) announcementFromParam(c echo.Context, user database.User) (database.Announcement, error) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return database.Announcement{}, echo.NewHTTPError(http.StatusBadRequest, "Invalid ID")
+	}
+
+	announcement, err := s.db.GetAnnouncement(c.Request().Context(), id)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		s.log.Error(err, "could not get announcement")
+		return announcement, echo.NewHTTPError(http.StatusInternalServerError, "Could not read announcement from database")
+	}
+	if errors.Is(err, sql.ErrNoRows) || announcement.OrganizationID != user.OrganizationID {
+		return announcement, echo.NewHTTPError(http.StatusNotFound, "Announcement not found")
+	}
+	return announcement, nil
+}
+
+// announcementChanged sends the (stored) announcement to the devices which it is shown on,
+// and tells the devices in oldDeviceIDs which it is no longer shown on to remove it.
+func (s *Server) announcementChanged(ctx context.Context, id uuid.UUID, oldDeviceIDs []uuid.UUID) {
+	announcement, err := s.db.GetAnnouncement(ctx, id)
+	if err != nil {
+		s.log.Error(err, "could not get announcement", "announcementId", id)
+		return
+	}
+
+	deviceIDs, err := s.db.GetAnnouncementDeviceIDs(ctx, id)
+	if err != nil {
+		s.log.Error(err, "could not get devices of announcement", "announcementId", id)
+		return
+	}
+
+	shownOn := make(map[uuid.UUID]struct{}, len(deviceIDs))
+	for _, deviceID := range deviceIDs {
+		shownOn[deviceID] = struct{}{}
+	}
+
+	var removedFrom []uuid.UUID
+	for _, deviceID := range oldDeviceIDs {
+		if _, ok := shownOn[deviceID]; !ok {
+			removedFrom = append(removedFrom, deviceID)
+		}
+	}
+
+	s.mqw.AnnouncementChanged(announcement, deviceIDs, removedFrom)
+}
+
+// devicesChangedGroup sends the announcements which are shown on devices which have moved to newGroupID
+// (and weren't shown on them in their old group), and tells the devices to remove the announcements
+// in oldAnnouncements which are no longer shown on them.
+func (s *Server) devicesChangedGroup(ctx context.Context,
+	organizationID uuid.UUID,
+	deviceIDs []uuid.UUID,
+	oldAnnouncements []database.Announcement,
+	newGroupID *uuid.UUID,
+) {
+	announcements, err := s.db.GetAnnouncementsForDevice(ctx, organizationID, newGroupID, time.Now())
+	if err != nil {
+		s.log.Error(err, "could not get announcements of group", "groupId", newGroupID)
+		return
+	}
+
+	shownBefore := make(map[uuid.UUID]struct{}, len(oldAnnouncements))
+	for _, a := range oldAnnouncements {
+		shownBefore[a.ID] = struct{}{}
+	}
+	shown := make(map[uuid.UUID]struct{}, len(announcements))
+	for _, a := range announcements {
+		shown[a.ID] = struct{}{}
+		if _, ok := shownBefore[a.ID]; !ok {
+			s.mqw.AnnouncementChanged(a, deviceIDs, nil)
+		}
+	}
+	for _, a := range oldAnnouncements {
+		if _, ok := shown[a.ID]; !ok {
+			s.mqw.AnnouncementRemoved(a.ID, deviceIDs)
+		}
+	}
+}
+
+func (s *Server) getAnnouncements(c echo.Context) error {
+	user, ok := authn.UserFromContext(c)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Not authenticated")
+	}
+
+	announcements, err := s.db.GetAnnouncements(c.Request().Context(), user.OrganizationID)
+	if err != nil {
+		s.log.Error(err, "could not get announcements")
+		return echo.NewHTTPError(http.StatusInternalServerError, "Could not read announcements from database")
+	}
+
+	return c.JSON(http.StatusOK, AnnouncementsFrom(announcements))
+}
+
+func (s *Server) getAnnouncement(c echo.Context) error {
+	user, ok := authn.UserFromContext(c)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Not authenticated")
+	}
+
+	announcement, err := s.announcementFromParam(c, user)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, AnnouncementFrom(announcement))
+}
+
+func (s *Server) createAnnouncement(c echo.Context) error {
+	user, ok := authn.UserFromContext(c)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Not authenticated")
+	}
+
+	var req CreateAnnouncementRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Could not bind data")
+	}
+
+	announcement, err := s.announcementFromRequest(c, user.OrganizationID, req)
+	if err != nil {
+		return err
+	}
+	announcement.CreatedByUserID = &user.ID
+
+	ctx := c.Request().Context()
+
+	announcement, err = s.db.CreateAnnouncement(ctx, announcement)
+	if err != nil {
+		s.log.Error(err, "could not create announcement")
+		return echo.NewHTTPError(http.StatusInternalServerError, "Could not create announcement")
+	}
+	s.announcementChanged(ctx, announcement.ID, nil)
+
+	return c.JSON(http.StatusCreated, AnnouncementFrom(announcement))
+}
+
+func (s *Server) replaceAnnouncement(c echo.Context) error {
+	user, ok := authn.UserFromContext(c)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Not authenticated")
+	}
+
+	old, err := s.announcementFromParam(c, user)
+	if err != nil {
+		return err
+	}
+
+	var req CreateAnnouncementRequest
+	if err = c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Could not bind data")
+	}
+
+	announcement, err := s.announcementFromRequest(c, user.OrganizationID, req)
+	if err != nil {
+		return err
+	}
+	announcement.ID = old.ID
+
+	ctx := c.Request().Context()
+
+	oldDeviceIDs, err := s.db.GetAnnouncementDeviceIDs(ctx, old.ID)
+	if err != nil {
+		s.log.Error(err, "could not get devices of announcement")
+		return echo.NewHTTPError(http.StatusInternalServerError, "Could not update announcement")
+	}
+
+	err = s.db.ReplaceAnnouncement(ctx, announcement)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return echo.NewHTTPError(http.StatusNotFound, "Announcement not found")
+		}
+
+		s.log.Error(err, "could not replace announcement")
+		return echo.NewHTTPError(http.StatusInternalServerError, "Could not update announcement")
+	}
+	s.announcementChanged(ctx, announcement.ID, oldDeviceIDs)
+
+	announcement, err = s.db.GetAnnouncement(ctx, announcement.ID)
+	if err != nil {
+		s.log.Error(err, "could not get announcement")
+		return echo.NewHTTPError(http.StatusInternalServerError, "Could not read announcement from database")
+	}
+
+	return c.JSON(http.StatusOK, AnnouncementFrom(announcement))
+}
+
+func (s *Server) deleteAnnouncement(c echo.Context) error {
+	user, ok := authn.UserFromContext(c)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Not authenticated")
+	}
+
+	announcement, err := s.announcementFromParam(c, user)
+	if err != nil {
+		return err
+	}
+
+	ctx := c.Request().Context()
+
+	deviceIDs, err := s.db.GetAnnouncementDeviceIDs(ctx, announcement.ID)
+	if err != nil {
+		s.log.Error(err, "could not get devices of announcement")
+		return echo.NewHTTPError(http.StatusInternalServerError, "Could not delete announcement")
+	}
+
+	err = s.db.DeleteAnnouncement(ctx, user.OrganizationID, announcement.ID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return echo.NewHTTPError(http.StatusNotFound, "Announcement not found")
+		}
+
+		s.log.Error(err, "could not delete announcement")
+		return echo.NewHTTPError(http.StatusInternalServerError, "Could not delete announcement")
+	}
+	s.mqw.AnnouncementRemoved(announcement.ID, deviceIDs)
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (s *Server) sendAnnouncementImage(c echo.Context, id uuid.UUID) error {
+	img, err := s.db.GetAnnouncementImage(c.Request().Context(), id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return echo.NewHTTPError(http.StatusNotFound, "Announcement has no image")
+		}
+
+		s.log.Error(err, "could not get announcement image")
+		return echo.NewHTTPError(http.StatusInternalServerError, "Could not read announcement image from database")
+	}
+
+	return c.Blob(http.StatusOK, img.ContentType, img.Data)
+}
+
+func (s *Server) getAnnouncementImage(c echo.Context) error {
+	user, ok := authn.UserFromContext(c)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Not authenticated")
+	}
+
+	announcement, err := s.announcementFromParam(c, user)
+	if err != nil {
+		return err
+	}
+
+	return s.sendAnnouncementImage(c, announcement.ID)
+}
+
+func (s *Server) replaceAnnouncementImage(c echo.Context) error {
+	user, ok := authn.UserFromContext(c)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Not authenticated")
+	}
+
+	announcement, err := s.announcementFromParam(c, user)
+	if err != nil {
+		return err
+	}
+
+	body := http.MaxBytesReader(c.Response(), c.Request().Body, database.MaxAnnouncementImageSize)
+	data, err := ioutil.ReadAll(body)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusRequestEntityTooLarge, "Image is too large")
+	}
+
+	contentType := http.DetectContentType(data)
+	if contentType != "image/png" && contentType != "image/jpeg" {
+		return echo.NewHTTPError(http.StatusBadRequest, "Image must be a PNG or JPEG image")
+	}
+
+	ctx := c.Request().Context()
+
+	err = s.db.ReplaceAnnouncementImage(ctx, user.OrganizationID, database.AnnouncementImage{
+		AnnouncementID: announcement.ID,
+		ContentType:    contentType,
+		Data:           data,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return echo.NewHTTPError(http.StatusNotFound, "Announcement not found")
+		}
+
+		s.log.Error(err, "could not replace announcement image")
+		return echo.NewHTTPError(http.StatusInternalServerError, "Could not store announcement image")
+	}
+	s.announcementChanged(ctx, announcement.ID, nil)
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (s *Server) deleteAnnouncementImage(c echo.Context) error {
+	user, ok := authn.UserFromContext(c)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Not authenticated")
+	}
+
+	announcement, err := s.announcementFromParam(c, user)
+	if err != nil {
+		return err
+	}
+
+	ctx := c.Request().Context()
+
+	err = s.db.DeleteAnnouncementImage(ctx, user.OrganizationID, announcement.ID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return echo.NewHTTPError(http.StatusNotFound, "Announcement has no image")
+		}
+
+		s.log.Error(err, "could not delete announcement image")
+		return echo.NewHTTPError(http.StatusInternalServerError, "Could not delete announcement image")
+	}
+	s.announcementChanged(ctx, announcement.ID, nil)
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// deviceFromContextParam retrieves the authenticated device, which must be the device with the ID in the path.
+func deviceFromContextParam(c echo.Context) (database.Device, error) {
+	dev, ok := authn.DeviceFromContext(c)
+	if !ok {
+		return dev, echo.NewHTTPError(http.StatusUnauthorized, "Not authenticated")
+	}
+
+	uid, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return dev, echo.NewHTTPError(http.StatusBadRequest, "Invalid ID")
+	}
+	if dev.ID != uid {
+		return dev, echo.NewHTTPError(http.StatusBadRequest, "ID mismatch")
+	}
+	return dev, nil
+}
+
+// getDeviceAnnouncements lets devices retrieve the announcements which they should show now or later,
+// so devices which have been offline don't depend on the messages in the EMDEV-ANNOUNCEMENTS stream.
+func (s *Server) getDeviceAnnouncements(c echo.Context) error {
+	dev, err := deviceFromContextParam(c)
+	if err != nil {
+		return err
+	}
+
+	announcements, err := s.db.GetAnnouncementsForDevice(c.Request().Context(),
+		dev.OrganizationID, dev.GroupID, time.Now(),
+	)
+	if err != nil {
+		s.log.Error(err, "could not get announcements for device")
+		return echo.NewHTTPError(http.StatusInternalServerError, "Could not read announcements from database")
+	}
+
+	return c.JSON(http.StatusOK, AnnouncementsFrom(announcements))
+}
+
+func (s *Server) getDeviceAnnouncementImage(c echo.Context) error {
+	dev, err := deviceFromContextParam(c)
+	if err != nil {
+		return err
+	}
+
+	id, err := uuid.Parse(c.Param("announcementId"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid announcement ID")
+	}
+
+	// Devices can only retrieve the images of the announcements which are shown on them.
+	announcement, err := s.db.GetAnnouncementForDevice(c.Request().Context(),
+		id, dev.OrganizationID, dev.GroupID, time.Now(),
+	)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		s.log.Error(err, "could not get announcement")
+		return echo.NewHTTPError(http.StatusInternalServerError, "Could not read announcement from database")
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		return echo.NewHTTPError(http.StatusNotFound, "Announcement not found")
+	}
+
+	return s.sendAnnouncementImage(c, announcement.ID)
+}
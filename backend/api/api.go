@@ -2,6 +2,8 @@ package api
 
 import (
 	"context"
+	"crypto/ed25519"
+	"errors"
 	"fmt"
 	"os"
 	"time"
@@ -13,10 +15,13 @@ import (
 
 	nmsdk "gitlab.com/timeterm/timeterm/nats-manager/pkg/sdk"
 
+	"gitlab.com/timeterm/timeterm/backend/alerts"
 	authn "gitlab.com/timeterm/timeterm/backend/auhtn"
+	"gitlab.com/timeterm/timeterm/backend/blobstore"
 	"gitlab.com/timeterm/timeterm/backend/database"
 	"gitlab.com/timeterm/timeterm/backend/messages"
 	"gitlab.com/timeterm/timeterm/backend/mq"
+	"gitlab.com/timeterm/timeterm/backend/scheduler"
 	"gitlab.com/timeterm/timeterm/backend/secrets"
 	"gitlab.com/timeterm/timeterm/backend/templates"
 )
@@ -29,6 +34,15 @@ type Server struct {
 	secr *secrets.Wrapper
 	nm   *nmsdk.Client
 	msgw *messages.Wrapper
+	alw  *alerts.Wrapper
+	schw *scheduler.Wrapper
+	// blobs stores the branding assets of organizations.
+	blobs blobstore.Store
+	// publicURL is the URL at which devices can reach the backend.
+	publicURL string
+	// releaseKey is the public key with which the vendor signs software releases.
+	// Software releases can't be published if it is nil.
+	releaseKey ed25519.PublicKey
 }
 
 func newEcho(log logr.Logger) (*echo.Echo, error) {
@@ -38,7 +52,12 @@ func newEcho(log logr.Logger) (*echo.Echo, error) {
 	e.HideBanner = true
 	e.HidePort = true
 	e.Logger = newEchoLogrLogger(log)
-	e.Use(middleware.Recover(), middleware.CORS())
+
+	trustedProxies, err := authn.ParseTrustedProxies(os.Getenv("TRUSTED_PROXIES"))
+	if err != nil {
+		return nil, err
+	}
+	e.Use(middleware.Recover(), authn.TrustedProxiesMiddleware(trustedProxies), middleware.CORS())
 
 	e.Renderer, err = templates.Load()
 	if err != nil {
@@ -75,6 +94,18 @@ func NewServer(log logr.Logger, db *database.Wrapper, secr *secrets.Wrapper) (Se
 		return Server{}, fmt.Errorf("could not create NATS wrapper: %w", err)
 	}
 
+	blobs, err := blobstore.NewFromEnv(db)
+	if err != nil {
+		return Server{}, fmt.Errorf("could not create blob store: %w", err)
+	}
+
+	releaseKey, err := parseReleaseKey(os.Getenv("UPDATE_RELEASE_PUBLIC_KEY"))
+	if err != nil {
+		return Server{}, err
+	}
+
+	msgw := messages.NewWrapper(log, db, secr)
+
 	server := Server{
 		db:   db,
 		log:  log,
@@ -82,7 +113,14 @@ func NewServer(log logr.Logger, db *database.Wrapper, secr *secrets.Wrapper) (Se
 		secr: secr,
 		mqw:  mqw,
 		nm:   nmsdk.NewClient(nc),
-		msgw: messages.NewWrapper(log, db, secr),
+		msgw: msgw,
+		alw:  alerts.NewWrapper(log, db, msgw),
+		schw: scheduler.NewWrapper(log, db, mqw),
+
+		blobs: blobs,
+
+		publicURL:  os.Getenv("PUBLIC_URL"),
+		releaseKey: releaseKey,
 	}
 	server.registerRoutes()
 
@@ -102,6 +140,13 @@ func (s *Server) registerRoutes() {
 	userGroup := g.Group("/user")
 	userGroup.GET("/me", s.getCurrentUser)
 	userGroup.PATCH("/:id", s.patchUser)
+	userGroup.GET("/sessions", s.getUserSessions)
+	userGroup.DELETE("/sessions", s.deleteUserSessions)
+	userGroup.DELETE("/sessions/current", s.deleteCurrentUserSession)
+	userGroup.DELETE("/sessions/:id", s.deleteUserSession)
+	userGroup.GET("/api-keys", s.getUserAPIKeys)
+	userGroup.POST("/api-keys", s.createUserAPIKey)
+	userGroup.DELETE("/api-keys/:id", s.deleteUserAPIKey)
 
 	devGroup := g.Group("/device")
 	devGroup.GET("", s.getDevices)
@@ -110,7 +155,33 @@ func (s *Server) registerRoutes() {
 	devGroup.PATCH("/:id", s.patchDevice)
 	devGroup.DELETE("/:id", s.deleteDevice)
 	devGroup.POST("/:id/restart", s.rebootDevice)
-	devGroup.GET("/registrationconfig", s.getRegistrationConfig)
+	devGroup.POST("/:id/revoke", s.revokeDevice)
+	devGroup.GET("/:id/command", s.getDeviceCommands)
+	devGroup.POST("/:id/command", s.createDeviceCommand)
+	devGroup.GET("/:id/logs", s.getDeviceLogBundles)
+	devGroup.GET("/:id/logs/:bundleId", s.downloadDeviceLogBundle)
+	devGroup.POST("/:id/screenshot", s.takeDeviceScreenshot)
+	devGroup.GET("/:id/screenshot", s.getDeviceScreenshots)
+	devGroup.GET("/:id/screenshot/:screenshotId", s.getDeviceScreenshot)
+	devGroup.POST("/registrationconfig", s.createRegistrationConfig)
+	devGroup.GET("/provisioning-key", s.getProvisioningKey)
+	devGroup.GET("/tags", s.getDeviceTags)
+	devGroup.GET("/group", s.getDeviceGroups)
+	devGroup.POST("/group", s.createDeviceGroup)
+	devGroup.PUT("/group/:id", s.replaceDeviceGroup)
+	devGroup.DELETE("/group/:id", s.deleteDeviceGroup)
+	devGroup.GET("/schedule", s.getDeviceSchedules)
+	devGroup.POST("/schedule", s.createDeviceSchedule)
+	devGroup.PUT("/schedule/:id", s.replaceDeviceSchedule)
+	devGroup.DELETE("/schedule/:id", s.deleteDeviceSchedule)
+	devGroup.GET("/config-profile", s.getDeviceConfigProfiles)
+	devGroup.POST("/config-profile", s.createDeviceConfigProfile)
+	devGroup.PUT("/config-profile/:id", s.replaceDeviceConfigProfile)
+	devGroup.DELETE("/config-profile/:id", s.deleteDeviceConfigProfile)
+	devGroup.GET("/registration-token", s.getDeviceRegistrationTokens)
+	devGroup.POST("/registration-token", s.postDeviceRegistrationToken)
+	devGroup.DELETE("/registration-token/:id", s.deleteDeviceRegistrationToken)
+	devGroup.GET("/unknown-card-taps", s.getUnknownCardTaps)
 	devGroup.POST("/restart", s.rebootDevices)
 
 	registrationLoginMiddleware := authn.DeviceRegistrationLoginMiddleware(s.db, s.log)
@@ -120,11 +191,43 @@ func (s *Server) registerRoutes() {
 	devConfigGroup.Use(authn.DeviceLoginMiddleware(s.db, s.log))
 	devConfigGroup.GET("/natscreds", s.generateNATSCredentials)
 	devConfigGroup.GET("/networks", s.getAllNetworkingServices)
+	devConfigGroup.GET("/settings", s.getDeviceConfig)
+
+	devTokenGroup := s.echo.Group("/device/:id/token")
+	devTokenGroup.Use(authn.DeviceLoginMiddleware(s.db, s.log))
+	devTokenGroup.POST("/rotate", s.rotateDeviceToken)
 
 	devHeartbeatGroup := s.echo.Group("/device/:id/heartbeat")
 	devHeartbeatGroup.Use(authn.DeviceLoginMiddleware(s.db, s.log))
 	devHeartbeatGroup.PUT("", s.updateLastHeartbeat)
 
+	devUpdateGroup := s.echo.Group("/device/:id/update")
+	devUpdateGroup.Use(authn.DeviceLoginMiddleware(s.db, s.log))
+	devUpdateGroup.GET("", s.checkForUpdate)
+
+	devScreenshotGroup := s.echo.Group("/device/:id/screenshot-upload")
+	devScreenshotGroup.Use(authn.DeviceLoginMiddleware(s.db, s.log))
+	devScreenshotGroup.PUT("/:commandId", s.uploadDeviceScreenshot)
+
+	devLogBundleGroup := s.echo.Group("/device/:id/log-bundle")
+	devLogBundleGroup.Use(authn.DeviceLoginMiddleware(s.db, s.log))
+	devLogBundleGroup.PUT("/:commandId", s.uploadDeviceLogBundle)
+
+	devAnnouncementGroup := s.echo.Group("/device/:id/announcements")
+	devAnnouncementGroup.Use(authn.DeviceLoginMiddleware(s.db, s.log))
+	devAnnouncementGroup.GET("", s.getDeviceAnnouncements)
+	devAnnouncementGroup.GET("/:announcementId/image", s.getDeviceAnnouncementImage)
+
+	devBrandingGroup := s.echo.Group("/device/:id/branding")
+	devBrandingGroup.Use(authn.DeviceLoginMiddleware(s.db, s.log))
+	devBrandingGroup.GET("", s.getDeviceBranding)
+	devBrandingGroup.GET("/logo", s.getDeviceBrandingLogo)
+
+	devCardEnrollmentGroup := s.echo.Group("/device/:id/card-enrollment")
+	devCardEnrollmentGroup.Use(authn.DeviceLoginMiddleware(s.db, s.log))
+	devCardEnrollmentGroup.POST("/:sessionId/complete", s.completeCardEnrollmentSession)
+	devCardEnrollmentGroup.POST("/:sessionId/cancel", s.cancelDeviceCardEnrollmentSession)
+
 	msgGroup := g.Group("/message")
 	msgGroup.GET("", s.getAdminMessages)
 	msgGroup.GET("/:sec/:nanosec", s.getAdminMessage)
@@ -132,6 +235,14 @@ func (s *Server) registerRoutes() {
 	orgGroup := g.Group("/organization")
 	orgGroup.PATCH("/:id", s.patchOrganization)
 	orgGroup.GET("/:id", s.getOrganization)
+	orgGroup.POST("/:id/card-key/rotate", s.rotateOrganizationCardKey)
+	orgGroup.GET("/:id/legacy-cards", s.getOrganizationLegacyCards)
+	orgGroup.DELETE("/:id/legacy-cards", s.deleteOrganizationLegacyCards)
+	orgGroup.GET("/:id/branding", s.getOrganizationBranding)
+	orgGroup.PUT("/:id/branding/colors", s.replaceOrganizationBrandingColors)
+	orgGroup.GET("/:id/branding/logo", s.getOrganizationBrandingLogo)
+	orgGroup.PUT("/:id/branding/logo", s.replaceOrganizationBrandingLogo)
+	orgGroup.DELETE("/:id/branding/logo", s.deleteOrganizationBrandingLogo)
 
 	stdGroup := g.Group("/student")
 	stdGroup.GET("", s.getStudents)
@@ -139,6 +250,14 @@ func (s *Server) registerRoutes() {
 	stdGroup.DELETE("", s.deleteStudents)
 	stdGroup.GET("/:id", s.getStudent)
 	stdGroup.PATCH("/:id", s.patchStudent)
+	stdGroup.DELETE("/:id/pin", s.deleteStudentPin)
+	stdGroup.GET("/:id/card", s.getStudentCards)
+	stdGroup.POST("/:id/card", s.createStudentCard)
+	stdGroup.PUT("/:id/card/:cardId/state", s.replaceStudentCardState)
+	stdGroup.DELETE("/:id/card/:cardId", s.deleteStudentCard)
+	stdGroup.POST("/:id/card/enrollment", s.createCardEnrollmentSession)
+	stdGroup.GET("/:id/card/enrollment/:sessionId", s.getCardEnrollmentSession)
+	stdGroup.DELETE("/:id/card/enrollment/:sessionId", s.cancelCardEnrollmentSession)
 
 	netServGroup := g.Group("/networking/service")
 	netServGroup.GET("", s.getNetworkingServices)
@@ -146,15 +265,41 @@ func (s *Server) registerRoutes() {
 	netServGroup.GET("/:id", s.getNetworkingService)
 	netServGroup.PUT("/:id", s.replaceNetworkingService)
 	netServGroup.DELETE("/:id", s.deleteNetworkingService)
+	netServGroup.GET("/:id/assignment", s.getNetworkingServiceAssignment)
+	netServGroup.PUT("/:id/assignment", s.replaceNetworkingServiceAssignment)
+
+	updateGroup := g.Group("/update")
+	updateGroup.GET("/signing-key", s.getUpdateSigningKey)
+	updateGroup.GET("/release", s.getSoftwareReleases)
+	updateGroup.POST("/release", s.createSoftwareRelease)
+	updateGroup.DELETE("/release/:id", s.deleteSoftwareRelease)
+	updateGroup.GET("/rollout", s.getUpdateRollouts)
+	updateGroup.PUT("/rollout", s.replaceUpdateRollout)
+	updateGroup.DELETE("/rollout/:id", s.deleteUpdateRollout)
+	updateGroup.GET("/rollout/:id/status", s.getUpdateRolloutStatus)
+
+	announcementGroup := g.Group("/announcement")
+	announcementGroup.GET("", s.getAnnouncements)
+	announcementGroup.POST("", s.createAnnouncement)
+	announcementGroup.GET("/:id", s.getAnnouncement)
+	announcementGroup.PUT("/:id", s.replaceAnnouncement)
+	announcementGroup.DELETE("/:id", s.deleteAnnouncement)
+	announcementGroup.GET("/:id/image", s.getAnnouncementImage)
+	announcementGroup.PUT("/:id/image", s.replaceAnnouncementImage)
+	announcementGroup.DELETE("/:id/image", s.deleteAnnouncementImage)
 
 	zappGroup := s.echo.Group("/zermelo/appointment")
-	zappGroup.Use(authn.DeviceLoginMiddleware(s.db, s.log), authn.StudentLoginMiddleware(s.db, s.log))
+	zappGroup.Use(authn.DeviceLoginMiddleware(s.db, s.log), authn.StudentLoginMiddleware(s.db, s.secr, s.log))
 	zappGroup.GET("", s.getZermeloAppointments)
 
 	zenrGroup := s.echo.Group("/zermelo/enrollment")
-	zenrGroup.Use(authn.DeviceLoginMiddleware(s.db, s.log), authn.StudentLoginMiddleware(s.db, s.log))
+	zenrGroup.Use(authn.DeviceLoginMiddleware(s.db, s.log), authn.StudentLoginMiddleware(s.db, s.secr, s.log))
 	zenrGroup.POST("", s.enrollZermelo)
 
+	stdMeGroup := s.echo.Group("/student/me")
+	stdMeGroup.Use(authn.DeviceLoginMiddleware(s.db, s.log), authn.StudentPINSetupLoginMiddleware(s.db, s.secr, s.log))
+	stdMeGroup.PUT("/pin", s.setCurrentStudentPin)
+
 	zconnGroup := g.Group("/zermelo/connect")
 	zconnGroup.POST("", s.connectZermeloOrganization)
 }
@@ -162,6 +307,24 @@ func (s *Server) registerRoutes() {
 func (s *Server) Run(ctx context.Context) error {
 	const shutdownTimeout = time.Second * 30
 
+	go func() {
+		if err := s.mqw.Run(ctx); err != nil && !errors.Is(err, context.Canceled) {
+			s.log.Error(err, "could not process device heartbeats")
+		}
+	}()
+
+	go func() {
+		if err := s.alw.Run(ctx); err != nil && !errors.Is(err, context.Canceled) {
+			s.log.Error(err, "could not check for offline devices")
+		}
+	}()
+
+	go func() {
+		if err := s.schw.Run(ctx); err != nil && !errors.Is(err, context.Canceled) {
+			s.log.Error(err, "could not run device schedules")
+		}
+	}()
+
 	errc := make(chan error)
 	go func() {
 		const serveAddr = ":1323"
@@ -0,0 +1,271 @@
+package api
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo"
+
+	authn "gitlab.com/timeterm/timeterm/backend/auhtn"
+	"gitlab.com/timeterm/timeterm/backend/blobstore"
+	"gitlab.com/timeterm/timeterm/backend/database"
+)
+
+const maxOrganizationLogoSize = 1 << 20 // 1 MiB
+
+var brandingColorRegexp = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+
+func logoETag(hash []byte) string {
+	return `"` + hex.EncodeToString(hash) + `"`
+}
+
+// brandingETag identifies a version of the branding. It also changes when the logo changes,
+// because the branding contains the ETag of the logo.
+func brandingETag(b OrganizationBranding) (string, error) {
+	data, err := json.Marshal(b)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`, nil
+}
+
+// etagMatches checks if etag is in the value of an If-None-Match header.
+// As required for If-None-Match, weak ETags are compared with the weak comparison function.
+func etagMatches(ifNoneMatch, etag string) bool {
+	for _, t := range strings.Split(ifNoneMatch, ",") {
+		t = strings.TrimSpace(t)
+		if t == "*" || strings.TrimPrefix(t, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
+
+// sendConditional calls send if the client doesn't have the version identified by etag yet,
+// and responds with 304 Not Modified otherwise. Clients must always revalidate their copy.
+func sendConditional(c echo.Context, etag string, send func() error) error {
+	c.Response().Header().Set("ETag", etag)
+	c.Response().Header().Set("Cache-Control", "no-cache")
+
+	if etagMatches(c.Request().Header.Get("If-None-Match"), etag) {
+		return c.NoContent(http.StatusNotModified)
+	}
+	return send()
+}
+
+// organizationIDFromParam parses the organization ID in the URL and checks if it is the organization of the user.
+func organizationIDFromParam(c echo.Context) (uuid.UUID, error) {
+	uid, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return uid, echo.NewHTTPError(http.StatusBadRequest, "Invalid ID")
+	}
+
+	user, ok := authn.UserFromContext(c)
+	if !ok {
+		return uid, echo.NewHTTPError(http.StatusUnauthorized, "Not authenticated")
+	}
+
+	if user.OrganizationID != uid {
+		return uid, echo.NewHTTPError(http.StatusUnauthorized, "Organization does not belong to user's organization")
+	}
+	return uid, nil
+}
+
+func (s *Server) getBranding(c echo.Context, organizationID uuid.UUID) (database.OrganizationBranding, error) {
+	branding, err := s.db.GetOrganizationBranding(c.Request().Context(), organizationID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		s.log.Error(err, "could not get organization branding")
+		return branding, echo.NewHTTPError(http.StatusInternalServerError, "Could not read branding from database")
+	}
+	// Organizations which have never configured their branding use the default branding.
+	return branding, nil
+}
+
+func (s *Server) sendBranding(c echo.Context, organizationID uuid.UUID) error {
+	branding, err := s.getBranding(c, organizationID)
+	if err != nil {
+		return err
+	}
+
+	apiBranding := OrganizationBrandingFrom(branding)
+	etag, err := brandingETag(apiBranding)
+	if err != nil {
+		s.log.Error(err, "could not compute branding ETag")
+		return echo.NewHTTPError(http.StatusInternalServerError, "Could not read branding")
+	}
+
+	return sendConditional(c, etag, func() error {
+		return c.JSON(http.StatusOK, apiBranding)
+	})
+}
+
+// brandingBlobPrefix is the prefix of the keys of the branding blobs of an organization.
+func brandingBlobPrefix(organizationID uuid.UUID) string {
+	return "branding/" + organizationID.String()
+}
+
+func (s *Server) sendBrandingLogo(c echo.Context, organizationID uuid.UUID) error {
+	branding, err := s.getBranding(c, organizationID)
+	if err != nil {
+		return err
+	}
+	if !branding.LogoKey.Valid {
+		return echo.NewHTTPError(http.StatusNotFound, "Organization has no logo")
+	}
+
+	return sendConditional(c, logoETag(branding.LogoSHA256), func() error {
+		data, err := s.blobs.Get(c.Request().Context(), branding.LogoKey.String)
+		if errors.Is(err, blobstore.ErrNotFound) {
+			return echo.NewHTTPError(http.StatusNotFound, "Organization has no logo")
+		}
+		if err != nil {
+			s.log.Error(err, "could not get organization logo", "key", branding.LogoKey.String)
+			return echo.NewHTTPError(http.StatusInternalServerError, "Could not read logo")
+		}
+
+		return c.Blob(http.StatusOK, branding.LogoContentType.String, data)
+	})
+}
+
+func (s *Server) getOrganizationBranding(c echo.Context) error {
+	organizationID, err := organizationIDFromParam(c)
+	if err != nil {
+		return err
+	}
+
+	return s.sendBranding(c, organizationID)
+}
+
+func (s *Server) replaceOrganizationBrandingColors(c echo.Context) error {
+	organizationID, err := organizationIDFromParam(c)
+	if err != nil {
+		return err
+	}
+
+	var colors OrganizationBrandingColors
+	if err = c.Bind(&colors); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Could not bind data")
+	}
+
+	for _, color := range []*string{colors.Primary, colors.Accent, colors.Background, colors.Text} {
+		if color != nil && !brandingColorRegexp.MatchString(*color) {
+			return echo.NewHTTPError(http.StatusBadRequest, "Colors must be formatted as #rrggbb")
+		}
+	}
+
+	err = s.db.ReplaceOrganizationBrandingColors(c.Request().Context(),
+		OrganizationBrandingColorsToDB(organizationID, colors))
+	if err != nil {
+		s.log.Error(err, "could not replace organization branding colors")
+		return echo.NewHTTPError(http.StatusInternalServerError, "Could not update branding")
+	}
+
+	return s.sendBranding(c, organizationID)
+}
+
+func (s *Server) getOrganizationBrandingLogo(c echo.Context) error {
+	organizationID, err := organizationIDFromParam(c)
+	if err != nil {
+		return err
+	}
+
+	return s.sendBrandingLogo(c, organizationID)
+}
+
+func (s *Server) replaceOrganizationBrandingLogo(c echo.Context) error {
+	organizationID, err := organizationIDFromParam(c)
+	if err != nil {
+		return err
+	}
+
+	body := http.MaxBytesReader(c.Response(), c.Request().Body, maxOrganizationLogoSize)
+	data, err := ioutil.ReadAll(body)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusRequestEntityTooLarge, "Logo is too large")
+	}
+
+	contentType := http.DetectContentType(data)
+	if contentType != "image/png" && contentType != "image/jpeg" {
+		return echo.NewHTTPError(http.StatusBadRequest, "Logo must be a PNG or JPEG image")
+	}
+
+	ctx := c.Request().Context()
+
+	// The key contains the hash of the logo, so a new logo never overwrites the logo which devices may be downloading.
+	sum := sha256.Sum256(data)
+	key := fmt.Sprintf("%s/logo/%x", brandingBlobPrefix(organizationID), sum)
+
+	if err = s.blobs.Put(ctx, key, data); err != nil {
+		s.log.Error(err, "could not store organization logo", "key", key)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Could not store logo")
+	}
+
+	oldKey, err := s.db.ReplaceOrganizationBrandingLogo(ctx, organizationID, key, contentType, sum[:])
+	if err != nil {
+		s.log.Error(err, "could not replace organization logo")
+		return echo.NewHTTPError(http.StatusInternalServerError, "Could not store logo")
+	}
+
+	if oldKey.Valid && oldKey.String != key {
+		if err = s.blobs.Delete(ctx, oldKey.String); err != nil {
+			s.log.Error(err, "could not delete old organization logo", "key", oldKey.String)
+		}
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (s *Server) deleteOrganizationBrandingLogo(c echo.Context) error {
+	organizationID, err := organizationIDFromParam(c)
+	if err != nil {
+		return err
+	}
+
+	ctx := c.Request().Context()
+
+	key, err := s.db.DeleteOrganizationBrandingLogo(ctx, organizationID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return echo.NewHTTPError(http.StatusNotFound, "Organization has no logo")
+		}
+
+		s.log.Error(err, "could not delete organization logo")
+		return echo.NewHTTPError(http.StatusInternalServerError, "Could not delete logo")
+	}
+
+	if err = s.blobs.Delete(ctx, key); err != nil {
+		s.log.Error(err, "could not delete organization logo from blob store", "key", key)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// getDeviceBranding lets devices retrieve the branding of their organization.
+// Devices can send the ETag of their copy in If-None-Match to only retrieve the branding if it has changed.
+func (s *Server) getDeviceBranding(c echo.Context) error {
+	dev, err := deviceFromContextParam(c)
+	if err != nil {
+		return err
+	}
+
+	return s.sendBranding(c, dev.OrganizationID)
+}
+
+func (s *Server) getDeviceBrandingLogo(c echo.Context) error {
+	dev, err := deviceFromContextParam(c)
+	if err != nil {
+		return err
+	}
+
+	return s.sendBrandingLogo(c, dev.OrganizationID)
+}
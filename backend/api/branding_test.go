@@ -0,0 +1,37 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEtagMatches(t *testing.T) {
+	etag := `"abc"`
+
+	assert.False(t, etagMatches("", etag))
+	assert.False(t, etagMatches(`"def"`, etag))
+	assert.True(t, etagMatches(`"abc"`, etag))
+	assert.True(t, etagMatches(`W/"abc"`, etag))
+	assert.True(t, etagMatches(`"def", "abc"`, etag))
+	assert.True(t, etagMatches("*", etag))
+}
+
+func TestBrandingETag(t *testing.T) {
+	color := "#ffffff"
+	logo := logoETag([]byte{1})
+
+	a, err := brandingETag(OrganizationBranding{})
+	require.NoError(t, err)
+	b, err := brandingETag(OrganizationBranding{Colors: OrganizationBrandingColors{Primary: &color}})
+	require.NoError(t, err)
+	c, err := brandingETag(OrganizationBranding{LogoETag: &logo})
+	require.NoError(t, err)
+	d, err := brandingETag(OrganizationBranding{})
+	require.NoError(t, err)
+
+	assert.NotEqual(t, a, b)
+	assert.NotEqual(t, a, c)
+	assert.Equal(t, a, d)
+}
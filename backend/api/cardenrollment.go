@@ -0,0 +1,213 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo"
+
+	authn "gitlab.com/timeterm/timeterm/backend/auhtn"
+	"gitlab.com/timeterm/timeterm/backend/database"
+)
+
+// createCardEnrollmentSession asks a device to bind the next card that is tapped to the student.
+func (s *Server) createCardEnrollmentSession(c echo.Context) error {
+	dbStudent, err := s.studentFromParam(c)
+	if err != nil {
+		return err
+	}
+
+	var req CreateCardEnrollmentSessionRequest
+	if err = c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Could not bind data")
+	}
+
+	dev, err := s.db.GetDevice(c.Request().Context(), req.DeviceID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return echo.NewHTTPError(http.StatusBadRequest, "Device not found")
+		}
+
+		s.log.Error(err, "could not get device")
+		return echo.NewHTTPError(http.StatusInternalServerError, "Could not read device from database")
+	}
+
+	if dev.OrganizationID != dbStudent.OrganizationID {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Device does not belong to user's organization")
+	}
+
+	session, err := s.db.CreateCardEnrollmentSession(c.Request().Context(), database.CardEnrollmentSession{
+		OrganizationID: dbStudent.OrganizationID,
+		StudentID:      dbStudent.ID,
+		DeviceID:       dev.ID,
+		Label:          req.Label,
+		ValidUntil:     TimePtrToDB(req.ValidUntil),
+	})
+	if err != nil {
+		s.log.Error(err, "could not create card enrollment session")
+		return echo.NewHTTPError(http.StatusInternalServerError, "Could not create card enrollment session")
+	}
+
+	if err = s.mqw.StartCardEnrollment(dev.ID, session.ID, session.ExpiresAt); err != nil {
+		s.log.Error(err, "could not publish start card enrollment message")
+		return echo.NewHTTPError(http.StatusInternalServerError, "Could not send card enrollment message to device")
+	}
+
+	return c.JSON(http.StatusCreated, CardEnrollmentSessionFrom(session))
+}
+
+// cardEnrollmentSessionFromParams retrieves the card enrollment session with the ID in the sessionId path parameter,
+// making sure that it belongs to the student with the ID in the id path parameter.
+func (s *Server) cardEnrollmentSessionFromParams(c echo.Context) (database.CardEnrollmentSession, error) {
+	dbStudent, err := s.studentFromParam(c)
+	if err != nil {
+		return database.CardEnrollmentSession{}, err
+	}
+
+	sessionID, err := uuid.Parse(c.Param("sessionId"))
+	if err != nil {
+		return database.CardEnrollmentSession{}, echo.NewHTTPError(http.StatusBadRequest, "Invalid session ID")
+	}
+
+	session, err := s.db.GetCardEnrollmentSession(c.Request().Context(), sessionID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return session, echo.NewHTTPError(http.StatusNotFound, "Card enrollment session not found")
+		}
+
+		s.log.Error(err, "could not get card enrollment session")
+		return session, echo.NewHTTPError(http.StatusInternalServerError, "Could not read card enrollment session from database")
+	}
+
+	if session.StudentID != dbStudent.ID {
+		return session, echo.NewHTTPError(http.StatusNotFound, "Card enrollment session not found")
+	}
+
+	return session, nil
+}
+
+func (s *Server) getCardEnrollmentSession(c echo.Context) error {
+	session, err := s.cardEnrollmentSessionFromParams(c)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, CardEnrollmentSessionFrom(session))
+}
+
+func (s *Server) cancelCardEnrollmentSession(c echo.Context) error {
+	session, err := s.cardEnrollmentSessionFromParams(c)
+	if err != nil {
+		return err
+	}
+
+	err = s.db.CancelCardEnrollmentSession(c.Request().Context(), session.ID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return echo.NewHTTPError(http.StatusConflict, "Card enrollment session is not pending")
+		}
+
+		s.log.Error(err, "could not cancel card enrollment session")
+		return echo.NewHTTPError(http.StatusInternalServerError, "Could not cancel card enrollment session")
+	}
+
+	// The session has already been cancelled, so the device can't complete it anymore anyway.
+	if err = s.mqw.CancelCardEnrollment(session.DeviceID, session.ID); err != nil {
+		s.log.Error(err, "could not publish cancel card enrollment message")
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// deviceFromParam retrieves the device which is logged in, making sure that its ID is in the id path parameter.
+func deviceFromParam(c echo.Context) (database.Device, error) {
+	dev, ok := authn.DeviceFromContext(c)
+	if !ok {
+		return dev, echo.NewHTTPError(http.StatusUnauthorized, "Not authenticated")
+	}
+
+	uid, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return dev, echo.NewHTTPError(http.StatusBadRequest, "Invalid ID")
+	}
+
+	if dev.ID != uid {
+		return dev, echo.NewHTTPError(http.StatusBadRequest, "ID mismatch")
+	}
+
+	return dev, nil
+}
+
+// completeCardEnrollmentSession is called by a device when a card has been tapped during a card enrollment session.
+func (s *Server) completeCardEnrollmentSession(c echo.Context) error {
+	dev, err := deviceFromParam(c)
+	if err != nil {
+		return err
+	}
+
+	sessionID, err := uuid.Parse(c.Param("sessionId"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid session ID")
+	}
+
+	var req CompleteCardEnrollmentSessionRequest
+	if err = c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Could not bind data")
+	}
+	if req.Uid == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "Card UID is required")
+	}
+
+	session, err := s.db.CompleteCardEnrollmentSession(c.Request().Context(), sessionID, dev.ID,
+		[]byte(req.Uid), s.secr.CardKeysFunc(dev.OrganizationID),
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return echo.NewHTTPError(http.StatusNotFound, "No pending card enrollment session")
+		}
+		if errors.Is(err, database.ErrConflict) {
+			return echo.NewHTTPError(http.StatusConflict, "Card is already in use")
+		}
+
+		s.log.Error(err, "could not complete card enrollment session")
+		return echo.NewHTTPError(http.StatusInternalServerError, "Could not complete card enrollment session")
+	}
+
+	return c.JSON(http.StatusOK, CardEnrollmentSessionFrom(session))
+}
+
+// cancelDeviceCardEnrollmentSession is called by a device when a card enrollment session is cancelled at the device.
+func (s *Server) cancelDeviceCardEnrollmentSession(c echo.Context) error {
+	dev, err := deviceFromParam(c)
+	if err != nil {
+		return err
+	}
+
+	sessionID, err := uuid.Parse(c.Param("sessionId"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid session ID")
+	}
+
+	session, err := s.db.GetCardEnrollmentSession(c.Request().Context(), sessionID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		s.log.Error(err, "could not get card enrollment session")
+		return echo.NewHTTPError(http.StatusInternalServerError, "Could not read card enrollment session from database")
+	}
+	if errors.Is(err, sql.ErrNoRows) || session.DeviceID != dev.ID {
+		return echo.NewHTTPError(http.StatusNotFound, "No pending card enrollment session")
+	}
+
+	err = s.db.CancelCardEnrollmentSession(c.Request().Context(), session.ID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return echo.NewHTTPError(http.StatusNotFound, "No pending card enrollment session")
+		}
+
+		s.log.Error(err, "could not cancel card enrollment session")
+		return echo.NewHTTPError(http.StatusInternalServerError, "Could not cancel card enrollment session")
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
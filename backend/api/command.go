@@ -0,0 +1,166 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo"
+
+	authn "gitlab.com/timeterm/timeterm/backend/auhtn"
+	"gitlab.com/timeterm/timeterm/backend/database"
+)
+
+// sendDeviceCommand stores a command for a device and sends it to the device.
+// If sending fails, the command stays queued and is marked as failed once it expires.
+func (s *Server) sendDeviceCommand(ctx context.Context,
+	deviceID uuid.UUID,
+	typ string,
+	userID uuid.UUID,
+) (database.DeviceCommand, error) {
+	cmd, err := s.db.CreateDeviceCommand(ctx, deviceID, typ, &userID, database.DeviceCommandTTL)
+	if err != nil {
+		s.log.Error(err, "could not create device command")
+		return cmd, echo.NewHTTPError(http.StatusInternalServerError, "Could not create command")
+	}
+
+	err = s.mqw.SendCommand(ctx, cmd)
+	if err != nil {
+		s.log.Error(err, "could not send device command", "commandId", cmd.ID)
+		return cmd, echo.NewHTTPError(http.StatusInternalServerError, "Could not send command")
+	}
+	cmd.Status = database.DeviceCommandStatusStored
+
+	return cmd, nil
+}
+
+// deviceFromParam retrieves the device with the ID in the path, if it belongs to the organization of the user.
+func (s *Server) deviceFromParam(c echo.Context, user database.User) (database.Device, error) {
+	uid, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return database.Device{}, echo.NewHTTPError(http.StatusBadRequest, "Invalid ID")
+	}
+
+	dev, err := s.db.GetDevice(c.Request().Context(), uid)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return dev, echo.NewHTTPError(http.StatusNotFound, "Device not found")
+		}
+		s.log.Error(err, "could not get device")
+		return dev, echo.NewHTTPError(http.StatusInternalServerError, "Could not get device")
+	}
+
+	if dev.OrganizationID != user.OrganizationID {
+		return dev, echo.NewHTTPError(http.StatusUnauthorized, "Device does not belong to user's organization")
+	}
+	return dev, nil
+}
+
+// deviceUploadCommandFromParam retrieves the command with the ID in the path, for which the device
+// uploads data (such as logs). The command must be of the type and may not have expired.
+func (s *Server) deviceUploadCommandFromParam(c echo.Context,
+	dev database.Device,
+	typ string,
+) (database.DeviceCommand, error) {
+	uid, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return database.DeviceCommand{}, echo.NewHTTPError(http.StatusBadRequest, "Invalid ID")
+	}
+	if dev.ID != uid {
+		return database.DeviceCommand{}, echo.NewHTTPError(http.StatusBadRequest, "ID mismatch")
+	}
+
+	commandID, err := uuid.Parse(c.Param("commandId"))
+	if err != nil {
+		return database.DeviceCommand{}, echo.NewHTTPError(http.StatusBadRequest, "Invalid command ID")
+	}
+
+	cmd, err := s.db.GetDeviceCommand(c.Request().Context(), commandID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		s.log.Error(err, "could not get device command")
+		return cmd, echo.NewHTTPError(http.StatusInternalServerError, "Could not get command")
+	}
+	if errors.Is(err, sql.ErrNoRows) || cmd.DeviceID != dev.ID || cmd.Type != typ {
+		return cmd, echo.NewHTTPError(http.StatusNotFound, "Command not found")
+	}
+	if !cmd.ExpiresAt.After(time.Now()) {
+		return cmd, echo.NewHTTPError(http.StatusGone, "Command has expired")
+	}
+	switch cmd.Status {
+	case database.DeviceCommandStatusQueued, database.DeviceCommandStatusStored, database.DeviceCommandStatusAcked:
+	default:
+		return cmd, echo.NewHTTPError(http.StatusConflict, "Command has already completed")
+	}
+
+	return cmd, nil
+}
+
+func (s *Server) createDeviceCommand(c echo.Context) error {
+	user, ok := authn.UserFromContext(c)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Not authenticated")
+	}
+
+	dev, err := s.deviceFromParam(c, user)
+	if err != nil {
+		return err
+	}
+
+	var req CreateDeviceCommandRequest
+	err = c.Bind(&req)
+	if err != nil {
+		return err
+	}
+
+	typ, ok := DeviceCommandTypeToDB(req.Type)
+	if !ok {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid command type")
+	}
+	if capability, ok := database.DeviceCommandCapability(typ); ok && !dev.HasCapability(capability) {
+		return echo.NewHTTPError(http.StatusConflict, "Device does not support this command")
+	}
+
+	cmd, err := s.sendDeviceCommand(c.Request().Context(), dev.ID, typ, user.ID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusCreated, DeviceCommandFrom(cmd))
+}
+
+type getDeviceCommandsParams struct {
+	paginationParams
+}
+
+func (s *Server) getDeviceCommands(c echo.Context) error {
+	user, ok := authn.UserFromContext(c)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Not authenticated")
+	}
+
+	dev, err := s.deviceFromParam(c, user)
+	if err != nil {
+		return err
+	}
+
+	var params getDeviceCommandsParams
+	err = c.Bind(&params)
+	if err != nil {
+		return err
+	}
+
+	cmds, err := s.db.GetDeviceCommands(c.Request().Context(), database.GetDeviceCommandsOpts{
+		DeviceID: dev.ID,
+		Limit:    params.MaxAmount,
+		Offset:   params.Offset,
+	})
+	if err != nil {
+		s.log.Error(err, "could not get device commands")
+		return echo.NewHTTPError(http.StatusInternalServerError, "Could not get device commands")
+	}
+
+	return c.JSON(http.StatusOK, PaginatedDeviceCommandsFrom(cmds))
+}
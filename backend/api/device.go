@@ -1,10 +1,17 @@
 package api
 
 import (
+	"bufio"
 	"context"
+	"database/sql"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"io/ioutil"
+	"net"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -14,8 +21,74 @@ import (
 
 	authn "gitlab.com/timeterm/timeterm/backend/auhtn"
 	"gitlab.com/timeterm/timeterm/backend/database"
+	"gitlab.com/timeterm/timeterm/backend/pkg/jsontypes"
 )
 
+const (
+	maxDeviceInventoryFieldLength = 200
+	maxDeviceNotesLength          = 4000
+)
+
+func unixTimePtrToTime(t *jsontypes.UnixTime) *time.Time {
+	if t == nil {
+		return nil
+	}
+	tt := t.Time()
+	return &tt
+}
+
+// normalizeDeviceInventory trims the inventory fields, clears empty fields
+// and converts the MAC address to its canonical form.
+func normalizeDeviceInventory(inv DeviceInventory) (DeviceInventory, error) {
+	fields := []struct {
+		value     **string
+		maxLength int
+	}{
+		{&inv.LocationDescription, maxDeviceInventoryFieldLength},
+		{&inv.SerialNumber, maxDeviceInventoryFieldLength},
+		{&inv.HardwareRevision, maxDeviceInventoryFieldLength},
+		{&inv.MacAddress, maxDeviceInventoryFieldLength},
+		{&inv.InstallDate, maxDeviceInventoryFieldLength},
+		{&inv.Notes, maxDeviceNotesLength},
+	}
+	for _, field := range fields {
+		if *field.value == nil {
+			continue
+		}
+
+		value := strings.TrimSpace(**field.value)
+		if value == "" {
+			*field.value = nil
+			continue
+		}
+		if len(value) > field.maxLength {
+			return inv, echo.NewHTTPError(http.StatusBadRequest, "Inventory field is too long")
+		}
+		*field.value = &value
+	}
+
+	if inv.MacAddress != nil {
+		mac, err := net.ParseMAC(*inv.MacAddress)
+		if err != nil {
+			return inv, echo.NewHTTPError(http.StatusBadRequest, "Invalid MAC address")
+		}
+		canonical := mac.String()
+		inv.MacAddress = &canonical
+	}
+	if inv.InstallDate != nil {
+		if _, err := time.Parse(installDateLayout, *inv.InstallDate); err != nil {
+			return inv, echo.NewHTTPError(http.StatusBadRequest, "Install date must be formatted as YYYY-MM-DD")
+		}
+	}
+
+	return inv, nil
+}
+
+type getDeviceParams struct {
+	// StatusHistoryLimit is the number of status reports to include, 10 by default.
+	StatusHistoryLimit *int `query:"statusHistoryLimit"`
+}
+
 func (s *Server) getDevice(c echo.Context) error {
 	id := c.Param("id")
 
@@ -24,6 +97,19 @@ func (s *Server) getDevice(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, "Invalid ID")
 	}
 
+	var params getDeviceParams
+	if err = c.Bind(&params); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Could not bind data")
+	}
+
+	historyLimit := defaultDeviceStatusHistoryLimit
+	if params.StatusHistoryLimit != nil {
+		historyLimit = *params.StatusHistoryLimit
+	}
+	if historyLimit < 1 || historyLimit > database.DeviceStatusHistoryLength {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid status history limit")
+	}
+
 	user, ok := authn.UserFromContext(c)
 	if !ok {
 		s.log.Error(nil, "user not in context")
@@ -35,11 +121,21 @@ func (s *Server) getDevice(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusInternalServerError, "Could not read device from database")
 	}
 
-	if user.OrganizationID != dbDevice.ID {
+	if user.OrganizationID != dbDevice.OrganizationID {
 		return echo.NewHTTPError(http.StatusUnauthorized, "Device does not belong to user's organization")
 	}
 
+	history, err := s.db.GetDeviceStatusHistory(c.Request().Context(), uid, historyLimit)
+	if err != nil {
+		s.log.Error(err, "could not read device status history")
+		return echo.NewHTTPError(http.StatusInternalServerError, "Could not read device status from database")
+	}
+
 	apiDevice := DeviceFrom(dbDevice)
+	apiDevice.StatusHistory = DeviceStatusesFrom(history)
+	if len(apiDevice.StatusHistory) > 0 {
+		apiDevice.Status = &apiDevice.StatusHistory[0]
+	}
 	return c.JSON(http.StatusOK, apiDevice)
 }
 
@@ -110,44 +206,27 @@ func (s *Server) deleteDevice(c echo.Context) error {
 }
 
 func (s *Server) rebootDevice(c echo.Context) error {
-	id := c.Param("id")
-
-	uid, err := uuid.Parse(id)
-	if err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, "Invalid ID")
-	}
-
-	dev, err := s.db.GetDevice(c.Request().Context(), uid)
-	if err != nil {
-		s.log.Error(err, "could not get device")
-		return echo.NewHTTPError(http.StatusBadRequest, "Could not get device")
-	}
-
 	user, ok := authn.UserFromContext(c)
 	if !ok {
 		return echo.NewHTTPError(http.StatusUnauthorized, "Not logged in")
 	}
 
-	if dev.OrganizationID != user.OrganizationID {
-		return echo.NewHTTPError(http.StatusUnauthorized, "Device does not belong to user's organization")
+	dev, err := s.deviceFromParam(c, user)
+	if err != nil {
+		return err
 	}
 
-	err = s.mqw.RebootDevice(uid)
+	cmd, err := s.sendDeviceCommand(c.Request().Context(), dev.ID, database.DeviceCommandTypeReboot, user.ID)
 	if err != nil {
-		s.log.Error(err, "could not publish reboot message")
-		return echo.NewHTTPError(http.StatusInternalServerError, "Could not send reboot message")
+		return err
 	}
 
-	return c.NoContent(http.StatusOK)
-}
-
-type rebootDevicesParams struct {
-	DeviceIDs []uuid.UUID `json:"deviceIds"`
+	return c.JSON(http.StatusOK, DeviceCommandFrom(cmd))
 }
 
 func (s *Server) rebootDevices(c echo.Context) error {
-	var params rebootDevicesParams
-	err := c.Bind(&params)
+	var sel DeviceSelector
+	err := c.Bind(&sel)
 	if err != nil {
 		return err
 	}
@@ -157,29 +236,54 @@ func (s *Server) rebootDevices(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusUnauthorized, "Not authenticated")
 	}
 
-	allInOrg, err := s.db.AreDevicesInOrganization(c.Request().Context(), user.OrganizationID, params.DeviceIDs...)
+	deviceIDs, err := s.resolveDeviceSelector(c.Request().Context(), user.OrganizationID, sel)
 	if err != nil {
-		s.log.Error(err, "could not get devices in organization")
-		return echo.NewHTTPError(http.StatusInternalServerError, "Could not retrieve device information")
-	}
-	if !allInOrg {
-		return echo.NewHTTPError(http.StatusUnauthorized, "Not all devices are in user's organization")
+		return err
 	}
 
-	for _, deviceID := range params.DeviceIDs {
-		err = s.mqw.RebootDevice(deviceID)
+	// A device to which the command can't be sent doesn't stop the other devices from being rebooted.
+	rsp := RebootDevicesResponse{Commands: make([]DeviceCommand, 0, len(deviceIDs))}
+	for _, deviceID := range deviceIDs {
+		cmd, err := s.sendDeviceCommand(c.Request().Context(), deviceID, database.DeviceCommandTypeReboot, user.ID)
 		if err != nil {
-			s.log.Error(err, "could not publish reboot message")
-			return echo.NewHTTPError(http.StatusInternalServerError, "Could not send reboot message")
+			rsp.Failures = append(rsp.Failures, DeviceCommandFailure{
+				DeviceID: deviceID,
+				Error:    httpErrorMessage(err),
+			})
+			continue
 		}
+		rsp.Commands = append(rsp.Commands, DeviceCommandFrom(cmd))
 	}
 
-	return c.NoContent(http.StatusOK)
+	return c.JSON(http.StatusOK, rsp)
+}
+
+// httpErrorMessage returns the message of an error returned by a handler.
+func httpErrorMessage(err error) string {
+	var herr *echo.HTTPError
+	if errors.As(err, &herr) {
+		return fmt.Sprint(herr.Message)
+	}
+	return http.StatusText(http.StatusInternalServerError)
 }
 
 type getDevicesParams struct {
 	paginationParams
 	SearchName *string `query:"searchName"`
+	// Search searches in the name, serial number, MAC address and location description of devices.
+	Search  *string `query:"search"`
+	GroupID *string `query:"groupId"`
+	// Tags filters on devices which have all of the tags.
+	Tags       []string             `query:"tag"`
+	Status     *PrimaryDeviceStatus `query:"status"`
+	OsVersion  *string              `query:"osVersion"`
+	AppVersion *string              `query:"appVersion"`
+	// LastHeartbeatAfter and LastHeartbeatBefore are Unix timestamps.
+	LastHeartbeatAfter  *jsontypes.UnixTime `query:"lastHeartbeatAfter"`
+	LastHeartbeatBefore *jsontypes.UnixTime `query:"lastHeartbeatBefore"`
+	// SortBy is Name by default. Devices without a value for the field are sorted last.
+	SortBy         *DeviceSortField `query:"sortBy"`
+	SortDescending bool             `query:"sortDescending"`
 }
 
 func (s *Server) getDevices(c echo.Context) error {
@@ -195,11 +299,50 @@ func (s *Server) getDevices(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusUnauthorized, "Not authenticated")
 	}
 
+	var groupID *uuid.UUID
+	if params.GroupID != nil {
+		uid, err := uuid.Parse(*params.GroupID)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "Invalid group ID")
+		}
+		groupID = &uid
+	}
+
+	var online *bool
+	if params.Status != nil {
+		switch *params.Status {
+		case PrimaryDeviceStatusOnline, PrimaryDeviceStatusOffline:
+			isOnline := *params.Status == PrimaryDeviceStatusOnline
+			online = &isOnline
+		default:
+			return echo.NewHTTPError(http.StatusBadRequest, "Invalid status")
+		}
+	}
+
+	var sortBy database.DeviceSortField
+	if params.SortBy != nil {
+		var ok bool
+		if sortBy, ok = DeviceSortFieldToDB(*params.SortBy); !ok {
+			return echo.NewHTTPError(http.StatusBadRequest, "Invalid sort field")
+		}
+	}
+
 	dbDevices, err := s.db.GetDevices(c.Request().Context(), database.GetDevicesOpts{
 		OrganizationID: user.OrganizationID,
 		Limit:          params.MaxAmount,
 		Offset:         params.Offset,
 		NameSearch:     params.SearchName,
+		Search:         params.Search,
+		GroupID:        groupID,
+		Tags:           params.Tags,
+		Online:         online,
+		OsVersion:      params.OsVersion,
+		AppVersion:     params.AppVersion,
+
+		LastHeartbeatAfter:  unixTimePtrToTime(params.LastHeartbeatAfter),
+		LastHeartbeatBefore: unixTimePtrToTime(params.LastHeartbeatBefore),
+		SortBy:              sortBy,
+		SortDescending:      params.SortDescending,
 	})
 	if err != nil {
 		s.log.Error(err, "could not get devices")
@@ -265,8 +408,26 @@ func (s *Server) patchDevice(c echo.Context) error {
 	newAPIDevice.PrimaryStatus = oldAPIDevice.PrimaryStatus
 	newAPIDevice.OrganizationID = oldDBDevice.OrganizationID
 
+	if newAPIDevice.Tags, err = normalizeDeviceTags(newAPIDevice.Tags); err != nil {
+		return err
+	}
+	if newAPIDevice.Inventory, err = normalizeDeviceInventory(newAPIDevice.Inventory); err != nil {
+		return err
+	}
+	if newAPIDevice.GroupID != nil {
+		err = s.checkDeviceGroup(c.Request().Context(), user.OrganizationID, *newAPIDevice.GroupID)
+		if err != nil {
+			return err
+		}
+	}
+	err = s.checkDeviceConfigProfile(c.Request().Context(), user.OrganizationID, newAPIDevice.DeviceConfigProfileID)
+	if err != nil {
+		return err
+	}
+
 	newDBDevice := DeviceToDB(newAPIDevice)
 	newDBDevice.LastHeartbeat = oldDBDevice.LastHeartbeat
+	newDBDevice.LastDisconnect = oldDBDevice.LastDisconnect
 
 	err = s.db.ReplaceDevice(c.Request().Context(), newDBDevice)
 	if err != nil {
@@ -274,11 +435,31 @@ func (s *Server) patchDevice(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusInternalServerError, "Could not update the device in the database")
 	}
 
+	if !uuidPtrEqual(oldDBDevice.GroupID, newDBDevice.GroupID) {
+		// Networking services may be assigned to the old or new group of the device.
+		s.mqw.NetworkingConfigUpdated(user.OrganizationID)
+
+		oldAnnouncements, err := s.db.GetAnnouncementsForDevice(c.Request().Context(),
+			user.OrganizationID, oldDBDevice.GroupID, time.Now(),
+		)
+		if err != nil {
+			s.log.Error(err, "could not get announcements of old group of device")
+		} else {
+			s.devicesChangedGroup(c.Request().Context(), user.OrganizationID,
+				[]uuid.UUID{newDBDevice.ID}, oldAnnouncements, newDBDevice.GroupID,
+			)
+		}
+	}
+	if !uuidPtrEqual(oldDBDevice.GroupID, newDBDevice.GroupID) ||
+		!uuidPtrEqual(oldDBDevice.DeviceConfigProfileID, newDBDevice.DeviceConfigProfileID) {
+		s.mqw.DeviceConfigUpdated(user.OrganizationID)
+	}
+
 	return c.JSON(http.StatusOK, newAPIDevice)
 }
 
 func (s *Server) createDevice(c echo.Context) error {
-	org, ok := authn.OrganizationFromContext(c)
+	regToken, ok := authn.DeviceRegistrationTokenFromContext(c)
 	if !ok {
 		return echo.NewHTTPError(http.StatusUnauthorized, "Not authenticated")
 	}
@@ -289,8 +470,12 @@ func (s *Server) createDevice(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusInternalServerError, "Could not bind data")
 	}
 
-	dbDevice, token, err := s.db.CreateDevice(c.Request().Context(), org.ID, dev.Name)
+	dbDevice, token, err := s.db.RegisterDevice(c.Request().Context(), regToken.ID, dev.Name)
 	if err != nil {
+		if errors.Is(err, database.ErrRegistrationTokenUsedUp) {
+			return echo.NewHTTPError(http.StatusUnauthorized, "Invalid token")
+		}
+
 		s.log.Error(err, "could not create device")
 		return echo.NewHTTPError(http.StatusInternalServerError, "Could not create device")
 	}
@@ -308,6 +493,64 @@ func (s *Server) createDevice(c echo.Context) error {
 	return c.JSON(http.StatusOK, rsp)
 }
 
+// rotateDeviceToken issues a new token for the device which is logged in.
+// The token that was used for this request keeps working for a short while, so the device can switch over.
+func (s *Server) rotateDeviceToken(c echo.Context) error {
+	dev, err := deviceFromParam(c)
+	if err != nil {
+		return err
+	}
+
+	token, err := s.db.RotateDeviceToken(c.Request().Context(), dev.ID)
+	if err != nil {
+		s.log.Error(err, "could not rotate device token")
+		return echo.NewHTTPError(http.StatusInternalServerError, "Could not rotate token")
+	}
+
+	return c.JSON(http.StatusOK, RotateDeviceTokenResponse{Token: token})
+}
+
+// revokeDevice revokes all tokens and NATS credentials of a device, after which it has to be registered again.
+func (s *Server) revokeDevice(c echo.Context) error {
+	uid, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid ID")
+	}
+
+	user, ok := authn.UserFromContext(c)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Not authenticated")
+	}
+
+	dev, err := s.db.GetDevice(c.Request().Context(), uid)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return echo.NewHTTPError(http.StatusNotFound, "Device not found")
+		}
+		s.log.Error(err, "could not get device")
+		return echo.NewHTTPError(http.StatusInternalServerError, "Could not get device")
+	}
+
+	if dev.OrganizationID != user.OrganizationID {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Device does not belong to user's organization")
+	}
+
+	// The NATS credentials are revoked first: if that fails, the device can still be revoked again,
+	// while a device without tokens could otherwise keep using its NATS credentials.
+	// Revoking the NATS credentials again only moves the revocation time forward.
+	if err = s.nm.RevokeDeviceCredentials(c.Request().Context(), dev.ID); err != nil {
+		s.log.Error(err, "could not revoke device NATS credentials")
+		return echo.NewHTTPError(http.StatusInternalServerError, "Could not revoke device NATS credentials")
+	}
+
+	if err = s.db.DeleteDeviceTokens(c.Request().Context(), dev.ID); err != nil {
+		s.log.Error(err, "could not delete device tokens")
+		return echo.NewHTTPError(http.StatusInternalServerError, "Could not revoke device tokens")
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
 func (s *Server) generateNATSCredentials(c echo.Context) error {
 	dev, ok := authn.DeviceFromContext(c)
 	if !ok {
@@ -328,30 +571,95 @@ func (s *Server) generateNATSCredentials(c echo.Context) error {
 	return c.JSON(http.StatusOK, rsp)
 }
 
-func (s *Server) getRegistrationConfig(c echo.Context) error {
+type createRegistrationConfigRequest struct {
+	CreateDeviceRegistrationTokenRequest
+	// ProvisioningBundle indicates if a provisioning bundle (and QR code) should be included.
+	ProvisioningBundle bool `json:"provisioningBundle"`
+	// Encoding is the encoding of the provisioning bundle, base45 by default.
+	Encoding ProvisioningBundleEncoding `json:"encoding"`
+}
+
+// createRegistrationConfig creates a registration token and returns it with the config that devices need
+// to register with it. This is a POST, so API keys with only the read scope can't create tokens.
+func (s *Server) createRegistrationConfig(c echo.Context) error {
 	user, ok := authn.UserFromContext(c)
 	if !ok {
 		return echo.NewHTTPError(http.StatusUnauthorized, "Not authenticated")
 	}
 
-	token, err := s.db.CreateDeviceRegistrationToken(c.Request().Context(), user.OrganizationID)
+	var params createRegistrationConfigRequest
+	// All fields are optional, so the body may be empty.
+	empty, err := isEmptyBody(c.Request())
 	if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, "Could not create token")
+		return echo.NewHTTPError(http.StatusBadRequest, "Could not read body")
+	}
+	if !empty {
+		if err = c.Bind(&params); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "Could not bind data")
+		}
+	}
+	if params.Encoding == "" {
+		params.Encoding = ProvisioningBundleEncodingBase45
+	}
+
+	regToken, token, err := s.createDeviceRegistrationToken(c.Request().Context(),
+		user.OrganizationID, params.CreateDeviceRegistrationTokenRequest,
+	)
+	if err != nil {
+		return err
 	}
 
-	apiNetworkingServices, err := s.apiGetAllNetworkingServices(c.Request().Context(), user.OrganizationID)
+	// The device does not exist yet, so it only gets the services assigned to all devices
+	// and to the group that devices registered with the token are added to.
+	apiNetworkingServices, err := s.apiGetNetworkingServicesForDevice(c.Request().Context(),
+		user.OrganizationID, uuid.Nil, regToken.DeviceGroupID,
+	)
 	if err != nil {
 		return err
 	}
 
 	rsp := RegistrationConfig{
 		Token:              token,
+		TokenExpiresAt:     regToken.ExpiresAt,
 		OrganizationID:     user.OrganizationID,
 		NetworkingServices: apiNetworkingServices,
 	}
-	return c.JSON(http.StatusOK, rsp)
+
+	if params.ProvisioningBundle {
+		rsp.ProvisioningBundle, err = s.createProvisioningBundle(c.Request().Context(),
+			user.OrganizationID, regToken.DeviceGroupID, token, regToken.ExpiresAt, params.Encoding,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return c.JSON(http.StatusCreated, rsp)
+}
+
+const defaultDeviceStatusHistoryLimit = 10
+
+// isEmptyBody checks if the body of the request is empty. The length of the body isn't always known up front,
+// e.g. when it is chunked, so the first byte is read. The body can still be read completely afterwards.
+func isEmptyBody(r *http.Request) (bool, error) {
+	if r.Body == nil || r.Body == http.NoBody {
+		return true, nil
+	}
+
+	body := bufio.NewReader(r.Body)
+	if _, err := body.Peek(1); err != nil {
+		if errors.Is(err, io.EOF) {
+			return true, nil
+		}
+		return false, err
+	}
+
+	r.Body = ioutil.NopCloser(body)
+	return false, nil
 }
 
+// updateLastHeartbeat records a heartbeat of a device. The device can report its status with the heartbeat,
+// devices which don't send a body only update the time of their last heartbeat.
 func (s *Server) updateLastHeartbeat(c echo.Context) error {
 	dev, ok := authn.DeviceFromContext(c)
 	if !ok {
@@ -368,9 +676,33 @@ func (s *Server) updateLastHeartbeat(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, "ID mismatch")
 	}
 
-	err = s.db.ReplaceDeviceHeartbeat(c.Request().Context(), uid)
+	empty, err := isEmptyBody(c.Request())
 	if err != nil {
-		s.log.Error(err, "could not update last heartbeat")
+		return echo.NewHTTPError(http.StatusBadRequest, "Could not read request body")
+	}
+	if empty {
+		err = s.db.ReplaceDeviceHeartbeat(c.Request().Context(), uid)
+		if err != nil {
+			s.log.Error(err, "could not update last heartbeat")
+			return echo.NewHTTPError(http.StatusInternalServerError, "Could not update last heartbeat")
+		}
+
+		return c.NoContent(http.StatusNoContent)
+	}
+
+	var status DeviceStatus
+	if err = c.Bind(&status); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Could not bind data")
+	}
+
+	dbStatus := DeviceStatusToDB(uid, status)
+	if err = dbStatus.Validate(); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid status: %v", err))
+	}
+
+	_, err = s.db.CreateDeviceStatus(c.Request().Context(), dbStatus)
+	if err != nil {
+		s.log.Error(err, "could not store device status")
 		return echo.NewHTTPError(http.StatusInternalServerError, "Could not update last heartbeat")
 	}
 
@@ -393,20 +725,46 @@ func (s *Server) getAllNetworkingServices(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, "ID mismatch")
 	}
 
-	apiNetworkingServices, err := s.apiGetAllNetworkingServices(c.Request().Context(), dev.OrganizationID)
+	dbNetworkingServices, err := s.db.GetNetworkingServicesForDevice(c.Request().Context(),
+		dev.OrganizationID, dev.ID, dev.GroupID,
+	)
+	if err != nil {
+		s.log.Error(err, "could not read networking services from database")
+		return echo.NewHTTPError(http.StatusInternalServerError, "Could not read networking services from database")
+	}
+
+	apiNetworkingServices, err := s.apiNetworkingServicesFrom(dbNetworkingServices)
 	if err != nil {
 		return err
 	}
+
+	// Remember which config the device has, so it is only notified again when its config changes.
+	err = s.db.ReplaceDeviceNetworkingConfigHash(c.Request().Context(),
+		dev.ID, database.NetworkingConfigHash(dbNetworkingServices),
+	)
+	if err != nil {
+		s.log.Error(err, "could not update device networking config hash")
+	}
+
 	return c.JSON(http.StatusOK, apiNetworkingServices)
 }
 
-func (s *Server) apiGetAllNetworkingServices(ctx context.Context, organizationID uuid.UUID) ([]NetworkingService, error) {
-	dbNetworkingServices, err := s.db.GetAllNetworkingServices(ctx, organizationID)
+// apiGetNetworkingServicesForDevice retrieves the networking services that a device in the organization
+// (and optionally a group) is assigned.
+func (s *Server) apiGetNetworkingServicesForDevice(ctx context.Context,
+	organizationID, deviceID uuid.UUID,
+	groupID *uuid.UUID,
+) ([]NetworkingService, error) {
+	dbNetworkingServices, err := s.db.GetNetworkingServicesForDevice(ctx, organizationID, deviceID, groupID)
 	if err != nil {
 		s.log.Error(err, "could not read networking services from database")
 		return nil, echo.NewHTTPError(http.StatusInternalServerError, "Could not read networking services from database")
 	}
 
+	return s.apiNetworkingServicesFrom(dbNetworkingServices)
+}
+
+func (s *Server) apiNetworkingServicesFrom(dbNetworkingServices []database.NetworkingService) ([]NetworkingService, error) {
 	apiNetworkingServices := make([]NetworkingService, len(dbNetworkingServices))
 
 	for i, networkingService := range dbNetworkingServices {
@@ -424,3 +782,33 @@ func (s *Server) apiGetAllNetworkingServices(ctx context.Context, organizationID
 
 	return apiNetworkingServices, nil
 }
+
+type getUnknownCardTapsParams struct {
+	SinceTimestamp *int64 `query:"sinceTimestamp"`
+}
+
+// getUnknownCardTaps summarizes the taps of unknown cards per device, by default over the last week.
+func (s *Server) getUnknownCardTaps(c echo.Context) error {
+	user, ok := authn.UserFromContext(c)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Not authenticated")
+	}
+
+	var params getUnknownCardTapsParams
+	if err := c.Bind(&params); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid parameters")
+	}
+
+	since := time.Now().Add(-7 * 24 * time.Hour)
+	if params.SinceTimestamp != nil {
+		since = time.Unix(*params.SinceTimestamp, 0)
+	}
+
+	taps, err := s.db.GetUnknownCardTaps(c.Request().Context(), user.OrganizationID, since)
+	if err != nil {
+		s.log.Error(err, "could not get unknown card taps")
+		return echo.NewHTTPError(http.StatusInternalServerError, "Could not read unknown card taps from database")
+	}
+
+	return c.JSON(http.StatusOK, UnknownCardTapsFrom(taps))
+}
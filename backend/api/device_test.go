@@ -0,0 +1,61 @@
+package api
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeDeviceInventory(t *testing.T) {
+	str := func(s string) *string { return &s }
+
+	inv, err := normalizeDeviceInventory(DeviceInventory{
+		LocationDescription: str("  Hall 2 "),
+		SerialNumber:        str(""),
+		MacAddress:          str("00-1A-2B-3C-4D-5E"),
+		InstallDate:         str("2021-09-01"),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "Hall 2", *inv.LocationDescription)
+	assert.Nil(t, inv.SerialNumber)
+	assert.Equal(t, "00:1a:2b:3c:4d:5e", *inv.MacAddress)
+	assert.Equal(t, "2021-09-01", *inv.InstallDate)
+
+	_, err = normalizeDeviceInventory(DeviceInventory{MacAddress: str("not a MAC address")})
+	assert.Error(t, err)
+
+	_, err = normalizeDeviceInventory(DeviceInventory{InstallDate: str("01-09-2021")})
+	assert.Error(t, err)
+
+	db := DeviceInventoryToDB(inv)
+	assert.Equal(t, inv, DeviceInventoryFrom(db))
+}
+
+func TestIsEmptyBody(t *testing.T) {
+	empty := httptest.NewRequest(http.MethodPut, "/", nil)
+	isEmpty, err := isEmptyBody(empty)
+	require.NoError(t, err)
+	assert.True(t, isEmpty)
+
+	// The length of chunked bodies is unknown.
+	chunked := httptest.NewRequest(http.MethodPut, "/", ioutil.NopCloser(strings.NewReader("")))
+	chunked.ContentLength = -1
+	isEmpty, err = isEmptyBody(chunked)
+	require.NoError(t, err)
+	assert.True(t, isEmpty)
+
+	withStatus := httptest.NewRequest(http.MethodPut, "/", ioutil.NopCloser(strings.NewReader(`{"uptimeSeconds":1}`)))
+	withStatus.ContentLength = -1
+	isEmpty, err = isEmptyBody(withStatus)
+	require.NoError(t, err)
+	assert.False(t, isEmpty)
+
+	body, err := ioutil.ReadAll(withStatus.Body)
+	require.NoError(t, err)
+	assert.Equal(t, `{"uptimeSeconds":1}`, string(body))
+}
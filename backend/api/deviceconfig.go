@@ -0,0 +1,230 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo"
+	"google.golang.org/protobuf/proto"
+
+	authn "gitlab.com/timeterm/timeterm/backend/auhtn"
+	"gitlab.com/timeterm/timeterm/backend/database"
+	"gitlab.com/timeterm/timeterm/backend/devcfg"
+)
+
+// checkDeviceConfigProfile checks if the profile which is assigned to an organization, group or device
+// exists and belongs to the organization. Profiles can be unassigned, so id may be nil.
+func (s *Server) checkDeviceConfigProfile(ctx context.Context, organizationID uuid.UUID, id *uuid.UUID) error {
+	if id == nil {
+		return nil
+	}
+
+	profile, err := s.db.GetDeviceConfigProfile(ctx, *id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return echo.NewHTTPError(http.StatusBadRequest, "Device config profile not found")
+		}
+
+		s.log.Error(err, "could not get device config profile")
+		return echo.NewHTTPError(http.StatusInternalServerError, "Could not read device config profile from database")
+	}
+
+	if profile.OrganizationID != organizationID {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Device config profile does not belong to user's organization")
+	}
+	return nil
+}
+
+// deviceConfigProfileFromRequest validates the request and converts it to a profile in the organization.
+func deviceConfigProfileFromRequest(organizationID uuid.UUID,
+	req CreateDeviceConfigProfileRequest,
+) (database.DeviceConfigProfile, error) {
+	if req.Name = strings.TrimSpace(req.Name); req.Name == "" {
+		return database.DeviceConfigProfile{}, echo.NewHTTPError(http.StatusBadRequest, "Name is required")
+	}
+
+	cfg := DeviceConfigToProto(req.Config)
+	if err := devcfg.Validate(cfg); err != nil {
+		return database.DeviceConfigProfile{}, echo.NewHTTPError(http.StatusBadRequest, "Invalid config: "+err.Error())
+	}
+
+	data, err := proto.Marshal(cfg)
+	if err != nil {
+		return database.DeviceConfigProfile{}, err
+	}
+
+	return database.DeviceConfigProfile{
+		OrganizationID: organizationID,
+		Name:           req.Name,
+		Config:         data,
+	}, nil
+}
+
+func (s *Server) getDeviceConfigProfiles(c echo.Context) error {
+	user, ok := authn.UserFromContext(c)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Not authenticated")
+	}
+
+	profiles, err := s.db.GetDeviceConfigProfiles(c.Request().Context(), user.OrganizationID)
+	if err != nil {
+		s.log.Error(err, "could not get device config profiles")
+		return echo.NewHTTPError(http.StatusInternalServerError, "Could not read device config profiles from database")
+	}
+
+	apiProfiles, err := DeviceConfigProfilesFrom(profiles)
+	if err != nil {
+		s.log.Error(err, "could not convert device config profiles")
+		return echo.NewHTTPError(http.StatusInternalServerError, "Could not read device config profiles")
+	}
+
+	return c.JSON(http.StatusOK, apiProfiles)
+}
+
+func (s *Server) createDeviceConfigProfile(c echo.Context) error {
+	user, ok := authn.UserFromContext(c)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Not authenticated")
+	}
+
+	var req CreateDeviceConfigProfileRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Could not bind data")
+	}
+
+	profile, err := deviceConfigProfileFromRequest(user.OrganizationID, req)
+	if err != nil {
+		return err
+	}
+
+	profile, err = s.db.CreateDeviceConfigProfile(c.Request().Context(), profile)
+	if err != nil {
+		if errors.Is(err, database.ErrConflict) {
+			return echo.NewHTTPError(http.StatusConflict, "Device config profile already exists")
+		}
+
+		s.log.Error(err, "could not create device config profile")
+		return echo.NewHTTPError(http.StatusInternalServerError, "Could not create device config profile")
+	}
+
+	apiProfile, err := DeviceConfigProfileFrom(profile)
+	if err != nil {
+		s.log.Error(err, "could not convert device config profile")
+		return echo.NewHTTPError(http.StatusInternalServerError, "Could not read device config profile")
+	}
+
+	return c.JSON(http.StatusCreated, apiProfile)
+}
+
+func (s *Server) replaceDeviceConfigProfile(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid ID")
+	}
+
+	user, ok := authn.UserFromContext(c)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Not authenticated")
+	}
+
+	var req CreateDeviceConfigProfileRequest
+	if err = c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Could not bind data")
+	}
+
+	profile, err := deviceConfigProfileFromRequest(user.OrganizationID, req)
+	if err != nil {
+		return err
+	}
+	profile.ID = id
+
+	err = s.db.ReplaceDeviceConfigProfile(c.Request().Context(), profile)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return echo.NewHTTPError(http.StatusNotFound, "Device config profile not found")
+		}
+		if errors.Is(err, database.ErrConflict) {
+			return echo.NewHTTPError(http.StatusConflict, "Device config profile already exists")
+		}
+
+		s.log.Error(err, "could not replace device config profile")
+		return echo.NewHTTPError(http.StatusInternalServerError, "Could not update device config profile")
+	}
+	s.mqw.DeviceConfigUpdated(user.OrganizationID)
+
+	profile, err = s.db.GetDeviceConfigProfile(c.Request().Context(), id)
+	if err != nil {
+		s.log.Error(err, "could not get device config profile")
+		return echo.NewHTTPError(http.StatusInternalServerError, "Could not read device config profile from database")
+	}
+
+	apiProfile, err := DeviceConfigProfileFrom(profile)
+	if err != nil {
+		s.log.Error(err, "could not convert device config profile")
+		return echo.NewHTTPError(http.StatusInternalServerError, "Could not read device config profile")
+	}
+
+	return c.JSON(http.StatusOK, apiProfile)
+}
+
+func (s *Server) deleteDeviceConfigProfile(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid ID")
+	}
+
+	user, ok := authn.UserFromContext(c)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Not authenticated")
+	}
+
+	err = s.db.DeleteDeviceConfigProfile(c.Request().Context(), user.OrganizationID, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return echo.NewHTTPError(http.StatusNotFound, "Device config profile not found")
+		}
+
+		s.log.Error(err, "could not delete device config profile")
+		return echo.NewHTTPError(http.StatusInternalServerError, "Could not delete device config profile")
+	}
+	// The organization, groups and devices which had the profile no longer have a profile assigned.
+	s.mqw.DeviceConfigUpdated(user.OrganizationID)
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// getDeviceConfig lets devices retrieve their effective device config,
+// which combines the profiles of their organization, group and the device itself.
+func (s *Server) getDeviceConfig(c echo.Context) error {
+	dev, err := deviceFromContextParam(c)
+	if err != nil {
+		return err
+	}
+
+	layers, err := s.db.GetDeviceConfigLayers(c.Request().Context(), dev.ID)
+	if err != nil {
+		s.log.Error(err, "could not read device config profiles from database")
+		return echo.NewHTTPError(http.StatusInternalServerError, "Could not read device config profiles from database")
+	}
+
+	cfg, err := devcfg.Merge(layers.OrganizationConfig, layers.GroupConfig, layers.DeviceConfig)
+	if err != nil {
+		s.log.Error(err, "could not merge device config profiles")
+		return echo.NewHTTPError(http.StatusInternalServerError, "Could not read device config")
+	}
+
+	// Remember which config the device has, so it is only notified again when its config changes.
+	hash, err := devcfg.Hash(cfg)
+	if err == nil {
+		err = s.db.ReplaceDeviceConfigHash(c.Request().Context(), dev.ID, hash)
+	}
+	if err != nil {
+		s.log.Error(err, "could not update device config hash")
+	}
+
+	return c.JSON(http.StatusOK, DeviceConfigFrom(cfg))
+}
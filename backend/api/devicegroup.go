@@ -0,0 +1,287 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo"
+
+	authn "gitlab.com/timeterm/timeterm/backend/auhtn"
+	"gitlab.com/timeterm/timeterm/backend/database"
+)
+
+const maxDeviceTagLength = 64
+
+// normalizeDeviceTags trims the tags and removes duplicates. The returned slice is never nil.
+func normalizeDeviceTags(tags []string) ([]string, error) {
+	seen := make(map[string]struct{}, len(tags))
+	normalized := make([]string, 0, len(tags))
+
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		if tag == "" {
+			return nil, echo.NewHTTPError(http.StatusBadRequest, "Tags can not be empty")
+		}
+		if len(tag) > maxDeviceTagLength {
+			return nil, echo.NewHTTPError(http.StatusBadRequest, "Tag is too long")
+		}
+
+		if _, ok := seen[tag]; !ok {
+			seen[tag] = struct{}{}
+			normalized = append(normalized, tag)
+		}
+	}
+	sort.Strings(normalized)
+
+	return normalized, nil
+}
+
+// checkDeviceGroup checks if the group exists and belongs to the organization.
+func (s *Server) checkDeviceGroup(ctx context.Context, organizationID, groupID uuid.UUID) error {
+	group, err := s.db.GetDeviceGroup(ctx, groupID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return echo.NewHTTPError(http.StatusBadRequest, "Device group not found")
+		}
+
+		s.log.Error(err, "could not get device group")
+		return echo.NewHTTPError(http.StatusInternalServerError, "Could not read device group from database")
+	}
+
+	if group.OrganizationID != organizationID {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Device group does not belong to user's organization")
+	}
+	return nil
+}
+
+// updateChannelFromRequest returns the update channel of the group, which is stable if it isn't set.
+func updateChannelFromRequest(req CreateDeviceGroupRequest) (database.UpdateChannel, error) {
+	if req.UpdateChannel == nil {
+		return database.UpdateChannelStable, nil
+	}
+
+	channel, ok := UpdateChannelToDB(*req.UpdateChannel)
+	if !ok {
+		return "", echo.NewHTTPError(http.StatusBadRequest, "Invalid update channel")
+	}
+	return channel, nil
+}
+
+func uuidPtrEqual(a, b *uuid.UUID) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// resolveDeviceSelector retrieves the IDs of the devices in the organization which are selected.
+// Devices which are explicitly selected by their ID must all be in the organization.
+func (s *Server) resolveDeviceSelector(ctx context.Context,
+	organizationID uuid.UUID,
+	sel DeviceSelector,
+) ([]uuid.UUID, error) {
+	allInOrg, err := s.db.AreDevicesInOrganization(ctx, organizationID, sel.DeviceIDs...)
+	if err != nil {
+		s.log.Error(err, "could not get devices in organization")
+		return nil, echo.NewHTTPError(http.StatusInternalServerError, "Could not retrieve device information")
+	}
+	if !allInOrg {
+		return nil, echo.NewHTTPError(http.StatusUnauthorized, "Not all devices are in user's organization")
+	}
+
+	ids, err := s.db.GetDeviceIDsBySelector(ctx, organizationID, DeviceSelectorToDB(sel))
+	if err != nil {
+		s.log.Error(err, "could not get selected devices")
+		return nil, echo.NewHTTPError(http.StatusInternalServerError, "Could not retrieve device information")
+	}
+	return ids, nil
+}
+
+func (s *Server) getDeviceGroups(c echo.Context) error {
+	user, ok := authn.UserFromContext(c)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Not authenticated")
+	}
+
+	groups, err := s.db.GetDeviceGroups(c.Request().Context(), user.OrganizationID)
+	if err != nil {
+		s.log.Error(err, "could not get device groups")
+		return echo.NewHTTPError(http.StatusInternalServerError, "Could not read device groups from database")
+	}
+
+	return c.JSON(http.StatusOK, DeviceGroupsFrom(groups))
+}
+
+func (s *Server) createDeviceGroup(c echo.Context) error {
+	user, ok := authn.UserFromContext(c)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Not authenticated")
+	}
+
+	var req CreateDeviceGroupRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Could not bind data")
+	}
+	if req.Name = strings.TrimSpace(req.Name); req.Name == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "Name is required")
+	}
+
+	channel, err := updateChannelFromRequest(req)
+	if err != nil {
+		return err
+	}
+	err = s.checkDeviceConfigProfile(c.Request().Context(), user.OrganizationID, req.DeviceConfigProfileID)
+	if err != nil {
+		return err
+	}
+
+	group, err := s.db.CreateDeviceGroup(c.Request().Context(), user.OrganizationID, req.Name)
+	if err != nil {
+		if errors.Is(err, database.ErrConflict) {
+			return echo.NewHTTPError(http.StatusConflict, "Device group already exists")
+		}
+
+		s.log.Error(err, "could not create device group")
+		return echo.NewHTTPError(http.StatusInternalServerError, "Could not create device group")
+	}
+
+	if channel != group.UpdateChannel || req.DeviceConfigProfileID != nil {
+		group.UpdateChannel = channel
+		group.DeviceConfigProfileID = req.DeviceConfigProfileID
+		if err = s.db.ReplaceDeviceGroup(c.Request().Context(), group); err != nil {
+			s.log.Error(err, "could not set update channel and device config profile of device group")
+			return echo.NewHTTPError(http.StatusInternalServerError, "Could not create device group")
+		}
+	}
+
+	return c.JSON(http.StatusCreated, DeviceGroupFrom(group))
+}
+
+func (s *Server) replaceDeviceGroup(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid ID")
+	}
+
+	user, ok := authn.UserFromContext(c)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Not authenticated")
+	}
+
+	var req CreateDeviceGroupRequest
+	if err = c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Could not bind data")
+	}
+	if req.Name = strings.TrimSpace(req.Name); req.Name == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "Name is required")
+	}
+
+	channel, err := updateChannelFromRequest(req)
+	if err != nil {
+		return err
+	}
+	err = s.checkDeviceConfigProfile(c.Request().Context(), user.OrganizationID, req.DeviceConfigProfileID)
+	if err != nil {
+		return err
+	}
+
+	err = s.db.ReplaceDeviceGroup(c.Request().Context(), database.DeviceGroup{
+		ID:                    id,
+		OrganizationID:        user.OrganizationID,
+		Name:                  req.Name,
+		UpdateChannel:         channel,
+		DeviceConfigProfileID: req.DeviceConfigProfileID,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return echo.NewHTTPError(http.StatusNotFound, "Device group not found")
+		}
+		if errors.Is(err, database.ErrConflict) {
+			return echo.NewHTTPError(http.StatusConflict, "Device group already exists")
+		}
+
+		s.log.Error(err, "could not replace device group")
+		return echo.NewHTTPError(http.StatusInternalServerError, "Could not update device group")
+	}
+	// The devices in the group may have a different device config profile now.
+	s.mqw.DeviceConfigUpdated(user.OrganizationID)
+
+	group, err := s.db.GetDeviceGroup(c.Request().Context(), id)
+	if err != nil {
+		s.log.Error(err, "could not get device group")
+		return echo.NewHTTPError(http.StatusInternalServerError, "Could not read device group from database")
+	}
+
+	return c.JSON(http.StatusOK, DeviceGroupFrom(group))
+}
+
+func (s *Server) deleteDeviceGroup(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid ID")
+	}
+
+	user, ok := authn.UserFromContext(c)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Not authenticated")
+	}
+
+	// The announcements for the group are no longer shown on its devices once it has been deleted.
+	deviceIDs, err := s.db.GetDeviceIDsBySelector(c.Request().Context(), user.OrganizationID, database.DeviceSelector{
+		GroupIDs: []uuid.UUID{id},
+	})
+	if err != nil {
+		s.log.Error(err, "could not get devices of device group")
+		return echo.NewHTTPError(http.StatusInternalServerError, "Could not delete device group")
+	}
+	oldAnnouncements, err := s.db.GetAnnouncementsForDevice(c.Request().Context(), user.OrganizationID, &id, time.Now())
+	if err != nil {
+		s.log.Error(err, "could not get announcements of device group")
+		return echo.NewHTTPError(http.StatusInternalServerError, "Could not delete device group")
+	}
+
+	err = s.db.DeleteDeviceGroup(c.Request().Context(), user.OrganizationID, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return echo.NewHTTPError(http.StatusNotFound, "Device group not found")
+		}
+
+		s.log.Error(err, "could not delete device group")
+		return echo.NewHTTPError(http.StatusInternalServerError, "Could not delete device group")
+	}
+
+	// Devices in the group lose the networking services and device config profile assigned to it.
+	s.mqw.NetworkingConfigUpdated(user.OrganizationID)
+	s.mqw.DeviceConfigUpdated(user.OrganizationID)
+	// The schedules of the group have been deleted as well.
+	s.schw.SchedulesUpdated()
+	if len(deviceIDs) > 0 {
+		s.devicesChangedGroup(c.Request().Context(), user.OrganizationID, deviceIDs, oldAnnouncements, nil)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (s *Server) getDeviceTags(c echo.Context) error {
+	user, ok := authn.UserFromContext(c)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Not authenticated")
+	}
+
+	tags, err := s.db.GetDeviceTags(c.Request().Context(), user.OrganizationID)
+	if err != nil {
+		s.log.Error(err, "could not get device tags")
+		return echo.NewHTTPError(http.StatusInternalServerError, "Could not read device tags from database")
+	}
+	if tags == nil {
+		tags = []string{}
+	}
+
+	return c.JSON(http.StatusOK, tags)
+}
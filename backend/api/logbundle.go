@@ -0,0 +1,134 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo"
+
+	authn "gitlab.com/timeterm/timeterm/backend/auhtn"
+	"gitlab.com/timeterm/timeterm/backend/database"
+	"gitlab.com/timeterm/timeterm/backend/messages"
+)
+
+// gzipMagic is the header with which all gzip files start.
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// uploadDeviceLogBundle stores the logs which a device uploads in response to an UploadLogs command.
+func (s *Server) uploadDeviceLogBundle(c echo.Context) error {
+	dev, ok := authn.DeviceFromContext(c)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Not authenticated")
+	}
+
+	cmd, err := s.deviceUploadCommandFromParam(c, dev, database.DeviceCommandTypeUploadLogs)
+	if err != nil {
+		return err
+	}
+
+	body := http.MaxBytesReader(c.Response(), c.Request().Body, database.MaxDeviceLogBundleSize)
+	data, err := ioutil.ReadAll(body)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusRequestEntityTooLarge, "Log bundle is too large")
+	}
+	if !bytes.HasPrefix(data, gzipMagic) {
+		return echo.NewHTTPError(http.StatusBadRequest, "Log bundle must be gzip-compressed")
+	}
+
+	bundleID := uuid.New()
+	nonce, encrypted, err := s.msgw.EncryptBlob(dev.OrganizationID, messages.BlobKindDeviceLogBundle, bundleID, data)
+	if err != nil {
+		s.log.Error(err, "could not encrypt log bundle")
+		return echo.NewHTTPError(http.StatusInternalServerError, "Could not store log bundle")
+	}
+
+	bundle, err := s.db.CreateDeviceLogBundle(c.Request().Context(), database.DeviceLogBundle{
+		ID:        bundleID,
+		DeviceID:  dev.ID,
+		CommandID: &cmd.ID,
+		Size:      int64(len(data)),
+	}, database.EncryptedBlob{
+		Nonce: nonce,
+		Data:  encrypted,
+	})
+	if err != nil {
+		if errors.Is(err, database.ErrConflict) {
+			return echo.NewHTTPError(http.StatusConflict, "Log bundle already uploaded")
+		}
+
+		s.log.Error(err, "could not create log bundle")
+		return echo.NewHTTPError(http.StatusInternalServerError, "Could not store log bundle")
+	}
+
+	err = s.db.ReplaceDeviceCommandStatus(c.Request().Context(), dev.ID, cmd.ID,
+		database.DeviceCommandStatusSucceeded, sql.NullString{}, sql.NullString{},
+	)
+	// The device may already have reported the result of the command.
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		s.log.Error(err, "could not update command status", "commandId", cmd.ID)
+	}
+
+	return c.JSON(http.StatusCreated, DeviceLogBundleFrom(bundle))
+}
+
+func (s *Server) getDeviceLogBundles(c echo.Context) error {
+	user, ok := authn.UserFromContext(c)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Not authenticated")
+	}
+
+	dev, err := s.deviceFromParam(c, user)
+	if err != nil {
+		return err
+	}
+
+	bundles, err := s.db.GetDeviceLogBundles(c.Request().Context(), dev.ID)
+	if err != nil {
+		s.log.Error(err, "could not get device log bundles")
+		return echo.NewHTTPError(http.StatusInternalServerError, "Could not get log bundles")
+	}
+
+	return c.JSON(http.StatusOK, DeviceLogBundlesFrom(bundles))
+}
+
+func (s *Server) downloadDeviceLogBundle(c echo.Context) error {
+	user, ok := authn.UserFromContext(c)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Not authenticated")
+	}
+
+	dev, err := s.deviceFromParam(c, user)
+	if err != nil {
+		return err
+	}
+
+	bundleID, err := uuid.Parse(c.Param("bundleId"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid log bundle ID")
+	}
+
+	bundle, data, err := s.db.GetDeviceLogBundle(c.Request().Context(), bundleID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		s.log.Error(err, "could not get device log bundle")
+		return echo.NewHTTPError(http.StatusInternalServerError, "Could not get log bundle")
+	}
+	if errors.Is(err, sql.ErrNoRows) || bundle.DeviceID != dev.ID {
+		return echo.NewHTTPError(http.StatusNotFound, "Log bundle not found")
+	}
+
+	decrypted, err := s.msgw.DecryptBlob(dev.OrganizationID, messages.BlobKindDeviceLogBundle, bundle.ID, data.Nonce, data.Data)
+	if err != nil {
+		s.log.Error(err, "could not decrypt device log bundle")
+		return echo.NewHTTPError(http.StatusInternalServerError, "Could not decrypt log bundle")
+	}
+
+	filename := fmt.Sprintf("timeterm-logs-%s-%s.tar.gz", dev.ID, bundle.CreatedAt.UTC().Format("20060102T150405Z"))
+	c.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%q", filename))
+
+	return c.Blob(http.StatusOK, "application/gzip", decrypted)
+}
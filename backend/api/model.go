@@ -2,22 +2,55 @@ package api
 
 import (
 	"database/sql"
+	"encoding/hex"
 	"encoding/json"
 	"time"
 
 	"github.com/google/uuid"
+	"google.golang.org/protobuf/proto"
 
 	devcfgpb "gitlab.com/timeterm/timeterm/proto/go/devcfg"
 
 	"gitlab.com/timeterm/timeterm/backend/database"
 	"gitlab.com/timeterm/timeterm/backend/messages"
 	"gitlab.com/timeterm/timeterm/backend/pkg/jsontypes"
+	"gitlab.com/timeterm/timeterm/backend/scheduler"
 )
 
 type Organization struct {
-	ID      uuid.UUID               `json:"id"`
-	Name    string                  `json:"name"`
-	Zermelo OrganizationZermeloInfo `json:"zermelo"`
+	ID                uuid.UUID                `json:"id"`
+	Name              string                   `json:"name"`
+	Zermelo           OrganizationZermeloInfo  `json:"zermelo"`
+	RequireStudentPin bool                     `json:"requireStudentPin"`
+	DeviceAlerts      OrganizationDeviceAlerts `json:"deviceAlerts"`
+	// DeviceConfigProfileID is the device config profile of all devices in the organization, if set.
+	DeviceConfigProfileID *uuid.UUID `json:"deviceConfigProfileId"`
+}
+
+// OrganizationDeviceAlerts configures when and how administrators are alerted about offline devices.
+// Alerts are always added to the admin messages, and optionally sent to a webhook and by email.
+type OrganizationDeviceAlerts struct {
+	// OfflineThresholdSeconds is the time after which offline devices are reported. Alerting is disabled if it is null.
+	OfflineThresholdSeconds *int32  `json:"offlineThresholdSeconds"`
+	WebhookURL              *string `json:"webhookUrl"`
+	Email                   *string `json:"email"`
+}
+
+// OrganizationBranding is the colour scheme and logo which devices of an organization show.
+type OrganizationBranding struct {
+	Colors OrganizationBrandingColors `json:"colors"`
+	// LogoETag is the ETag of the logo, so devices can tell if their copy of the logo is outdated.
+	// It is null if the organization has no logo.
+	LogoETag *string `json:"logoEtag"`
+}
+
+// OrganizationBrandingColors are hexadecimal RGB colours (#rrggbb).
+// Devices use their default colour for colours which are null.
+type OrganizationBrandingColors struct {
+	Primary    *string `json:"primary"`
+	Accent     *string `json:"accent"`
+	Background *string `json:"background"`
+	Text       *string `json:"text"`
 }
 
 type OrganizationZermeloInfo struct {
@@ -47,6 +80,383 @@ type Device struct {
 	OrganizationID uuid.UUID           `json:"organizationId"`
 	Name           string              `json:"name"`
 	PrimaryStatus  PrimaryDeviceStatus `json:"primaryStatus"`
+	GroupID        *uuid.UUID          `json:"groupId"`
+	Tags           []string            `json:"tags"`
+	// DeviceConfigProfileID overrides the device config profiles of the organization and group, if set.
+	DeviceConfigProfileID *uuid.UUID      `json:"deviceConfigProfileId"`
+	Inventory             DeviceInventory `json:"inventory"`
+	// Capabilities are the optional features which the device has reported that it supports.
+	Capabilities []DeviceCapability `json:"capabilities"`
+	// Status is the last status reported by the device. It is only included for single devices.
+	Status *DeviceStatus `json:"status,omitempty"`
+	// StatusHistory contains the last status reports of the device, the most recent first.
+	// It is only included for single devices.
+	StatusHistory []DeviceStatus `json:"statusHistory,omitempty"`
+}
+
+// DeviceInventory is the inventory information which administrators keep about a device.
+type DeviceInventory struct {
+	LocationDescription *string `json:"locationDescription"`
+	SerialNumber        *string `json:"serialNumber"`
+	HardwareRevision    *string `json:"hardwareRevision"`
+	MacAddress          *string `json:"macAddress"`
+	// InstallDate is formatted as YYYY-MM-DD.
+	InstallDate *string `json:"installDate"`
+	Notes       *string `json:"notes"`
+}
+
+type DeviceCapability string
+
+const (
+	// DeviceCapabilityScreenPower is required for the ScreenOff and ScreenOn commands.
+	DeviceCapabilityScreenPower DeviceCapability = "ScreenPower"
+)
+
+type DeviceSortField string
+
+const (
+	DeviceSortFieldName          DeviceSortField = "Name"
+	DeviceSortFieldLastHeartbeat DeviceSortField = "LastHeartbeat"
+	DeviceSortFieldSerialNumber  DeviceSortField = "SerialNumber"
+	DeviceSortFieldInstallDate   DeviceSortField = "InstallDate"
+)
+
+type CardReaderStatus string
+
+const (
+	CardReaderStatusOk           CardReaderStatus = "Ok"
+	CardReaderStatusNotConnected CardReaderStatus = "NotConnected"
+	CardReaderStatusError        CardReaderStatus = "Error"
+)
+
+// DeviceStatus is the status which a device reports with its heartbeat.
+// Devices only have to report the fields they know about.
+type DeviceStatus struct {
+	ReportedAt    time.Time `json:"reportedAt"`
+	OsVersion     *string   `json:"osVersion,omitempty"`
+	AppVersion    *string   `json:"appVersion,omitempty"`
+	UptimeSeconds *int64    `json:"uptimeSeconds,omitempty"`
+	IpAddress     *string   `json:"ipAddress,omitempty"`
+	SSID          *string   `json:"ssid,omitempty"`
+	// SignalStrength is the strength of the Wi-Fi signal, as a percentage.
+	SignalStrength   *int32            `json:"signalStrength,omitempty"`
+	CardReaderStatus *CardReaderStatus `json:"cardReaderStatus,omitempty"`
+	FreeDiskBytes    *int64            `json:"freeDiskBytes,omitempty"`
+	LastError        *string           `json:"lastError,omitempty"`
+}
+
+type DeviceCommandType string
+
+const (
+	DeviceCommandTypeReboot                      DeviceCommandType = "Reboot"
+	DeviceCommandTypeRetrieveNewNetworkingConfig DeviceCommandType = "RetrieveNewNetworkingConfig"
+	DeviceCommandTypeScreenOff                   DeviceCommandType = "ScreenOff"
+	DeviceCommandTypeScreenOn                    DeviceCommandType = "ScreenOn"
+	DeviceCommandTypeUploadLogs                  DeviceCommandType = "UploadLogs"
+	DeviceCommandTypeTakeScreenshot              DeviceCommandType = "TakeScreenshot"
+)
+
+type DeviceCommandStatus string
+
+const (
+	DeviceCommandStatusQueued    DeviceCommandStatus = "Queued"
+	DeviceCommandStatusStored    DeviceCommandStatus = "Stored"
+	DeviceCommandStatusAcked     DeviceCommandStatus = "Acked"
+	DeviceCommandStatusSucceeded DeviceCommandStatus = "Succeeded"
+	DeviceCommandStatusFailed    DeviceCommandStatus = "Failed"
+)
+
+// DeviceCommand is a command sent to a device. Commands are stored until the device retrieves them,
+// devices acknowledge commands when they receive them and report the result when they have executed them.
+// Commands which are not completed before they expire are marked as failed.
+type DeviceCommand struct {
+	ID              uuid.UUID           `json:"id"`
+	DeviceID        uuid.UUID           `json:"deviceId"`
+	Type            DeviceCommandType   `json:"type"`
+	Status          DeviceCommandStatus `json:"status"`
+	CreatedByUserID *uuid.UUID          `json:"createdByUserId,omitempty"`
+	CreatedAt       time.Time           `json:"createdAt"`
+	ExpiresAt       time.Time           `json:"expiresAt"`
+	StoredAt        *time.Time          `json:"storedAt,omitempty"`
+	AckedAt         *time.Time          `json:"ackedAt,omitempty"`
+	CompletedAt     *time.Time          `json:"completedAt,omitempty"`
+	Error           *string             `json:"error,omitempty"`
+	Output          *string             `json:"output,omitempty"`
+}
+
+// RebootDevicesResponse contains the reboot commands which have been sent,
+// and the devices to which the reboot command could not be sent.
+type RebootDevicesResponse struct {
+	Commands []DeviceCommand        `json:"commands"`
+	Failures []DeviceCommandFailure `json:"failures,omitempty"`
+}
+
+type DeviceCommandFailure struct {
+	DeviceID uuid.UUID `json:"deviceId"`
+	Error    string    `json:"error"`
+}
+
+type CreateDeviceCommandRequest struct {
+	Type DeviceCommandType `json:"type"`
+}
+
+// DeviceLogBundle is a gzip-compressed tarball of logs, uploaded by a device after an UploadLogs command.
+type DeviceLogBundle struct {
+	ID        uuid.UUID  `json:"id"`
+	DeviceID  uuid.UUID  `json:"deviceId"`
+	CommandID *uuid.UUID `json:"commandId,omitempty"`
+	CreatedAt time.Time  `json:"createdAt"`
+	Size      int64      `json:"size"`
+}
+
+// DeviceScreenshot is a PNG screenshot of the display of a device.
+type DeviceScreenshot struct {
+	ID        uuid.UUID  `json:"id"`
+	DeviceID  uuid.UUID  `json:"deviceId"`
+	CommandID *uuid.UUID `json:"commandId,omitempty"`
+	CreatedAt time.Time  `json:"createdAt"`
+	Size      int64      `json:"size"`
+}
+
+// DeviceSchedule periodically sends a command (the action) to all devices in a device group.
+type DeviceSchedule struct {
+	ID             uuid.UUID         `json:"id"`
+	OrganizationID uuid.UUID         `json:"organizationId"`
+	DeviceGroupID  uuid.UUID         `json:"deviceGroupId"`
+	Action         DeviceCommandType `json:"action"`
+	// CronExpression is a standard cron expression with five fields (minute, hour, day of month, month
+	// and day of week), e.g. "0 3 * * *" for every night at 03:00.
+	CronExpression string     `json:"cronExpression"`
+	Timezone       string     `json:"timezone"`
+	Enabled        bool       `json:"enabled"`
+	CreatedAt      time.Time  `json:"createdAt"`
+	LastRunAt      *time.Time `json:"lastRunAt,omitempty"`
+	NextRunAt      *time.Time `json:"nextRunAt,omitempty"`
+}
+
+type CreateDeviceScheduleRequest struct {
+	DeviceGroupID  uuid.UUID         `json:"deviceGroupId"`
+	Action         DeviceCommandType `json:"action"`
+	CronExpression string            `json:"cronExpression"`
+	// Timezone is an IANA timezone name, UTC by default.
+	Timezone string `json:"timezone"`
+	// Enabled is true by default.
+	Enabled *bool `json:"enabled"`
+}
+
+type AnnouncementSeverity string
+
+const (
+	AnnouncementSeverityInfo     AnnouncementSeverity = "Info"
+	AnnouncementSeverityWarning  AnnouncementSeverity = "Warning"
+	AnnouncementSeverityCritical AnnouncementSeverity = "Critical"
+)
+
+// Announcement is a notice which is shown on the devices in an organization from StartsAt until EndsAt.
+type Announcement struct {
+	ID             uuid.UUID            `json:"id"`
+	OrganizationID uuid.UUID            `json:"organizationId"`
+	Text           string               `json:"text"`
+	Severity       AnnouncementSeverity `json:"severity"`
+	StartsAt       time.Time            `json:"startsAt"`
+	EndsAt         *time.Time           `json:"endsAt,omitempty"`
+	// AllDevices indicates if the announcement is shown on all devices in the organization.
+	// Otherwise, it is only shown on the devices in the groups in DeviceGroupIDs.
+	AllDevices     bool        `json:"allDevices"`
+	DeviceGroupIDs []uuid.UUID `json:"deviceGroupIds"`
+	HasImage       bool        `json:"hasImage"`
+	CreatedAt      time.Time   `json:"createdAt"`
+	UpdatedAt      time.Time   `json:"updatedAt"`
+}
+
+type CreateAnnouncementRequest struct {
+	Text     string               `json:"text"`
+	Severity AnnouncementSeverity `json:"severity"`
+	// StartsAt is the current time by default.
+	StartsAt *time.Time `json:"startsAt"`
+	// The announcement is shown until it is deleted if EndsAt is not set.
+	EndsAt         *time.Time  `json:"endsAt"`
+	AllDevices     bool        `json:"allDevices"`
+	DeviceGroupIDs []uuid.UUID `json:"deviceGroupIds"`
+}
+
+type DeviceGroup struct {
+	ID             uuid.UUID     `json:"id"`
+	OrganizationID uuid.UUID     `json:"organizationId"`
+	Name           string        `json:"name"`
+	CreatedAt      time.Time     `json:"createdAt"`
+	UpdateChannel  UpdateChannel `json:"updateChannel"`
+	// DeviceConfigProfileID overrides the device config profile of the organization, if set.
+	DeviceConfigProfileID *uuid.UUID `json:"deviceConfigProfileId"`
+}
+
+type CreateDeviceGroupRequest struct {
+	Name string `json:"name"`
+	// UpdateChannel is Stable by default.
+	UpdateChannel         *UpdateChannel `json:"updateChannel"`
+	DeviceConfigProfileID *uuid.UUID     `json:"deviceConfigProfileId"`
+}
+
+// DeviceConfig configures the kiosk application of devices. Only the fields which are set are applied,
+// overriding those of less specific profiles (organization, then device group, then device).
+type DeviceConfig struct {
+	// Language is a BCP 47 language tag, e.g. "nl-NL".
+	Language *string `json:"language,omitempty"`
+	// Timezone is the IANA timezone in which the brightness schedule is evaluated.
+	Timezone           *string               `json:"timezone,omitempty"`
+	BrightnessSchedule *BrightnessSchedule   `json:"brightnessSchedule,omitempty"`
+	IdleTimeoutSeconds *uint32               `json:"idleTimeoutSeconds,omitempty"`
+	Screens            *DeviceConfigScreens  `json:"screens,omitempty"`
+	Branding           *DeviceConfigBranding `json:"branding,omitempty"`
+}
+
+type BrightnessSchedule struct {
+	// Periods are sorted by their start. Each period lasts until the next one starts.
+	Periods []BrightnessPeriod `json:"periods"`
+}
+
+type BrightnessPeriod struct {
+	// StartMinute is the number of minutes after midnight at which the period starts.
+	StartMinute uint32 `json:"startMinute"`
+	// Brightness is a percentage.
+	Brightness uint32 `json:"brightness"`
+}
+
+type DeviceConfigScreens struct {
+	Timetable      bool `json:"timetable"`
+	CardEnrollment bool `json:"cardEnrollment"`
+	Announcements  bool `json:"announcements"`
+}
+
+type DeviceConfigBranding struct {
+	// Title is shown at the top of the kiosk, the name of the organization is shown if it is empty.
+	Title    string `json:"title"`
+	ShowLogo bool   `json:"showLogo"`
+}
+
+type DeviceConfigProfile struct {
+	ID             uuid.UUID    `json:"id"`
+	OrganizationID uuid.UUID    `json:"organizationId"`
+	Name           string       `json:"name"`
+	Config         DeviceConfig `json:"config"`
+	CreatedAt      time.Time    `json:"createdAt"`
+	UpdatedAt      time.Time    `json:"updatedAt"`
+}
+
+type CreateDeviceConfigProfileRequest struct {
+	Name   string       `json:"name"`
+	Config DeviceConfig `json:"config"`
+}
+
+type UpdateChannel string
+
+const (
+	UpdateChannelStable UpdateChannel = "Stable"
+	UpdateChannelBeta   UpdateChannel = "Beta"
+)
+
+type SoftwareComponent string
+
+const (
+	SoftwareComponentTimetermOS       SoftwareComponent = "TimetermOs"
+	SoftwareComponentFrontendEmbedded SoftwareComponent = "FrontendEmbedded"
+)
+
+// SoftwareRelease is a version of Timeterm OS or frontend-embedded which devices can update to.
+type SoftwareRelease struct {
+	ID          uuid.UUID         `json:"id"`
+	Component   SoftwareComponent `json:"component"`
+	Version     string            `json:"version"`
+	Channel     UpdateChannel     `json:"channel"`
+	ArtifactURL string            `json:"artifactUrl"`
+	// SHA256 is the hex-encoded SHA-256 hash of the artifact.
+	SHA256    string    `json:"sha256"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type CreateSoftwareReleaseRequest struct {
+	Component   SoftwareComponent `json:"component"`
+	Version     string            `json:"version"`
+	Channel     UpdateChannel     `json:"channel"`
+	ArtifactURL string            `json:"artifactUrl"`
+	SHA256      string            `json:"sha256"`
+	// Signature is the Ed25519 signature of the release by the release key of the vendor,
+	// see softwareReleaseSignedData.
+	Signature []byte `json:"signature"`
+}
+
+// UpdateRollout pins a percentage of the devices in a group to a release, instead of the latest release
+// in the channel of the group. The other devices in the group get the latest release in the channel.
+type UpdateRollout struct {
+	ID            uuid.UUID         `json:"id"`
+	DeviceGroupID uuid.UUID         `json:"deviceGroupId"`
+	Component     SoftwareComponent `json:"component"`
+	ReleaseID     uuid.UUID         `json:"releaseId"`
+	Percentage    int               `json:"percentage"`
+	CreatedAt     time.Time         `json:"createdAt"`
+	UpdatedAt     time.Time         `json:"updatedAt"`
+}
+
+// ReplaceUpdateRolloutRequest creates or replaces the rollout of the component of the release in the group.
+type ReplaceUpdateRolloutRequest struct {
+	DeviceGroupID uuid.UUID `json:"deviceGroupId"`
+	ReleaseID     uuid.UUID `json:"releaseId"`
+	Percentage    int       `json:"percentage"`
+}
+
+// UpdateRolloutStatus is derived from the versions which devices have last reported with their heartbeats.
+type UpdateRolloutStatus struct {
+	RolloutID     uuid.UUID `json:"rolloutId"`
+	TargetVersion string    `json:"targetVersion"`
+	Devices       int       `json:"devices"`
+	// InRollout is the number of devices which are selected by the percentage of the rollout.
+	InRollout int `json:"inRollout"`
+	// Updated is the number of devices which are in the rollout and run the target version.
+	Updated int `json:"updated"`
+	// Pending is the number of devices which are in the rollout but don't run the target version (yet).
+	Pending int `json:"pending"`
+	// Versions contains the number of devices per reported version. Devices which haven't reported a version
+	// are counted with an empty version.
+	Versions map[string]int `json:"versions"`
+}
+
+// UpdateManifest tells a device which release to install.
+type UpdateManifest struct {
+	DeviceID    uuid.UUID         `json:"deviceId"`
+	Component   SoftwareComponent `json:"component"`
+	Version     string            `json:"version"`
+	ArtifactURL string            `json:"artifactUrl"`
+	SHA256      string            `json:"sha256"`
+	// ExpiresAt is the time after which the device must no longer accept the manifest.
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// SignedUpdateManifest contains a JSON-encoded UpdateManifest and its Ed25519 signature,
+// which can be verified with the public key from UpdateSigningKey.
+type SignedUpdateManifest struct {
+	Manifest  []byte `json:"manifest"`
+	Signature []byte `json:"signature"`
+}
+
+type UpdateSigningKey struct {
+	// PublicKey is the Ed25519 public key which update manifests are signed with.
+	PublicKey []byte `json:"publicKey"`
+}
+
+// NetworkingServiceAssignment describes which devices a networking service is provided to.
+// A device gets the networking service if it is assigned to all devices, the device itself or its group.
+type NetworkingServiceAssignment struct {
+	AllDevices bool        `json:"allDevices"`
+	DeviceIDs  []uuid.UUID `json:"deviceIds"`
+	GroupIDs   []uuid.UUID `json:"groupIds"`
+}
+
+// DeviceSelector selects devices by their ID, group or tags, for operations on multiple devices.
+// A device is selected if it matches any of the criteria.
+type DeviceSelector struct {
+	DeviceIDs []uuid.UUID `json:"deviceIds,omitempty"`
+	GroupIDs  []uuid.UUID `json:"groupIds,omitempty"`
+	Tags      []string    `json:"tags,omitempty"`
 }
 
 type User struct {
@@ -56,6 +466,44 @@ type User struct {
 	Email          string    `json:"email"`
 }
 
+type UserSession struct {
+	ID         uuid.UUID `json:"id"`
+	CreatedAt  time.Time `json:"createdAt"`
+	LastUsedAt time.Time `json:"lastUsedAt"`
+	ExpiresAt  time.Time `json:"expiresAt"`
+	UserAgent  string    `json:"userAgent"`
+	IPAddress  string    `json:"ipAddress"`
+	Current    bool      `json:"current"`
+}
+
+type UserAPIKeyScope string
+
+const (
+	UserAPIKeyScopeRead  UserAPIKeyScope = "read"
+	UserAPIKeyScopeWrite UserAPIKeyScope = "write"
+)
+
+type UserAPIKey struct {
+	ID         uuid.UUID         `json:"id"`
+	Name       string            `json:"name"`
+	Scopes     []UserAPIKeyScope `json:"scopes"`
+	CreatedAt  time.Time         `json:"createdAt"`
+	LastUsedAt *time.Time        `json:"lastUsedAt,omitempty"`
+	ExpiresAt  *time.Time        `json:"expiresAt,omitempty"`
+}
+
+type CreateUserAPIKeyRequest struct {
+	Name      string            `json:"name"`
+	Scopes    []UserAPIKeyScope `json:"scopes"`
+	ExpiresAt *time.Time        `json:"expiresAt,omitempty"`
+}
+
+type CreateUserAPIKeyResponse struct {
+	APIKey UserAPIKey `json:"apiKey"`
+	// Key is the API key itself. It is only returned once, when the API key is created.
+	Key string `json:"key"`
+}
+
 type NetworkingServiceType string
 
 const (
@@ -613,6 +1061,11 @@ type PaginatedDevices struct {
 	Data []Device `json:"data"`
 }
 
+type PaginatedDeviceCommands struct {
+	Pagination
+	Data []DeviceCommand `json:"data"`
+}
+
 type PaginatedStudents struct {
 	Pagination
 	Data []Student `json:"data"`
@@ -628,14 +1081,41 @@ type CreateDeviceResponse struct {
 	Token  uuid.UUID `json:"token"`
 }
 
+type RotateDeviceTokenResponse struct {
+	Token uuid.UUID `json:"token"`
+}
+
 type GenerateNATSCredentialsResponse struct {
 	Credentials string `json:"credentials"`
 }
 
 type RegistrationConfig struct {
 	Token              uuid.UUID           `json:"token"`
+	TokenExpiresAt     time.Time           `json:"tokenExpiresAt"`
 	OrganizationID     uuid.UUID           `json:"organizationId"`
 	NetworkingServices []NetworkingService `json:"networkingServices"`
+	ProvisioningBundle *ProvisioningBundle `json:"provisioningBundle,omitempty"`
+}
+
+type ProvisioningBundleEncoding string
+
+const (
+	ProvisioningBundleEncodingBase45 ProvisioningBundleEncoding = "base45"
+	ProvisioningBundleEncodingBase64 ProvisioningBundleEncoding = "base64"
+)
+
+// ProvisioningBundle is a signed bundle (devcfg.SignedProvisioningBundle) containing everything a new device
+// needs to register itself.
+type ProvisioningBundle struct {
+	Encoding ProvisioningBundleEncoding `json:"encoding"`
+	Data     string                     `json:"data"`
+	// QRCode is a PNG image of a QR code containing Data. It is omitted when Data is too large for a QR code.
+	QRCode []byte `json:"qrCode,omitempty"`
+}
+
+type ProvisioningKey struct {
+	// PublicKey is the Ed25519 public key which provisioning bundles are signed with.
+	PublicKey []byte `json:"publicKey"`
 }
 
 func OrganizationFrom(org database.Organization) Organization {
@@ -645,6 +1125,13 @@ func OrganizationFrom(org database.Organization) Organization {
 		Zermelo: OrganizationZermeloInfo{
 			Institution: org.ZermeloInstitution,
 		},
+		RequireStudentPin: org.RequireStudentPIN,
+		DeviceAlerts: OrganizationDeviceAlerts{
+			OfflineThresholdSeconds: Int32PtrFrom(org.DeviceOfflineAlertThresholdSeconds),
+			WebhookURL:              StringPtrFrom(org.DeviceAlertWebhookURL),
+			Email:                   StringPtrFrom(org.DeviceAlertEmail),
+		},
+		DeviceConfigProfileID: org.DeviceConfigProfileID,
 	}
 }
 
@@ -653,6 +1140,38 @@ func OrganisationToDB(org Organization) database.Organization {
 		ID:                 org.ID,
 		Name:               org.Name,
 		ZermeloInstitution: org.Zermelo.Institution,
+		RequireStudentPIN:  org.RequireStudentPin,
+
+		DeviceOfflineAlertThresholdSeconds: Int32PtrToDB(org.DeviceAlerts.OfflineThresholdSeconds),
+		DeviceAlertWebhookURL:              StringPtrToDB(org.DeviceAlerts.WebhookURL),
+		DeviceAlertEmail:                   StringPtrToDB(org.DeviceAlerts.Email),
+		DeviceConfigProfileID:              org.DeviceConfigProfileID,
+	}
+}
+
+func OrganizationBrandingFrom(b database.OrganizationBranding) OrganizationBranding {
+	branding := OrganizationBranding{
+		Colors: OrganizationBrandingColors{
+			Primary:    StringPtrFrom(b.PrimaryColor),
+			Accent:     StringPtrFrom(b.AccentColor),
+			Background: StringPtrFrom(b.BackgroundColor),
+			Text:       StringPtrFrom(b.TextColor),
+		},
+	}
+	if b.LogoSHA256 != nil {
+		etag := logoETag(b.LogoSHA256)
+		branding.LogoETag = &etag
+	}
+	return branding
+}
+
+func OrganizationBrandingColorsToDB(organizationID uuid.UUID, c OrganizationBrandingColors) database.OrganizationBranding {
+	return database.OrganizationBranding{
+		OrganizationID:  organizationID,
+		PrimaryColor:    StringPtrToDB(c.Primary),
+		AccentColor:     StringPtrToDB(c.Accent),
+		BackgroundColor: StringPtrToDB(c.Background),
+		TextColor:       StringPtrToDB(c.Text),
 	}
 }
 
@@ -673,6 +1192,57 @@ func StringPtrToDB(p *string) sql.NullString {
 	}
 }
 
+func Int64PtrFrom(ni sql.NullInt64) *int64 {
+	if !ni.Valid {
+		return nil
+	}
+	return &ni.Int64
+}
+
+func Int64PtrToDB(p *int64) sql.NullInt64 {
+	if p == nil {
+		return sql.NullInt64{}
+	}
+	return sql.NullInt64{
+		Valid: true,
+		Int64: *p,
+	}
+}
+
+func Int32PtrFrom(ni sql.NullInt32) *int32 {
+	if !ni.Valid {
+		return nil
+	}
+	return &ni.Int32
+}
+
+func Int32PtrToDB(p *int32) sql.NullInt32 {
+	if p == nil {
+		return sql.NullInt32{}
+	}
+	return sql.NullInt32{
+		Valid: true,
+		Int32: *p,
+	}
+}
+
+func TimePtrFrom(nt sql.NullTime) *time.Time {
+	if !nt.Valid {
+		return nil
+	}
+	return &nt.Time
+}
+
+func TimePtrToDB(p *time.Time) sql.NullTime {
+	if p == nil {
+		return sql.NullTime{}
+	}
+	return sql.NullTime{
+		Valid: true,
+		Time:  *p,
+	}
+}
+
 func StudentFrom(student database.Student) Student {
 	return Student{
 		ID:             student.ID,
@@ -684,19 +1254,341 @@ func StudentFrom(student database.Student) Student {
 	}
 }
 
-func lastHeartbeatToPrimaryDeviceStatus(t sql.NullTime) PrimaryDeviceStatus {
-	if t.Valid && t.Time.After(time.Now().Add(-30*time.Second)) {
-		return PrimaryDeviceStatusOnline
+// primaryDeviceStatus derives whether a device is online from its last heartbeat.
+// Devices which have disconnected from NATS after their last heartbeat are offline immediately.
+func primaryDeviceStatus(lastHeartbeat, lastDisconnect sql.NullTime) PrimaryDeviceStatus {
+	if !lastHeartbeat.Valid || !lastHeartbeat.Time.After(time.Now().Add(-database.DeviceOnlineThreshold)) {
+		return PrimaryDeviceStatusOffline
+	}
+	if lastDisconnect.Valid && lastDisconnect.Time.After(lastHeartbeat.Time) {
+		return PrimaryDeviceStatusOffline
 	}
-	return PrimaryDeviceStatusOffline
+	return PrimaryDeviceStatusOnline
 }
 
 func DeviceFrom(device database.Device) Device {
 	return Device{
-		ID:             device.ID,
-		OrganizationID: device.OrganizationID,
-		Name:           device.Name,
-		PrimaryStatus:  lastHeartbeatToPrimaryDeviceStatus(device.LastHeartbeat),
+		ID:                    device.ID,
+		OrganizationID:        device.OrganizationID,
+		Name:                  device.Name,
+		PrimaryStatus:         primaryDeviceStatus(device.LastHeartbeat, device.LastDisconnect),
+		GroupID:               device.GroupID,
+		Tags:                  device.Tags,
+		DeviceConfigProfileID: device.DeviceConfigProfileID,
+		Inventory:             DeviceInventoryFrom(device.DeviceInventory),
+		Capabilities:          DeviceCapabilitiesFrom(device.Capabilities),
+	}
+}
+
+// DeviceCapabilitiesFrom converts the capabilities of a device, leaving out capabilities which are unknown.
+func DeviceCapabilitiesFrom(capabilities []string) []DeviceCapability {
+	apiCapabilities := make([]DeviceCapability, 0, len(capabilities))
+	for _, capability := range capabilities {
+		if capability == database.DeviceCapabilityScreenPower {
+			apiCapabilities = append(apiCapabilities, DeviceCapabilityScreenPower)
+		}
+	}
+	return apiCapabilities
+}
+
+const installDateLayout = "2006-01-02"
+
+func DeviceInventoryFrom(inv database.DeviceInventory) DeviceInventory {
+	var installDate *string
+	if inv.InstallDate.Valid {
+		date := inv.InstallDate.Time.Format(installDateLayout)
+		installDate = &date
+	}
+
+	return DeviceInventory{
+		LocationDescription: StringPtrFrom(inv.LocationDescription),
+		SerialNumber:        StringPtrFrom(inv.SerialNumber),
+		HardwareRevision:    StringPtrFrom(inv.HardwareRevision),
+		MacAddress:          StringPtrFrom(inv.MacAddress),
+		InstallDate:         installDate,
+		Notes:               StringPtrFrom(inv.Notes),
+	}
+}
+
+// DeviceInventoryToDB converts the inventory, which must have been normalized (see normalizeDeviceInventory).
+func DeviceInventoryToDB(inv DeviceInventory) database.DeviceInventory {
+	var installDate sql.NullTime
+	if inv.InstallDate != nil {
+		if date, err := time.Parse(installDateLayout, *inv.InstallDate); err == nil {
+			installDate = sql.NullTime{Time: date, Valid: true}
+		}
+	}
+
+	return database.DeviceInventory{
+		LocationDescription: StringPtrToDB(inv.LocationDescription),
+		SerialNumber:        StringPtrToDB(inv.SerialNumber),
+		HardwareRevision:    StringPtrToDB(inv.HardwareRevision),
+		MacAddress:          StringPtrToDB(inv.MacAddress),
+		InstallDate:         installDate,
+		Notes:               StringPtrToDB(inv.Notes),
+	}
+}
+
+func DeviceSortFieldToDB(f DeviceSortField) (database.DeviceSortField, bool) {
+	switch f {
+	case DeviceSortFieldName:
+		return database.DeviceSortFieldName, true
+	case DeviceSortFieldLastHeartbeat:
+		return database.DeviceSortFieldLastHeartbeat, true
+	case DeviceSortFieldSerialNumber:
+		return database.DeviceSortFieldSerialNumber, true
+	case DeviceSortFieldInstallDate:
+		return database.DeviceSortFieldInstallDate, true
+	default:
+		return "", false
+	}
+}
+
+func DeviceStatusFrom(status database.DeviceStatus) DeviceStatus {
+	var cardReaderStatus *CardReaderStatus
+	if status.CardReaderStatus.Valid {
+		crs := CardReaderStatus(status.CardReaderStatus.String)
+		cardReaderStatus = &crs
+	}
+
+	return DeviceStatus{
+		ReportedAt:       status.ReportedAt,
+		OsVersion:        StringPtrFrom(status.OsVersion),
+		AppVersion:       StringPtrFrom(status.AppVersion),
+		UptimeSeconds:    Int64PtrFrom(status.UptimeSeconds),
+		IpAddress:        StringPtrFrom(status.IpAddress),
+		SSID:             StringPtrFrom(status.SSID),
+		SignalStrength:   Int32PtrFrom(status.SignalStrength),
+		CardReaderStatus: cardReaderStatus,
+		FreeDiskBytes:    Int64PtrFrom(status.FreeDiskBytes),
+		LastError:        StringPtrFrom(status.LastError),
+	}
+}
+
+func DeviceStatusesFrom(statuses []database.DeviceStatus) []DeviceStatus {
+	apiStatuses := make([]DeviceStatus, len(statuses))
+	for i, status := range statuses {
+		apiStatuses[i] = DeviceStatusFrom(status)
+	}
+	return apiStatuses
+}
+
+func DeviceCommandTypeFrom(typ string) DeviceCommandType {
+	switch typ {
+	case database.DeviceCommandTypeReboot:
+		return DeviceCommandTypeReboot
+	case database.DeviceCommandTypeRetrieveNewNetworkingConfig:
+		return DeviceCommandTypeRetrieveNewNetworkingConfig
+	case database.DeviceCommandTypeScreenOff:
+		return DeviceCommandTypeScreenOff
+	case database.DeviceCommandTypeScreenOn:
+		return DeviceCommandTypeScreenOn
+	case database.DeviceCommandTypeUploadLogs:
+		return DeviceCommandTypeUploadLogs
+	case database.DeviceCommandTypeTakeScreenshot:
+		return DeviceCommandTypeTakeScreenshot
+	default:
+		return DeviceCommandType(typ)
+	}
+}
+
+// DeviceCommandTypeToDB converts the API command type to the type stored in the database.
+// The second return value is false if the type is unknown.
+func DeviceCommandTypeToDB(typ DeviceCommandType) (string, bool) {
+	switch typ {
+	case DeviceCommandTypeReboot:
+		return database.DeviceCommandTypeReboot, true
+	case DeviceCommandTypeRetrieveNewNetworkingConfig:
+		return database.DeviceCommandTypeRetrieveNewNetworkingConfig, true
+	case DeviceCommandTypeScreenOff:
+		return database.DeviceCommandTypeScreenOff, true
+	case DeviceCommandTypeScreenOn:
+		return database.DeviceCommandTypeScreenOn, true
+	case DeviceCommandTypeUploadLogs:
+		return database.DeviceCommandTypeUploadLogs, true
+	case DeviceCommandTypeTakeScreenshot:
+		return database.DeviceCommandTypeTakeScreenshot, true
+	default:
+		return "", false
+	}
+}
+
+func DeviceCommandStatusFrom(status database.DeviceCommandStatus) DeviceCommandStatus {
+	switch status {
+	case database.DeviceCommandStatusStored:
+		return DeviceCommandStatusStored
+	case database.DeviceCommandStatusAcked:
+		return DeviceCommandStatusAcked
+	case database.DeviceCommandStatusSucceeded:
+		return DeviceCommandStatusSucceeded
+	case database.DeviceCommandStatusFailed:
+		return DeviceCommandStatusFailed
+	default:
+		return DeviceCommandStatusQueued
+	}
+}
+
+func DeviceCommandFrom(cmd database.DeviceCommand) DeviceCommand {
+	return DeviceCommand{
+		ID:              cmd.ID,
+		DeviceID:        cmd.DeviceID,
+		Type:            DeviceCommandTypeFrom(cmd.Type),
+		Status:          DeviceCommandStatusFrom(cmd.Status),
+		CreatedByUserID: cmd.CreatedByUserID,
+		CreatedAt:       cmd.CreatedAt,
+		ExpiresAt:       cmd.ExpiresAt,
+		StoredAt:        TimePtrFrom(cmd.StoredAt),
+		AckedAt:         TimePtrFrom(cmd.AckedAt),
+		CompletedAt:     TimePtrFrom(cmd.CompletedAt),
+		Error:           StringPtrFrom(cmd.Error),
+		Output:          StringPtrFrom(cmd.Output),
+	}
+}
+
+func DeviceCommandsFrom(cmds []database.DeviceCommand) []DeviceCommand {
+	apiCmds := make([]DeviceCommand, len(cmds))
+	for i, cmd := range cmds {
+		apiCmds[i] = DeviceCommandFrom(cmd)
+	}
+	return apiCmds
+}
+
+func DeviceLogBundleFrom(b database.DeviceLogBundle) DeviceLogBundle {
+	return DeviceLogBundle{
+		ID:        b.ID,
+		DeviceID:  b.DeviceID,
+		CommandID: b.CommandID,
+		CreatedAt: b.CreatedAt,
+		Size:      b.Size,
+	}
+}
+
+func DeviceLogBundlesFrom(bundles []database.DeviceLogBundle) []DeviceLogBundle {
+	apiBundles := make([]DeviceLogBundle, len(bundles))
+	for i, b := range bundles {
+		apiBundles[i] = DeviceLogBundleFrom(b)
+	}
+	return apiBundles
+}
+
+func DeviceScreenshotFrom(sc database.DeviceScreenshot) DeviceScreenshot {
+	return DeviceScreenshot{
+		ID:        sc.ID,
+		DeviceID:  sc.DeviceID,
+		CommandID: sc.CommandID,
+		CreatedAt: sc.CreatedAt,
+		Size:      sc.Size,
+	}
+}
+
+func DeviceScreenshotsFrom(screenshots []database.DeviceScreenshot) []DeviceScreenshot {
+	apiScreenshots := make([]DeviceScreenshot, len(screenshots))
+	for i, sc := range screenshots {
+		apiScreenshots[i] = DeviceScreenshotFrom(sc)
+	}
+	return apiScreenshots
+}
+
+func DeviceScheduleFrom(s database.DeviceSchedule) DeviceSchedule {
+	var nextRunAt *time.Time
+	if sched, err := scheduler.ParseSchedule(s.CronExpression, s.Timezone); err == nil && s.Enabled {
+		next := sched.Next(time.Now())
+		nextRunAt = &next
+	}
+
+	return DeviceSchedule{
+		ID:             s.ID,
+		OrganizationID: s.OrganizationID,
+		DeviceGroupID:  s.DeviceGroupID,
+		Action:         DeviceCommandTypeFrom(s.Action),
+		CronExpression: s.CronExpression,
+		Timezone:       s.Timezone,
+		Enabled:        s.Enabled,
+		CreatedAt:      s.CreatedAt,
+		LastRunAt:      TimePtrFrom(s.LastRunAt),
+		NextRunAt:      nextRunAt,
+	}
+}
+
+func DeviceSchedulesFrom(schedules []database.DeviceSchedule) []DeviceSchedule {
+	apiSchedules := make([]DeviceSchedule, len(schedules))
+	for i, s := range schedules {
+		apiSchedules[i] = DeviceScheduleFrom(s)
+	}
+	return apiSchedules
+}
+
+func AnnouncementSeverityFrom(s database.AnnouncementSeverity) AnnouncementSeverity {
+	switch s {
+	case database.AnnouncementSeverityWarning:
+		return AnnouncementSeverityWarning
+	case database.AnnouncementSeverityCritical:
+		return AnnouncementSeverityCritical
+	default:
+		return AnnouncementSeverityInfo
+	}
+}
+
+// AnnouncementSeverityToDB converts the severity. The second return value is false if the severity is unknown.
+func AnnouncementSeverityToDB(s AnnouncementSeverity) (database.AnnouncementSeverity, bool) {
+	switch s {
+	case AnnouncementSeverityInfo:
+		return database.AnnouncementSeverityInfo, true
+	case AnnouncementSeverityWarning:
+		return database.AnnouncementSeverityWarning, true
+	case AnnouncementSeverityCritical:
+		return database.AnnouncementSeverityCritical, true
+	default:
+		return "", false
+	}
+}
+
+func AnnouncementFrom(a database.Announcement) Announcement {
+	groupIDs := a.GroupIDs
+	if groupIDs == nil {
+		groupIDs = []uuid.UUID{}
+	}
+
+	return Announcement{
+		ID:             a.ID,
+		OrganizationID: a.OrganizationID,
+		Text:           a.Text,
+		Severity:       AnnouncementSeverityFrom(a.Severity),
+		StartsAt:       a.StartsAt,
+		EndsAt:         TimePtrFrom(a.EndsAt),
+		AllDevices:     a.AllDevices,
+		DeviceGroupIDs: groupIDs,
+		HasImage:       a.HasImage,
+		CreatedAt:      a.CreatedAt,
+		UpdatedAt:      a.UpdatedAt,
+	}
+}
+
+func AnnouncementsFrom(announcements []database.Announcement) []Announcement {
+	apiAnnouncements := make([]Announcement, len(announcements))
+	for i, a := range announcements {
+		apiAnnouncements[i] = AnnouncementFrom(a)
+	}
+	return apiAnnouncements
+}
+
+func DeviceStatusToDB(deviceID uuid.UUID, status DeviceStatus) database.DeviceStatus {
+	var cardReaderStatus sql.NullString
+	if status.CardReaderStatus != nil {
+		cardReaderStatus = sql.NullString{Valid: true, String: string(*status.CardReaderStatus)}
+	}
+
+	return database.DeviceStatus{
+		DeviceID:         deviceID,
+		OsVersion:        StringPtrToDB(status.OsVersion),
+		AppVersion:       StringPtrToDB(status.AppVersion),
+		UptimeSeconds:    Int64PtrToDB(status.UptimeSeconds),
+		IpAddress:        StringPtrToDB(status.IpAddress),
+		SSID:             StringPtrToDB(status.SSID),
+		SignalStrength:   Int32PtrToDB(status.SignalStrength),
+		CardReaderStatus: cardReaderStatus,
+		FreeDiskBytes:    Int64PtrToDB(status.FreeDiskBytes),
+		LastError:        StringPtrToDB(status.LastError),
 	}
 }
 
@@ -709,9 +1601,245 @@ func CreateDeviceResponseFrom(device database.Device, token uuid.UUID) CreateDev
 
 func DeviceToDB(device Device) database.Device {
 	return database.Device{
-		ID:             device.ID,
-		OrganizationID: device.OrganizationID,
-		Name:           device.Name,
+		ID:                    device.ID,
+		OrganizationID:        device.OrganizationID,
+		Name:                  device.Name,
+		GroupID:               device.GroupID,
+		Tags:                  device.Tags,
+		DeviceConfigProfileID: device.DeviceConfigProfileID,
+		DeviceInventory:       DeviceInventoryToDB(device.Inventory),
+	}
+}
+
+func DeviceGroupFrom(group database.DeviceGroup) DeviceGroup {
+	return DeviceGroup{
+		ID:                    group.ID,
+		OrganizationID:        group.OrganizationID,
+		Name:                  group.Name,
+		CreatedAt:             group.CreatedAt,
+		UpdateChannel:         UpdateChannelFrom(group.UpdateChannel),
+		DeviceConfigProfileID: group.DeviceConfigProfileID,
+	}
+}
+
+func DeviceConfigFrom(cfg *devcfgpb.DeviceConfig) DeviceConfig {
+	apiCfg := DeviceConfig{
+		Language:           cfg.Language,
+		Timezone:           cfg.Timezone,
+		IdleTimeoutSeconds: cfg.IdleTimeoutSeconds,
+	}
+
+	if cfg.BrightnessSchedule != nil {
+		periods := make([]BrightnessPeriod, len(cfg.BrightnessSchedule.Periods))
+		for i, period := range cfg.BrightnessSchedule.Periods {
+			periods[i] = BrightnessPeriod{
+				StartMinute: period.StartMinute,
+				Brightness:  period.Brightness,
+			}
+		}
+		apiCfg.BrightnessSchedule = &BrightnessSchedule{Periods: periods}
+	}
+	if cfg.Screens != nil {
+		apiCfg.Screens = &DeviceConfigScreens{
+			Timetable:      cfg.Screens.Timetable,
+			CardEnrollment: cfg.Screens.CardEnrollment,
+			Announcements:  cfg.Screens.Announcements,
+		}
+	}
+	if cfg.Branding != nil {
+		apiCfg.Branding = &DeviceConfigBranding{
+			Title:    cfg.Branding.Title,
+			ShowLogo: cfg.Branding.ShowLogo,
+		}
+	}
+
+	return apiCfg
+}
+
+func DeviceConfigToProto(cfg DeviceConfig) *devcfgpb.DeviceConfig {
+	protoCfg := &devcfgpb.DeviceConfig{
+		Language:           cfg.Language,
+		Timezone:           cfg.Timezone,
+		IdleTimeoutSeconds: cfg.IdleTimeoutSeconds,
+	}
+
+	if cfg.BrightnessSchedule != nil {
+		periods := make([]*devcfgpb.BrightnessPeriod, len(cfg.BrightnessSchedule.Periods))
+		for i, period := range cfg.BrightnessSchedule.Periods {
+			periods[i] = &devcfgpb.BrightnessPeriod{
+				StartMinute: period.StartMinute,
+				Brightness:  period.Brightness,
+			}
+		}
+		protoCfg.BrightnessSchedule = &devcfgpb.BrightnessSchedule{Periods: periods}
+	}
+	if cfg.Screens != nil {
+		protoCfg.Screens = &devcfgpb.Screens{
+			Timetable:      cfg.Screens.Timetable,
+			CardEnrollment: cfg.Screens.CardEnrollment,
+			Announcements:  cfg.Screens.Announcements,
+		}
+	}
+	if cfg.Branding != nil {
+		protoCfg.Branding = &devcfgpb.Branding{
+			Title:    cfg.Branding.Title,
+			ShowLogo: cfg.Branding.ShowLogo,
+		}
+	}
+
+	return protoCfg
+}
+
+// DeviceConfigProfileFrom converts the profile. An error is returned if its config can't be unmarshaled.
+func DeviceConfigProfileFrom(p database.DeviceConfigProfile) (DeviceConfigProfile, error) {
+	var cfg devcfgpb.DeviceConfig
+	if err := proto.Unmarshal(p.Config, &cfg); err != nil {
+		return DeviceConfigProfile{}, err
+	}
+
+	return DeviceConfigProfile{
+		ID:             p.ID,
+		OrganizationID: p.OrganizationID,
+		Name:           p.Name,
+		Config:         DeviceConfigFrom(&cfg),
+		CreatedAt:      p.CreatedAt,
+		UpdatedAt:      p.UpdatedAt,
+	}, nil
+}
+
+func DeviceConfigProfilesFrom(profiles []database.DeviceConfigProfile) ([]DeviceConfigProfile, error) {
+	apiProfiles := make([]DeviceConfigProfile, len(profiles))
+	for i, p := range profiles {
+		apiProfile, err := DeviceConfigProfileFrom(p)
+		if err != nil {
+			return nil, err
+		}
+		apiProfiles[i] = apiProfile
+	}
+	return apiProfiles, nil
+}
+
+func UpdateChannelFrom(c database.UpdateChannel) UpdateChannel {
+	switch c {
+	case database.UpdateChannelBeta:
+		return UpdateChannelBeta
+	default:
+		return UpdateChannelStable
+	}
+}
+
+// UpdateChannelToDB converts the channel. The second return value is false if the channel is unknown.
+func UpdateChannelToDB(c UpdateChannel) (database.UpdateChannel, bool) {
+	switch c {
+	case UpdateChannelStable:
+		return database.UpdateChannelStable, true
+	case UpdateChannelBeta:
+		return database.UpdateChannelBeta, true
+	default:
+		return "", false
+	}
+}
+
+func SoftwareComponentFrom(c database.SoftwareComponent) SoftwareComponent {
+	switch c {
+	case database.SoftwareComponentTimetermOS:
+		return SoftwareComponentTimetermOS
+	case database.SoftwareComponentFrontendEmbedded:
+		return SoftwareComponentFrontendEmbedded
+	default:
+		return SoftwareComponent(c)
+	}
+}
+
+// SoftwareComponentToDB converts the component. The second return value is false if the component is unknown.
+func SoftwareComponentToDB(c SoftwareComponent) (database.SoftwareComponent, bool) {
+	switch c {
+	case SoftwareComponentTimetermOS:
+		return database.SoftwareComponentTimetermOS, true
+	case SoftwareComponentFrontendEmbedded:
+		return database.SoftwareComponentFrontendEmbedded, true
+	default:
+		return "", false
+	}
+}
+
+func SoftwareReleaseFrom(r database.SoftwareRelease) SoftwareRelease {
+	return SoftwareRelease{
+		ID:          r.ID,
+		Component:   SoftwareComponentFrom(r.Component),
+		Version:     r.Version,
+		Channel:     UpdateChannelFrom(r.Channel),
+		ArtifactURL: r.ArtifactURL,
+		SHA256:      hex.EncodeToString(r.SHA256),
+		CreatedAt:   r.CreatedAt,
+	}
+}
+
+func SoftwareReleasesFrom(releases []database.SoftwareRelease) []SoftwareRelease {
+	apiReleases := make([]SoftwareRelease, len(releases))
+	for i, r := range releases {
+		apiReleases[i] = SoftwareReleaseFrom(r)
+	}
+	return apiReleases
+}
+
+func UpdateRolloutFrom(r database.UpdateRollout) UpdateRollout {
+	return UpdateRollout{
+		ID:            r.ID,
+		DeviceGroupID: r.DeviceGroupID,
+		Component:     SoftwareComponentFrom(r.Component),
+		ReleaseID:     r.ReleaseID,
+		Percentage:    r.Percentage,
+		CreatedAt:     r.CreatedAt,
+		UpdatedAt:     r.UpdatedAt,
+	}
+}
+
+func UpdateRolloutsFrom(rollouts []database.UpdateRollout) []UpdateRollout {
+	apiRollouts := make([]UpdateRollout, len(rollouts))
+	for i, r := range rollouts {
+		apiRollouts[i] = UpdateRolloutFrom(r)
+	}
+	return apiRollouts
+}
+
+func DeviceGroupsFrom(groups []database.DeviceGroup) []DeviceGroup {
+	apiGroups := make([]DeviceGroup, len(groups))
+	for i, group := range groups {
+		apiGroups[i] = DeviceGroupFrom(group)
+	}
+	return apiGroups
+}
+
+func NetworkingServiceAssignmentFrom(a database.NetworkingServiceAssignment) NetworkingServiceAssignment {
+	apiAssignment := NetworkingServiceAssignment{
+		AllDevices: a.AllDevices,
+		DeviceIDs:  a.DeviceIDs,
+		GroupIDs:   a.GroupIDs,
+	}
+	if apiAssignment.DeviceIDs == nil {
+		apiAssignment.DeviceIDs = []uuid.UUID{}
+	}
+	if apiAssignment.GroupIDs == nil {
+		apiAssignment.GroupIDs = []uuid.UUID{}
+	}
+	return apiAssignment
+}
+
+func NetworkingServiceAssignmentToDB(id uuid.UUID, a NetworkingServiceAssignment) database.NetworkingServiceAssignment {
+	return database.NetworkingServiceAssignment{
+		NetworkingServiceID: id,
+		AllDevices:          a.AllDevices,
+		DeviceIDs:           a.DeviceIDs,
+		GroupIDs:            a.GroupIDs,
+	}
+}
+
+func DeviceSelectorToDB(sel DeviceSelector) database.DeviceSelector {
+	return database.DeviceSelector{
+		DeviceIDs: sel.DeviceIDs,
+		GroupIDs:  sel.GroupIDs,
+		Tags:      sel.Tags,
 	}
 }
 
@@ -725,6 +1853,89 @@ func DevicesFrom(dbDevices []database.Device) []Device {
 	return apiDevices
 }
 
+func StudentCardFrom(card database.StudentCard) StudentCard {
+	return StudentCard{
+		ID:         card.ID,
+		StudentID:  card.StudentID,
+		State:      StudentCardState(card.State),
+		Label:      card.Label,
+		CreatedAt:  card.CreatedAt,
+		ValidFrom:  card.ValidFrom,
+		ValidUntil: TimePtrFrom(card.ValidUntil),
+	}
+}
+
+func StudentCardsFrom(cards []database.StudentCard) []StudentCard {
+	apiCards := make([]StudentCard, len(cards))
+	for i, card := range cards {
+		apiCards[i] = StudentCardFrom(card)
+	}
+	return apiCards
+}
+
+// StudentCardStateToDB converts a card state to its database representation.
+// It returns false if the state is invalid.
+func StudentCardStateToDB(state StudentCardState) (database.StudentCardState, bool) {
+	switch state {
+	case StudentCardStateActive, StudentCardStateBlocked, StudentCardStateLost, StudentCardStateExpired:
+		return database.StudentCardState(state), true
+	default:
+		return "", false
+	}
+}
+
+func CardEnrollmentSessionFrom(session database.CardEnrollmentSession) CardEnrollmentSession {
+	state := CardEnrollmentSessionState(session.State)
+	if session.State == database.CardEnrollmentSessionStatePending && session.ExpiresAt.Before(time.Now()) {
+		state = CardEnrollmentSessionStateExpired
+	}
+
+	return CardEnrollmentSession{
+		ID:        session.ID,
+		StudentID: session.StudentID,
+		DeviceID:  session.DeviceID,
+		State:     state,
+		Error:     session.Error,
+		CardID:    session.CardID,
+		CreatedAt: session.CreatedAt,
+		ExpiresAt: session.ExpiresAt,
+	}
+}
+
+func DeviceRegistrationTokenFrom(regToken database.DeviceRegistrationToken) DeviceRegistrationToken {
+	return DeviceRegistrationToken{
+		ID:            regToken.ID,
+		CreatedAt:     regToken.CreatedAt,
+		ExpiresAt:     regToken.ExpiresAt,
+		MaxUses:       regToken.MaxUses,
+		Uses:          regToken.Uses,
+		DeviceName:    StringPtrFrom(regToken.DeviceName),
+		DeviceGroupID: regToken.DeviceGroupID,
+	}
+}
+
+func DeviceRegistrationTokensFrom(regTokens []database.DeviceRegistrationToken) []DeviceRegistrationToken {
+	apiRegTokens := make([]DeviceRegistrationToken, len(regTokens))
+	for i, regToken := range regTokens {
+		apiRegTokens[i] = DeviceRegistrationTokenFrom(regToken)
+	}
+	return apiRegTokens
+}
+
+func UnknownCardTapsFrom(taps []database.UnknownCardTaps) []UnknownCardTaps {
+	apiTaps := make([]UnknownCardTaps, len(taps))
+	for i, t := range taps {
+		apiTaps[i] = UnknownCardTaps{
+			DeviceID:      t.DeviceID,
+			DeviceName:    t.DeviceName,
+			Taps:          t.Taps,
+			DistinctCards: t.DistinctCards,
+			LastTapAt:     t.LastTapAt,
+		}
+	}
+	return apiTaps
+}
+
 func StudentsFrom(dbStudents []database.Student) []Student {
 	apiStudents := make([]Student, len(dbStudents))
 
@@ -742,6 +1953,13 @@ func PaginatedDevicesFrom(p database.PaginatedDevices) PaginatedDevices {
 	}
 }
 
+func PaginatedDeviceCommandsFrom(p database.PaginatedDeviceCommands) PaginatedDeviceCommands {
+	return PaginatedDeviceCommands{
+		Pagination: PaginationFrom(p.Pagination),
+		Data:       DeviceCommandsFrom(p.DeviceCommands),
+	}
+}
+
 func PaginatedStudentsFrom(p database.PaginatedStudents) PaginatedStudents {
 	return PaginatedStudents{
 		Pagination: PaginationFrom(p.Pagination),
@@ -765,6 +1983,67 @@ func UserFrom(user database.User) User {
 	}
 }
 
+func UserSessionFrom(session database.UserSession, currentSessionID uuid.UUID) UserSession {
+	return UserSession{
+		ID:         session.ID,
+		CreatedAt:  session.CreatedAt,
+		LastUsedAt: session.LastUsedAt,
+		ExpiresAt:  session.ExpiresAt,
+		UserAgent:  session.UserAgent,
+		IPAddress:  session.ClientIP,
+		Current:    session.ID == currentSessionID,
+	}
+}
+
+func UserSessionsFrom(sessions []database.UserSession, currentSessionID uuid.UUID) []UserSession {
+	apiSessions := make([]UserSession, len(sessions))
+	for i, session := range sessions {
+		apiSessions[i] = UserSessionFrom(session, currentSessionID)
+	}
+	return apiSessions
+}
+
+func UserAPIKeyFrom(apiKey database.UserAPIKey) UserAPIKey {
+	scopes := make([]UserAPIKeyScope, len(apiKey.Scopes))
+	for i, scope := range apiKey.Scopes {
+		scopes[i] = UserAPIKeyScope(scope)
+	}
+
+	return UserAPIKey{
+		ID:         apiKey.ID,
+		Name:       apiKey.Name,
+		Scopes:     scopes,
+		CreatedAt:  apiKey.CreatedAt,
+		LastUsedAt: TimePtrFrom(apiKey.LastUsedAt),
+		ExpiresAt:  TimePtrFrom(apiKey.ExpiresAt),
+	}
+}
+
+func UserAPIKeysFrom(apiKeys []database.UserAPIKey) []UserAPIKey {
+	apiAPIKeys := make([]UserAPIKey, len(apiKeys))
+	for i, apiKey := range apiKeys {
+		apiAPIKeys[i] = UserAPIKeyFrom(apiKey)
+	}
+	return apiAPIKeys
+}
+
+// UserAPIKeyScopesToDB converts API key scopes to their database representation.
+// It returns false if any of the scopes is invalid.
+func UserAPIKeyScopesToDB(scopes []UserAPIKeyScope) (database.UserAPIKeyScopes, bool) {
+	dbScopes := make(database.UserAPIKeyScopes, 0, len(scopes))
+	for _, scope := range scopes {
+		switch scope {
+		case UserAPIKeyScopeRead, UserAPIKeyScopeWrite:
+			if !dbScopes.Has(database.UserAPIKeyScope(scope)) {
+				dbScopes = append(dbScopes, database.UserAPIKeyScope(scope))
+			}
+		default:
+			return nil, false
+		}
+	}
+	return dbScopes, true
+}
+
 func UserToDB(user User) database.User {
 	return database.User{
 		ID:             user.ID,
@@ -814,6 +2093,112 @@ type ZermeloAppointmentsResponse struct {
 	Data []*ZermeloAppointment `json:"data"`
 }
 
+type StudentCardState string
+
+const (
+	StudentCardStateActive  StudentCardState = "active"
+	StudentCardStateBlocked StudentCardState = "blocked"
+	StudentCardStateLost    StudentCardState = "lost"
+	StudentCardStateExpired StudentCardState = "expired"
+)
+
+type StudentCard struct {
+	ID         uuid.UUID        `json:"id"`
+	StudentID  uuid.UUID        `json:"studentId"`
+	State      StudentCardState `json:"state"`
+	Label      string           `json:"label"`
+	CreatedAt  time.Time        `json:"createdAt"`
+	ValidFrom  time.Time        `json:"validFrom"`
+	ValidUntil *time.Time       `json:"validUntil,omitempty"`
+}
+
+// DeleteLegacyStudentCardsResponse contains the number of cards which have been removed.
+type DeleteLegacyStudentCardsResponse struct {
+	Deleted int64 `json:"deleted"`
+}
+
+type CreateStudentCardRequest struct {
+	Uid        string     `json:"uid"`
+	Label      string     `json:"label"`
+	ValidFrom  *time.Time `json:"validFrom,omitempty"`
+	ValidUntil *time.Time `json:"validUntil,omitempty"`
+}
+
+type ReplaceStudentCardStateRequest struct {
+	State StudentCardState `json:"state"`
+}
+
+type CardEnrollmentSessionState string
+
+const (
+	CardEnrollmentSessionStatePending   CardEnrollmentSessionState = "pending"
+	CardEnrollmentSessionStateCompleted CardEnrollmentSessionState = "completed"
+	CardEnrollmentSessionStateFailed    CardEnrollmentSessionState = "failed"
+	CardEnrollmentSessionStateCancelled CardEnrollmentSessionState = "cancelled"
+	CardEnrollmentSessionStateExpired   CardEnrollmentSessionState = "expired"
+)
+
+type CardEnrollmentSession struct {
+	ID        uuid.UUID                  `json:"id"`
+	StudentID uuid.UUID                  `json:"studentId"`
+	DeviceID  uuid.UUID                  `json:"deviceId"`
+	State     CardEnrollmentSessionState `json:"state"`
+	Error     string                     `json:"error,omitempty"`
+	CardID    *uuid.UUID                 `json:"cardId,omitempty"`
+	CreatedAt time.Time                  `json:"createdAt"`
+	ExpiresAt time.Time                  `json:"expiresAt"`
+}
+
+type CreateCardEnrollmentSessionRequest struct {
+	DeviceID   uuid.UUID  `json:"deviceId"`
+	Label      string     `json:"label"`
+	ValidUntil *time.Time `json:"validUntil,omitempty"`
+}
+
+type CompleteCardEnrollmentSessionRequest struct {
+	Uid string `json:"uid"`
+}
+
+type DeviceRegistrationToken struct {
+	ID            uuid.UUID  `json:"id"`
+	CreatedAt     time.Time  `json:"createdAt"`
+	ExpiresAt     time.Time  `json:"expiresAt"`
+	MaxUses       int        `json:"maxUses"`
+	Uses          int        `json:"uses"`
+	DeviceName    *string    `json:"deviceName,omitempty"`
+	DeviceGroupID *uuid.UUID `json:"deviceGroupId,omitempty"`
+}
+
+type CreateDeviceRegistrationTokenRequest struct {
+	// TTLSeconds is the number of seconds that the token is valid for.
+	// If not set, DefaultDeviceRegistrationTokenTTL is used.
+	TTLSeconds *int64 `json:"ttlSeconds,omitempty" query:"ttlSeconds"`
+	// MaxUses is the number of devices that can be registered with the token, one if not set.
+	MaxUses    *int    `json:"maxUses,omitempty" query:"maxUses"`
+	DeviceName *string `json:"deviceName,omitempty" query:"deviceName"`
+	// DeviceGroupID is the group that devices registered with the token are added to.
+	// It can't be bound from a query, as UUIDs are not supported there.
+	DeviceGroupID *uuid.UUID `json:"deviceGroupId,omitempty" query:"-"`
+}
+
+type CreateDeviceRegistrationTokenResponse struct {
+	RegistrationToken DeviceRegistrationToken `json:"registrationToken"`
+	// Token is the registration token itself. It is only returned once, when the token is created.
+	Token uuid.UUID `json:"token"`
+}
+
+type UnknownCardTaps struct {
+	DeviceID      uuid.UUID `json:"deviceId"`
+	DeviceName    string    `json:"deviceName"`
+	Taps          int       `json:"taps"`
+	DistinctCards int       `json:"distinctCards"`
+	LastTapAt     time.Time `json:"lastTapAt"`
+}
+
+type SetStudentPinRequest struct {
+	Pin string `json:"pin"`
+}
+
 type PatchedStudent struct {
 	Student
 	CardID StringPatch `json:"cardId"`
@@ -1,8 +1,10 @@
 package api
 
 import (
+	"database/sql"
 	"encoding/json"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -46,3 +48,18 @@ func TestStringPatch_UnmarshalJSON(t *testing.T) {
 		assert.Equal(t, "hello", *got.Test.Value)
 	})
 }
+
+func TestPrimaryDeviceStatus(t *testing.T) {
+	now := time.Now()
+	at := func(d time.Duration) sql.NullTime {
+		return sql.NullTime{Valid: true, Time: now.Add(d)}
+	}
+
+	assert.Equal(t, PrimaryDeviceStatus(PrimaryDeviceStatusOffline), primaryDeviceStatus(sql.NullTime{}, sql.NullTime{}))
+	assert.Equal(t, PrimaryDeviceStatus(PrimaryDeviceStatusOnline), primaryDeviceStatus(at(-5*time.Second), sql.NullTime{}))
+	assert.Equal(t, PrimaryDeviceStatus(PrimaryDeviceStatusOffline), primaryDeviceStatus(at(-time.Minute), sql.NullTime{}))
+	// Disconnected after the last heartbeat.
+	assert.Equal(t, PrimaryDeviceStatus(PrimaryDeviceStatusOffline), primaryDeviceStatus(at(-5*time.Second), at(-time.Second)))
+	// Reconnected after disconnecting.
+	assert.Equal(t, PrimaryDeviceStatus(PrimaryDeviceStatusOnline), primaryDeviceStatus(at(-time.Second), at(-5*time.Second)))
+}
@@ -1,15 +1,21 @@
 package api
 
 import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"io/ioutil"
 	"net/http"
 
 	"github.com/google/uuid"
 	"github.com/labstack/echo"
+	"google.golang.org/protobuf/proto"
 
 	authn "gitlab.com/timeterm/timeterm/backend/auhtn"
 	"gitlab.com/timeterm/timeterm/backend/database"
+	devcfgpb "gitlab.com/timeterm/timeterm/proto/go/devcfg"
 )
 
 type getNetworkingServicesParams struct {
@@ -149,6 +155,12 @@ func (s *Server) replaceNetworkingService(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusInternalServerError, "Could not update secret networking service")
 	}
 
+	if err = s.updateNetworkingServiceConfigHash(c.Request().Context(),
+		uid, oldNetworkingService.Name, oldProtoNetworkingService,
+	); err != nil {
+		return err
+	}
+
 	s.mqw.NetworkingConfigUpdated(user.OrganizationID)
 
 	return c.NoContent(http.StatusNoContent)
@@ -186,6 +198,10 @@ func (s *Server) createNetworkingService(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusInternalServerError, "Could not create new secret networking service")
 	}
 
+	if err = s.updateNetworkingServiceConfigHash(c.Request().Context(), ns.ID, ns.Name, secretNS); err != nil {
+		return err
+	}
+
 	s.mqw.NetworkingConfigUpdated(user.OrganizationID)
 
 	return c.JSON(http.StatusOK, ns)
@@ -231,3 +247,116 @@ func (s *Server) deleteNetworkingService(c echo.Context) error {
 
 	return c.NoContent(http.StatusNoContent)
 }
+
+// updateNetworkingServiceConfigHash stores a hash of the configuration of a networking service,
+// so only devices which the change is relevant to are asked to retrieve their networking config again.
+func (s *Server) updateNetworkingServiceConfigHash(ctx context.Context,
+	id uuid.UUID,
+	name string,
+	cfg *devcfgpb.NetworkingService,
+) error {
+	data, err := proto.MarshalOptions{Deterministic: true}.Marshal(cfg)
+	if err != nil {
+		s.log.Error(err, "could not marshal networking service")
+		return echo.NewHTTPError(http.StatusInternalServerError, "Could not marshal networking service")
+	}
+
+	h := sha256.New()
+	_, _ = h.Write([]byte(name))
+	_, _ = h.Write([]byte{0})
+	_, _ = h.Write(data)
+
+	if err = s.db.ReplaceNetworkingServiceConfigHash(ctx, id, h.Sum(nil)); err != nil {
+		s.log.Error(err, "could not update networking service config hash")
+		return echo.NewHTTPError(http.StatusInternalServerError, "Could not update networking service")
+	}
+	return nil
+}
+
+// networkingServiceFromParam retrieves the networking service in the id parameter,
+// which must belong to the organization of the user.
+func (s *Server) networkingServiceFromParam(c echo.Context) (database.NetworkingService, error) {
+	uid, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return database.NetworkingService{}, echo.NewHTTPError(http.StatusBadRequest, "Invalid ID")
+	}
+
+	user, ok := authn.UserFromContext(c)
+	if !ok {
+		return database.NetworkingService{}, echo.NewHTTPError(http.StatusUnauthorized, "Not authenticated")
+	}
+
+	dbNetworkingService, err := s.db.GetNetworkingService(c.Request().Context(), uid)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return database.NetworkingService{}, echo.NewHTTPError(http.StatusNotFound, "Networking service not found")
+		}
+
+		s.log.Error(err, "could not get networking service")
+		return database.NetworkingService{}, echo.NewHTTPError(http.StatusInternalServerError, "Could not get networking service")
+	}
+
+	if dbNetworkingService.OrganizationID != user.OrganizationID {
+		return database.NetworkingService{}, echo.NewHTTPError(http.StatusUnauthorized,
+			"Networking service does not belong to user's organization",
+		)
+	}
+	return dbNetworkingService, nil
+}
+
+func (s *Server) getNetworkingServiceAssignment(c echo.Context) error {
+	dbNetworkingService, err := s.networkingServiceFromParam(c)
+	if err != nil {
+		return err
+	}
+
+	a, err := s.db.GetNetworkingServiceAssignment(c.Request().Context(), dbNetworkingService.ID)
+	if err != nil {
+		s.log.Error(err, "could not get networking service assignment")
+		return echo.NewHTTPError(http.StatusInternalServerError, "Could not read networking service assignment from database")
+	}
+
+	return c.JSON(http.StatusOK, NetworkingServiceAssignmentFrom(a))
+}
+
+func (s *Server) replaceNetworkingServiceAssignment(c echo.Context) error {
+	dbNetworkingService, err := s.networkingServiceFromParam(c)
+	if err != nil {
+		return err
+	}
+
+	var a NetworkingServiceAssignment
+	if err = c.Bind(&a); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Could not bind data")
+	}
+
+	ctx := c.Request().Context()
+	organizationID := dbNetworkingService.OrganizationID
+
+	allInOrg, err := s.db.AreDevicesInOrganization(ctx, organizationID, a.DeviceIDs...)
+	if err != nil {
+		s.log.Error(err, "could not get devices in organization")
+		return echo.NewHTTPError(http.StatusInternalServerError, "Could not retrieve device information")
+	}
+	if !allInOrg {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Not all devices are in user's organization")
+	}
+
+	for _, groupID := range a.GroupIDs {
+		if err = s.checkDeviceGroup(ctx, organizationID, groupID); err != nil {
+			return err
+		}
+	}
+
+	dbAssignment := NetworkingServiceAssignmentToDB(dbNetworkingService.ID, a)
+
+	err = s.db.ReplaceNetworkingServiceAssignment(ctx, dbAssignment)
+	if err != nil {
+		s.log.Error(err, "could not update networking service assignment")
+		return echo.NewHTTPError(http.StatusInternalServerError, "Could not update networking service assignment")
+	}
+
+	s.mqw.NetworkingConfigUpdated(organizationID)
+
+	return c.JSON(http.StatusOK, NetworkingServiceAssignmentFrom(dbAssignment))
+}
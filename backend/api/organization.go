@@ -1,14 +1,19 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
 	"io/ioutil"
 	"net/http"
+	"net/mail"
+	"time"
 
 	jsonpatch "github.com/evanphx/json-patch/v5"
 	"github.com/google/uuid"
 	"github.com/labstack/echo"
 
+	"gitlab.com/timeterm/timeterm/backend/alerts"
 	authn "gitlab.com/timeterm/timeterm/backend/auhtn"
 )
 
@@ -89,6 +94,13 @@ func (s *Server) patchOrganization(c echo.Context) error {
 	}
 
 	newAPIOrganization.ID = oldDBOrganization.ID
+	if err = validateOrganizationDeviceAlerts(c.Request().Context(), newAPIOrganization.DeviceAlerts); err != nil {
+		return err
+	}
+	err = s.checkDeviceConfigProfile(c.Request().Context(), uid, newAPIOrganization.DeviceConfigProfileID)
+	if err != nil {
+		return err
+	}
 	newDBOrganization := OrganisationToDB(newAPIOrganization)
 
 	err = s.db.ReplaceOrganization(c.Request().Context(), newDBOrganization)
@@ -97,5 +109,101 @@ func (s *Server) patchOrganization(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusInternalServerError, "Could not update the organization in the database")
 	}
 
+	if !uuidPtrEqual(oldDBOrganization.DeviceConfigProfileID, newDBOrganization.DeviceConfigProfileID) {
+		s.mqw.DeviceConfigUpdated(uid)
+	}
+
 	return c.JSON(http.StatusOK, newAPIOrganization)
 }
+
+// minDeviceOfflineAlertThreshold prevents alerts about devices which are only briefly unreachable.
+const minDeviceOfflineAlertThreshold = 5 * time.Minute
+
+func validateOrganizationDeviceAlerts(ctx context.Context, deviceAlerts OrganizationDeviceAlerts) error {
+	if deviceAlerts.OfflineThresholdSeconds != nil &&
+		time.Duration(*deviceAlerts.OfflineThresholdSeconds)*time.Second < minDeviceOfflineAlertThreshold {
+		return echo.NewHTTPError(http.StatusBadRequest, "Offline alert threshold too short")
+	}
+
+	if deviceAlerts.WebhookURL != nil {
+		if err := alerts.CheckWebhookURL(ctx, *deviceAlerts.WebhookURL); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid webhook URL: %v", err))
+		}
+	}
+
+	if deviceAlerts.Email != nil {
+		if _, err := mail.ParseAddress(*deviceAlerts.Email); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "Invalid email address")
+		}
+	}
+	return nil
+}
+
+// rotateOrganizationCardKey generates a new key to hash card UIDs with.
+// Cards are hashed with the new key when they are used, after which the old keys are pruned by later rotations.
+func (s *Server) rotateOrganizationCardKey(c echo.Context) error {
+	uid, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid ID")
+	}
+
+	user, ok := authn.UserFromContext(c)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Not authenticated")
+	}
+
+	if user.OrganizationID != uid {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Organization does not belong to user's organization")
+	}
+
+	// Cards can't be added or rehashed while rotating, so a key is never pruned while it is being used.
+	err = s.db.RotateStudentCardKeys(c.Request().Context(), uid, func(inUse []int) error {
+		if _, err := s.secr.RotateOrganizationCardKey(uid); err != nil {
+			return fmt.Errorf("could not rotate organization card key: %w", err)
+		}
+		if err := s.secr.PruneOrganizationCardKeys(uid, inUse); err != nil {
+			return fmt.Errorf("could not prune organization card keys: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		s.log.Error(err, "could not rotate organization card keys")
+		return echo.NewHTTPError(http.StatusInternalServerError, "Could not rotate card key")
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// getOrganizationLegacyCards lists the cards which are still hashed without a key, so administrators can ask
+// the students to use their card (which rehashes it) or to have it added again.
+func (s *Server) getOrganizationLegacyCards(c echo.Context) error {
+	uid, err := organizationIDFromParam(c)
+	if err != nil {
+		return err
+	}
+
+	cards, err := s.db.GetLegacyStudentCards(c.Request().Context(), uid)
+	if err != nil {
+		s.log.Error(err, "could not get legacy student cards")
+		return echo.NewHTTPError(http.StatusInternalServerError, "Could not read cards from database")
+	}
+
+	return c.JSON(http.StatusOK, StudentCardsFrom(cards))
+}
+
+// deleteOrganizationLegacyCards removes the cards which are still hashed without a key,
+// once administrators have decided that the remaining cards have to be added again.
+func (s *Server) deleteOrganizationLegacyCards(c echo.Context) error {
+	uid, err := organizationIDFromParam(c)
+	if err != nil {
+		return err
+	}
+
+	deleted, err := s.db.DeleteLegacyStudentCards(c.Request().Context(), uid)
+	if err != nil {
+		s.log.Error(err, "could not delete legacy student cards")
+		return echo.NewHTTPError(http.StatusInternalServerError, "Could not delete cards")
+	}
+
+	return c.JSON(http.StatusOK, DeleteLegacyStudentCardsResponse{Deleted: deleted})
+}
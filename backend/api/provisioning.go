@@ -0,0 +1,147 @@
+package api
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo"
+	"github.com/skip2/go-qrcode"
+	"google.golang.org/protobuf/proto"
+
+	authn "gitlab.com/timeterm/timeterm/backend/auhtn"
+	"gitlab.com/timeterm/timeterm/backend/pkg/base45"
+	devcfgpb "gitlab.com/timeterm/timeterm/proto/go/devcfg"
+)
+
+// provisioningQRCodeSize is the width and height of the rendered QR codes in pixels.
+const provisioningQRCodeSize = 512
+
+// createProvisioningBundle creates a signed provisioning bundle containing the registration token
+// and the networking services of the organization, so a device can be onboarded by scanning a QR code.
+// The bundle is only signed, so anyone who sees the QR code can read it. Therefore the secrets of the
+// networking services are left out; the device retrieves them after it has registered itself.
+func (s *Server) createProvisioningBundle(ctx context.Context,
+	organizationID uuid.UUID,
+	groupID *uuid.UUID,
+	token uuid.UUID,
+	expiresAt time.Time,
+	encoding ProvisioningBundleEncoding,
+) (*ProvisioningBundle, error) {
+	if s.publicURL == "" {
+		return nil, echo.NewHTTPError(http.StatusInternalServerError, "Backend URL is not configured")
+	}
+
+	networkingServices, err := s.getNetworkingServicesConfig(ctx, organizationID, groupID)
+	if err != nil {
+		return nil, err
+	}
+
+	bundle, err := proto.Marshal(&devcfgpb.ProvisioningBundle{
+		RegistrationToken:  token[:],
+		OrganizationId:     organizationID[:],
+		BackendUrl:         s.publicURL,
+		NetworkingServices: networkingServices,
+		ExpiresAt:          expiresAt.Unix(),
+	})
+	if err != nil {
+		s.log.Error(err, "could not marshal provisioning bundle")
+		return nil, echo.NewHTTPError(http.StatusInternalServerError, "Could not create provisioning bundle")
+	}
+
+	key, err := s.secr.GetProvisioningSigningKey()
+	if err != nil {
+		s.log.Error(err, "could not get provisioning signing key")
+		return nil, echo.NewHTTPError(http.StatusInternalServerError, "Could not read provisioning signing key")
+	}
+
+	signedBundle, err := proto.Marshal(&devcfgpb.SignedProvisioningBundle{
+		Bundle:    bundle,
+		Signature: ed25519.Sign(key, bundle),
+	})
+	if err != nil {
+		s.log.Error(err, "could not marshal signed provisioning bundle")
+		return nil, echo.NewHTTPError(http.StatusInternalServerError, "Could not create provisioning bundle")
+	}
+
+	var data string
+	switch encoding {
+	case ProvisioningBundleEncodingBase45:
+		data = base45.EncodeToString(signedBundle)
+	case ProvisioningBundleEncodingBase64:
+		data = base64.RawURLEncoding.EncodeToString(signedBundle)
+	default:
+		return nil, echo.NewHTTPError(http.StatusBadRequest, "Invalid provisioning bundle encoding")
+	}
+
+	// Bundles with many networking services (e.g. with certificates) may not fit in a QR code,
+	// in which case the data has to be transferred to the device in another way.
+	qrCode, err := qrcode.Encode(data, qrcode.Low, provisioningQRCodeSize)
+	if err != nil {
+		s.log.V(1).Info("could not render provisioning bundle QR code", "error", err, "size", len(data))
+		qrCode = nil
+	}
+
+	return &ProvisioningBundle{
+		Encoding: encoding,
+		Data:     data,
+		QRCode:   qrCode,
+	}, nil
+}
+
+// getNetworkingServicesConfig retrieves the configuration of the networking services that a new device
+// in the organization (and optionally a group) is assigned, by their name. Secrets are left out.
+func (s *Server) getNetworkingServicesConfig(ctx context.Context,
+	organizationID uuid.UUID,
+	groupID *uuid.UUID,
+) (*devcfgpb.NetworkingServices, error) {
+	dbNetworkingServices, err := s.db.GetNetworkingServicesForDevice(ctx, organizationID, uuid.Nil, groupID)
+	if err != nil {
+		s.log.Error(err, "could not read networking services from database")
+		return nil, echo.NewHTTPError(http.StatusInternalServerError, "Could not read networking services from database")
+	}
+
+	services := &devcfgpb.NetworkingServices{
+		Services: make(map[string]*devcfgpb.NetworkingService, len(dbNetworkingServices)),
+	}
+	for _, networkingService := range dbNetworkingServices {
+		cfg, err := s.secr.GetNetworkingService(networkingService.ID)
+		if err != nil {
+			s.log.Error(err, "could not read secret networking service")
+			return nil, echo.NewHTTPError(http.StatusInternalServerError, "Could not read secret networking service")
+		}
+		services.Services[networkingService.Name] = withoutNetworkingServiceSecrets(cfg)
+	}
+
+	return services, nil
+}
+
+// withoutNetworkingServiceSecrets returns a copy of the networking service without its passphrases and private key.
+func withoutNetworkingServiceSecrets(cfg *devcfgpb.NetworkingService) *devcfgpb.NetworkingService {
+	cfg = proto.Clone(cfg).(*devcfgpb.NetworkingService)
+	cfg.Passphrase = ""
+	cfg.PrivateKey = nil
+	cfg.PrivateKeyPassphrase = ""
+	return cfg
+}
+
+// getProvisioningKey returns the public key which provisioning bundles can be verified with.
+// It is meant to be built into device images.
+func (s *Server) getProvisioningKey(c echo.Context) error {
+	if _, ok := authn.UserFromContext(c); !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Not authenticated")
+	}
+
+	key, err := s.secr.GetProvisioningSigningKey()
+	if err != nil {
+		s.log.Error(err, "could not get provisioning signing key")
+		return echo.NewHTTPError(http.StatusInternalServerError, "Could not read provisioning signing key")
+	}
+
+	return c.JSON(http.StatusOK, ProvisioningKey{
+		PublicKey: key.Public().(ed25519.PublicKey),
+	})
+}
@@ -0,0 +1,27 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	devcfgpb "gitlab.com/timeterm/timeterm/proto/go/devcfg"
+)
+
+func TestWithoutNetworkingServiceSecrets(t *testing.T) {
+	cfg := &devcfgpb.NetworkingService{
+		Ssid:                 "school",
+		Passphrase:           "secret",
+		PrivateKey:           []byte("key"),
+		PrivateKeyPassphrase: "key secret",
+	}
+
+	stripped := withoutNetworkingServiceSecrets(cfg)
+	assert.Equal(t, "school", stripped.Ssid)
+	assert.Empty(t, stripped.Passphrase)
+	assert.Empty(t, stripped.PrivateKey)
+	assert.Empty(t, stripped.PrivateKeyPassphrase)
+
+	// The original is left alone.
+	assert.Equal(t, "secret", cfg.Passphrase)
+}
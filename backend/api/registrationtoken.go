@@ -0,0 +1,127 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo"
+
+	authn "gitlab.com/timeterm/timeterm/backend/auhtn"
+	"gitlab.com/timeterm/timeterm/backend/database"
+)
+
+const (
+	DefaultDeviceRegistrationTokenTTL = time.Hour * 24
+	MaxDeviceRegistrationTokenTTL     = time.Hour * 24 * 30
+	MaxDeviceRegistrationTokenUses    = 1000
+)
+
+// createDeviceRegistrationToken validates the request and creates a registration token for the organization.
+func (s *Server) createDeviceRegistrationToken(ctx context.Context,
+	organizationID uuid.UUID,
+	req CreateDeviceRegistrationTokenRequest,
+) (database.DeviceRegistrationToken, uuid.UUID, error) {
+	ttl := DefaultDeviceRegistrationTokenTTL
+	if req.TTLSeconds != nil {
+		ttl = time.Duration(*req.TTLSeconds) * time.Second
+	}
+	if ttl <= 0 || ttl > MaxDeviceRegistrationTokenTTL {
+		return database.DeviceRegistrationToken{}, uuid.UUID{},
+			echo.NewHTTPError(http.StatusBadRequest, "Invalid TTL")
+	}
+
+	maxUses := 1
+	if req.MaxUses != nil {
+		maxUses = *req.MaxUses
+	}
+	if maxUses <= 0 || maxUses > MaxDeviceRegistrationTokenUses {
+		return database.DeviceRegistrationToken{}, uuid.UUID{},
+			echo.NewHTTPError(http.StatusBadRequest, "Invalid maximum number of uses")
+	}
+
+	if req.DeviceName != nil && *req.DeviceName == "" {
+		return database.DeviceRegistrationToken{}, uuid.UUID{},
+			echo.NewHTTPError(http.StatusBadRequest, "Device name can not be empty")
+	}
+
+	if req.DeviceGroupID != nil {
+		if err := s.checkDeviceGroup(ctx, organizationID, *req.DeviceGroupID); err != nil {
+			return database.DeviceRegistrationToken{}, uuid.UUID{}, err
+		}
+	}
+
+	regToken, token, err := s.db.CreateDeviceRegistrationToken(ctx,
+		organizationID, time.Now().Add(ttl), maxUses, StringPtrToDB(req.DeviceName), req.DeviceGroupID,
+	)
+	if err != nil {
+		s.log.Error(err, "could not create device registration token")
+		return regToken, token, echo.NewHTTPError(http.StatusInternalServerError, "Could not create token")
+	}
+
+	return regToken, token, nil
+}
+
+func (s *Server) getDeviceRegistrationTokens(c echo.Context) error {
+	user, ok := authn.UserFromContext(c)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Not authenticated")
+	}
+
+	regTokens, err := s.db.GetDeviceRegistrationTokens(c.Request().Context(), user.OrganizationID)
+	if err != nil {
+		s.log.Error(err, "could not get device registration tokens")
+		return echo.NewHTTPError(http.StatusInternalServerError, "Could not read registration tokens from database")
+	}
+
+	return c.JSON(http.StatusOK, DeviceRegistrationTokensFrom(regTokens))
+}
+
+func (s *Server) postDeviceRegistrationToken(c echo.Context) error {
+	user, ok := authn.UserFromContext(c)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Not authenticated")
+	}
+
+	var req CreateDeviceRegistrationTokenRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Could not bind data")
+	}
+
+	regToken, token, err := s.createDeviceRegistrationToken(c.Request().Context(), user.OrganizationID, req)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusCreated, CreateDeviceRegistrationTokenResponse{
+		RegistrationToken: DeviceRegistrationTokenFrom(regToken),
+		Token:             token,
+	})
+}
+
+func (s *Server) deleteDeviceRegistrationToken(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid ID")
+	}
+
+	user, ok := authn.UserFromContext(c)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Not authenticated")
+	}
+
+	err = s.db.DeleteDeviceRegistrationToken(c.Request().Context(), user.OrganizationID, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return echo.NewHTTPError(http.StatusNotFound, "Registration token not found")
+		}
+
+		s.log.Error(err, "could not delete device registration token")
+		return echo.NewHTTPError(http.StatusInternalServerError, "Could not delete registration token")
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
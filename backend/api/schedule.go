@@ -0,0 +1,160 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo"
+
+	authn "gitlab.com/timeterm/timeterm/backend/auhtn"
+	"gitlab.com/timeterm/timeterm/backend/database"
+	"gitlab.com/timeterm/timeterm/backend/scheduler"
+)
+
+// deviceScheduleFromRequest validates the request and converts it to a schedule in the organization.
+func (s *Server) deviceScheduleFromRequest(c echo.Context,
+	organizationID uuid.UUID,
+	req CreateDeviceScheduleRequest,
+) (database.DeviceSchedule, error) {
+	action, ok := DeviceCommandTypeToDB(req.Action)
+	if !ok {
+		return database.DeviceSchedule{}, echo.NewHTTPError(http.StatusBadRequest, "Invalid action")
+	}
+
+	if req.Timezone = strings.TrimSpace(req.Timezone); req.Timezone == "" {
+		req.Timezone = "UTC"
+	}
+	req.CronExpression = strings.TrimSpace(req.CronExpression)
+	if _, err := scheduler.ParseSchedule(req.CronExpression, req.Timezone); err != nil {
+		return database.DeviceSchedule{}, echo.NewHTTPError(http.StatusBadRequest, "Invalid schedule: "+err.Error())
+	}
+
+	if err := s.checkDeviceGroup(c.Request().Context(), organizationID, req.DeviceGroupID); err != nil {
+		return database.DeviceSchedule{}, err
+	}
+
+	enabled := true
+	if req.Enabled != nil {
+		enabled = *req.Enabled
+	}
+
+	return database.DeviceSchedule{
+		OrganizationID: organizationID,
+		DeviceGroupID:  req.DeviceGroupID,
+		Action:         action,
+		CronExpression: req.CronExpression,
+		Timezone:       req.Timezone,
+		Enabled:        enabled,
+	}, nil
+}
+
+func (s *Server) getDeviceSchedules(c echo.Context) error {
+	user, ok := authn.UserFromContext(c)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Not authenticated")
+	}
+
+	schedules, err := s.db.GetDeviceSchedules(c.Request().Context(), user.OrganizationID)
+	if err != nil {
+		s.log.Error(err, "could not get device schedules")
+		return echo.NewHTTPError(http.StatusInternalServerError, "Could not read device schedules from database")
+	}
+
+	return c.JSON(http.StatusOK, DeviceSchedulesFrom(schedules))
+}
+
+func (s *Server) createDeviceSchedule(c echo.Context) error {
+	user, ok := authn.UserFromContext(c)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Not authenticated")
+	}
+
+	var req CreateDeviceScheduleRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Could not bind data")
+	}
+
+	schedule, err := s.deviceScheduleFromRequest(c, user.OrganizationID, req)
+	if err != nil {
+		return err
+	}
+
+	schedule, err = s.db.CreateDeviceSchedule(c.Request().Context(), schedule)
+	if err != nil {
+		s.log.Error(err, "could not create device schedule")
+		return echo.NewHTTPError(http.StatusInternalServerError, "Could not create device schedule")
+	}
+	s.schw.SchedulesUpdated()
+
+	return c.JSON(http.StatusCreated, DeviceScheduleFrom(schedule))
+}
+
+func (s *Server) replaceDeviceSchedule(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid ID")
+	}
+
+	user, ok := authn.UserFromContext(c)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Not authenticated")
+	}
+
+	var req CreateDeviceScheduleRequest
+	if err = c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Could not bind data")
+	}
+
+	schedule, err := s.deviceScheduleFromRequest(c, user.OrganizationID, req)
+	if err != nil {
+		return err
+	}
+	schedule.ID = id
+
+	err = s.db.ReplaceDeviceSchedule(c.Request().Context(), schedule)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return echo.NewHTTPError(http.StatusNotFound, "Device schedule not found")
+		}
+
+		s.log.Error(err, "could not replace device schedule")
+		return echo.NewHTTPError(http.StatusInternalServerError, "Could not update device schedule")
+	}
+	s.schw.SchedulesUpdated()
+
+	schedule, err = s.db.GetDeviceSchedule(c.Request().Context(), id)
+	if err != nil {
+		s.log.Error(err, "could not get device schedule")
+		return echo.NewHTTPError(http.StatusInternalServerError, "Could not read device schedule from database")
+	}
+
+	return c.JSON(http.StatusOK, DeviceScheduleFrom(schedule))
+}
+
+func (s *Server) deleteDeviceSchedule(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid ID")
+	}
+
+	user, ok := authn.UserFromContext(c)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Not authenticated")
+	}
+
+	err = s.db.DeleteDeviceSchedule(c.Request().Context(), user.OrganizationID, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return echo.NewHTTPError(http.StatusNotFound, "Device schedule not found")
+		}
+
+		s.log.Error(err, "could not delete device schedule")
+		return echo.NewHTTPError(http.StatusInternalServerError, "Could not delete device schedule")
+	}
+	s.schw.SchedulesUpdated()
+
+	return c.NoContent(http.StatusNoContent)
+}
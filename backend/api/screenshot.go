@@ -0,0 +1,195 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo"
+	mqpb "gitlab.com/timeterm/timeterm/proto/go/mq"
+
+	authn "gitlab.com/timeterm/timeterm/backend/auhtn"
+	"gitlab.com/timeterm/timeterm/backend/database"
+	"gitlab.com/timeterm/timeterm/backend/messages"
+)
+
+const (
+	// screenshotTimeout is the maximum time a device may take to upload a requested screenshot.
+	screenshotTimeout = 30 * time.Second
+	// screenshotCommandTTL is the time after which devices should no longer take a requested screenshot.
+	screenshotCommandTTL = time.Minute
+)
+
+// pngMagic is the header with which all PNG files start.
+var pngMagic = []byte{0x89, 'P', 'N', 'G', '\r', '\n', 0x1a, '\n'}
+
+// takeDeviceScreenshot requests a screenshot from a device, waits until the device has uploaded it and returns it.
+func (s *Server) takeDeviceScreenshot(c echo.Context) error {
+	user, ok := authn.UserFromContext(c)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Not authenticated")
+	}
+
+	dev, err := s.deviceFromParam(c, user)
+	if err != nil {
+		return err
+	}
+
+	cmd, err := s.db.CreateDeviceCommand(c.Request().Context(), dev.ID,
+		database.DeviceCommandTypeTakeScreenshot, &user.ID, screenshotCommandTTL,
+	)
+	if err != nil {
+		s.log.Error(err, "could not create device command")
+		return echo.NewHTTPError(http.StatusInternalServerError, "Could not create command")
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request().Context(), screenshotTimeout)
+	defer cancel()
+
+	res, err := s.mqw.RequestCommand(ctx, cmd)
+	if errors.Is(err, context.DeadlineExceeded) {
+		return echo.NewHTTPError(http.StatusGatewayTimeout, "Device did not send a screenshot in time")
+	}
+	if err != nil {
+		s.log.Error(err, "could not request screenshot", "commandId", cmd.ID)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Could not request screenshot")
+	}
+	if res.GetStatus() != mqpb.CommandStatus_COMMAND_STATUS_SUCCEEDED {
+		return echo.NewHTTPError(http.StatusBadGateway, "Device could not take screenshot: "+res.GetError())
+	}
+
+	screenshot, data, err := s.db.GetDeviceScreenshotByCommand(c.Request().Context(), cmd.ID)
+	if err != nil {
+		s.log.Error(err, "could not get device screenshot", "commandId", cmd.ID)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Could not get screenshot")
+	}
+
+	return s.sendDeviceScreenshot(c, dev, screenshot, data)
+}
+
+func (s *Server) sendDeviceScreenshot(c echo.Context,
+	dev database.Device,
+	screenshot database.DeviceScreenshot,
+	data database.EncryptedBlob,
+) error {
+	decrypted, err := s.msgw.DecryptBlob(dev.OrganizationID, messages.BlobKindDeviceScreenshot, screenshot.ID, data.Nonce, data.Data)
+	if err != nil {
+		s.log.Error(err, "could not decrypt device screenshot")
+		return echo.NewHTTPError(http.StatusInternalServerError, "Could not decrypt screenshot")
+	}
+
+	c.Response().Header().Set(echo.HeaderLocation, fmt.Sprintf("/device/%s/screenshot/%s", dev.ID, screenshot.ID))
+	return c.Blob(http.StatusOK, "image/png", decrypted)
+}
+
+// uploadDeviceScreenshot stores the screenshot which a device uploads in response to a TakeScreenshot command.
+func (s *Server) uploadDeviceScreenshot(c echo.Context) error {
+	dev, ok := authn.DeviceFromContext(c)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Not authenticated")
+	}
+
+	cmd, err := s.deviceUploadCommandFromParam(c, dev, database.DeviceCommandTypeTakeScreenshot)
+	if err != nil {
+		return err
+	}
+
+	body := http.MaxBytesReader(c.Response(), c.Request().Body, database.MaxDeviceScreenshotSize)
+	data, err := ioutil.ReadAll(body)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusRequestEntityTooLarge, "Screenshot is too large")
+	}
+	if !bytes.HasPrefix(data, pngMagic) {
+		return echo.NewHTTPError(http.StatusBadRequest, "Screenshot must be a PNG image")
+	}
+
+	screenshotID := uuid.New()
+	nonce, encrypted, err := s.msgw.EncryptBlob(dev.OrganizationID, messages.BlobKindDeviceScreenshot, screenshotID, data)
+	if err != nil {
+		s.log.Error(err, "could not encrypt screenshot")
+		return echo.NewHTTPError(http.StatusInternalServerError, "Could not store screenshot")
+	}
+
+	screenshot, err := s.db.CreateDeviceScreenshot(c.Request().Context(), database.DeviceScreenshot{
+		ID:        screenshotID,
+		DeviceID:  dev.ID,
+		CommandID: &cmd.ID,
+		Size:      int64(len(data)),
+	}, database.EncryptedBlob{
+		Nonce: nonce,
+		Data:  encrypted,
+	})
+	if err != nil {
+		if errors.Is(err, database.ErrConflict) {
+			return echo.NewHTTPError(http.StatusConflict, "Screenshot already uploaded")
+		}
+
+		s.log.Error(err, "could not create screenshot")
+		return echo.NewHTTPError(http.StatusInternalServerError, "Could not store screenshot")
+	}
+
+	// The request for the screenshot may be waited for by another backend, which is notified by the result.
+	err = s.mqw.PublishCommandResult(dev.ID, &mqpb.CommandResultMessage{
+		CommandId: cmd.ID.String(),
+		Status:    mqpb.CommandStatus_COMMAND_STATUS_SUCCEEDED,
+	})
+	if err != nil {
+		s.log.Error(err, "could not publish command result", "commandId", cmd.ID)
+	}
+
+	return c.JSON(http.StatusCreated, DeviceScreenshotFrom(screenshot))
+}
+
+func (s *Server) getDeviceScreenshots(c echo.Context) error {
+	user, ok := authn.UserFromContext(c)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Not authenticated")
+	}
+
+	dev, err := s.deviceFromParam(c, user)
+	if err != nil {
+		return err
+	}
+
+	screenshots, err := s.db.GetDeviceScreenshots(c.Request().Context(), dev.ID)
+	if err != nil {
+		s.log.Error(err, "could not get device screenshots")
+		return echo.NewHTTPError(http.StatusInternalServerError, "Could not get screenshots")
+	}
+
+	return c.JSON(http.StatusOK, DeviceScreenshotsFrom(screenshots))
+}
+
+func (s *Server) getDeviceScreenshot(c echo.Context) error {
+	user, ok := authn.UserFromContext(c)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Not authenticated")
+	}
+
+	dev, err := s.deviceFromParam(c, user)
+	if err != nil {
+		return err
+	}
+
+	screenshotID, err := uuid.Parse(c.Param("screenshotId"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid screenshot ID")
+	}
+
+	screenshot, data, err := s.db.GetDeviceScreenshot(c.Request().Context(), screenshotID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		s.log.Error(err, "could not get device screenshot")
+		return echo.NewHTTPError(http.StatusInternalServerError, "Could not get screenshot")
+	}
+	if errors.Is(err, sql.ErrNoRows) || screenshot.DeviceID != dev.ID {
+		return echo.NewHTTPError(http.StatusNotFound, "Screenshot not found")
+	}
+
+	return s.sendDeviceScreenshot(c, dev, screenshot, data)
+}
@@ -1,10 +1,12 @@
 package api
 
 import (
+	"database/sql"
 	"encoding/json"
 	"errors"
 	"io/ioutil"
 	"net/http"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/labstack/echo"
@@ -158,12 +160,18 @@ func (s *Server) patchStudent(c echo.Context) error {
 
 	if newAPIStudent.CardID.Value != nil {
 		cardID := []byte(*newAPIStudent.CardID.Value)
-		if err = s.db.ReplaceStudentCard(ctx, user.OrganizationID, newDBStudent.ID, cardID); err != nil {
+		if err = s.db.ReplaceStudentCard(ctx, user.OrganizationID, newDBStudent.ID, cardID,
+			s.secr.CardKeysFunc(user.OrganizationID),
+		); err != nil {
+			if errors.Is(err, database.ErrConflict) {
+				return echo.NewHTTPError(http.StatusConflict, "Card is already in use")
+			}
+
 			s.log.Error(err, "could not replace student card")
 			return echo.NewHTTPError(http.StatusInternalServerError, "Could not update student card in the database")
 		}
 	} else if newAPIStudent.CardID.ExplicitlyNull {
-		if err = s.db.DeleteStudentCards(ctx, user.ID); err != nil {
+		if err = s.db.DeleteStudentCards(ctx, newDBStudent.ID); err != nil {
 			s.log.Error(err, "could not delete student cards")
 			return echo.NewHTTPError(http.StatusInternalServerError, "Could not delete student cards from the database")
 		}
@@ -205,3 +213,200 @@ func (s *Server) deleteStudents(c echo.Context) error {
 
 	return c.NoContent(http.StatusNoContent)
 }
+
+// isValidPin checks if pin consists of 4 to 8 digits.
+func isValidPin(pin string) bool {
+	if len(pin) < 4 || len(pin) > 8 {
+		return false
+	}
+	for _, r := range pin {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// setCurrentStudentPin sets the PIN of the student logged in at the kiosk.
+// If the student already has a PIN, the current PIN has been verified by the student login middleware.
+func (s *Server) setCurrentStudentPin(c echo.Context) error {
+	student, ok := authn.StudentFromContext(c)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Not authenticated")
+	}
+
+	var req SetStudentPinRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Could not bind data")
+	}
+
+	if !isValidPin(req.Pin) {
+		return echo.NewHTTPError(http.StatusBadRequest, "PIN must consist of 4 to 8 digits")
+	}
+
+	if err := s.db.ReplaceStudentPIN(c.Request().Context(), student.ID, req.Pin); err != nil {
+		s.log.Error(err, "could not set student PIN")
+		return echo.NewHTTPError(http.StatusInternalServerError, "Could not set PIN")
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// deleteStudentPin removes the PIN of a student, for when the student has forgotten it.
+func (s *Server) deleteStudentPin(c echo.Context) error {
+	dbStudent, err := s.studentFromParam(c)
+	if err != nil {
+		return err
+	}
+
+	if err = s.db.DeleteStudentPIN(c.Request().Context(), dbStudent.ID); err != nil {
+		s.log.Error(err, "could not delete student PIN")
+		return echo.NewHTTPError(http.StatusInternalServerError, "Could not delete PIN")
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// studentFromParam retrieves the student with the ID in the id path parameter,
+// making sure that the student is in the organization of the user.
+func (s *Server) studentFromParam(c echo.Context) (database.Student, error) {
+	uid, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return database.Student{}, echo.NewHTTPError(http.StatusBadRequest, "Invalid ID")
+	}
+
+	user, ok := authn.UserFromContext(c)
+	if !ok {
+		return database.Student{}, echo.NewHTTPError(http.StatusUnauthorized, "Not authenticated")
+	}
+
+	dbStudent, err := s.db.GetStudent(c.Request().Context(), uid)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return dbStudent, echo.NewHTTPError(http.StatusNotFound, "Student not found")
+		}
+		return dbStudent, echo.NewHTTPError(http.StatusInternalServerError, "Could not read student from database")
+	}
+
+	if user.OrganizationID != dbStudent.OrganizationID {
+		return dbStudent, echo.NewHTTPError(http.StatusUnauthorized, "Student does not belong to user's organization")
+	}
+
+	return dbStudent, nil
+}
+
+func (s *Server) getStudentCards(c echo.Context) error {
+	dbStudent, err := s.studentFromParam(c)
+	if err != nil {
+		return err
+	}
+
+	cards, err := s.db.GetStudentCards(c.Request().Context(), dbStudent.ID)
+	if err != nil {
+		s.log.Error(err, "could not get student cards")
+		return echo.NewHTTPError(http.StatusInternalServerError, "Could not read student cards from database")
+	}
+
+	return c.JSON(http.StatusOK, StudentCardsFrom(cards))
+}
+
+func (s *Server) createStudentCard(c echo.Context) error {
+	dbStudent, err := s.studentFromParam(c)
+	if err != nil {
+		return err
+	}
+
+	var req CreateStudentCardRequest
+	if err = c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Could not bind data")
+	}
+	if req.Uid == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "Card UID is required")
+	}
+
+	validFrom := time.Now()
+	if req.ValidFrom != nil {
+		validFrom = *req.ValidFrom
+	}
+	if req.ValidUntil != nil && !req.ValidUntil.After(validFrom) {
+		return echo.NewHTTPError(http.StatusBadRequest, "Validity period ends before it starts")
+	}
+
+	card, err := s.db.CreateStudentCard(c.Request().Context(), database.StudentCard{
+		OrganizationID: dbStudent.OrganizationID,
+		StudentID:      dbStudent.ID,
+		Label:          req.Label,
+		ValidFrom:      validFrom,
+		ValidUntil:     TimePtrToDB(req.ValidUntil),
+	}, []byte(req.Uid), s.secr.CardKeysFunc(dbStudent.OrganizationID))
+	if err != nil {
+		if errors.Is(err, database.ErrConflict) {
+			return echo.NewHTTPError(http.StatusConflict, "Card is already in use")
+		}
+
+		s.log.Error(err, "could not create student card")
+		return echo.NewHTTPError(http.StatusInternalServerError, "Could not create student card")
+	}
+
+	return c.JSON(http.StatusCreated, StudentCardFrom(card))
+}
+
+// replaceStudentCardState changes the state of a card, e.g. to block it.
+// Because the state is checked on every request, this takes effect immediately.
+func (s *Server) replaceStudentCardState(c echo.Context) error {
+	dbStudent, err := s.studentFromParam(c)
+	if err != nil {
+		return err
+	}
+
+	cardID, err := uuid.Parse(c.Param("cardId"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid card ID")
+	}
+
+	var req ReplaceStudentCardStateRequest
+	if err = c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Could not bind data")
+	}
+
+	state, ok := StudentCardStateToDB(req.State)
+	if !ok {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid card state")
+	}
+
+	err = s.db.ReplaceStudentCardState(c.Request().Context(), dbStudent.ID, cardID, state)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return echo.NewHTTPError(http.StatusNotFound, "Card not found")
+		}
+
+		s.log.Error(err, "could not replace student card state")
+		return echo.NewHTTPError(http.StatusInternalServerError, "Could not update student card in the database")
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (s *Server) deleteStudentCard(c echo.Context) error {
+	dbStudent, err := s.studentFromParam(c)
+	if err != nil {
+		return err
+	}
+
+	cardID, err := uuid.Parse(c.Param("cardId"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid card ID")
+	}
+
+	err = s.db.DeleteStudentCard(c.Request().Context(), dbStudent.ID, cardID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return echo.NewHTTPError(http.StatusNotFound, "Card not found")
+		}
+
+		s.log.Error(err, "could not delete student card")
+		return echo.NewHTTPError(http.StatusInternalServerError, "Could not delete student card from the database")
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
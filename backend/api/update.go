@@ -0,0 +1,424 @@
+package api
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo"
+
+	authn "gitlab.com/timeterm/timeterm/backend/auhtn"
+	"gitlab.com/timeterm/timeterm/backend/database"
+)
+
+const (
+	maxSoftwareVersionLength = 64
+	// updateManifestTTL is the time for which devices may use an update manifest.
+	updateManifestTTL = 24 * time.Hour
+)
+
+// inRollout checks if a device is selected by the percentage of a rollout. Devices are divided into
+// buckets by hashing their ID with the ID of the rollout, so increasing the percentage only adds devices
+// and different rollouts select different devices first.
+func inRollout(rolloutID, deviceID uuid.UUID, percentage int) bool {
+	h := sha256.New()
+	h.Write(rolloutID[:])
+	h.Write(deviceID[:])
+	bucket := binary.BigEndian.Uint64(h.Sum(nil)) % 100
+
+	return int(bucket) < percentage
+}
+
+// softwareReleaseSignedData returns the data which the vendor signs when publishing a release.
+func softwareReleaseSignedData(component database.SoftwareComponent, version string, hash []byte) []byte {
+	return []byte(fmt.Sprintf("timeterm-release\n%s\n%s\n%x", component, version, hash))
+}
+
+// parseReleaseKey parses the base64-encoded Ed25519 public key with which the vendor signs software releases.
+// Nil is returned if s is empty.
+func parseReleaseKey(s string) (ed25519.PublicKey, error) {
+	if s == "" {
+		return nil, nil
+	}
+
+	key, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("could not decode release key: %w", err)
+	}
+	if len(key) != ed25519.PublicKeySize {
+		return nil, errors.New("release key has an invalid size")
+	}
+	return key, nil
+}
+
+func (s *Server) getSoftwareReleases(c echo.Context) error {
+	user, ok := authn.UserFromContext(c)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Not authenticated")
+	}
+
+	releases, err := s.db.GetSoftwareReleases(c.Request().Context(), user.OrganizationID)
+	if err != nil {
+		s.log.Error(err, "could not get software releases")
+		return echo.NewHTTPError(http.StatusInternalServerError, "Could not read software releases from database")
+	}
+
+	return c.JSON(http.StatusOK, SoftwareReleasesFrom(releases))
+}
+
+func (s *Server) createSoftwareRelease(c echo.Context) error {
+	user, ok := authn.UserFromContext(c)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Not authenticated")
+	}
+
+	var req CreateSoftwareReleaseRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Could not bind data")
+	}
+
+	component, ok := SoftwareComponentToDB(req.Component)
+	if !ok {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid component")
+	}
+	channel, ok := UpdateChannelToDB(req.Channel)
+	if !ok {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid channel")
+	}
+	if req.Version = strings.TrimSpace(req.Version); req.Version == "" || len(req.Version) > maxSoftwareVersionLength {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid version")
+	}
+	if u, err := url.Parse(req.ArtifactURL); err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "Artifact URL must be an HTTP(S) URL")
+	}
+	hash, err := hex.DecodeString(req.SHA256)
+	if err != nil || len(hash) != sha256.Size {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid SHA-256 hash")
+	}
+	// Devices trust every release for which the backend signs a manifest, so only releases which are
+	// signed by the vendor can be published by organizations.
+	if s.releaseKey == nil {
+		return echo.NewHTTPError(http.StatusForbidden, "Publishing software releases is not enabled")
+	}
+	if !ed25519.Verify(s.releaseKey, softwareReleaseSignedData(component, req.Version, hash), req.Signature) {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid release signature")
+	}
+
+	release, err := s.db.CreateSoftwareRelease(c.Request().Context(), database.SoftwareRelease{
+		OrganizationID: user.OrganizationID,
+		Component:      component,
+		Version:        req.Version,
+		Channel:        channel,
+		ArtifactURL:    req.ArtifactURL,
+		SHA256:         hash,
+	})
+	if err != nil {
+		if errors.Is(err, database.ErrConflict) {
+			return echo.NewHTTPError(http.StatusConflict, "Software release already exists")
+		}
+
+		s.log.Error(err, "could not create software release")
+		return echo.NewHTTPError(http.StatusInternalServerError, "Could not create software release")
+	}
+
+	return c.JSON(http.StatusCreated, SoftwareReleaseFrom(release))
+}
+
+func (s *Server) deleteSoftwareRelease(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid ID")
+	}
+
+	user, ok := authn.UserFromContext(c)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Not authenticated")
+	}
+
+	// Rollouts of the release are deleted as well.
+	err = s.db.DeleteSoftwareRelease(c.Request().Context(), user.OrganizationID, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return echo.NewHTTPError(http.StatusNotFound, "Software release not found")
+		}
+
+		s.log.Error(err, "could not delete software release")
+		return echo.NewHTTPError(http.StatusInternalServerError, "Could not delete software release")
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (s *Server) getUpdateRollouts(c echo.Context) error {
+	user, ok := authn.UserFromContext(c)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Not authenticated")
+	}
+
+	rollouts, err := s.db.GetUpdateRollouts(c.Request().Context(), user.OrganizationID)
+	if err != nil {
+		s.log.Error(err, "could not get update rollouts")
+		return echo.NewHTTPError(http.StatusInternalServerError, "Could not read update rollouts from database")
+	}
+
+	return c.JSON(http.StatusOK, UpdateRolloutsFrom(rollouts))
+}
+
+func (s *Server) replaceUpdateRollout(c echo.Context) error {
+	user, ok := authn.UserFromContext(c)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Not authenticated")
+	}
+
+	var req ReplaceUpdateRolloutRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Could not bind data")
+	}
+	if req.Percentage < 0 || req.Percentage > 100 {
+		return echo.NewHTTPError(http.StatusBadRequest, "Percentage must be between 0 and 100")
+	}
+
+	if err := s.checkDeviceGroup(c.Request().Context(), user.OrganizationID, req.DeviceGroupID); err != nil {
+		return err
+	}
+
+	release, err := s.db.GetSoftwareRelease(c.Request().Context(), req.ReleaseID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		s.log.Error(err, "could not get software release")
+		return echo.NewHTTPError(http.StatusInternalServerError, "Could not read software release from database")
+	}
+	if errors.Is(err, sql.ErrNoRows) || release.OrganizationID != user.OrganizationID {
+		return echo.NewHTTPError(http.StatusBadRequest, "Software release not found")
+	}
+
+	rollout, err := s.db.ReplaceUpdateRollout(c.Request().Context(), database.UpdateRollout{
+		OrganizationID: user.OrganizationID,
+		DeviceGroupID:  req.DeviceGroupID,
+		Component:      release.Component,
+		ReleaseID:      release.ID,
+		Percentage:     req.Percentage,
+	})
+	if err != nil {
+		s.log.Error(err, "could not replace update rollout")
+		return echo.NewHTTPError(http.StatusInternalServerError, "Could not update update rollout")
+	}
+
+	return c.JSON(http.StatusOK, UpdateRolloutFrom(rollout))
+}
+
+func (s *Server) deleteUpdateRollout(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid ID")
+	}
+
+	user, ok := authn.UserFromContext(c)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Not authenticated")
+	}
+
+	err = s.db.DeleteUpdateRollout(c.Request().Context(), user.OrganizationID, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return echo.NewHTTPError(http.StatusNotFound, "Update rollout not found")
+		}
+
+		s.log.Error(err, "could not delete update rollout")
+		return echo.NewHTTPError(http.StatusInternalServerError, "Could not delete update rollout")
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// updateRolloutStatus summarizes the versions reported by the devices in the group of the rollout.
+func updateRolloutStatus(rollout database.UpdateRollout,
+	release database.SoftwareRelease,
+	versions []database.DeviceSoftwareVersion,
+) UpdateRolloutStatus {
+	status := UpdateRolloutStatus{
+		RolloutID:     rollout.ID,
+		TargetVersion: release.Version,
+		Devices:       len(versions),
+		Versions:      make(map[string]int),
+	}
+
+	for _, v := range versions {
+		status.Versions[v.Version.String]++
+
+		if !inRollout(rollout.ID, v.DeviceID, rollout.Percentage) {
+			continue
+		}
+		status.InRollout++
+
+		if v.Version.Valid && v.Version.String == release.Version {
+			status.Updated++
+		} else {
+			status.Pending++
+		}
+	}
+
+	return status
+}
+
+func (s *Server) getUpdateRolloutStatus(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid ID")
+	}
+
+	user, ok := authn.UserFromContext(c)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Not authenticated")
+	}
+
+	rollout, err := s.db.GetUpdateRollout(c.Request().Context(), id)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		s.log.Error(err, "could not get update rollout")
+		return echo.NewHTTPError(http.StatusInternalServerError, "Could not read update rollout from database")
+	}
+	if errors.Is(err, sql.ErrNoRows) || rollout.OrganizationID != user.OrganizationID {
+		return echo.NewHTTPError(http.StatusNotFound, "Update rollout not found")
+	}
+
+	release, err := s.db.GetSoftwareRelease(c.Request().Context(), rollout.ReleaseID)
+	if err != nil {
+		s.log.Error(err, "could not get software release")
+		return echo.NewHTTPError(http.StatusInternalServerError, "Could not read software release from database")
+	}
+
+	versions, err := s.db.GetDeviceSoftwareVersions(c.Request().Context(), rollout.DeviceGroupID, rollout.Component)
+	if err != nil {
+		s.log.Error(err, "could not get device software versions")
+		return echo.NewHTTPError(http.StatusInternalServerError, "Could not read device versions from database")
+	}
+
+	return c.JSON(http.StatusOK, updateRolloutStatus(rollout, release, versions))
+}
+
+func (s *Server) getUpdateSigningKey(c echo.Context) error {
+	if _, ok := authn.UserFromContext(c); !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Not authenticated")
+	}
+
+	key, err := s.secr.GetUpdateSigningKey()
+	if err != nil {
+		s.log.Error(err, "could not get update signing key")
+		return echo.NewHTTPError(http.StatusInternalServerError, "Could not read update signing key")
+	}
+
+	return c.JSON(http.StatusOK, UpdateSigningKey{
+		PublicKey: key.Public().(ed25519.PublicKey),
+	})
+}
+
+// resolveDeviceUpdate determines the release of the component which the device should run.
+// Devices in a group with a rollout for the component get the release of the rollout if they are
+// selected by its percentage. Other devices get the latest release in the channel of their group
+// (or the stable channel if they are not in a group).
+// The second return value is false if there is no release for the device.
+func (s *Server) resolveDeviceUpdate(ctx context.Context,
+	dev database.Device,
+	component database.SoftwareComponent,
+) (database.SoftwareRelease, bool, error) {
+	channel := database.UpdateChannelStable
+
+	if dev.GroupID != nil {
+		group, err := s.db.GetDeviceGroup(ctx, *dev.GroupID)
+		if err != nil {
+			return database.SoftwareRelease{}, false, err
+		}
+		channel = group.UpdateChannel
+
+		rollout, err := s.db.GetUpdateRolloutForGroup(ctx, group.ID, component)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return database.SoftwareRelease{}, false, err
+		}
+		if err == nil && inRollout(rollout.ID, dev.ID, rollout.Percentage) {
+			release, err := s.db.GetSoftwareRelease(ctx, rollout.ReleaseID)
+			return release, err == nil, err
+		}
+	}
+
+	release, err := s.db.GetLatestSoftwareRelease(ctx, dev.OrganizationID, component, channel)
+	if errors.Is(err, sql.ErrNoRows) {
+		return release, false, nil
+	}
+	return release, err == nil, err
+}
+
+type checkForUpdateParams struct {
+	Component      SoftwareComponent `query:"component"`
+	CurrentVersion string            `query:"currentVersion"`
+}
+
+// checkForUpdate returns a signed manifest of the release which the device should install,
+// or No Content if the device is up to date.
+func (s *Server) checkForUpdate(c echo.Context) error {
+	dev, ok := authn.DeviceFromContext(c)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Not authenticated")
+	}
+
+	uid, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid ID")
+	}
+	if dev.ID != uid {
+		return echo.NewHTTPError(http.StatusBadRequest, "ID mismatch")
+	}
+
+	var params checkForUpdateParams
+	if err = c.Bind(&params); err != nil {
+		return err
+	}
+
+	component, ok := SoftwareComponentToDB(params.Component)
+	if !ok {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid component")
+	}
+
+	release, ok, err := s.resolveDeviceUpdate(c.Request().Context(), dev, component)
+	if err != nil {
+		s.log.Error(err, "could not resolve device update")
+		return echo.NewHTTPError(http.StatusInternalServerError, "Could not check for update")
+	}
+	if !ok || release.Version == params.CurrentVersion {
+		return c.NoContent(http.StatusNoContent)
+	}
+
+	manifest, err := json.Marshal(UpdateManifest{
+		DeviceID:    dev.ID,
+		Component:   params.Component,
+		Version:     release.Version,
+		ArtifactURL: release.ArtifactURL,
+		SHA256:      hex.EncodeToString(release.SHA256),
+		ExpiresAt:   time.Now().Add(updateManifestTTL),
+	})
+	if err != nil {
+		s.log.Error(err, "could not marshal update manifest")
+		return echo.NewHTTPError(http.StatusInternalServerError, "Could not create update manifest")
+	}
+
+	key, err := s.secr.GetUpdateSigningKey()
+	if err != nil {
+		s.log.Error(err, "could not get update signing key")
+		return echo.NewHTTPError(http.StatusInternalServerError, "Could not read update signing key")
+	}
+
+	return c.JSON(http.StatusOK, SignedUpdateManifest{
+		Manifest:  manifest,
+		Signature: ed25519.Sign(key, manifest),
+	})
+}
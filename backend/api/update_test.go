@@ -0,0 +1,78 @@
+package api
+
+import (
+	"crypto/ed25519"
+	"database/sql"
+	"encoding/base64"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gitlab.com/timeterm/timeterm/backend/database"
+)
+
+func TestInRollout(t *testing.T) {
+	rolloutID := uuid.New()
+
+	devices := make([]uuid.UUID, 1000)
+	for i := range devices {
+		devices[i] = uuid.New()
+	}
+
+	countInRollout := func(percentage int) int {
+		n := 0
+		for _, id := range devices {
+			if inRollout(rolloutID, id, percentage) {
+				n++
+			}
+		}
+		return n
+	}
+
+	assert.Equal(t, 0, countInRollout(0))
+	assert.Equal(t, len(devices), countInRollout(100))
+	assert.InDelta(t, 250, countInRollout(25), 75)
+
+	// Increasing the percentage never removes devices from the rollout.
+	for _, id := range devices {
+		if inRollout(rolloutID, id, 25) {
+			assert.True(t, inRollout(rolloutID, id, 50))
+		}
+	}
+}
+
+func TestUpdateRolloutStatus(t *testing.T) {
+	rollout := database.UpdateRollout{ID: uuid.New(), Percentage: 100}
+	release := database.SoftwareRelease{Version: "1.1.0"}
+
+	status := updateRolloutStatus(rollout, release, []database.DeviceSoftwareVersion{
+		{DeviceID: uuid.New(), Version: sql.NullString{Valid: true, String: "1.1.0"}},
+		{DeviceID: uuid.New(), Version: sql.NullString{Valid: true, String: "1.0.0"}},
+		{DeviceID: uuid.New()},
+	})
+
+	assert.Equal(t, "1.1.0", status.TargetVersion)
+	assert.Equal(t, 3, status.Devices)
+	assert.Equal(t, 3, status.InRollout)
+	assert.Equal(t, 1, status.Updated)
+	assert.Equal(t, 2, status.Pending)
+	assert.Equal(t, map[string]int{"1.1.0": 1, "1.0.0": 1, "": 1}, status.Versions)
+}
+
+func TestParseReleaseKey(t *testing.T) {
+	key, err := parseReleaseKey("")
+	assert.NoError(t, err)
+	assert.Nil(t, key)
+
+	pub, _, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+
+	key, err = parseReleaseKey(base64.StdEncoding.EncodeToString(pub))
+	assert.NoError(t, err)
+	assert.Equal(t, pub, key)
+
+	_, err = parseReleaseKey(base64.StdEncoding.EncodeToString(pub[:16]))
+	assert.Error(t, err)
+}
@@ -1,9 +1,12 @@
 package api
 
 import (
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"io/ioutil"
 	"net/http"
+	"time"
 
 	jsonpatch "github.com/evanphx/json-patch/v5"
 	"github.com/google/uuid"
@@ -83,3 +86,160 @@ func (s *Server) patchUser(c echo.Context) error {
 
 	return c.JSON(http.StatusOK, newAPIUser)
 }
+
+func (s *Server) getUserSessions(c echo.Context) error {
+	user, ok := authn.UserFromContext(c)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Not authenticated")
+	}
+	currentSessionID, _ := authn.UserSessionIDFromContext(c)
+
+	sessions, err := s.db.GetUserSessions(c.Request().Context(), user.ID)
+	if err != nil {
+		s.log.Error(err, "could not get user sessions")
+		return echo.NewHTTPError(http.StatusInternalServerError, "Could not get user sessions")
+	}
+
+	return c.JSON(http.StatusOK, UserSessionsFrom(sessions, currentSessionID))
+}
+
+func (s *Server) deleteUserSession(c echo.Context) error {
+	sessionID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid ID")
+	}
+
+	user, ok := authn.UserFromContext(c)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Not authenticated")
+	}
+
+	err = s.db.DeleteUserSession(c.Request().Context(), user.ID, sessionID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return echo.NewHTTPError(http.StatusNotFound, "Session not found")
+		}
+
+		s.log.Error(err, "could not delete user session")
+		return echo.NewHTTPError(http.StatusInternalServerError, "Could not delete user session")
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// deleteCurrentUserSession logs out the user by revoking the session that is currently being used.
+func (s *Server) deleteCurrentUserSession(c echo.Context) error {
+	user, ok := authn.UserFromContext(c)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Not authenticated")
+	}
+
+	sessionID, ok := authn.UserSessionIDFromContext(c)
+	if !ok {
+		s.log.Error(nil, "user session not in context")
+		return echo.NewHTTPError(http.StatusUnauthorized, "Not authenticated")
+	}
+
+	err := s.db.DeleteUserSession(c.Request().Context(), user.ID, sessionID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		s.log.Error(err, "could not delete user session")
+		return echo.NewHTTPError(http.StatusInternalServerError, "Could not delete user session")
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (s *Server) deleteUserSessions(c echo.Context) error {
+	user, ok := authn.UserFromContext(c)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Not authenticated")
+	}
+
+	err := s.db.DeleteUserSessions(c.Request().Context(), user.ID)
+	if err != nil {
+		s.log.Error(err, "could not delete user sessions")
+		return echo.NewHTTPError(http.StatusInternalServerError, "Could not delete user sessions")
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (s *Server) getUserAPIKeys(c echo.Context) error {
+	user, ok := authn.UserFromContext(c)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Not authenticated")
+	}
+
+	apiKeys, err := s.db.GetUserAPIKeys(c.Request().Context(), user.ID)
+	if err != nil {
+		s.log.Error(err, "could not get user API keys")
+		return echo.NewHTTPError(http.StatusInternalServerError, "Could not get user API keys")
+	}
+
+	return c.JSON(http.StatusOK, UserAPIKeysFrom(apiKeys))
+}
+
+func (s *Server) createUserAPIKey(c echo.Context) error {
+	user, ok := authn.UserFromContext(c)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Not authenticated")
+	}
+
+	// An API key should not be able to create API keys which outlive it or have more scopes.
+	if _, ok = authn.UserAPIKeyFromContext(c); ok {
+		return echo.NewHTTPError(http.StatusForbidden, "API keys can not be created using an API key")
+	}
+
+	var req CreateUserAPIKeyRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Could not bind data")
+	}
+
+	if req.Name == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "Name is required")
+	}
+	scopes, ok := UserAPIKeyScopesToDB(req.Scopes)
+	if !ok || len(scopes) == 0 {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid scopes")
+	}
+	if req.ExpiresAt != nil && req.ExpiresAt.Before(time.Now()) {
+		return echo.NewHTTPError(http.StatusBadRequest, "Expiry time is in the past")
+	}
+
+	apiKey, key, err := s.db.CreateUserAPIKey(c.Request().Context(),
+		user.ID, req.Name, scopes, TimePtrToDB(req.ExpiresAt),
+	)
+	if err != nil {
+		s.log.Error(err, "could not create user API key")
+		return echo.NewHTTPError(http.StatusInternalServerError, "Could not create user API key")
+	}
+
+	return c.JSON(http.StatusCreated, CreateUserAPIKeyResponse{
+		APIKey: UserAPIKeyFrom(apiKey),
+		Key:    authn.FormatAPIKey(key),
+	})
+}
+
+func (s *Server) deleteUserAPIKey(c echo.Context) error {
+	apiKeyID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid ID")
+	}
+
+	user, ok := authn.UserFromContext(c)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Not authenticated")
+	}
+
+	err = s.db.DeleteUserAPIKey(c.Request().Context(), user.ID, apiKeyID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return echo.NewHTTPError(http.StatusNotFound, "API key not found")
+		}
+
+		s.log.Error(err, "could not delete user API key")
+		return echo.NewHTTPError(http.StatusInternalServerError, "Could not delete user API key")
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
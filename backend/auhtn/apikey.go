@@ -0,0 +1,49 @@
+package authn
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"gitlab.com/timeterm/timeterm/backend/database"
+)
+
+// APIKeyPrefix is the prefix of API keys, which is used to distinguish them from session tokens.
+const APIKeyPrefix = "ttk_"
+
+var errNotAnAPIKey = errors.New("not an API key")
+
+// FormatAPIKey formats an API key as it should be presented to the user.
+func FormatAPIKey(key uuid.UUID) string {
+	return APIKeyPrefix + key.String()
+}
+
+// parseAPIKey parses an API key formatted by FormatAPIKey.
+// errNotAnAPIKey is returned if s does not start with APIKeyPrefix.
+func parseAPIKey(s string) (uuid.UUID, error) {
+	if !strings.HasPrefix(s, APIKeyPrefix) {
+		return uuid.Nil, errNotAnAPIKey
+	}
+	return uuid.Parse(strings.TrimPrefix(s, APIKeyPrefix))
+}
+
+// apiKeyAllowsMethod checks if an API key with the provided scopes may be used for a request with the method.
+// The read scope allows safe methods only, while the write scope allows all methods.
+func apiKeyAllowsMethod(scopes database.UserAPIKeyScopes, method string) bool {
+	if isSafeMethod(method) {
+		return scopes.Has(database.UserAPIKeyScopeRead) || scopes.Has(database.UserAPIKeyScopeWrite)
+	}
+	return scopes.Has(database.UserAPIKeyScopeWrite)
+}
+
+// isSafeMethod checks if requests with the method only retrieve data.
+func isSafeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	default:
+		return false
+	}
+}
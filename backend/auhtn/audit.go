@@ -0,0 +1,107 @@
+package authn
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/labstack/echo"
+
+	"gitlab.com/timeterm/timeterm/backend/database"
+)
+
+const (
+	// loginLockoutWindow is the period in which failed login attempts are counted.
+	loginLockoutWindow = time.Minute * 10
+	// maxFailedTokenLoginsPerIP is the number of failed device (registration) token logins from an IP address
+	// in loginLockoutWindow after which logins from the IP address are rejected.
+	// Card taps are not counted here, as all kiosks of a school may well share the same IP address.
+	maxFailedTokenLoginsPerIP = 20
+	// maxFailedCardLoginsPerDevice is the number of failed card logins at a device in loginLockoutWindow
+	// after which card logins at the device are throttled.
+	// Logins aren't rejected, so tapping unknown cards can't stop students from using the device.
+	maxFailedCardLoginsPerDevice = 30
+	// cardLoginThrottleDelayStep is how much card logins at a device are delayed
+	// per failed card login over maxFailedCardLoginsPerDevice.
+	cardLoginThrottleDelayStep = time.Millisecond * 200
+	// maxCardLoginThrottleDelay is the maximum delay of card logins at a device.
+	maxCardLoginThrottleDelay = time.Second * 5
+)
+
+var tokenLoginAttemptKinds = []database.LoginAttemptKind{
+	database.LoginAttemptKindDevice,
+	database.LoginAttemptKindDeviceRegistration,
+}
+
+// checkIPLockout rejects the request if there have been too many failed token logins from the IP address of the client.
+// The IP address can only be taken from X-Forwarded-For because of TrustedProxiesMiddleware.
+func checkIPLockout(c echo.Context, db *database.Wrapper, log logr.Logger) error {
+	n, err := db.CountFailedLoginAttemptsByIP(c.Request().Context(),
+		c.RealIP(), tokenLoginAttemptKinds, time.Now().Add(-loginLockoutWindow),
+	)
+	if err != nil {
+		log.Error(err, "failed to count failed login attempts by IP")
+		return echo.NewHTTPError(http.StatusInternalServerError, "Could not query database")
+	}
+	if n >= maxFailedTokenLoginsPerIP {
+		return echo.NewHTTPError(http.StatusTooManyRequests, "Too many failed login attempts")
+	}
+	return nil
+}
+
+// throttleDeviceCardLogins delays the request if there have been too many failed card logins at the device.
+func throttleDeviceCardLogins(c echo.Context, db *database.Wrapper, log logr.Logger, dev database.Device) error {
+	ctx := c.Request().Context()
+
+	n, err := db.CountFailedLoginAttemptsByDevice(ctx,
+		dev.ID, database.LoginAttemptKindStudentCard, time.Now().Add(-loginLockoutWindow),
+	)
+	if err != nil {
+		log.Error(err, "failed to count failed login attempts by device")
+		return echo.NewHTTPError(http.StatusInternalServerError, "Could not query database")
+	}
+
+	delay := cardLoginThrottleDelay(n)
+	if delay == 0 {
+		return nil
+	}
+
+	t := time.NewTimer(delay)
+	defer t.Stop()
+
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return echo.NewHTTPError(http.StatusTooManyRequests, "Too many failed card taps")
+	}
+}
+
+// cardLoginThrottleDelay returns how long a card login is delayed after a number of failed card logins at the device.
+func cardLoginThrottleDelay(failed int) time.Duration {
+	if failed < maxFailedCardLoginsPerDevice {
+		return 0
+	}
+
+	delay := time.Duration(failed-maxFailedCardLoginsPerDevice+1) * cardLoginThrottleDelayStep
+	if delay > maxCardLoginThrottleDelay {
+		return maxCardLoginThrottleDelay
+	}
+	return delay
+}
+
+// recordLoginAttempt saves the login attempt for auditing. The IP address of the client is filled in.
+// Failing to do so is logged, but does not fail the request.
+func recordLoginAttempt(c echo.Context, db *database.Wrapper, log logr.Logger, a database.LoginAttempt) {
+	a.ClientIP = c.RealIP()
+	if err := db.CreateLoginAttempt(c.Request().Context(), a); err != nil {
+		log.Error(err, "failed to record login attempt", "kind", a.Kind, "success", a.Success)
+	}
+}
+
+// isClientError checks if err is an HTTP error caused by the client, i.e. a rejected login attempt.
+func isClientError(err error) bool {
+	var herr *echo.HTTPError
+	return errors.As(err, &herr) && herr.Code >= 400 && herr.Code < 500
+}
@@ -10,6 +10,7 @@ import (
 	"net/url"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/coreos/go-oidc"
@@ -35,7 +36,8 @@ type Authorizer struct {
 	log     logr.Logger
 	issuers map[string]Issuer
 
-	redirectURL *url.URL
+	redirectURL            *url.URL
+	allowedRedirectOrigins map[string]struct{}
 }
 
 func New(log logr.Logger, dbw *database.Wrapper, secr *secrets.Wrapper) (*Authorizer, error) {
@@ -44,12 +46,18 @@ func New(log logr.Logger, dbw *database.Wrapper, secr *secrets.Wrapper) (*Author
 		return nil, fmt.Errorf("invalid OIDC_REDIRECT_URL: %w", err)
 	}
 
+	allowedRedirectOrigins, err := parseOrigins(os.Getenv("OIDC_ALLOWED_REDIRECT_ORIGINS"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid OIDC_ALLOWED_REDIRECT_ORIGINS: %w", err)
+	}
+
 	a := &Authorizer{
-		dbw:         dbw,
-		secr:        secr,
-		log:         log,
-		issuers:     make(map[string]Issuer),
-		redirectURL: redirectURL,
+		dbw:                    dbw,
+		secr:                   secr,
+		log:                    log,
+		issuers:                make(map[string]Issuer),
+		redirectURL:            redirectURL,
+		allowedRedirectOrigins: allowedRedirectOrigins,
 	}
 
 	err = a.setupIssuers()
@@ -60,9 +68,12 @@ func New(log logr.Logger, dbw *database.Wrapper, secr *secrets.Wrapper) (*Author
 }
 
 func (a *Authorizer) RegisterRoutes(g *echo.Group) {
-	g = g.Group("/oidc")
-	g.GET("/login/:issuer", a.HandleLogin)
-	g.GET("/callback", a.HandleOauth2Callback)
+	oidcGroup := g.Group("/oidc")
+	oidcGroup.GET("/login/:issuer", a.HandleLogin)
+	oidcGroup.GET("/callback", a.HandleOauth2Callback)
+
+	tokenGroup := g.Group("/token")
+	tokenGroup.POST("/refresh", a.HandleRefresh)
 }
 
 func (a *Authorizer) setupIssuers() error {
@@ -141,13 +152,19 @@ func errorMsg(msg string) url.Values {
 	return url.Values{"error": []string{msg}}
 }
 
-func tokenData(token string) url.Values {
+func tokenData(tokens database.UserTokens) url.Values {
 	return url.Values{
 		"token": []string{
-			base64.URLEncoding.EncodeToString([]byte(token)),
+			base64.URLEncoding.EncodeToString([]byte(tokens.AccessToken.String())),
 		},
 		"expires": []string{
-			strconv.FormatInt(time.Now().Add(database.DefaultTokenExpiration).Unix(), 10),
+			strconv.FormatInt(tokens.AccessTokenExpiresAt.Unix(), 10),
+		},
+		"refreshToken": []string{
+			base64.URLEncoding.EncodeToString([]byte(tokens.RefreshToken.String())),
+		},
+		"refreshTokenExpires": []string{
+			strconv.FormatInt(tokens.RefreshTokenExpiresAt.Unix(), 10),
 		},
 	}
 }
@@ -167,10 +184,72 @@ func redirectToOrigin(c echo.Context, redirectTo *url.URL, status Status, data u
 	return c.Redirect(http.StatusFound, origin.String())
 }
 
+// redirectToOriginWithTokens redirects to the origin with the tokens in the fragment of the URL.
+// Unlike the query, the fragment is never sent to servers, so the tokens don't end up
+// in proxy logs or Referer headers. The frontend removes the fragment from the browser history.
+func redirectToOriginWithTokens(c echo.Context, redirectTo *url.URL, tokens database.UserTokens) error {
+	origin := *redirectTo
+	origin.Fragment = ""
+
+	q := origin.Query()
+	q.Set("status", string(StatusOK))
+	origin.RawQuery = q.Encode()
+
+	return c.Redirect(http.StatusFound, origin.String()+"#"+tokenData(tokens).Encode())
+}
+
+// origin returns the origin (scheme://host[:port]) of u in lowercase.
+func origin(u *url.URL) string {
+	return strings.ToLower(u.Scheme + "://" + u.Host)
+}
+
+// parseOrigins parses a comma-separated list of origins.
+func parseOrigins(s string) (map[string]struct{}, error) {
+	origins := make(map[string]struct{})
+
+	for _, o := range strings.Split(s, ",") {
+		o = strings.TrimSpace(o)
+		if o == "" {
+			continue
+		}
+
+		u, err := url.Parse(o)
+		if err != nil {
+			return nil, fmt.Errorf("invalid origin %q: %w", o, err)
+		}
+		if u.Scheme == "" || u.Host == "" {
+			return nil, fmt.Errorf("invalid origin %q: scheme and host are required", o)
+		}
+
+		origins[origin(u)] = struct{}{}
+	}
+
+	return origins, nil
+}
+
+// parseRedirectURL parses the URL to redirect to after logging in,
+// and checks if its origin is allowed to receive tokens.
+func (a *Authorizer) parseRedirectURL(s string) (*url.URL, error) {
+	u, err := url.Parse(s)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, errors.New("redirect URL must be an absolute HTTP(S) URL")
+	}
+	if u.User != nil {
+		return nil, errors.New("redirect URL must not contain user info")
+	}
+	if _, ok := a.allowedRedirectOrigins[origin(u)]; !ok {
+		return nil, errors.New("redirect URL origin is not allowed")
+	}
+	return u, nil
+}
+
 func (a *Authorizer) HandleLogin(c echo.Context) error {
-	redirectURL, err := url.Parse(c.QueryParam("redirectTo"))
+	redirectURL, err := a.parseRedirectURL(c.QueryParam("redirectTo"))
 	if err != nil {
-		return err
+		return templates.RenderError(c, http.StatusBadRequest, "Invalid redirect URL")
 	}
 
 	issuerName := c.Param("issuer")
@@ -179,13 +258,28 @@ func (a *Authorizer) HandleLogin(c echo.Context) error {
 		return redirectToOrigin(c, redirectURL, StatusError, errorMsg("Unknown issuer"))
 	}
 
-	state, err := a.dbw.CreateOAuth2State(c.Request().Context(), issuerName, redirectURL.String())
+	codeVerifier, err := newCodeVerifier()
+	if err != nil {
+		a.log.Error(err, "could not create code verifier")
+		return redirectToOrigin(c, redirectURL, StatusError, errorMsg("Could not create token"))
+	}
+
+	nonce, err := newNonce()
+	if err != nil {
+		a.log.Error(err, "could not create nonce")
+		return redirectToOrigin(c, redirectURL, StatusError, errorMsg("Could not create token"))
+	}
+
+	state, err := a.dbw.CreateOAuth2State(c.Request().Context(),
+		issuerName, redirectURL.String(), codeVerifier, nonce,
+	)
 	if err != nil {
 		a.log.Error(err, "could not create token")
 		return redirectToOrigin(c, redirectURL, StatusError, errorMsg("Could not create token"))
 	}
 
-	return c.Redirect(http.StatusFound, issuer.config.AuthCodeURL(state.State.String()))
+	opts := append(codeChallengeOptions(codeVerifier), oidc.Nonce(nonce))
+	return c.Redirect(http.StatusFound, issuer.config.AuthCodeURL(state.State.String(), opts...))
 }
 
 func (a *Authorizer) issuerFromState(state database.OAuth2State) (Issuer, error) {
@@ -238,7 +332,7 @@ func (a *Authorizer) HandleOauth2Callback(c echo.Context) error {
 		return err
 	}
 
-	oauth2Token, err := issuer.config.Exchange(ctx, reqData.Code)
+	oauth2Token, err := issuer.config.Exchange(ctx, reqData.Code, codeVerifierOption(state.CodeVerifier))
 	if err != nil {
 		return redirectToOrigin(c, redirectURL, StatusError, errorMsg("Could not exchange code with provider"))
 	}
@@ -253,6 +347,10 @@ func (a *Authorizer) HandleOauth2Callback(c echo.Context) error {
 		return redirectToOrigin(c, redirectURL, StatusError, errorMsg("Could not verify token"))
 	}
 
+	if !nonceMatches(state.Nonce, idToken.Nonce) {
+		return redirectToOrigin(c, redirectURL, StatusError, errorMsg("Could not verify token"))
+	}
+
 	var claims struct {
 		Issuer     string `json:"iss"`
 		Subject    string `json:"sub"`
@@ -310,15 +408,66 @@ func (a *Authorizer) HandleOauth2Callback(c echo.Context) error {
 			a.log.Error(err, "could not create organization logs key")
 			return redirectToOrigin(c, redirectURL, StatusError, errorMsg("Could not create user"))
 		}
+
+		if err = a.secr.NewOrganizationCardKeys(user.OrganizationID); err != nil {
+			a.log.Error(err, "could not create organization card keys")
+			return redirectToOrigin(c, redirectURL, StatusError, errorMsg("Could not create user"))
+		}
 	} else {
 		return redirectToOrigin(c, redirectURL, StatusError, errorMsg("No user for login"))
 	}
 
-	token, err := a.dbw.CreateUserToken(c.Request().Context(), user.ID)
+	tokens, err := a.dbw.CreateUserSession(c.Request().Context(), user.ID, c.Request().UserAgent(), c.RealIP())
 	if err != nil {
-		a.log.Error(err, "could not create token")
+		a.log.Error(err, "could not create user session")
 		return redirectToOrigin(c, redirectURL, StatusError, errorMsg("Could not create token"))
 	}
 
-	return redirectToOrigin(c, redirectURL, StatusOK, tokenData(token.String()))
+	return redirectToOriginWithTokens(c, redirectURL, tokens)
+}
+
+type refreshRequest struct {
+	RefreshToken string `json:"refreshToken"`
+}
+
+type refreshResponse struct {
+	Token               string    `json:"token"`
+	Expires             time.Time `json:"expires"`
+	RefreshToken        string    `json:"refreshToken"`
+	RefreshTokenExpires time.Time `json:"refreshTokenExpires"`
+}
+
+// HandleRefresh exchanges a refresh token for a new access token and a new refresh token.
+// The refresh token that was used can not be used again.
+func (a *Authorizer) HandleRefresh(c echo.Context) error {
+	var req refreshRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request body")
+	}
+
+	refreshToken, err := uuid.Parse(req.RefreshToken)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid refresh token format")
+	}
+
+	tokens, err := a.dbw.RefreshUserSession(c.Request().Context(), refreshToken)
+	if err != nil {
+		if errors.Is(err, database.ErrRefreshTokenReused) {
+			a.log.Info("refresh token reused, session revoked")
+			return echo.NewHTTPError(http.StatusUnauthorized, "Invalid refresh token")
+		}
+		if errors.Is(err, sql.ErrNoRows) {
+			return echo.NewHTTPError(http.StatusUnauthorized, "Invalid refresh token")
+		}
+
+		a.log.Error(err, "could not refresh user session")
+		return echo.NewHTTPError(http.StatusInternalServerError, "Could not refresh session")
+	}
+
+	return c.JSON(http.StatusOK, refreshResponse{
+		Token:               tokens.AccessToken.String(),
+		Expires:             tokens.AccessTokenExpiresAt,
+		RefreshToken:        tokens.RefreshToken.String(),
+		RefreshTokenExpires: tokens.RefreshTokenExpiresAt,
+	})
 }
@@ -0,0 +1,200 @@
+package authn
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gitlab.com/timeterm/timeterm/backend/database"
+)
+
+func TestCodeChallengeS256(t *testing.T) {
+	// Example from RFC 7636, appendix B.
+	const verifier = "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+	const challenge = "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM"
+
+	assert.Equal(t, challenge, codeChallengeS256(verifier))
+}
+
+func TestNewCodeVerifier(t *testing.T) {
+	v, err := newCodeVerifier()
+	require.NoError(t, err)
+
+	assert.Len(t, v, 43)
+}
+
+func TestNonceMatches(t *testing.T) {
+	assert.True(t, nonceMatches("abc", "abc"))
+	assert.False(t, nonceMatches("abc", "abd"))
+	assert.False(t, nonceMatches("", ""))
+}
+
+func TestAuthorizer_parseRedirectURL(t *testing.T) {
+	origins, err := parseOrigins("https://admin.timeterm.nl, http://localhost:3000")
+	require.NoError(t, err)
+
+	a := &Authorizer{allowedRedirectOrigins: origins}
+
+	for _, allowed := range []string{
+		"https://admin.timeterm.nl/login/done",
+		"https://ADMIN.timeterm.nl/login/done?x=y",
+		"http://localhost:3000/login/done",
+	} {
+		_, err := a.parseRedirectURL(allowed)
+		assert.NoError(t, err, allowed)
+	}
+
+	for _, disallowed := range []string{
+		"",
+		"/login/done",
+		"//evil.example/login/done",
+		"https://evil.example/login/done",
+		"https://admin.timeterm.nl.evil.example/login/done",
+		"http://admin.timeterm.nl/login/done",
+		"http://localhost:3001/login/done",
+		"https://user@admin.timeterm.nl/login/done",
+		"javascript://admin.timeterm.nl/%0Aalert(1)",
+	} {
+		_, err := a.parseRedirectURL(disallowed)
+		assert.Error(t, err, disallowed)
+	}
+}
+
+func TestParseOrigins(t *testing.T) {
+	_, err := parseOrigins("localhost:3000")
+	assert.Error(t, err)
+
+	origins, err := parseOrigins("")
+	require.NoError(t, err)
+	assert.Empty(t, origins)
+}
+
+func TestParseAPIKey(t *testing.T) {
+	key := uuid.New()
+
+	got, err := parseAPIKey(FormatAPIKey(key))
+	require.NoError(t, err)
+	assert.Equal(t, key, got)
+
+	_, err = parseAPIKey(key.String())
+	assert.True(t, errors.Is(err, errNotAnAPIKey))
+
+	_, err = parseAPIKey(APIKeyPrefix + "invalid")
+	assert.Error(t, err)
+	assert.False(t, errors.Is(err, errNotAnAPIKey))
+}
+
+func TestAPIKeyAllowsMethod(t *testing.T) {
+	read := database.UserAPIKeyScopes{database.UserAPIKeyScopeRead}
+	write := database.UserAPIKeyScopes{database.UserAPIKeyScopeWrite}
+
+	assert.True(t, apiKeyAllowsMethod(read, http.MethodGet))
+	assert.False(t, apiKeyAllowsMethod(read, http.MethodPatch))
+	assert.True(t, apiKeyAllowsMethod(write, http.MethodGet))
+	assert.True(t, apiKeyAllowsMethod(write, http.MethodDelete))
+}
+
+func TestCheckStudentCard(t *testing.T) {
+	now := time.Now()
+
+	active := database.StudentCard{
+		State:     database.StudentCardStateActive,
+		ValidFrom: now.Add(-time.Hour),
+	}
+	assert.NoError(t, checkStudentCard(active, now))
+
+	blocked := active
+	blocked.State = database.StudentCardStateBlocked
+	assert.Error(t, checkStudentCard(blocked, now))
+
+	lost := active
+	lost.State = database.StudentCardStateLost
+	assert.Error(t, checkStudentCard(lost, now))
+
+	// Cards may not have been marked as expired yet.
+	expired := active
+	expired.ValidUntil = sql.NullTime{Valid: true, Time: now.Add(-time.Minute)}
+	assert.Error(t, checkStudentCard(expired, now))
+
+	notYetValid := active
+	notYetValid.ValidFrom = now.Add(time.Hour)
+	assert.Error(t, checkStudentCard(notYetValid, now))
+}
+
+func TestRedirectToOriginWithTokens(t *testing.T) {
+	rec := httptest.NewRecorder()
+	c := echo.New().NewContext(httptest.NewRequest(http.MethodGet, "/oidc/callback", nil), rec)
+
+	redirectTo, err := url.Parse("http://localhost:3000/login/done")
+	require.NoError(t, err)
+
+	tokens := database.UserTokens{
+		AccessToken:           uuid.New(),
+		AccessTokenExpiresAt:  time.Now(),
+		RefreshToken:          uuid.New(),
+		RefreshTokenExpiresAt: time.Now(),
+	}
+	require.NoError(t, redirectToOriginWithTokens(c, redirectTo, tokens))
+
+	location, err := url.Parse(rec.Header().Get("Location"))
+	require.NoError(t, err)
+	assert.Equal(t, "ok", location.Query().Get("status"))
+	assert.Empty(t, location.Query().Get("token"))
+	assert.Empty(t, location.Query().Get("refreshToken"))
+
+	fragment, err := url.ParseQuery(location.Fragment)
+	require.NoError(t, err)
+	assert.Equal(t, tokenData(tokens), fragment)
+}
+
+func TestTrimForwardedHeaders(t *testing.T) {
+	trusted, err := ParseTrustedProxies("10.0.0.0/8, 192.168.1.1")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		forwarded  string
+		want       string
+	}{
+		{"untrusted peer", "203.0.113.1:1234", "198.51.100.1", "203.0.113.1"},
+		{"trusted peer", "10.0.0.1:1234", "198.51.100.1", "198.51.100.1"},
+		{"spoofed by client", "10.0.0.1:1234", "127.0.0.1, 198.51.100.1", "198.51.100.1"},
+		{"chain of proxies", "10.0.0.1:1234", "198.51.100.1, 192.168.1.1", "198.51.100.1"},
+		{"trusted peer without header", "10.0.0.1:1234", "", "10.0.0.1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = tt.remoteAddr
+			req.Header.Set(echo.HeaderXRealIP, "127.0.0.1")
+			if tt.forwarded != "" {
+				req.Header.Set(echo.HeaderXForwardedFor, tt.forwarded)
+			} else {
+				req.Header.Del(echo.HeaderXRealIP)
+			}
+
+			trimForwardedHeaders(req, trusted)
+			assert.Equal(t, tt.want, echo.New().NewContext(req, nil).RealIP())
+		})
+	}
+
+	_, err = ParseTrustedProxies("not an IP")
+	assert.Error(t, err)
+}
+
+func TestCardLoginThrottleDelay(t *testing.T) {
+	assert.Zero(t, cardLoginThrottleDelay(maxFailedCardLoginsPerDevice-1))
+	assert.Equal(t, cardLoginThrottleDelayStep, cardLoginThrottleDelay(maxFailedCardLoginsPerDevice))
+	assert.Equal(t, maxCardLoginThrottleDelay, cardLoginThrottleDelay(1000))
+}
@@ -0,0 +1,93 @@
+package authn
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo"
+)
+
+// ParseTrustedProxies parses a comma-separated list of IP addresses and CIDR ranges of trusted reverse proxies.
+func ParseTrustedProxies(s string) ([]*net.IPNet, error) {
+	var nets []*net.IPNet
+
+	for _, p := range strings.Split(s, ",") {
+		p = strings.TrimSpace(p)
+		if p == "" {
+			continue
+		}
+
+		if !strings.Contains(p, "/") {
+			ip := net.ParseIP(p)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid trusted proxy %q", p)
+			}
+			bits := 8 * net.IPv6len
+			if ip4 := ip.To4(); ip4 != nil {
+				ip, bits = ip4, 8*net.IPv4len
+			}
+			nets = append(nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+
+		_, ipNet, err := net.ParseCIDR(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q: %w", p, err)
+		}
+		nets = append(nets, ipNet)
+	}
+
+	return nets, nil
+}
+
+func isTrustedProxy(trusted []*net.IPNet, s string) bool {
+	ip := net.ParseIP(strings.TrimSpace(s))
+	if ip == nil {
+		return false
+	}
+	for _, n := range trusted {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// TrustedProxiesMiddleware makes c.RealIP() return an address that the client can't choose itself.
+// X-Forwarded-For and X-Real-IP are only kept for requests coming from a trusted proxy.
+// Because clients can send their own X-Forwarded-For, which proxies append to, only the last address
+// which isn't a trusted proxy is kept.
+func TrustedProxiesMiddleware(trusted []*net.IPNet) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			trimForwardedHeaders(c.Request(), trusted)
+			return next(c)
+		}
+	}
+}
+
+func trimForwardedHeaders(r *http.Request, trusted []*net.IPNet) {
+	remoteIP, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil || !isTrustedProxy(trusted, remoteIP) {
+		r.Header.Del(echo.HeaderXForwardedFor)
+		r.Header.Del(echo.HeaderXRealIP)
+		return
+	}
+
+	forwardedFor := r.Header.Values(echo.HeaderXForwardedFor)
+	if len(forwardedFor) == 0 {
+		return
+	}
+
+	addrs := strings.Split(strings.Join(forwardedFor, ","), ",")
+	client := remoteIP
+	for i := len(addrs) - 1; i >= 0; i-- {
+		client = strings.TrimSpace(addrs[i])
+		if !isTrustedProxy(trusted, client) {
+			break
+		}
+	}
+	r.Header.Set(echo.HeaderXForwardedFor, client)
+}
@@ -4,6 +4,7 @@ import (
 	"database/sql"
 	"errors"
 	"net/http"
+	"time"
 
 	"github.com/go-logr/logr"
 	"github.com/google/uuid"
@@ -11,13 +12,17 @@ import (
 	"github.com/labstack/echo/middleware"
 
 	"gitlab.com/timeterm/timeterm/backend/database"
+	"gitlab.com/timeterm/timeterm/backend/secrets"
 )
 
 const (
-	deviceEchoContextKey       = "gitlab.com/timeterm/timeterm/backend/authn/device"
-	organizationEchoContextKey = "gitlab.com/timeterm/timeterm/backend/authn/organization"
-	userEchoContextKey         = "gitlab.com/timeterm/timeterm/backend/authn/user"
-	studentEchoContextKey      = "gitlab.com/timeterm/timeterm/backend/authn/student"
+	deviceEchoContextKey                  = "gitlab.com/timeterm/timeterm/backend/authn/device"
+	deviceRegistrationTokenEchoContextKey = "gitlab.com/timeterm/timeterm/backend/authn/device-registration-token"
+	organizationEchoContextKey            = "gitlab.com/timeterm/timeterm/backend/authn/organization"
+	userEchoContextKey                    = "gitlab.com/timeterm/timeterm/backend/authn/user"
+	userSessionEchoContextKey             = "gitlab.com/timeterm/timeterm/backend/authn/user-session"
+	userAPIKeyEchoContextKey              = "gitlab.com/timeterm/timeterm/backend/authn/user-api-key"
+	studentEchoContextKey                 = "gitlab.com/timeterm/timeterm/backend/authn/student"
 )
 
 func DeviceFromContext(c echo.Context) (database.Device, bool) {
@@ -29,6 +34,16 @@ func AddDeviceToContext(c echo.Context, d database.Device) {
 	c.Set(deviceEchoContextKey, d)
 }
 
+// DeviceRegistrationTokenFromContext returns the registration token that is used to register a device.
+func DeviceRegistrationTokenFromContext(c echo.Context) (database.DeviceRegistrationToken, bool) {
+	regToken, ok := c.Get(deviceRegistrationTokenEchoContextKey).(database.DeviceRegistrationToken)
+	return regToken, ok
+}
+
+func AddDeviceRegistrationTokenToContext(c echo.Context, t database.DeviceRegistrationToken) {
+	c.Set(deviceRegistrationTokenEchoContextKey, t)
+}
+
 func OrganizationFromContext(c echo.Context) (database.Organization, bool) {
 	org, ok := c.Get(organizationEchoContextKey).(database.Organization)
 	return org, ok
@@ -47,6 +62,26 @@ func AddUserToContext(c echo.Context, u database.User) {
 	c.Set(userEchoContextKey, u)
 }
 
+// UserSessionIDFromContext returns the ID of the session that the logged in user is using.
+func UserSessionIDFromContext(c echo.Context) (uuid.UUID, bool) {
+	id, ok := c.Get(userSessionEchoContextKey).(uuid.UUID)
+	return id, ok
+}
+
+func AddUserSessionIDToContext(c echo.Context, id uuid.UUID) {
+	c.Set(userSessionEchoContextKey, id)
+}
+
+// UserAPIKeyFromContext returns the API key that the user logged in with, if any.
+func UserAPIKeyFromContext(c echo.Context) (database.UserAPIKey, bool) {
+	apiKey, ok := c.Get(userAPIKeyEchoContextKey).(database.UserAPIKey)
+	return apiKey, ok
+}
+
+func AddUserAPIKeyToContext(c echo.Context, k database.UserAPIKey) {
+	c.Set(userAPIKeyEchoContextKey, k)
+}
+
 func StudentFromContext(c echo.Context) (database.Student, bool) {
 	student, ok := c.Get(studentEchoContextKey).(database.Student)
 	return student, ok
@@ -60,12 +95,19 @@ func UserLoginMiddleware(db *database.Wrapper, log logr.Logger) echo.MiddlewareF
 	return middleware.KeyAuthWithConfig(middleware.KeyAuthConfig{
 		KeyLookup: "header:X-Api-Key",
 		Validator: func(key string, c echo.Context) (bool, error) {
+			if apiKey, err := parseAPIKey(key); !errors.Is(err, errNotAnAPIKey) {
+				if err != nil {
+					return false, echo.NewHTTPError(http.StatusBadRequest, "Invalid API key format")
+				}
+				return validateUserAPIKey(c, db, log, apiKey)
+			}
+
 			token, err := uuid.Parse(key)
 			if err != nil {
 				return false, echo.NewHTTPError(http.StatusBadRequest, "Invalid token format")
 			}
 
-			user, err := db.GetUserByToken(c.Request().Context(), token)
+			user, sessionID, err := db.GetUserByToken(c.Request().Context(), token)
 			if err != nil {
 				if errors.Is(err, sql.ErrNoRows) {
 					return false, echo.NewHTTPError(http.StatusUnauthorized, "Invalid token")
@@ -75,13 +117,43 @@ func UserLoginMiddleware(db *database.Wrapper, log logr.Logger) echo.MiddlewareF
 				return false, echo.NewHTTPError(http.StatusInternalServerError, "Could not query database")
 			}
 
+			if err = db.ReplaceUserSessionLastUsed(c.Request().Context(), sessionID); err != nil {
+				log.Error(err, "failed to update time of last use of user session")
+			}
+
 			AddUserToContext(c, user)
+			AddUserSessionIDToContext(c, sessionID)
 
 			return true, nil
 		},
 	})
 }
 
+func validateUserAPIKey(c echo.Context, db *database.Wrapper, log logr.Logger, key uuid.UUID) (bool, error) {
+	user, apiKey, err := db.GetUserByAPIKey(c.Request().Context(), key)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, echo.NewHTTPError(http.StatusUnauthorized, "Invalid API key")
+		}
+
+		log.Error(err, "failed to get user by API key")
+		return false, echo.NewHTTPError(http.StatusInternalServerError, "Could not query database")
+	}
+
+	if !apiKeyAllowsMethod(apiKey.Scopes, c.Request().Method) {
+		return false, echo.NewHTTPError(http.StatusForbidden, "API key does not have the required scope")
+	}
+
+	if err = db.ReplaceUserAPIKeyLastUsed(c.Request().Context(), apiKey.ID); err != nil {
+		log.Error(err, "failed to update time of last use of user API key")
+	}
+
+	AddUserToContext(c, user)
+	AddUserAPIKeyToContext(c, apiKey)
+
+	return true, nil
+}
+
 func DeviceRegistrationLoginMiddleware(db *database.Wrapper, log logr.Logger) echo.MiddlewareFunc {
 	return middleware.KeyAuthWithConfig(middleware.KeyAuthConfig{
 		KeyLookup: "header:X-Api-Key",
@@ -91,17 +163,38 @@ func DeviceRegistrationLoginMiddleware(db *database.Wrapper, log logr.Logger) ec
 				return false, echo.NewHTTPError(http.StatusBadRequest, "Invalid token format")
 			}
 
-			organization, err := db.GetOrganizationByDeviceRegistrationToken(c.Request().Context(), token)
+			if err = checkIPLockout(c, db, log); err != nil {
+				return false, err
+			}
+
+			regToken, err := db.GetDeviceRegistrationTokenByToken(c.Request().Context(), token)
 			if err != nil {
 				if errors.Is(err, sql.ErrNoRows) {
+					recordLoginAttempt(c, db, log, database.LoginAttempt{
+						Kind:          database.LoginAttemptKindDeviceRegistration,
+						FailureReason: database.LoginFailureReasonInvalidToken,
+					})
 					return false, echo.NewHTTPError(http.StatusUnauthorized, "Invalid token")
 				}
 
-				log.Error(err, "failed to get organization by registration token")
+				log.Error(err, "failed to get registration token")
 				return false, echo.NewHTTPError(http.StatusInternalServerError, "Could not query database")
 			}
 
+			organization, err := db.GetOrganization(c.Request().Context(), regToken.OrganizationID)
+			if err != nil {
+				log.Error(err, "failed to get organization of registration token")
+				return false, echo.NewHTTPError(http.StatusInternalServerError, "Could not query database")
+			}
+
+			recordLoginAttempt(c, db, log, database.LoginAttempt{
+				Kind:           database.LoginAttemptKindDeviceRegistration,
+				Success:        true,
+				OrganizationID: &organization.ID,
+			})
+
 			AddOrganizationToContext(c, organization)
+			AddDeviceRegistrationTokenToContext(c, regToken)
 
 			return true, nil
 		},
@@ -117,9 +210,18 @@ func DeviceLoginMiddleware(db *database.Wrapper, log logr.Logger) echo.Middlewar
 				return false, echo.NewHTTPError(http.StatusBadRequest, "Invalid token format")
 			}
 
+			if err = checkIPLockout(c, db, log); err != nil {
+				return false, err
+			}
+
+			// Only failed attempts are recorded, because devices log in for every request (e.g. heartbeats).
 			device, err := db.GetDeviceByToken(c.Request().Context(), token)
 			if err != nil {
 				if errors.Is(err, sql.ErrNoRows) {
+					recordLoginAttempt(c, db, log, database.LoginAttempt{
+						Kind:          database.LoginAttemptKindDevice,
+						FailureReason: database.LoginFailureReasonInvalidToken,
+					})
 					return false, echo.NewHTTPError(http.StatusUnauthorized, "Invalid token")
 				}
 
@@ -134,7 +236,23 @@ func DeviceLoginMiddleware(db *database.Wrapper, log logr.Logger) echo.Middlewar
 	})
 }
 
-func StudentLoginMiddleware(db *database.Wrapper, log logr.Logger) echo.MiddlewareFunc {
+// StudentLoginMiddleware logs in the student using the card UID in the X-Card-Uid header.
+// For requests which make changes, the PIN of the student (in the X-Card-Pin header) is verified too.
+func StudentLoginMiddleware(db *database.Wrapper, secr *secrets.Wrapper, log logr.Logger) echo.MiddlewareFunc {
+	return studentLoginMiddleware(db, secr, log, false)
+}
+
+// StudentPINSetupLoginMiddleware is like StudentLoginMiddleware, but lets students who haven't set a PIN
+// make changes even if their organization requires a PIN, so they can set one.
+func StudentPINSetupLoginMiddleware(db *database.Wrapper, secr *secrets.Wrapper, log logr.Logger) echo.MiddlewareFunc {
+	return studentLoginMiddleware(db, secr, log, true)
+}
+
+func studentLoginMiddleware(db *database.Wrapper,
+	secr *secrets.Wrapper,
+	log logr.Logger,
+	allowWithoutPIN bool,
+) echo.MiddlewareFunc {
 	return middleware.KeyAuthWithConfig(middleware.KeyAuthConfig{
 		KeyLookup: "header:X-Card-Uid",
 		Validator: func(uid string, c echo.Context) (bool, error) {
@@ -146,19 +264,133 @@ func StudentLoginMiddleware(db *database.Wrapper, log logr.Logger) echo.Middlewa
 				)
 			}
 
-			student, err := db.GetStudentByCard(c.Request().Context(), []byte(uid), dev.OrganizationID)
+			if err := throttleDeviceCardLogins(c, db, log, dev); err != nil {
+				return false, err
+			}
+
+			cardKeys, err := secr.GetOrganizationCardKeys(dev.OrganizationID)
+			if err != nil {
+				log.Error(err, "failed to get organization card keys")
+				return false, echo.NewHTTPError(http.StatusInternalServerError, "Could not retrieve card keys")
+			}
+
+			attempt := database.LoginAttempt{
+				Kind:           database.LoginAttemptKindStudentCard,
+				OrganizationID: &dev.OrganizationID,
+				DeviceID:       &dev.ID,
+				CardHashPrefix: database.CardUIDHashPrefix(cardKeys.Keys[cardKeys.Current], []byte(uid)),
+			}
+
+			student, card, err := db.GetStudentByCard(c.Request().Context(),
+				[]byte(uid), dev.OrganizationID, secr.CardKeysFunc(dev.OrganizationID),
+			)
 			if err != nil {
 				if errors.Is(err, sql.ErrNoRows) {
+					attempt.FailureReason = database.LoginFailureReasonUnknownCard
+					recordLoginAttempt(c, db, log, attempt)
 					return false, echo.NewHTTPError(http.StatusUnauthorized, "Invalid card UID")
 				}
+				if errors.Is(err, database.ErrConflict) {
+					// The card has been added to multiple students, so we can't tell who is logging in.
+					log.Error(err, "card UID matches multiple cards", "organizationId", dev.OrganizationID)
+					attempt.FailureReason = database.LoginFailureReasonCardRejected
+					recordLoginAttempt(c, db, log, attempt)
+					return false, echo.NewHTTPError(http.StatusConflict, "Card is in use by multiple students")
+				}
 
 				log.Error(err, "failed to get student by card UID")
 				return false, echo.NewHTTPError(http.StatusInternalServerError, "Could not query database")
 			}
 
+			if err = checkStudentCard(card, time.Now()); err != nil {
+				attempt.FailureReason = database.LoginFailureReasonCardRejected
+				recordLoginAttempt(c, db, log, attempt)
+				return false, err
+			}
+
+			if err = verifyStudentPIN(c, db, log, student, allowWithoutPIN); err != nil {
+				if isClientError(err) {
+					attempt.FailureReason = database.LoginFailureReasonPINRejected
+					recordLoginAttempt(c, db, log, attempt)
+				}
+				return false, err
+			}
+
+			attempt.Success = true
+			recordLoginAttempt(c, db, log, attempt)
+
 			AddStudentToContext(c, student)
 
 			return true, nil
 		},
 	})
 }
+
+// checkStudentCard checks if the card can be used to log in at the moment.
+// The kiosk shows the message of the returned error to the student.
+func checkStudentCard(card database.StudentCard, now time.Time) error {
+	switch {
+	case card.State == database.StudentCardStateBlocked:
+		return echo.NewHTTPError(http.StatusForbidden, "Card blocked")
+	case card.State == database.StudentCardStateLost:
+		return echo.NewHTTPError(http.StatusForbidden, "Card reported lost")
+	case card.State == database.StudentCardStateExpired,
+		card.ValidUntil.Valid && !card.ValidUntil.Time.After(now):
+		return echo.NewHTTPError(http.StatusForbidden, "Card expired")
+	case card.ValidFrom.After(now):
+		return echo.NewHTTPError(http.StatusForbidden, "Card not valid yet")
+	}
+	return nil
+}
+
+func verifyStudentPIN(c echo.Context,
+	db *database.Wrapper,
+	log logr.Logger,
+	student database.Student,
+	allowWithoutPIN bool,
+) error {
+	if isSafeMethod(c.Request().Method) {
+		return nil
+	}
+
+	hasPIN, err := db.HasStudentPIN(c.Request().Context(), student.ID)
+	if err != nil {
+		log.Error(err, "failed to check if student has a PIN")
+		return echo.NewHTTPError(http.StatusInternalServerError, "Could not query database")
+	}
+	if !hasPIN {
+		if allowWithoutPIN {
+			return nil
+		}
+
+		org, err := db.GetOrganization(c.Request().Context(), student.OrganizationID)
+		if err != nil {
+			log.Error(err, "failed to get organization of student")
+			return echo.NewHTTPError(http.StatusInternalServerError, "Could not query database")
+		}
+		if org.RequireStudentPIN {
+			return echo.NewHTTPError(http.StatusForbidden, "A PIN must be set before making changes")
+		}
+		return nil
+	}
+
+	pin := c.Request().Header.Get("X-Card-Pin")
+	if pin == "" {
+		return echo.NewHTTPError(http.StatusUnauthorized, "PIN required")
+	}
+
+	err = db.VerifyStudentPIN(c.Request().Context(), student.ID, pin)
+	if err != nil {
+		if errors.Is(err, database.ErrPINLocked) {
+			return echo.NewHTTPError(http.StatusTooManyRequests, "Too many incorrect PIN attempts")
+		}
+		if errors.Is(err, database.ErrIncorrectPIN) {
+			return echo.NewHTTPError(http.StatusUnauthorized, "Incorrect PIN")
+		}
+
+		log.Error(err, "failed to verify student PIN")
+		return echo.NewHTTPError(http.StatusInternalServerError, "Could not verify PIN")
+	}
+
+	return nil
+}
@@ -0,0 +1,64 @@
+package authn
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"io"
+
+	"golang.org/x/oauth2"
+)
+
+// randomURLSafeString generates a random string from n random bytes, encoded with base64url (without padding).
+func randomURLSafeString(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := io.ReadFull(rand.Reader, b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// newCodeVerifier generates a new PKCE code verifier.
+// 32 random bytes make for a 43 character verifier, the minimum length allowed by RFC 7636.
+func newCodeVerifier() (string, error) {
+	v, err := randomURLSafeString(32)
+	if err != nil {
+		return "", fmt.Errorf("could not generate code verifier: %w", err)
+	}
+	return v, nil
+}
+
+// newNonce generates a new nonce, to be included in the ID token by the issuer.
+func newNonce() (string, error) {
+	n, err := randomURLSafeString(32)
+	if err != nil {
+		return "", fmt.Errorf("could not generate nonce: %w", err)
+	}
+	return n, nil
+}
+
+// codeChallengeS256 derives the S256 code challenge from a PKCE code verifier.
+func codeChallengeS256(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// codeChallengeOptions returns the options for the authorization request containing the code challenge.
+func codeChallengeOptions(verifier string) []oauth2.AuthCodeOption {
+	return []oauth2.AuthCodeOption{
+		oauth2.SetAuthURLParam("code_challenge", codeChallengeS256(verifier)),
+		oauth2.SetAuthURLParam("code_challenge_method", "S256"),
+	}
+}
+
+// codeVerifierOption returns the option for the token request containing the code verifier.
+func codeVerifierOption(verifier string) oauth2.AuthCodeOption {
+	return oauth2.SetAuthURLParam("code_verifier", verifier)
+}
+
+// nonceMatches checks if the nonce in the ID token is the nonce that was sent to the issuer.
+func nonceMatches(want, got string) bool {
+	return want != "" && subtle.ConstantTimeCompare([]byte(want), []byte(got)) == 1
+}
@@ -0,0 +1,65 @@
+// Package blobstore stores binary objects, such as the branding assets of organizations,
+// in the database, on the local filesystem or in an S3-compatible object storage service.
+package blobstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"gitlab.com/timeterm/timeterm/backend/database"
+)
+
+// ErrNotFound is returned if a blob doesn't exist.
+var ErrNotFound = errors.New("blob not found")
+
+// ErrInvalidKey is returned if a key is not a slash-separated path of letters, digits, dots, dashes and underscores.
+var ErrInvalidKey = errors.New("invalid blob key")
+
+var keyRegexp = regexp.MustCompile(`^[a-zA-Z0-9._-]+(/[a-zA-Z0-9._-]+)*$`)
+
+// Store stores blobs by key.
+type Store interface {
+	// Put stores a blob, overwriting the blob with the same key if it exists.
+	Put(ctx context.Context, key string, data []byte) error
+	// Get retrieves a blob. ErrNotFound is returned if the blob doesn't exist.
+	Get(ctx context.Context, key string) ([]byte, error)
+	// Delete removes a blob. No error is returned if the blob doesn't exist.
+	Delete(ctx context.Context, key string) error
+	// DeleteAll removes all blobs whose keys start with prefix followed by a slash.
+	DeleteAll(ctx context.Context, prefix string) error
+}
+
+// NewFromEnv creates an S3 store in the bucket BLOB_STORE_S3_BUCKET if it is set (see S3Config for
+// the other BLOB_STORE_S3_* variables), a filesystem store in the directory BLOB_STORE_DIR if it is set,
+// and a store in the database otherwise.
+func NewFromEnv(db *database.Wrapper) (Store, error) {
+	if bucket := os.Getenv("BLOB_STORE_S3_BUCKET"); bucket != "" {
+		return NewS3Store(S3Config{
+			Endpoint:        os.Getenv("BLOB_STORE_S3_ENDPOINT"),
+			Bucket:          bucket,
+			Region:          os.Getenv("BLOB_STORE_S3_REGION"),
+			AccessKeyID:     os.Getenv("BLOB_STORE_S3_ACCESS_KEY_ID"),
+			SecretAccessKey: os.Getenv("BLOB_STORE_S3_SECRET_ACCESS_KEY"),
+		})
+	}
+	if dir := os.Getenv("BLOB_STORE_DIR"); dir != "" {
+		return NewFSStore(dir)
+	}
+	return NewDatabaseStore(db), nil
+}
+
+func checkKey(key string) error {
+	if !keyRegexp.MatchString(key) {
+		return fmt.Errorf("%w: %q", ErrInvalidKey, key)
+	}
+	for _, part := range strings.Split(key, "/") {
+		if part == "." || part == ".." {
+			return fmt.Errorf("%w: %q", ErrInvalidKey, key)
+		}
+	}
+	return nil
+}
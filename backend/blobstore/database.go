@@ -0,0 +1,54 @@
+package blobstore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"gitlab.com/timeterm/timeterm/backend/database"
+)
+
+// DatabaseStore stores blobs in the database.
+type DatabaseStore struct {
+	db *database.Wrapper
+}
+
+func NewDatabaseStore(db *database.Wrapper) *DatabaseStore {
+	return &DatabaseStore{db: db}
+}
+
+func (s *DatabaseStore) Put(ctx context.Context, key string, data []byte) error {
+	if err := checkKey(key); err != nil {
+		return err
+	}
+	return s.db.ReplaceBlob(ctx, key, data)
+}
+
+func (s *DatabaseStore) Get(ctx context.Context, key string) ([]byte, error) {
+	if err := checkKey(key); err != nil {
+		return nil, err
+	}
+
+	blob, err := s.db.GetBlob(ctx, key)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return blob.Data, nil
+}
+
+func (s *DatabaseStore) Delete(ctx context.Context, key string) error {
+	if err := checkKey(key); err != nil {
+		return err
+	}
+	return s.db.DeleteBlob(ctx, key)
+}
+
+func (s *DatabaseStore) DeleteAll(ctx context.Context, prefix string) error {
+	if err := checkKey(prefix); err != nil {
+		return err
+	}
+	return s.db.DeleteBlobsWithPrefix(ctx, prefix+"/")
+}
@@ -0,0 +1,53 @@
+package blobstore
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFSStore(t *testing.T) {
+	ctx := context.Background()
+
+	s, err := NewFSStore(t.TempDir())
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	_, err = s.Get(ctx, "branding/a/logo")
+	assert.True(t, errors.Is(err, ErrNotFound))
+
+	assert.NoError(t, s.Put(ctx, "branding/a/logo", []byte{1, 2, 3}))
+	data, err := s.Get(ctx, "branding/a/logo")
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{1, 2, 3}, data)
+
+	assert.NoError(t, s.Put(ctx, "branding/a/logo", []byte{4}))
+	data, err = s.Get(ctx, "branding/a/logo")
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{4}, data)
+
+	assert.NoError(t, s.Delete(ctx, "branding/a/logo"))
+	assert.NoError(t, s.Delete(ctx, "branding/a/logo"))
+	_, err = s.Get(ctx, "branding/a/logo")
+	assert.True(t, errors.Is(err, ErrNotFound))
+
+	assert.NoError(t, s.Put(ctx, "branding/a/logo", []byte{1}))
+	assert.NoError(t, s.Put(ctx, "branding/b/logo", []byte{2}))
+	assert.NoError(t, s.DeleteAll(ctx, "branding/a"))
+	_, err = s.Get(ctx, "branding/a/logo")
+	assert.True(t, errors.Is(err, ErrNotFound))
+	_, err = s.Get(ctx, "branding/b/logo")
+	assert.NoError(t, err)
+}
+
+func TestCheckKey(t *testing.T) {
+	for _, key := range []string{"a", "branding/a/logo/0f", "a_b-c.d"} {
+		assert.NoError(t, checkKey(key), key)
+	}
+	for _, key := range []string{"", "/a", "a/", "a//b", "../a", "a/../b", "a/.", "a b", `a\b`} {
+		assert.True(t, errors.Is(checkKey(key), ErrInvalidKey), key)
+	}
+}
@@ -0,0 +1,286 @@
+package blobstore
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// s3RequestTimeout is the maximum time a request to the object storage service may take.
+const s3RequestTimeout = 30 * time.Second
+
+// S3Config configures an S3Store.
+type S3Config struct {
+	// Endpoint is the URL of the service, https://s3.<region>.amazonaws.com by default.
+	Endpoint string
+	Bucket   string
+	// Region is us-east-1 by default.
+	Region          string
+	AccessKeyID     string
+	SecretAccessKey string
+}
+
+// S3Store stores blobs in a bucket of an S3-compatible object storage service, such as Amazon S3 or MinIO.
+// Objects are addressed path-style (<endpoint>/<bucket>/<key>), which all S3-compatible services support.
+type S3Store struct {
+	client          *http.Client
+	endpoint        *url.URL
+	bucket          string
+	region          string
+	accessKeyID     string
+	secretAccessKey string
+}
+
+func NewS3Store(cfg S3Config) (*S3Store, error) {
+	if cfg.Bucket == "" {
+		return nil, fmt.Errorf("no S3 bucket configured")
+	}
+	if cfg.Region == "" {
+		cfg.Region = "us-east-1"
+	}
+	if cfg.Endpoint == "" {
+		cfg.Endpoint = fmt.Sprintf("https://s3.%s.amazonaws.com", cfg.Region)
+	}
+
+	endpoint, err := url.Parse(cfg.Endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid S3 endpoint: %w", err)
+	}
+	if (endpoint.Scheme != "https" && endpoint.Scheme != "http") || endpoint.Host == "" {
+		return nil, fmt.Errorf("S3 endpoint must be an absolute http(s) URL")
+	}
+
+	return &S3Store{
+		client:          &http.Client{Timeout: s3RequestTimeout},
+		endpoint:        endpoint,
+		bucket:          cfg.Bucket,
+		region:          cfg.Region,
+		accessKeyID:     cfg.AccessKeyID,
+		secretAccessKey: cfg.SecretAccessKey,
+	}, nil
+}
+
+func (s *S3Store) Put(ctx context.Context, key string, data []byte) error {
+	if err := checkKey(key); err != nil {
+		return err
+	}
+
+	rsp, err := s.do(ctx, http.MethodPut, key, "", data)
+	if err != nil {
+		return err
+	}
+	defer rsp.Body.Close()
+
+	return checkS3Response(rsp)
+}
+
+func (s *S3Store) Get(ctx context.Context, key string) ([]byte, error) {
+	if err := checkKey(key); err != nil {
+		return nil, err
+	}
+
+	rsp, err := s.do(ctx, http.MethodGet, key, "", nil)
+	if err != nil {
+		return nil, err
+	}
+	defer rsp.Body.Close()
+
+	if rsp.StatusCode == http.StatusNotFound {
+		return nil, ErrNotFound
+	}
+	if err = checkS3Response(rsp); err != nil {
+		return nil, err
+	}
+	return ioutil.ReadAll(rsp.Body)
+}
+
+// Delete removes a blob. S3 doesn't return an error if the object doesn't exist.
+func (s *S3Store) Delete(ctx context.Context, key string) error {
+	if err := checkKey(key); err != nil {
+		return err
+	}
+
+	rsp, err := s.do(ctx, http.MethodDelete, key, "", nil)
+	if err != nil {
+		return err
+	}
+	defer rsp.Body.Close()
+
+	return checkS3Response(rsp)
+}
+
+// listBucketResult is the response of ListObjectsV2.
+type listBucketResult struct {
+	Contents []struct {
+		Key string `xml:"Key"`
+	} `xml:"Contents"`
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+}
+
+func (s *S3Store) DeleteAll(ctx context.Context, prefix string) error {
+	if err := checkKey(prefix); err != nil {
+		return err
+	}
+
+	var continuationToken string
+	for {
+		params := map[string]string{"list-type": "2", "prefix": prefix + "/"}
+		if continuationToken != "" {
+			params["continuation-token"] = continuationToken
+		}
+
+		result, err := s.listObjects(ctx, params)
+		if err != nil {
+			return err
+		}
+
+		for _, obj := range result.Contents {
+			if err = s.Delete(ctx, obj.Key); err != nil {
+				return err
+			}
+		}
+
+		if !result.IsTruncated {
+			return nil
+		}
+		continuationToken = result.NextContinuationToken
+	}
+}
+
+func (s *S3Store) listObjects(ctx context.Context, params map[string]string) (listBucketResult, error) {
+	var result listBucketResult
+
+	rsp, err := s.do(ctx, http.MethodGet, "", s3CanonicalQuery(params), nil)
+	if err != nil {
+		return result, err
+	}
+	defer rsp.Body.Close()
+
+	if err = checkS3Response(rsp); err != nil {
+		return result, err
+	}
+	if err = xml.NewDecoder(rsp.Body).Decode(&result); err != nil {
+		return result, fmt.Errorf("could not decode S3 object list: %w", err)
+	}
+	return result, nil
+}
+
+// do sends a signed request for the object with the key, or for the bucket if the key is empty.
+// The query must be in its canonical form (see s3CanonicalQuery), as it is signed as is.
+func (s *S3Store) do(ctx context.Context, method, key, query string, body []byte) (*http.Response, error) {
+	u := *s.endpoint
+	u.Path = strings.TrimSuffix(u.Path, "/") + "/" + s.bucket
+	if key != "" {
+		u.Path += "/" + key
+	}
+	u.RawQuery = query
+
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	s.sign(req, body, time.Now())
+
+	rsp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("could not send S3 request: %w", err)
+	}
+	return rsp, nil
+}
+
+// sign signs the request with AWS Signature Version 4, including the Host header and all headers
+// which are set on the request when it is signed.
+func (s *S3Store) sign(req *http.Request, body []byte, now time.Time) {
+	payloadHash := sha256.Sum256(body)
+	amzDate := now.UTC().Format("20060102T150405Z")
+	date := amzDate[:8]
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", hex.EncodeToString(payloadHash[:]))
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(strings.Join(values, ","))
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		canonicalHeaders.String(),
+		signedHeaders,
+		hex.EncodeToString(payloadHash[:]),
+	}, "\n")
+	canonicalRequestHash := sha256.Sum256([]byte(canonicalRequest))
+
+	scope := date + "/" + s.region + "/s3/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(canonicalRequestHash[:])
+
+	signingKey := hmacSHA256([]byte("AWS4"+s.secretAccessKey), date)
+	signingKey = hmacSHA256(signingKey, s.region)
+	signingKey = hmacSHA256(signingKey, "s3")
+	signingKey = hmacSHA256(signingKey, "aws4_request")
+	signature := hmacSHA256(signingKey, stringToSign)
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%x",
+		s.accessKeyID, scope, signedHeaders, signature,
+	))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+// s3CanonicalQuery encodes the parameters sorted by name, with all characters except for
+// unreserved characters percent-encoded, as required by AWS Signature Version 4.
+func s3CanonicalQuery(params map[string]string) string {
+	names := make([]string, 0, len(params))
+	for name := range params {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	escape := func(s string) string {
+		return strings.ReplaceAll(url.QueryEscape(s), "+", "%20")
+	}
+
+	pairs := make([]string, len(names))
+	for i, name := range names {
+		pairs[i] = escape(name) + "=" + escape(params[name])
+	}
+	return strings.Join(pairs, "&")
+}
+
+func checkS3Response(rsp *http.Response) error {
+	if rsp.StatusCode >= 200 && rsp.StatusCode < 300 {
+		return nil
+	}
+
+	msg, _ := ioutil.ReadAll(io.LimitReader(rsp.Body, 1024))
+	return fmt.Errorf("S3 request failed with status %s: %s", rsp.Status, bytes.TrimSpace(msg))
+}
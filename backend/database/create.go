@@ -2,22 +2,49 @@ package database
 
 import (
 	"context"
+	"crypto/hmac"
+	"crypto/sha256"
 	"database/sql"
+	"database/sql/driver"
 	"errors"
 	"fmt"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
 	"github.com/lib/pq"
 	"golang.org/x/crypto/sha3"
 )
 
-const DefaultTokenExpiration = time.Hour * 24
+const (
+	DefaultTokenExpiration        = time.Minute * 15
+	DefaultRefreshTokenExpiration = time.Hour * 24 * 30
+	// DeviceTokenRotationGracePeriod is how long a device token can still be used after it has been rotated,
+	// so requests which are already in flight don't fail.
+	DeviceTokenRotationGracePeriod = time.Minute * 10
+)
+
+const (
+	// MaxStudentPINAttempts is the number of consecutive incorrect attempts after which a PIN is locked.
+	MaxStudentPINAttempts = 5
+	// StudentPINLockoutDuration is how long a PIN stays locked after too many incorrect attempts.
+	StudentPINLockoutDuration = time.Minute * 15
+)
 
 type Organization struct {
 	ID                 uuid.UUID
 	Name               string
 	ZermeloInstitution string
+	RequireStudentPIN  bool
+	// DeviceOfflineAlertThresholdSeconds is the time after which offline devices are reported.
+	// Alerting is disabled if it is not set.
+	DeviceOfflineAlertThresholdSeconds sql.NullInt32
+	// DeviceAlertWebhookURL is the URL to which device alerts are posted, if set.
+	DeviceAlertWebhookURL sql.NullString
+	// DeviceAlertEmail is the email address to which device alerts are sent, if set.
+	DeviceAlertEmail sql.NullString
+	// DeviceConfigProfileID is the device config profile of all devices in the organization, if set.
+	DeviceConfigProfileID *uuid.UUID
 }
 
 type Student struct {
@@ -27,12 +54,80 @@ type Student struct {
 	HasCardAssociated bool
 }
 
+type StudentCardState string
+
+const (
+	StudentCardStateActive  StudentCardState = "active"
+	StudentCardStateBlocked StudentCardState = "blocked"
+	StudentCardStateLost    StudentCardState = "lost"
+	StudentCardStateExpired StudentCardState = "expired"
+)
+
+// StudentCard is a card which a student can use to log in at a kiosk.
+// The hash of the card UID is deliberately left out.
+type StudentCard struct {
+	ID             uuid.UUID
+	OrganizationID uuid.UUID
+	StudentID      uuid.UUID
+	KeyVersion     int
+	State          StudentCardState
+	Label          string
+	CreatedAt      time.Time
+	ValidFrom      time.Time
+	ValidUntil     sql.NullTime
+}
+
+// studentCardColumns are the columns which are selected into a StudentCard.
+const studentCardColumns = `
+	student_card.id, student_card.organization_id, student_card.student_id, student_card.key_version,
+	student_card.state, student_card.label, student_card.created_at, student_card.valid_from, student_card.valid_until
+`
+
+type LoginAttemptKind string
+
+const (
+	LoginAttemptKindDevice             LoginAttemptKind = "device"
+	LoginAttemptKindDeviceRegistration LoginAttemptKind = "device_registration"
+	LoginAttemptKindStudentCard        LoginAttemptKind = "student_card"
+)
+
+const (
+	LoginFailureReasonInvalidToken = "invalid_token"
+	LoginFailureReasonUnknownCard  = "unknown_card"
+	LoginFailureReasonCardRejected = "card_rejected"
+	LoginFailureReasonPINRejected  = "pin_rejected"
+)
+
+// LoginAttempt is a record of a device or student (card) trying to log in.
+type LoginAttempt struct {
+	ID             int64
+	Kind           LoginAttemptKind
+	Success        bool
+	FailureReason  string
+	OrganizationID *uuid.UUID
+	DeviceID       *uuid.UUID
+	// CardHashPrefix is a prefix of the hash of the card UID, which is enough to recognize the same (unknown) card
+	// being tapped again, but not to look up the card.
+	CardHashPrefix []byte
+	ClientIP       string
+	CreatedAt      time.Time
+}
+
+type StudentPIN struct {
+	StudentID      uuid.UUID
+	Hash           []byte
+	FailedAttempts int
+	LockedUntil    sql.NullTime
+}
+
 type OAuth2State struct {
-	State       uuid.UUID
-	Issuer      string
-	RedirectURL string
-	CreatedAt   time.Time
-	ExpiresAt   time.Time
+	State        uuid.UUID
+	Issuer       string
+	RedirectURL  string
+	CodeVerifier string
+	Nonce        string
+	CreatedAt    time.Time
+	ExpiresAt    time.Time
 }
 
 type OIDCFederation struct {
@@ -49,17 +144,530 @@ type User struct {
 	OrganizationID uuid.UUID
 }
 
+type UserSession struct {
+	ID                       uuid.UUID
+	UserID                   uuid.UUID
+	RefreshTokenHash         []byte
+	PreviousRefreshTokenHash []byte
+	CreatedAt                time.Time
+	LastUsedAt               time.Time
+	ExpiresAt                time.Time
+	UserAgent                string
+	ClientIP                 string
+}
+
+// UserTokens contains a short-lived access token and the refresh token which can be used
+// to retrieve a new access token (and refresh token) when the access token has expired.
+type UserTokens struct {
+	SessionID             uuid.UUID
+	AccessToken           uuid.UUID
+	AccessTokenExpiresAt  time.Time
+	RefreshToken          uuid.UUID
+	RefreshTokenExpiresAt time.Time
+}
+
+type UserAPIKeyScope string
+
+const (
+	UserAPIKeyScopeRead  UserAPIKeyScope = "read"
+	UserAPIKeyScopeWrite UserAPIKeyScope = "write"
+)
+
+// UserAPIKeyScopes is a set of scopes, stored as a Postgres array.
+type UserAPIKeyScopes []UserAPIKeyScope
+
+func (s *UserAPIKeyScopes) Scan(src interface{}) error {
+	var scopes pq.StringArray
+	if err := scopes.Scan(src); err != nil {
+		return err
+	}
+
+	*s = make(UserAPIKeyScopes, len(scopes))
+	for i, scope := range scopes {
+		(*s)[i] = UserAPIKeyScope(scope)
+	}
+	return nil
+}
+
+func (s UserAPIKeyScopes) Value() (driver.Value, error) {
+	scopes := make(pq.StringArray, len(s))
+	for i, scope := range s {
+		scopes[i] = string(scope)
+	}
+	return scopes.Value()
+}
+
+// Has checks if the set contains the scope.
+func (s UserAPIKeyScopes) Has(scope UserAPIKeyScope) bool {
+	for _, other := range s {
+		if other == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// UserAPIKey is a long-lived key which a user can use to access the API from scripts.
+type UserAPIKey struct {
+	ID         uuid.UUID
+	UserID     uuid.UUID
+	Name       string
+	KeyHash    []byte
+	Scopes     UserAPIKeyScopes
+	CreatedAt  time.Time
+	LastUsedAt sql.NullTime
+	ExpiresAt  sql.NullTime
+}
+
 type Device struct {
 	ID             uuid.UUID
 	OrganizationID uuid.UUID
 	Name           string
 	LastHeartbeat  sql.NullTime
+	LastDisconnect sql.NullTime
+	// OfflineAlertedAt is set when administrators have been alerted that the device is offline.
+	OfflineAlertedAt sql.NullTime
+	GroupID          *uuid.UUID
+	Tags             pq.StringArray
+	// DeviceConfigProfileID overrides the device config profiles of the organization and group, if set.
+	DeviceConfigProfileID *uuid.UUID
+	// Capabilities are the optional features which the device has reported that it supports.
+	Capabilities pq.StringArray
+	DeviceInventory
+}
+
+// DeviceCapabilityScreenPower is the capability of devices to turn their screen on and off.
+const DeviceCapabilityScreenPower = "screen_power"
+
+// HasCapability reports whether the device has reported that it supports the capability.
+func (d Device) HasCapability(capability string) bool {
+	for _, c := range d.Capabilities {
+		if c == capability {
+			return true
+		}
+	}
+	return false
+}
+
+// DeviceInventory is the inventory information which administrators keep about a device.
+type DeviceInventory struct {
+	LocationDescription sql.NullString
+	SerialNumber        sql.NullString
+	HardwareRevision    sql.NullString
+	// MacAddress is in its canonical form (lowercase, separated by colons).
+	MacAddress  sql.NullString
+	InstallDate sql.NullTime
+	Notes       sql.NullString
+}
+
+// DeviceStatus is the status that a device reports with a heartbeat.
+// All fields except for the device and time of the report are optional.
+type DeviceStatus struct {
+	ID               uuid.UUID
+	DeviceID         uuid.UUID
+	ReportedAt       time.Time
+	OsVersion        sql.NullString
+	AppVersion       sql.NullString
+	UptimeSeconds    sql.NullInt64
+	IpAddress        sql.NullString
+	SSID             sql.NullString
+	SignalStrength   sql.NullInt32
+	CardReaderStatus sql.NullString
+	FreeDiskBytes    sql.NullInt64
+	LastError        sql.NullString
+}
+
+// DeviceStatusHistoryLength is the number of status reports which are kept per device.
+const DeviceStatusHistoryLength = 100
+
+type DeviceGroup struct {
+	ID             uuid.UUID
+	OrganizationID uuid.UUID
+	Name           string
+	CreatedAt      time.Time
+	UpdateChannel  UpdateChannel
+	// DeviceConfigProfileID overrides the device config profile of the organization, if set.
+	DeviceConfigProfileID *uuid.UUID
+}
+
+type UpdateChannel string
+
+const (
+	UpdateChannelStable UpdateChannel = "stable"
+	UpdateChannelBeta   UpdateChannel = "beta"
+)
+
+type SoftwareComponent string
+
+const (
+	SoftwareComponentTimetermOS       SoftwareComponent = "timeterm_os"
+	SoftwareComponentFrontendEmbedded SoftwareComponent = "frontend_embedded"
+)
+
+// SoftwareRelease is a version of a software component which devices can update to.
+type SoftwareRelease struct {
+	ID             uuid.UUID
+	OrganizationID uuid.UUID
+	Component      SoftwareComponent
+	Version        string
+	Channel        UpdateChannel
+	ArtifactURL    string
+	// SHA256 is the SHA-256 hash of the artifact.
+	SHA256    []byte
+	CreatedAt time.Time
+}
+
+// UpdateRollout pins a percentage of the devices in a group to a release of a component.
+type UpdateRollout struct {
+	ID             uuid.UUID
+	OrganizationID uuid.UUID
+	DeviceGroupID  uuid.UUID
+	Component      SoftwareComponent
+	ReleaseID      uuid.UUID
+	Percentage     int
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
 }
 
 type NetworkingService struct {
 	ID             uuid.UUID
 	OrganizationID uuid.UUID
 	Name           string
+	// AllDevices indicates if the service is assigned to all devices in the organization.
+	// Otherwise, it is only assigned to specific devices and groups (see NetworkingServiceAssignment).
+	AllDevices bool
+	// ConfigHash is a hash of the configuration of the service, which is stored in Vault.
+	ConfigHash []byte
+}
+
+type DeviceCommandStatus string
+
+const (
+	DeviceCommandStatusQueued    DeviceCommandStatus = "queued"
+	DeviceCommandStatusStored    DeviceCommandStatus = "stored"
+	DeviceCommandStatusAcked     DeviceCommandStatus = "acked"
+	DeviceCommandStatusSucceeded DeviceCommandStatus = "succeeded"
+	DeviceCommandStatusFailed    DeviceCommandStatus = "failed"
+)
+
+const (
+	DeviceCommandTypeReboot                      = "reboot"
+	DeviceCommandTypeRetrieveNewNetworkingConfig = "retrieve_new_networking_config"
+	DeviceCommandTypeScreenOff                   = "screen_off"
+	DeviceCommandTypeScreenOn                    = "screen_on"
+	DeviceCommandTypeUploadLogs                  = "upload_logs"
+	DeviceCommandTypeTakeScreenshot              = "take_screenshot"
+)
+
+// DeviceCommandCapability returns the capability which devices must have to execute commands of the type,
+// if the type requires one.
+func DeviceCommandCapability(typ string) (string, bool) {
+	switch typ {
+	case DeviceCommandTypeScreenOff, DeviceCommandTypeScreenOn:
+		return DeviceCapabilityScreenPower, true
+	default:
+		return "", false
+	}
+}
+
+// DeviceCommand is a command sent to a device, such as a reboot.
+type DeviceCommand struct {
+	ID              uuid.UUID
+	DeviceID        uuid.UUID
+	Type            string
+	Status          DeviceCommandStatus
+	CreatedByUserID *uuid.UUID
+	CreatedAt       time.Time
+	ExpiresAt       time.Time
+	StoredAt        sql.NullTime
+	AckedAt         sql.NullTime
+	CompletedAt     sql.NullTime
+	Error           sql.NullString
+	Output          sql.NullString
+}
+
+// DeviceCommandTTL is the default time after which devices should no longer execute a command.
+const DeviceCommandTTL = 7 * 24 * time.Hour
+
+// CreateDeviceCommand stores a new command for a device, which expires after ttl.
+// It still has to be sent to the device.
+func (w *Wrapper) CreateDeviceCommand(ctx context.Context,
+	deviceID uuid.UUID,
+	typ string,
+	createdByUserID *uuid.UUID,
+	ttl time.Duration,
+) (DeviceCommand, error) {
+	var cmd DeviceCommand
+
+	err := w.db.GetContext(ctx, &cmd, `
+		INSERT INTO "device_command" ("device_id", "type", "created_by_user_id", "expires_at")
+		VALUES ($1, $2, $3, now() + make_interval(secs => $4))
+		RETURNING *
+	`, deviceID, typ, createdByUserID, ttl.Seconds())
+
+	return cmd, err
+}
+
+// DeviceLogBundle is a compressed bundle of logs uploaded by a device. Its (encrypted) data
+// is retrieved separately.
+type DeviceLogBundle struct {
+	ID        uuid.UUID
+	DeviceID  uuid.UUID
+	CommandID *uuid.UUID
+	CreatedAt time.Time
+	Size      int64
+}
+
+// EncryptedBlob is data which is encrypted with the logs key of the organization, such as a log bundle.
+type EncryptedBlob struct {
+	Nonce []byte
+	Data  []byte
+}
+
+const (
+	// MaxDeviceLogBundleSize is the maximum size of an uploaded log bundle in bytes.
+	MaxDeviceLogBundleSize = 10 << 20
+	// MaxDeviceLogBundles is the maximum number of log bundles which are kept per device.
+	MaxDeviceLogBundles = 5
+)
+
+// CreateDeviceLogBundle stores a log bundle and deletes the oldest bundles of the device
+// if it has more than MaxDeviceLogBundles. ErrConflict is returned if a bundle has already been uploaded
+// for the command. The ID of the bundle must be set, as the data is encrypted with it.
+func (w *Wrapper) CreateDeviceLogBundle(ctx context.Context,
+	b DeviceLogBundle,
+	data EncryptedBlob,
+) (DeviceLogBundle, error) {
+	tx, err := w.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return DeviceLogBundle{}, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var bundle DeviceLogBundle
+	err = tx.GetContext(ctx, &bundle, `
+		INSERT INTO "device_log_bundle" ("id", "device_id", "command_id", "size", "nonce", "data")
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING "id", "device_id", "command_id", "created_at", "size"
+	`, b.ID, b.DeviceID, b.CommandID, b.Size, data.Nonce, data.Data)
+	if isUniqueViolation(err) {
+		return bundle, fmt.Errorf("log bundle already uploaded: %w", ErrConflict.withUnderlying(err))
+	}
+	if err != nil {
+		return DeviceLogBundle{}, err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		DELETE FROM "device_log_bundle"
+		WHERE "device_id" = $1
+		  AND "id" NOT IN (SELECT "id" FROM "device_log_bundle"
+		                   WHERE "device_id" = $1
+		                   ORDER BY "created_at" DESC
+		                   LIMIT $2)
+	`, bundle.DeviceID, MaxDeviceLogBundles)
+	if err != nil {
+		return DeviceLogBundle{}, err
+	}
+
+	return bundle, tx.Commit()
+}
+
+// DeviceScreenshot is a PNG screenshot of the display of a device. Its (encrypted) data is retrieved separately.
+type DeviceScreenshot struct {
+	ID        uuid.UUID
+	DeviceID  uuid.UUID
+	CommandID *uuid.UUID
+	CreatedAt time.Time
+	Size      int64
+}
+
+const (
+	// MaxDeviceScreenshotSize is the maximum size of an uploaded screenshot in bytes.
+	MaxDeviceScreenshotSize = 5 << 20
+	// MaxDeviceScreenshots is the maximum number of screenshots which are kept per device.
+	MaxDeviceScreenshots = 10
+)
+
+// CreateDeviceScreenshot stores a screenshot and deletes the oldest screenshots of the device
+// if it has more than MaxDeviceScreenshots. ErrConflict is returned if a screenshot has already been uploaded
+// for the command. The ID of the screenshot must be set, as the data is encrypted with it.
+func (w *Wrapper) CreateDeviceScreenshot(ctx context.Context,
+	s DeviceScreenshot,
+	data EncryptedBlob,
+) (DeviceScreenshot, error) {
+	tx, err := w.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return DeviceScreenshot{}, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var screenshot DeviceScreenshot
+	err = tx.GetContext(ctx, &screenshot, `
+		INSERT INTO "device_screenshot" ("id", "device_id", "command_id", "size", "nonce", "data")
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING "id", "device_id", "command_id", "created_at", "size"
+	`, s.ID, s.DeviceID, s.CommandID, s.Size, data.Nonce, data.Data)
+	if isUniqueViolation(err) {
+		return screenshot, fmt.Errorf("screenshot already uploaded: %w", ErrConflict.withUnderlying(err))
+	}
+	if err != nil {
+		return DeviceScreenshot{}, err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		DELETE FROM "device_screenshot"
+		WHERE "device_id" = $1
+		  AND "id" NOT IN (SELECT "id" FROM "device_screenshot"
+		                   WHERE "device_id" = $1
+		                   ORDER BY "created_at" DESC
+		                   LIMIT $2)
+	`, screenshot.DeviceID, MaxDeviceScreenshots)
+	if err != nil {
+		return DeviceScreenshot{}, err
+	}
+
+	return screenshot, tx.Commit()
+}
+
+// DeviceSchedule periodically sends a command (the action) to the devices in a device group.
+type DeviceSchedule struct {
+	ID             uuid.UUID
+	OrganizationID uuid.UUID
+	DeviceGroupID  uuid.UUID
+	// Action is the type of the command which is sent, see the DeviceCommandType constants.
+	Action string
+	// CronExpression is a standard (five field) cron expression, evaluated in Timezone.
+	CronExpression string
+	Timezone       string
+	Enabled        bool
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+	LastRunAt      sql.NullTime
+}
+
+func (w *Wrapper) CreateDeviceSchedule(ctx context.Context, s DeviceSchedule) (DeviceSchedule, error) {
+	var schedule DeviceSchedule
+
+	err := w.db.GetContext(ctx, &schedule, `
+		INSERT INTO "device_schedule" ("organization_id", "device_group_id", "action", "cron_expression", "timezone", "enabled")
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING *
+	`, s.OrganizationID, s.DeviceGroupID, s.Action, s.CronExpression, s.Timezone, s.Enabled)
+
+	return schedule, err
+}
+
+// DeviceConfigProfile is a (partial) configuration of the kiosk application of devices.
+// Profiles are assigned to organizations, device groups and devices.
+type DeviceConfigProfile struct {
+	ID             uuid.UUID
+	OrganizationID uuid.UUID
+	Name           string
+	// Config is a serialized devcfg.DeviceConfig protobuf message.
+	Config    []byte
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// CreateDeviceConfigProfile creates a profile. ErrConflict is returned if the organization
+// already has a profile with the same name.
+func (w *Wrapper) CreateDeviceConfigProfile(ctx context.Context, p DeviceConfigProfile) (DeviceConfigProfile, error) {
+	var profile DeviceConfigProfile
+
+	err := w.db.GetContext(ctx, &profile, `
+		INSERT INTO "device_config_profile" ("organization_id", "name", "config")
+		VALUES ($1, $2, $3)
+		RETURNING *
+	`, p.OrganizationID, p.Name, p.Config)
+	if isUniqueViolation(err) {
+		return profile, fmt.Errorf("device config profile already exists: %w", ErrConflict.withUnderlying(err))
+	}
+
+	return profile, err
+}
+
+// OrganizationBranding is the colour scheme and logo which devices of an organization show.
+type OrganizationBranding struct {
+	OrganizationID  uuid.UUID
+	PrimaryColor    sql.NullString
+	AccentColor     sql.NullString
+	BackgroundColor sql.NullString
+	TextColor       sql.NullString
+	// LogoKey is the key of the logo in the blob store.
+	LogoKey         sql.NullString
+	LogoContentType sql.NullString
+	LogoSHA256      []byte
+	UpdatedAt       time.Time
+}
+
+// Blob is a binary object stored in the database, used if no other blob store has been configured.
+type Blob struct {
+	Key       string
+	Data      []byte
+	CreatedAt time.Time
+}
+
+type AnnouncementSeverity string
+
+const (
+	AnnouncementSeverityInfo     AnnouncementSeverity = "info"
+	AnnouncementSeverityWarning  AnnouncementSeverity = "warning"
+	AnnouncementSeverityCritical AnnouncementSeverity = "critical"
+)
+
+// Announcement is a notice which is shown on the devices in an organization.
+type Announcement struct {
+	ID             uuid.UUID
+	OrganizationID uuid.UUID
+	Text           string
+	Severity       AnnouncementSeverity
+	StartsAt       time.Time
+	// EndsAt is not set if the announcement is shown until it is deleted.
+	EndsAt sql.NullTime
+	// AllDevices indicates if the announcement is shown on all devices in the organization.
+	// Otherwise, it is only shown on the devices in the groups in GroupIDs.
+	AllDevices      bool
+	CreatedByUserID *uuid.UUID
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+
+	GroupIDs []uuid.UUID `db:"-"`
+	HasImage bool
+}
+
+// MaxAnnouncementImageSize is the maximum size of the image of an announcement in bytes.
+const MaxAnnouncementImageSize = 1 << 20
+
+type AnnouncementImage struct {
+	AnnouncementID uuid.UUID
+	ContentType    string
+	Data           []byte
+}
+
+func (w *Wrapper) CreateAnnouncement(ctx context.Context, a Announcement) (Announcement, error) {
+	tx, err := w.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return Announcement{}, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var announcement Announcement
+	err = tx.GetContext(ctx, &announcement, `
+		INSERT INTO "announcement" ("organization_id", "text", "severity", "starts_at", "ends_at", "all_devices",
+		                            "created_by_user_id")
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING *
+	`, a.OrganizationID, a.Text, a.Severity, a.StartsAt, a.EndsAt, a.AllDevices, a.CreatedByUserID)
+	if err != nil {
+		return Announcement{}, err
+	}
+
+	if err = replaceAnnouncementGroups(ctx, tx, announcement.ID, a.GroupIDs); err != nil {
+		return Announcement{}, err
+	}
+	announcement.GroupIDs = a.GroupIDs
+
+	return announcement, tx.Commit()
 }
 
 type AdminMessageSeverity string
@@ -88,18 +696,189 @@ func (w *Wrapper) CreateOrganization(ctx context.Context,
 	name string,
 	zermeloInstitution string,
 ) (Organization, error) {
-	org := Organization{
-		Name:               name,
-		ZermeloInstitution: zermeloInstitution,
-	}
+	var org Organization
 
-	row := w.db.QueryRowContext(ctx, `
+	err := w.db.GetContext(ctx, &org, `
 		INSERT INTO "organization" ("name", "zermelo_institution") 
 		VALUES ($1, $2) 
-		RETURNING "id"
+		RETURNING *
 	`, name, zermeloInstitution)
 
-	return org, row.Scan(&org.ID)
+	return org, err
+}
+
+// CreateStudentCard adds a card to a student. The card UID is hashed with the current key.
+// ErrConflict is returned if the card is already in use in the organization.
+func (w *Wrapper) CreateStudentCard(ctx context.Context,
+	card StudentCard,
+	uid []byte,
+	getKeys CardKeysFunc,
+) (StudentCard, error) {
+	tx, err := w.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return card, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	created, err := createStudentCard(ctx, tx, card, uid, getKeys)
+	if err != nil {
+		return created, err
+	}
+
+	return created, tx.Commit()
+}
+
+func createStudentCard(ctx context.Context,
+	tx *sqlx.Tx,
+	card StudentCard,
+	uid []byte,
+	getKeys CardKeysFunc,
+) (StudentCard, error) {
+	var created StudentCard
+
+	if err := lockStudentCards(ctx, tx, card.OrganizationID); err != nil {
+		return created, err
+	}
+
+	keys, err := getKeys()
+	if err != nil {
+		return created, fmt.Errorf("could not get card keys: %w", err)
+	}
+	key, ok := keys.Keys[keys.Current]
+	if !ok {
+		return created, errors.New("current card key not provided")
+	}
+
+	existing, err := getStudentCardsByUID(ctx, tx, card.OrganizationID, uid, keys, true)
+	if err != nil {
+		return created, err
+	}
+	if len(existing) != 0 {
+		return created, fmt.Errorf("card already in use: %w", ErrConflict)
+	}
+
+	err = tx.GetContext(ctx, &created, `
+		INSERT INTO student_card (id_hash, key_version, organization_id, student_id, label, valid_from, valid_until)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING `+studentCardColumns,
+		hashCardUID(key, uid), keys.Current, card.OrganizationID, card.StudentID,
+		card.Label, card.ValidFrom, card.ValidUntil,
+	)
+
+	return created, err
+}
+
+type CardEnrollmentSessionState string
+
+const (
+	CardEnrollmentSessionStatePending   CardEnrollmentSessionState = "pending"
+	CardEnrollmentSessionStateCompleted CardEnrollmentSessionState = "completed"
+	CardEnrollmentSessionStateFailed    CardEnrollmentSessionState = "failed"
+	CardEnrollmentSessionStateCancelled CardEnrollmentSessionState = "cancelled"
+)
+
+// CardEnrollmentSessionDuration is how long a device waits for a card to be tapped in a card enrollment session.
+const CardEnrollmentSessionDuration = time.Minute * 2
+
+// CardEnrollmentSession binds the next card tapped at a device to a student.
+type CardEnrollmentSession struct {
+	ID             uuid.UUID
+	OrganizationID uuid.UUID
+	StudentID      uuid.UUID
+	DeviceID       uuid.UUID
+	Label          string
+	ValidUntil     sql.NullTime
+	State          CardEnrollmentSessionState
+	Error          string
+	CardID         *uuid.UUID
+	CreatedAt      time.Time
+	ExpiresAt      time.Time
+}
+
+// CreateCardEnrollmentSession starts a new card enrollment session.
+// A device can only be in one session at a time, so other pending sessions of the device are cancelled.
+func (w *Wrapper) CreateCardEnrollmentSession(ctx context.Context,
+	session CardEnrollmentSession,
+) (CardEnrollmentSession, error) {
+	var created CardEnrollmentSession
+
+	tx, err := w.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return created, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if _, err = tx.ExecContext(ctx, `
+		UPDATE card_enrollment_session SET state = 'cancelled'
+		WHERE device_id = $1 AND state = 'pending'
+	`, session.DeviceID); err != nil {
+		return created, err
+	}
+
+	if err = tx.GetContext(ctx, &created, `
+		INSERT INTO card_enrollment_session (organization_id, student_id, device_id, label, valid_until, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING *
+	`, session.OrganizationID, session.StudentID, session.DeviceID, session.Label, session.ValidUntil,
+		time.Now().Add(CardEnrollmentSessionDuration),
+	); err != nil {
+		return created, err
+	}
+
+	return created, tx.Commit()
+}
+
+func (w *Wrapper) CreateLoginAttempt(ctx context.Context, a LoginAttempt) error {
+	_, err := w.db.ExecContext(ctx, `
+		INSERT INTO login_attempt (kind, success, failure_reason, organization_id, device_id, card_hash_prefix, client_ip)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`, a.Kind, a.Success, a.FailureReason, a.OrganizationID, a.DeviceID, a.CardHashPrefix, a.ClientIP)
+
+	return err
+}
+
+// CreateDeviceStatus stores a status report of a device, which also counts as a heartbeat.
+// Reports older than the last DeviceStatusHistoryLength reports of the device are deleted.
+func (w *Wrapper) CreateDeviceStatus(ctx context.Context, s DeviceStatus) (DeviceStatus, error) {
+	tx, err := w.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return DeviceStatus{}, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var status DeviceStatus
+	err = tx.GetContext(ctx, &status, `
+		INSERT INTO "device_status" ("device_id", "os_version", "app_version", "uptime_seconds", "ip_address",
+		                             "ssid", "signal_strength", "card_reader_status", "free_disk_bytes", "last_error")
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		RETURNING *
+	`, s.DeviceID, s.OsVersion, s.AppVersion, s.UptimeSeconds, s.IpAddress,
+		s.SSID, s.SignalStrength, s.CardReaderStatus, s.FreeDiskBytes, s.LastError)
+	if err != nil {
+		return DeviceStatus{}, err
+	}
+
+	_, err = tx.ExecContext(ctx,
+		`UPDATE "device" SET "last_heartbeat" = $1 WHERE "id" = $2`,
+		status.ReportedAt, status.DeviceID,
+	)
+	if err != nil {
+		return DeviceStatus{}, err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		DELETE FROM "device_status"
+		WHERE "device_id" = $1
+		  AND "id" NOT IN (SELECT "id" FROM "device_status"
+		                   WHERE "device_id" = $1
+		                   ORDER BY "reported_at" DESC
+		                   LIMIT $2)
+	`, status.DeviceID, DeviceStatusHistoryLength)
+	if err != nil {
+		return DeviceStatus{}, err
+	}
+
+	return status, tx.Commit()
 }
 
 func (w *Wrapper) CreateStudent(ctx context.Context, s Student) (Student, error) {
@@ -134,6 +913,7 @@ func (w *Wrapper) CreateNetworkingService(
 	ns := NetworkingService{
 		OrganizationID: organizationID,
 		Name:           name,
+		AllDevices:     true,
 	}
 
 	row := w.db.QueryRowContext(ctx, `
@@ -149,28 +929,78 @@ func (w *Wrapper) CreateDevice(ctx context.Context,
 	organizationID uuid.UUID,
 	name string,
 ) (Device, uuid.UUID, error) {
-	dev := Device{
-		OrganizationID: organizationID,
-		Name:           name,
+	tx, err := w.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return Device{}, uuid.UUID{}, err
 	}
+	defer func() { _ = tx.Rollback() }()
 
-	token := uuid.New()
-	tokenHash, err := hashToken(token)
+	dev, token, err := createDevice(ctx, tx, organizationID, name, nil)
 	if err != nil {
 		return dev, token, err
 	}
 
-	tx, err := w.db.Beginx()
+	return dev, token, tx.Commit()
+}
+
+// RegisterDevice creates a device using a registration token, counting it as one use of the token.
+// If the token has a preset device name, it is used instead of name.
+// If the token has a preset device group, the device is added to the group.
+// ErrRegistrationTokenUsedUp is returned if the token has expired or has been used up in the meantime.
+func (w *Wrapper) RegisterDevice(ctx context.Context,
+	registrationTokenID uuid.UUID,
+	name string,
+) (Device, uuid.UUID, error) {
+	tx, err := w.db.BeginTxx(ctx, nil)
 	if err != nil {
-		return dev, token, err
+		return Device{}, uuid.UUID{}, err
 	}
 	defer func() { _ = tx.Rollback() }()
 
-	err = tx.GetContext(ctx, &dev.ID, `
-		INSERT INTO "device" (organization_id, name)
-		VALUES ($1, $2)
-		RETURNING "id"
-	`, dev.OrganizationID, dev.Name)
+	var regToken DeviceRegistrationToken
+	err = tx.GetContext(ctx, &regToken, `
+		UPDATE "device_registration_token" SET "uses" = "uses" + 1
+		WHERE "id" = $1 AND "expires_at" > now() AND "uses" < "max_uses"
+		RETURNING *
+	`, registrationTokenID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Device{}, uuid.UUID{}, ErrRegistrationTokenUsedUp.withUnderlying(err)
+	}
+	if err != nil {
+		return Device{}, uuid.UUID{}, err
+	}
+
+	if regToken.DeviceName.Valid {
+		name = regToken.DeviceName.String
+	}
+
+	dev, token, err := createDevice(ctx, tx, regToken.OrganizationID, name, regToken.DeviceGroupID)
+	if err != nil {
+		return dev, token, err
+	}
+
+	return dev, token, tx.Commit()
+}
+
+func createDevice(ctx context.Context,
+	tx *sqlx.Tx,
+	organizationID uuid.UUID,
+	name string,
+	groupID *uuid.UUID,
+) (Device, uuid.UUID, error) {
+	var dev Device
+
+	token := uuid.New()
+	tokenHash, err := hashToken(token)
+	if err != nil {
+		return dev, token, err
+	}
+
+	err = tx.GetContext(ctx, &dev, `
+		INSERT INTO "device" (organization_id, name, group_id)
+		VALUES ($1, $2, $3)
+		RETURNING *
+	`, organizationID, name, groupID)
 	if err != nil {
 		return dev, token, err
 	}
@@ -179,24 +1009,25 @@ func (w *Wrapper) CreateDevice(ctx context.Context,
 		INSERT INTO "device_token" ("token_hash", "device_id")
 		VALUES ($1, $2)
 	`, tokenHash, dev.ID)
-	if err != nil {
-		return dev, token, err
-	}
 
-	return dev, token, tx.Commit()
+	return dev, token, err
 }
 
-func (w *Wrapper) CreateOAuth2State(ctx context.Context, issuer, redirectURL string) (OAuth2State, error) {
+func (w *Wrapper) CreateOAuth2State(ctx context.Context,
+	issuer, redirectURL, codeVerifier, nonce string,
+) (OAuth2State, error) {
 	state := OAuth2State{
-		Issuer:      issuer,
-		RedirectURL: redirectURL,
+		Issuer:       issuer,
+		RedirectURL:  redirectURL,
+		CodeVerifier: codeVerifier,
+		Nonce:        nonce,
 	}
 
 	row := w.db.QueryRowContext(ctx, `
-		INSERT INTO "oauth2_state" ("issuer", "redirect_url")
-		VALUES ($1, $2)
+		INSERT INTO "oauth2_state" ("issuer", "redirect_url", "code_verifier", "nonce")
+		VALUES ($1, $2, $3, $4)
 		RETURNING "state"
-	`, issuer, redirectURL)
+	`, issuer, redirectURL, codeVerifier, nonce)
 
 	return state, row.Scan(&state.State)
 }
@@ -267,19 +1098,134 @@ func (w *Wrapper) CreateNewUser(ctx context.Context, name, email string, federat
 	return user, tx.Commit()
 }
 
-func (w *Wrapper) CreateUserToken(ctx context.Context, userID uuid.UUID) (uuid.UUID, error) {
+func (w *Wrapper) CreateUserSession(ctx context.Context, userID uuid.UUID, userAgent, clientIP string) (UserTokens, error) {
+	var tokens UserTokens
+
+	refreshToken := uuid.New()
+	refreshTokenHash, err := hashToken(refreshToken)
+	if err != nil {
+		return tokens, err
+	}
+	refreshTokenExpiresAt := time.Now().Add(DefaultRefreshTokenExpiration)
+
+	tx, err := w.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return tokens, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var sessionID uuid.UUID
+	err = tx.GetContext(ctx, &sessionID, `
+		INSERT INTO "user_session" ("user_id", "refresh_token_hash", "expires_at", "user_agent", "client_ip")
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING "id"
+	`, userID, refreshTokenHash, refreshTokenExpiresAt, userAgent, clientIP)
+	if err != nil {
+		return tokens, err
+	}
+
+	accessToken, accessTokenExpiresAt, err := createUserToken(ctx, tx, userID, sessionID)
+	if err != nil {
+		return tokens, err
+	}
+
+	tokens = UserTokens{
+		SessionID:             sessionID,
+		AccessToken:           accessToken,
+		AccessTokenExpiresAt:  accessTokenExpiresAt,
+		RefreshToken:          refreshToken,
+		RefreshTokenExpiresAt: refreshTokenExpiresAt,
+	}
+	return tokens, tx.Commit()
+}
+
+func createUserToken(ctx context.Context, tx *sqlx.Tx, userID, sessionID uuid.UUID) (uuid.UUID, time.Time, error) {
 	token := uuid.New()
+	expiresAt := time.Now().Add(DefaultTokenExpiration)
+
 	tokenHash, err := hashToken(token)
 	if err != nil {
-		return token, err
+		return token, expiresAt, err
 	}
 
-	_, err = w.db.ExecContext(ctx, `
-		INSERT INTO "user_token" ("token_hash", "user_id")
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO "user_token" ("token_hash", "user_id", "session_id", "expires_at")
+		VALUES ($1, $2, $3, $4)
+	`, tokenHash, userID, sessionID, expiresAt)
+
+	return token, expiresAt, err
+}
+
+// CreateUserAPIKey creates a new API key for the user. Only the hash of the key is stored,
+// so the returned key can not be retrieved again later.
+func (w *Wrapper) CreateUserAPIKey(ctx context.Context,
+	userID uuid.UUID,
+	name string,
+	scopes UserAPIKeyScopes,
+	expiresAt sql.NullTime,
+) (UserAPIKey, uuid.UUID, error) {
+	apiKey := UserAPIKey{
+		UserID:    userID,
+		Name:      name,
+		Scopes:    scopes,
+		ExpiresAt: expiresAt,
+	}
+
+	key := uuid.New()
+	keyHash, err := hashToken(key)
+	if err != nil {
+		return apiKey, key, err
+	}
+	apiKey.KeyHash = keyHash
+
+	err = w.db.GetContext(ctx, &apiKey, `
+		INSERT INTO "user_api_key" ("user_id", "name", "key_hash", "scopes", "expires_at")
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING *
+	`, userID, name, keyHash, scopes, expiresAt)
+
+	return apiKey, key, err
+}
+
+// CreateDeviceGroup creates a device group in an organization, which gets updates from the stable channel.
+// ErrConflict is returned if the organization already has a group with the same name.
+func (w *Wrapper) CreateDeviceGroup(ctx context.Context, organizationID uuid.UUID, name string) (DeviceGroup, error) {
+	var group DeviceGroup
+
+	err := w.db.GetContext(ctx, &group, `
+		INSERT INTO "device_group" ("organization_id", "name")
 		VALUES ($1, $2)
-	`, tokenHash, userID)
+		RETURNING *
+	`, organizationID, name)
+	if isUniqueViolation(err) {
+		return group, fmt.Errorf("device group already exists: %w", ErrConflict.withUnderlying(err))
+	}
 
-	return token, err
+	return group, err
+}
+
+// CreateSoftwareRelease creates a release of a software component.
+// ErrConflict is returned if the organization already has a release with the same component and version.
+func (w *Wrapper) CreateSoftwareRelease(ctx context.Context, r SoftwareRelease) (SoftwareRelease, error) {
+	var release SoftwareRelease
+
+	err := w.db.GetContext(ctx, &release, `
+		INSERT INTO "software_release" ("organization_id", "component", "version", "channel", "artifact_url", "sha256")
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING *
+	`, r.OrganizationID, r.Component, r.Version, r.Channel, r.ArtifactURL, r.SHA256)
+	if isUniqueViolation(err) {
+		return release, fmt.Errorf("software release already exists: %w", ErrConflict.withUnderlying(err))
+	}
+
+	return release, err
+}
+
+// isUniqueViolation checks if err is caused by a violated unique constraint.
+func isUniqueViolation(err error) bool {
+	var perr *pq.Error
+	// Error code 23505 is unique_violation.
+	return errors.As(err, &perr) && perr.Code == "23505"
 }
 
 func (w *Wrapper) CreateDeviceToken(ctx context.Context, deviceID uuid.UUID) (uuid.UUID, error) {
@@ -297,19 +1243,43 @@ func (w *Wrapper) CreateDeviceToken(ctx context.Context, deviceID uuid.UUID) (uu
 	return token, err
 }
 
-func (w *Wrapper) CreateDeviceRegistrationToken(ctx context.Context, organizationID uuid.UUID) (uuid.UUID, error) {
+type DeviceRegistrationToken struct {
+	ID             uuid.UUID
+	TokenHash      []byte
+	OrganizationID uuid.UUID
+	CreatedAt      time.Time
+	ExpiresAt      time.Time
+	MaxUses        int
+	Uses           int
+	// DeviceName is the name that devices registered with the token get.
+	DeviceName sql.NullString
+	// DeviceGroupID is the group that devices registered with the token are added to.
+	DeviceGroupID *uuid.UUID
+}
+
+func (w *Wrapper) CreateDeviceRegistrationToken(ctx context.Context,
+	organizationID uuid.UUID,
+	expiresAt time.Time,
+	maxUses int,
+	deviceName sql.NullString,
+	deviceGroupID *uuid.UUID,
+) (DeviceRegistrationToken, uuid.UUID, error) {
+	var regToken DeviceRegistrationToken
+
 	token := uuid.New()
 	tokenHash, err := hashToken(token)
 	if err != nil {
-		return token, err
+		return regToken, token, err
 	}
 
-	_, err = w.db.ExecContext(ctx, `
-		INSERT INTO "device_registration_token" ("token_hash", "organization_id")
-		VALUES ($1, $2)
-	`, tokenHash, organizationID)
+	err = w.db.GetContext(ctx, &regToken, `
+		INSERT INTO "device_registration_token"
+		    ("token_hash", "organization_id", "expires_at", "max_uses", "device_name", "device_group_id")
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING *
+	`, tokenHash, organizationID, expiresAt, maxUses, deviceName, deviceGroupID)
 
-	return token, err
+	return regToken, token, err
 }
 
 func (w *Wrapper) CreateAdminMessage(ctx context.Context, msg AdminMessage) error {
@@ -321,6 +1291,108 @@ func (w *Wrapper) CreateAdminMessage(ctx context.Context, msg AdminMessage) erro
 	return err
 }
 
+// legacyCardKeyVersion is the key version of card UIDs which have been hashed with hashBytes instead of hashCardUID.
+// These hashes can only be replaced when the card is used, because the UID cannot be recovered from the hash.
+const legacyCardKeyVersion = 0
+
+// CardKeys are the keys that card UIDs of an organization are hashed with.
+type CardKeys struct {
+	Current int
+	Keys    map[int][]byte
+}
+
+// CardKeysFunc retrieves the card keys of an organization.
+// It is called while the cards of the organization are locked before cards are added or rehashed,
+// so the keys cannot be rotated in the meantime.
+type CardKeysFunc func() (CardKeys, error)
+
+// lockStudentCards locks the cards of an organization until the end of the transaction.
+// Hashes of the same card UID differ per key version, so a unique index cannot prevent a card from being
+// added twice with different key versions. Instead, everything that adds or rehashes cards holds this lock.
+func lockStudentCards(ctx context.Context, tx *sqlx.Tx, organizationID uuid.UUID) error {
+	_, err := tx.ExecContext(ctx,
+		`SELECT pg_advisory_xact_lock(hashtext('student_card:' || $1::text))`,
+		organizationID,
+	)
+	return err
+}
+
+// getStudentCardsByUID retrieves the cards of an organization with the card UID, hashed with any of the keys.
+// The cards are locked for updates if forUpdate is true.
+func getStudentCardsByUID(ctx context.Context,
+	tx *sqlx.Tx,
+	organizationID uuid.UUID,
+	uid []byte,
+	keys CardKeys,
+	forUpdate bool,
+) ([]StudentCard, error) {
+	legacyHash, err := hashBytes(uid)
+	if err != nil {
+		return nil, err
+	}
+
+	versions := []int64{legacyCardKeyVersion}
+	hashes := [][]byte{legacyHash}
+	for version, key := range keys.Keys {
+		versions = append(versions, int64(version))
+		hashes = append(hashes, hashCardUID(key, uid))
+	}
+
+	query := `
+		SELECT ` + studentCardColumns + ` FROM student_card
+		WHERE student_card.organization_id = $1
+		  AND (student_card.key_version, student_card.id_hash) IN (
+		      SELECT * FROM unnest($2::int[], $3::bytea[])
+		  )
+		ORDER BY student_card.created_at
+	`
+	if forUpdate {
+		query += "FOR UPDATE"
+	}
+
+	var cards []StudentCard
+	err = tx.SelectContext(ctx, &cards, query, organizationID, pq.Array(versions), pq.Array(hashes))
+
+	return cards, err
+}
+
+// getStudentCardByUID retrieves the card of an organization with the card UID, like getStudentCardsByUID.
+// sql.ErrNoRows is returned if there is no such card, and ErrConflict if the card has been added more than once.
+func getStudentCardByUID(ctx context.Context,
+	tx *sqlx.Tx,
+	organizationID uuid.UUID,
+	uid []byte,
+	keys CardKeys,
+	forUpdate bool,
+) (StudentCard, error) {
+	cards, err := getStudentCardsByUID(ctx, tx, organizationID, uid, keys, forUpdate)
+	if err != nil {
+		return StudentCard{}, err
+	}
+
+	switch len(cards) {
+	case 0:
+		return StudentCard{}, sql.ErrNoRows
+	case 1:
+		return cards[0], nil
+	default:
+		return StudentCard{}, fmt.Errorf("card has been added %d times: %w", len(cards), ErrConflict)
+	}
+}
+
+// hashCardUID hashes a card UID using HMAC-SHA256. Because card UIDs are only a few bytes long,
+// a hash without a (secret) key could easily be reversed by trying all possible UIDs.
+func hashCardUID(key, uid []byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	_, _ = mac.Write(uid)
+	return mac.Sum(nil)
+}
+
+// CardUIDHashPrefix returns the first bytes of the hash of a card UID, for use in a LoginAttempt.
+func CardUIDHashPrefix(key, uid []byte) []byte {
+	return hashCardUID(key, uid)[:6]
+}
+
 func hashToken(token uuid.UUID) ([]byte, error) {
 	return hashBytes(token[:])
 }
@@ -2,8 +2,12 @@ package database
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"testing"
+	"time"
 
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -43,6 +47,141 @@ func TestWrapper_CreateDevice(t *testing.T) {
 	assert.Equal(t, devName, dev.Name)
 }
 
+func TestWrapper_RegisterDevice(t *testing.T) {
+	f := newFixture(t)
+	defer f.Close()
+
+	org, err := f.dbw.CreateOrganization(context.Background(), "test", "example")
+	require.NoError(t, err)
+
+	group, err := f.dbw.CreateDeviceGroup(context.Background(), org.ID, "Library")
+	require.NoError(t, err)
+
+	regToken, token, err := f.dbw.CreateDeviceRegistrationToken(context.Background(),
+		org.ID, time.Now().Add(time.Hour), 1, sql.NullString{String: "preset", Valid: true}, &group.ID,
+	)
+	require.NoError(t, err)
+
+	got, err := f.dbw.GetDeviceRegistrationTokenByToken(context.Background(), token)
+	require.NoError(t, err)
+	assert.Equal(t, regToken.ID, got.ID)
+
+	dev, _, err := f.dbw.RegisterDevice(context.Background(), regToken.ID, "example device")
+	require.NoError(t, err)
+	assert.Equal(t, org.ID, dev.OrganizationID)
+	assert.Equal(t, "preset", dev.Name)
+	assert.Equal(t, &group.ID, dev.GroupID)
+
+	// The token can only be used once.
+	_, _, err = f.dbw.RegisterDevice(context.Background(), regToken.ID, "example device")
+	assert.True(t, errors.Is(err, ErrRegistrationTokenUsedUp))
+
+	_, err = f.dbw.GetDeviceRegistrationTokenByToken(context.Background(), token)
+	assert.True(t, errors.Is(err, sql.ErrNoRows))
+}
+
+func TestWrapper_CreateDeviceGroup(t *testing.T) {
+	f := newFixture(t)
+	defer f.Close()
+
+	org, err := f.dbw.CreateOrganization(context.Background(), "test", "example")
+	require.NoError(t, err)
+
+	group, err := f.dbw.CreateDeviceGroup(context.Background(), org.ID, "Building A")
+	require.NoError(t, err)
+	assert.Equal(t, "Building A", group.Name)
+
+	_, err = f.dbw.CreateDeviceGroup(context.Background(), org.ID, "Building A")
+	assert.True(t, errors.Is(err, ErrConflict))
+}
+
+func TestWrapper_CreateDeviceStatus(t *testing.T) {
+	f := newFixture(t)
+	defer f.Close()
+
+	ctx := context.Background()
+
+	org, err := f.dbw.CreateOrganization(ctx, "test", "example")
+	require.NoError(t, err)
+
+	dev, _, err := f.dbw.CreateDevice(ctx, org.ID, "example device")
+	require.NoError(t, err)
+
+	for i := 0; i < DeviceStatusHistoryLength+5; i++ {
+		_, err = f.dbw.CreateDeviceStatus(ctx, DeviceStatus{
+			DeviceID:      dev.ID,
+			AppVersion:    sql.NullString{Valid: true, String: "1.0.0"},
+			UptimeSeconds: sql.NullInt64{Valid: true, Int64: int64(i)},
+		})
+		require.NoError(t, err)
+	}
+
+	history, err := f.dbw.GetDeviceStatusHistory(ctx, dev.ID, DeviceStatusHistoryLength*2)
+	require.NoError(t, err)
+	require.Len(t, history, DeviceStatusHistoryLength)
+	assert.Equal(t, int64(DeviceStatusHistoryLength+4), history[0].UptimeSeconds.Int64)
+	assert.Equal(t, "1.0.0", history[0].AppVersion.String)
+	assert.False(t, history[0].LastError.Valid)
+
+	dev, err = f.dbw.GetDevice(ctx, dev.ID)
+	require.NoError(t, err)
+	assert.True(t, dev.LastHeartbeat.Valid)
+}
+
+func TestWrapper_CreateDeviceLogBundle(t *testing.T) {
+	f := newFixture(t)
+	defer f.Close()
+
+	ctx := context.Background()
+
+	org, err := f.dbw.CreateOrganization(ctx, "test", "example")
+	require.NoError(t, err)
+
+	dev, _, err := f.dbw.CreateDevice(ctx, org.ID, "example device")
+	require.NoError(t, err)
+
+	var first DeviceLogBundle
+	for i := 0; i < MaxDeviceLogBundles+1; i++ {
+		cmd, err := f.dbw.CreateDeviceCommand(ctx, dev.ID, DeviceCommandTypeUploadLogs, nil, DeviceCommandTTL)
+		require.NoError(t, err)
+
+		bundle, err := f.dbw.CreateDeviceLogBundle(ctx, DeviceLogBundle{
+			ID:        uuid.New(),
+			DeviceID:  dev.ID,
+			CommandID: &cmd.ID,
+			Size:      3,
+		}, EncryptedBlob{
+			Nonce: []byte("nonce"),
+			Data:  []byte("encrypted"),
+		})
+		require.NoError(t, err)
+		if i == 0 {
+			first = bundle
+		}
+	}
+
+	// Only one bundle can be uploaded per command.
+	_, err = f.dbw.CreateDeviceLogBundle(ctx, DeviceLogBundle{
+		ID:        uuid.New(),
+		DeviceID:  dev.ID,
+		CommandID: first.CommandID,
+		Size:      3,
+	}, EncryptedBlob{Nonce: []byte("nonce"), Data: []byte("encrypted")})
+	assert.True(t, errors.Is(err, ErrConflict))
+
+	bundles, err := f.dbw.GetDeviceLogBundles(ctx, dev.ID)
+	require.NoError(t, err)
+	assert.Len(t, bundles, MaxDeviceLogBundles)
+
+	_, _, err = f.dbw.GetDeviceLogBundle(ctx, first.ID)
+	assert.True(t, errors.Is(err, sql.ErrNoRows))
+
+	bundle, data, err := f.dbw.GetDeviceLogBundle(ctx, bundles[0].ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), bundle.Size)
+	assert.Equal(t, []byte("encrypted"), data.Data)
+}
+
 func TestWrapper_CreateStudent(t *testing.T) {
 	const orgName = "test"
 	const orgZermeloInstitution = "example"
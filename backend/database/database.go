@@ -15,7 +15,7 @@ import (
 	_ "github.com/golang-migrate/migrate/v4/source/file"
 )
 
-const version uint = 22
+const version uint = 50
 
 // Wrapper wraps the PostgreSQL database.
 type Wrapper struct {
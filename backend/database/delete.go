@@ -2,6 +2,7 @@ package database
 
 import (
 	"context"
+	"database/sql"
 
 	"github.com/google/uuid"
 	"github.com/lib/pq"
@@ -37,11 +38,222 @@ func (w *Wrapper) DeleteOldUserTokens(ctx context.Context) error {
 	return err
 }
 
+func (w *Wrapper) DeleteOldUserSessions(ctx context.Context) error {
+	_, err := w.db.ExecContext(ctx, `DELETE FROM "user_session" WHERE "expires_at" < now()`)
+	return err
+}
+
+// DeleteUserSession revokes a session of a user, including all access tokens created for it.
+// It returns sql.ErrNoRows if the user has no session with the provided ID.
+func (w *Wrapper) DeleteUserSession(ctx context.Context, userID, id uuid.UUID) error {
+	res, err := w.db.ExecContext(ctx,
+		`DELETE FROM "user_session" WHERE "id" = $1 AND "user_id" = $2`,
+		id, userID,
+	)
+	if err != nil {
+		return err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// DeleteUserSessions revokes all sessions of a user, including all access tokens created for them.
+func (w *Wrapper) DeleteUserSessions(ctx context.Context, userID uuid.UUID) error {
+	_, err := w.db.ExecContext(ctx, `DELETE FROM "user_session" WHERE "user_id" = $1`, userID)
+	return err
+}
+
+func (w *Wrapper) DeleteOldUserAPIKeys(ctx context.Context) error {
+	_, err := w.db.ExecContext(ctx, `DELETE FROM "user_api_key" WHERE "expires_at" < now()`)
+	return err
+}
+
+// DeleteUserAPIKey revokes an API key of a user.
+// It returns sql.ErrNoRows if the user has no API key with the provided ID.
+func (w *Wrapper) DeleteUserAPIKey(ctx context.Context, userID, id uuid.UUID) error {
+	res, err := w.db.ExecContext(ctx,
+		`DELETE FROM "user_api_key" WHERE "id" = $1 AND "user_id" = $2`,
+		id, userID,
+	)
+	if err != nil {
+		return err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// DeleteOldCardEnrollmentSessions removes card enrollment sessions which have expired over a day ago.
+func (w *Wrapper) DeleteOldCardEnrollmentSessions(ctx context.Context) error {
+	_, err := w.db.ExecContext(ctx,
+		`DELETE FROM card_enrollment_session WHERE expires_at < now() - interval '1 day'`,
+	)
+	return err
+}
+
+// DeleteLegacyStudentCards removes the cards of an organization which are still hashed without a key
+// (see GetLegacyStudentCards) and returns how many have been removed.
+func (w *Wrapper) DeleteLegacyStudentCards(ctx context.Context, organizationID uuid.UUID) (int64, error) {
+	res, err := w.db.ExecContext(ctx,
+		`DELETE FROM student_card WHERE organization_id = $1 AND key_version = $2`,
+		organizationID, legacyCardKeyVersion,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// DeleteOldLoginAttempts removes login attempts which are older than 30 days.
+func (w *Wrapper) DeleteOldLoginAttempts(ctx context.Context) error {
+	_, err := w.db.ExecContext(ctx, `DELETE FROM login_attempt WHERE created_at < now() - interval '30 days'`)
+	return err
+}
+
+func (w *Wrapper) DeleteOldDeviceCommands(ctx context.Context) error {
+	_, err := w.db.ExecContext(ctx, `DELETE FROM "device_command" WHERE "created_at" < now() - interval '30 days'`)
+	return err
+}
+
+func (w *Wrapper) DeleteOldDeviceLogBundles(ctx context.Context) error {
+	_, err := w.db.ExecContext(ctx, `DELETE FROM "device_log_bundle" WHERE "created_at" < now() - interval '30 days'`)
+	return err
+}
+
+func (w *Wrapper) DeleteOldDeviceScreenshots(ctx context.Context) error {
+	_, err := w.db.ExecContext(ctx, `DELETE FROM "device_screenshot" WHERE "created_at" < now() - interval '7 days'`)
+	return err
+}
+
 func (w *Wrapper) DeleteOldDeviceTokens(ctx context.Context) error {
 	_, err := w.db.ExecContext(ctx, `DELETE FROM "device_token" WHERE "expires_at" < now()`)
 	return err
 }
 
+// DeleteDeviceTokens revokes all tokens of a device, after which it has to be registered again.
+func (w *Wrapper) DeleteDeviceTokens(ctx context.Context, deviceID uuid.UUID) error {
+	_, err := w.db.ExecContext(ctx, `DELETE FROM "device_token" WHERE "device_id" = $1`, deviceID)
+	return err
+}
+
+func (w *Wrapper) DeleteDeviceRegistrationToken(ctx context.Context, organizationID, id uuid.UUID) error {
+	res, err := w.db.ExecContext(ctx,
+		`DELETE FROM "device_registration_token" WHERE "id" = $1 AND "organization_id" = $2`,
+		id, organizationID,
+	)
+	if err != nil {
+		return err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// DeleteOldDeviceRegistrationTokens removes registration tokens which have expired or have been used up.
+func (w *Wrapper) DeleteOldDeviceRegistrationTokens(ctx context.Context) error {
+	_, err := w.db.ExecContext(ctx, `
+		DELETE FROM "device_registration_token"
+		WHERE "expires_at" < now() OR "uses" >= "max_uses"
+	`)
+	return err
+}
+
+// DeleteDeviceGroup deletes a device group. The devices in the group are kept, but no longer have a group.
+func (w *Wrapper) DeleteDeviceGroup(ctx context.Context, organizationID, id uuid.UUID) error {
+	res, err := w.db.ExecContext(ctx,
+		`DELETE FROM "device_group" WHERE "id" = $1 AND "organization_id" = $2`,
+		id, organizationID,
+	)
+	if err != nil {
+		return err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func (w *Wrapper) DeleteDeviceSchedule(ctx context.Context, organizationID, id uuid.UUID) error {
+	res, err := w.db.ExecContext(ctx,
+		`DELETE FROM "device_schedule" WHERE "id" = $1 AND "organization_id" = $2`,
+		id, organizationID,
+	)
+	if err != nil {
+		return err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func (w *Wrapper) DeleteSoftwareRelease(ctx context.Context, organizationID, id uuid.UUID) error {
+	res, err := w.db.ExecContext(ctx,
+		`DELETE FROM "software_release" WHERE "id" = $1 AND "organization_id" = $2`,
+		id, organizationID,
+	)
+	if err != nil {
+		return err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func (w *Wrapper) DeleteUpdateRollout(ctx context.Context, organizationID, id uuid.UUID) error {
+	res, err := w.db.ExecContext(ctx,
+		`DELETE FROM "update_rollout" WHERE "id" = $1 AND "organization_id" = $2`,
+		id, organizationID,
+	)
+	if err != nil {
+		return err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
 func (w *Wrapper) DeleteDevices(ctx context.Context, ids []uuid.UUID) error {
 	_, err := w.db.ExecContext(ctx, `DELETE FROM "device" WHERE "id" = ANY($1)`, pq.Array(ids))
 	return err
@@ -52,7 +264,146 @@ func (w *Wrapper) DeleteStudents(ctx context.Context, ids []uuid.UUID) error {
 	return err
 }
 
+func (w *Wrapper) DeleteStudentPIN(ctx context.Context, studentID uuid.UUID) error {
+	_, err := w.db.ExecContext(ctx, `DELETE FROM "student_pin" WHERE "student_id" = $1`, studentID)
+	return err
+}
+
+// DeleteStudentCard removes a card of a student.
+// It returns sql.ErrNoRows if the student has no card with the provided ID.
+func (w *Wrapper) DeleteStudentCard(ctx context.Context, studentID, id uuid.UUID) error {
+	res, err := w.db.ExecContext(ctx,
+		`DELETE FROM "student_card" WHERE "id" = $1 AND "student_id" = $2`,
+		id, studentID,
+	)
+	if err != nil {
+		return err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
 func (w *Wrapper) DeleteStudentCards(ctx context.Context, studentID uuid.UUID) error {
 	_, err := w.db.ExecContext(ctx, `DELETE FROM "student_card" WHERE "student_id" = $1`, studentID)
 	return err
 }
+
+func (w *Wrapper) DeleteAnnouncement(ctx context.Context, organizationID, id uuid.UUID) error {
+	res, err := w.db.ExecContext(ctx,
+		`DELETE FROM "announcement" WHERE "id" = $1 AND "organization_id" = $2`,
+		id, organizationID,
+	)
+	if err != nil {
+		return err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// DeleteAnnouncementImage removes the image of an announcement in the organization.
+// sql.ErrNoRows is returned if the announcement doesn't exist or has no image.
+func (w *Wrapper) DeleteAnnouncementImage(ctx context.Context, organizationID, id uuid.UUID) error {
+	res, err := w.db.ExecContext(ctx, `
+		WITH "a" AS (
+			UPDATE "announcement" SET "updated_at" = now()
+			WHERE "id" = $1 AND "organization_id" = $2
+			RETURNING "id"
+		)
+		DELETE FROM "announcement_image" WHERE "announcement_id" IN (SELECT "id" FROM "a")
+	`, id, organizationID)
+	if err != nil {
+		return err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func (w *Wrapper) DeleteDeviceConfigProfile(ctx context.Context, organizationID, id uuid.UUID) error {
+	res, err := w.db.ExecContext(ctx,
+		`DELETE FROM "device_config_profile" WHERE "id" = $1 AND "organization_id" = $2`,
+		id, organizationID,
+	)
+	if err != nil {
+		return err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// DeleteOrganizationBrandingLogo removes the logo of an organization. The key of the logo is returned,
+// so it can be removed from the blob store. sql.ErrNoRows is returned if the organization has no logo.
+func (w *Wrapper) DeleteOrganizationBrandingLogo(ctx context.Context, organizationID uuid.UUID) (string, error) {
+	var key string
+
+	tx, err := w.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return key, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	err = tx.GetContext(ctx, &key, `
+		SELECT "logo_key" FROM "organization_branding"
+		WHERE "organization_id" = $1 AND "logo_key" IS NOT NULL
+		FOR UPDATE
+	`, organizationID)
+	if err != nil {
+		return key, err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE "organization_branding"
+		SET "logo_key"          = NULL,
+		    "logo_content_type" = NULL,
+		    "logo_sha256"       = NULL,
+		    "updated_at"        = now()
+		WHERE "organization_id" = $1
+	`, organizationID)
+	if err != nil {
+		return key, err
+	}
+
+	return key, tx.Commit()
+}
+
+// DeleteBlob removes a blob. No error is returned if the blob doesn't exist.
+func (w *Wrapper) DeleteBlob(ctx context.Context, key string) error {
+	_, err := w.db.ExecContext(ctx, `DELETE FROM "blob" WHERE "key" = $1`, key)
+
+	return err
+}
+
+// DeleteBlobsWithPrefix deletes the blobs whose keys start with prefix.
+func (w *Wrapper) DeleteBlobsWithPrefix(ctx context.Context, prefix string) error {
+	// LIKE is not used, as keys may contain underscores.
+	_, err := w.db.ExecContext(ctx, `DELETE FROM "blob" WHERE left("key", length($1)) = $1`, prefix)
+
+	return err
+}
@@ -0,0 +1,52 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net"
+)
+
+// MaxDeviceStatusStringLength is the maximum length of the text fields in a status reported by a device.
+const MaxDeviceStatusStringLength = 1024
+
+// CardReaderStatus is the status of the card reader of a device, as stored in DeviceStatus.
+type CardReaderStatus string
+
+const (
+	CardReaderStatusOk           CardReaderStatus = "Ok"
+	CardReaderStatusNotConnected CardReaderStatus = "NotConnected"
+	CardReaderStatusError        CardReaderStatus = "Error"
+)
+
+func (s CardReaderStatus) IsValid() bool {
+	switch s {
+	case CardReaderStatusOk, CardReaderStatusNotConnected, CardReaderStatusError:
+		return true
+	default:
+		return false
+	}
+}
+
+// Validate checks a status reported by a device. Devices report their status both over HTTP and NATS,
+// so this is shared by both.
+func (s DeviceStatus) Validate() error {
+	for _, str := range []sql.NullString{s.OsVersion, s.AppVersion, s.IpAddress, s.SSID, s.LastError} {
+		if str.Valid && len(str.String) > MaxDeviceStatusStringLength {
+			return errors.New("status field too long")
+		}
+	}
+	if s.IpAddress.Valid && net.ParseIP(s.IpAddress.String) == nil {
+		return fmt.Errorf("invalid IP address %q", s.IpAddress.String)
+	}
+	if s.SignalStrength.Valid && (s.SignalStrength.Int32 < 0 || s.SignalStrength.Int32 > 100) {
+		return errors.New("invalid signal strength")
+	}
+	if s.CardReaderStatus.Valid && !CardReaderStatus(s.CardReaderStatus.String).IsValid() {
+		return errors.New("invalid card reader status")
+	}
+	if (s.UptimeSeconds.Valid && s.UptimeSeconds.Int64 < 0) || (s.FreeDiskBytes.Valid && s.FreeDiskBytes.Int64 < 0) {
+		return errors.New("negative status field")
+	}
+	return nil
+}
@@ -31,4 +31,11 @@ func (e dbError) withUnderlying(cause error) *dbError {
 	return &e
 }
 
-var ErrConflict = &dbError{message: "conflict"}
+var (
+	ErrConflict           = &dbError{message: "conflict"}
+	ErrRefreshTokenReused = &dbError{message: "refresh token reused"}
+	ErrIncorrectPIN       = &dbError{message: "incorrect PIN"}
+	ErrPINLocked          = &dbError{message: "PIN locked"}
+
+	ErrRegistrationTokenUsedUp = &dbError{message: "registration token used up"}
+)
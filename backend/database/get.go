@@ -1,8 +1,11 @@
 package database
 
 import (
+	"bytes"
 	"context"
 	"database/sql"
+	"errors"
+	"fmt"
 	"strings"
 	"time"
 
@@ -35,6 +38,68 @@ func (w *Wrapper) GetDevice(ctx context.Context, id uuid.UUID) (Device, error) {
 	return device, err
 }
 
+// GetDeviceStatusHistory retrieves the last status reports of a device, the most recent first.
+func (w *Wrapper) GetDeviceStatusHistory(ctx context.Context, deviceID uuid.UUID, limit int) ([]DeviceStatus, error) {
+	var history []DeviceStatus
+
+	err := w.db.SelectContext(ctx, &history, `
+		SELECT * FROM "device_status"
+		WHERE "device_id" = $1
+		ORDER BY "reported_at" DESC
+		LIMIT $2
+	`, deviceID, limit)
+
+	return history, err
+}
+
+type GetDeviceCommandsOpts struct {
+	DeviceID uuid.UUID
+	Limit    *uint64
+	Offset   *uint64
+}
+
+type PaginatedDeviceCommands struct {
+	Pagination
+	DeviceCommands []DeviceCommand
+}
+
+// GetDeviceCommands retrieves the commands sent to a device, the most recent first.
+func (w *Wrapper) GetDeviceCommands(ctx context.Context, opts GetDeviceCommandsOpts) (PaginatedDeviceCommands, error) {
+	commands := PaginatedDeviceCommands{
+		Pagination: Pagination{
+			Limit:  min(or(opts.Limit, 50), 100),
+			Offset: or(opts.Offset, 0),
+		},
+	}
+
+	rows, err := w.db.QueryxContext(ctx, `
+		SELECT *, COUNT(*) OVER() AS "total" FROM "device_command"
+		WHERE "device_id" = $1
+		ORDER BY "created_at" DESC
+		LIMIT $2 OFFSET $3
+	`, opts.DeviceID, commands.Pagination.Limit, commands.Pagination.Offset)
+	if err != nil {
+		return commands, err
+	}
+	defer rows.Close()
+
+	commands.DeviceCommands = []DeviceCommand{}
+	for rows.Next() {
+		var command struct {
+			DeviceCommand
+			Total uint64
+		}
+		if err = rows.StructScan(&command); err != nil {
+			return commands, err
+		}
+
+		commands.DeviceCommands = append(commands.DeviceCommands, command.DeviceCommand)
+		commands.Total = command.Total
+	}
+
+	return commands, rows.Err()
+}
+
 func min(x, y uint64) uint64 {
 	if x < y {
 		return x
@@ -58,17 +123,66 @@ type PaginatedDevices struct {
 	Devices []Device
 }
 
+// DeviceSortField is a column which devices can be sorted on.
+type DeviceSortField string
+
+const (
+	DeviceSortFieldName          DeviceSortField = "name"
+	DeviceSortFieldLastHeartbeat DeviceSortField = "last_heartbeat"
+	DeviceSortFieldSerialNumber  DeviceSortField = "serial_number"
+	DeviceSortFieldInstallDate   DeviceSortField = "install_date"
+)
+
+func (f DeviceSortField) IsValid() bool {
+	switch f {
+	case DeviceSortFieldName, DeviceSortFieldLastHeartbeat, DeviceSortFieldSerialNumber, DeviceSortFieldInstallDate:
+		return true
+	default:
+		return false
+	}
+}
+
+// DeviceOnlineThreshold is the time after its last heartbeat after which a device is considered to be offline.
+const DeviceOnlineThreshold = 30 * time.Second
+
 type GetDevicesOpts struct {
 	OrganizationID uuid.UUID
 	Limit          *uint64
 	Offset         *uint64
 	NameSearch     *string
+	// Search searches in the name, serial number, MAC address and location description of devices.
+	Search  *string
+	GroupID *uuid.UUID
+	// Tags filters on devices which have all of the tags.
+	Tags []string
+	// Online filters on devices which are online or offline (see DeviceOnlineThreshold).
+	Online *bool
+	// OsVersion and AppVersion filter on the versions in the last status which devices have reported.
+	OsVersion  *string
+	AppVersion *string
+	// LastHeartbeatAfter and LastHeartbeatBefore filter on devices with a last heartbeat in [after, before).
+	LastHeartbeatAfter  *time.Time
+	LastHeartbeatBefore *time.Time
+	// SortBy defaults to DeviceSortFieldName. Devices without a value for the field are sorted last.
+	SortBy         DeviceSortField
+	SortDescending bool
 }
 
 func cleanSearch(s string) string {
 	return strings.NewReplacer("%", "\\%", "_", "\\_").Replace(s)
 }
 
+// deviceOnlineCond matches devices which have sent a heartbeat since the given time
+// and haven't disconnected from NATS after their last heartbeat.
+const deviceOnlineCond = `COALESCE("last_heartbeat" > ? AND ("last_disconnect" IS NULL OR "last_disconnect" <= "last_heartbeat"), false)`
+
+// latestDeviceStatusCond matches devices of which the column in their last reported status equals the argument.
+// The last status is looked up with the ("device_id", "reported_at" DESC) index of "device_status".
+func latestDeviceStatusCond(column string) string {
+	return fmt.Sprintf(`(SELECT %q FROM "device_status" WHERE "device_id" = "device"."id"
+		ORDER BY "reported_at" DESC LIMIT 1) = ?`, column)
+}
+
 func (w *Wrapper) GetDevices(ctx context.Context, opts GetDevicesOpts) (PaginatedDevices, error) {
 	devs := PaginatedDevices{
 		Pagination: Pagination{
@@ -77,12 +191,61 @@ func (w *Wrapper) GetDevices(ctx context.Context, opts GetDevicesOpts) (Paginate
 		},
 	}
 
+	sortBy := opts.SortBy
+	if sortBy == "" {
+		sortBy = DeviceSortFieldName
+	}
+	if !sortBy.IsValid() {
+		return devs, fmt.Errorf("invalid device sort field %q", sortBy)
+	}
+	sortOrder := "ASC"
+	if opts.SortDescending {
+		sortOrder = "DESC"
+	}
+
 	conds := sq.And{
 		sq.Eq{"organization_id": opts.OrganizationID},
 	}
 	if opts.NameSearch != nil {
 		conds = append(conds, sq.Expr("name LIKE '%' || ? || '%'", cleanSearch(*opts.NameSearch)))
 	}
+	if opts.Search != nil {
+		search := cleanSearch(*opts.Search)
+		conds = append(conds, sq.Or{
+			// LIKE is not supported for the nondeterministic collation of the name.
+			// These conditions use the trigram indexes of migration 48.
+			sq.Expr(`name COLLATE "default" ILIKE '%' || ? || '%'`, search),
+			sq.Expr("serial_number ILIKE '%' || ? || '%'", search),
+			sq.Expr("mac_address ILIKE '%' || ? || '%'", search),
+			sq.Expr("location_description ILIKE '%' || ? || '%'", search),
+		})
+	}
+	if opts.GroupID != nil {
+		conds = append(conds, sq.Eq{"group_id": *opts.GroupID})
+	}
+	if len(opts.Tags) != 0 {
+		conds = append(conds, sq.Expr("tags @> ?", pq.Array(opts.Tags)))
+	}
+	if opts.Online != nil {
+		onlineSince := time.Now().Add(-DeviceOnlineThreshold)
+		if *opts.Online {
+			conds = append(conds, sq.Expr(deviceOnlineCond, onlineSince))
+		} else {
+			conds = append(conds, sq.Expr("NOT "+deviceOnlineCond, onlineSince))
+		}
+	}
+	if opts.OsVersion != nil {
+		conds = append(conds, sq.Expr(latestDeviceStatusCond("os_version"), *opts.OsVersion))
+	}
+	if opts.AppVersion != nil {
+		conds = append(conds, sq.Expr(latestDeviceStatusCond("app_version"), *opts.AppVersion))
+	}
+	if opts.LastHeartbeatAfter != nil {
+		conds = append(conds, sq.GtOrEq{"last_heartbeat": *opts.LastHeartbeatAfter})
+	}
+	if opts.LastHeartbeatBefore != nil {
+		conds = append(conds, sq.Lt{"last_heartbeat": *opts.LastHeartbeatBefore})
+	}
 
 	devsSql, args, err := sq.
 		Select(`*, COUNT(*) as subtotal, COUNT(*) OVER() as total`).
@@ -90,7 +253,7 @@ func (w *Wrapper) GetDevices(ctx context.Context, opts GetDevicesOpts) (Paginate
 		Where(conds).
 		Limit(devs.Pagination.Limit).
 		Offset(devs.Pagination.Offset).
-		OrderBy("name ASC").
+		OrderBy(fmt.Sprintf("%s %s NULLS LAST", sortBy, sortOrder), "id ASC").
 		GroupBy("id").
 		PlaceholderFormat(sq.Dollar).
 		ToSql()
@@ -217,7 +380,7 @@ func (w *Wrapper) GetUserByEmail(ctx context.Context, email string) (User, error
 }
 
 func (w *Wrapper) GetOAuth2State(ctx context.Context, state uuid.UUID) (OAuth2State, error) {
-	tx, err := w.db.Begin()
+	tx, err := w.db.BeginTxx(ctx, nil)
 	if err != nil {
 		return OAuth2State{}, err
 	}
@@ -225,13 +388,14 @@ func (w *Wrapper) GetOAuth2State(ctx context.Context, state uuid.UUID) (OAuth2St
 		_ = tx.Rollback()
 	}()
 
+	// The state is locked so it can only ever be used once, even with concurrent callbacks.
 	var oauth2State OAuth2State
-	err = w.db.GetContext(ctx, &oauth2State, `SELECT * FROM "oauth2_state" WHERE "state" = $1`, state)
+	err = tx.GetContext(ctx, &oauth2State, `SELECT * FROM "oauth2_state" WHERE "state" = $1 FOR UPDATE`, state)
 	if err != nil {
 		return oauth2State, err
 	}
 
-	_, err = w.db.ExecContext(ctx, `DELETE FROM "oauth2_state" WHERE "state" = $1`, state)
+	_, err = tx.ExecContext(ctx, `DELETE FROM "oauth2_state" WHERE "state" = $1`, state)
 	if err != nil {
 		return oauth2State, err
 	}
@@ -246,21 +410,559 @@ func (w *Wrapper) GetOAuth2State(ctx context.Context, state uuid.UUID) (OAuth2St
 	return oauth2State, tx.Commit()
 }
 
-func (w *Wrapper) GetUserByToken(ctx context.Context, token uuid.UUID) (User, error) {
-	var user User
+// GetUserByToken retrieves the user that an access token belongs to, together with the ID of the session
+// that the token was created for.
+func (w *Wrapper) GetUserByToken(ctx context.Context, token uuid.UUID) (User, uuid.UUID, error) {
+	var user struct {
+		User
+		SessionID uuid.UUID
+	}
 
 	tokenHash, err := hashToken(token)
 	if err != nil {
-		return user, err
+		return user.User, user.SessionID, err
 	}
 
 	err = w.db.GetContext(ctx, &user, `
-		SELECT u.* FROM "user_token"
+		SELECT u.*, user_token.session_id FROM "user_token"
 		INNER JOIN "user" u on u.id = user_token.user_id
 		WHERE "user_token"."token_hash" = $1 AND "expires_at" > now()
 	`, tokenHash)
 
-	return user, err
+	return user.User, user.SessionID, err
+}
+
+func (w *Wrapper) GetUserSessions(ctx context.Context, userID uuid.UUID) ([]UserSession, error) {
+	var sessions []UserSession
+
+	err := w.db.SelectContext(ctx, &sessions, `
+		SELECT * FROM "user_session"
+		WHERE "user_id" = $1 AND "expires_at" > now()
+		ORDER BY "last_used_at" DESC
+	`, userID)
+
+	return sessions, err
+}
+
+// GetUserByAPIKey retrieves the user that an API key belongs to, together with the API key itself.
+func (w *Wrapper) GetUserByAPIKey(ctx context.Context, key uuid.UUID) (User, UserAPIKey, error) {
+	var (
+		user   User
+		apiKey UserAPIKey
+	)
+
+	keyHash, err := hashToken(key)
+	if err != nil {
+		return user, apiKey, err
+	}
+
+	err = w.db.GetContext(ctx, &apiKey, `
+		SELECT * FROM "user_api_key"
+		WHERE "key_hash" = $1 AND ("expires_at" IS NULL OR "expires_at" > now())
+	`, keyHash)
+	if err != nil {
+		return user, apiKey, err
+	}
+
+	user, err = w.GetUserByID(ctx, apiKey.UserID)
+	return user, apiKey, err
+}
+
+func (w *Wrapper) GetUserAPIKeys(ctx context.Context, userID uuid.UUID) ([]UserAPIKey, error) {
+	var apiKeys []UserAPIKey
+
+	err := w.db.SelectContext(ctx, &apiKeys, `
+		SELECT * FROM "user_api_key"
+		WHERE "user_id" = $1
+		ORDER BY "created_at" DESC
+	`, userID)
+
+	return apiKeys, err
+}
+
+// DeviceSelector selects devices by their ID, group or tags.
+// A device is selected if it matches any of the criteria.
+type DeviceSelector struct {
+	DeviceIDs []uuid.UUID
+	GroupIDs  []uuid.UUID
+	// Tags selects devices which have any of the tags.
+	Tags []string
+	// Capability only selects the devices which have the capability, if set.
+	Capability string
+}
+
+// GetDeviceIDsBySelector retrieves the IDs of the devices in an organization which are selected by sel.
+func (w *Wrapper) GetDeviceIDsBySelector(ctx context.Context,
+	organizationID uuid.UUID,
+	sel DeviceSelector,
+) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+
+	err := w.db.SelectContext(ctx, &ids, `
+		SELECT "id" FROM "device"
+		WHERE "organization_id" = $1
+		  AND ("id" = ANY($2) OR "group_id" = ANY($3) OR "tags" && $4)
+		  AND ($5::text = '' OR $5 = ANY("capabilities"))
+		ORDER BY "id"
+	`, organizationID, pq.Array(sel.DeviceIDs), pq.Array(sel.GroupIDs), pq.Array(sel.Tags), sel.Capability)
+
+	return ids, err
+}
+
+func (w *Wrapper) GetDeviceCommand(ctx context.Context, id uuid.UUID) (DeviceCommand, error) {
+	var cmd DeviceCommand
+
+	err := w.db.GetContext(ctx, &cmd, `SELECT * FROM "device_command" WHERE "id" = $1`, id)
+
+	return cmd, err
+}
+
+// GetDeviceLogBundles retrieves the log bundles of a device, the most recent first.
+func (w *Wrapper) GetDeviceLogBundles(ctx context.Context, deviceID uuid.UUID) ([]DeviceLogBundle, error) {
+	var bundles []DeviceLogBundle
+
+	err := w.db.SelectContext(ctx, &bundles, `
+		SELECT "id", "device_id", "command_id", "created_at", "size" FROM "device_log_bundle"
+		WHERE "device_id" = $1
+		ORDER BY "created_at" DESC
+	`, deviceID)
+
+	return bundles, err
+}
+
+func (w *Wrapper) GetDeviceLogBundle(ctx context.Context, id uuid.UUID) (DeviceLogBundle, EncryptedBlob, error) {
+	var row struct {
+		DeviceLogBundle
+		EncryptedBlob
+	}
+
+	err := w.db.GetContext(ctx, &row, `SELECT * FROM "device_log_bundle" WHERE "id" = $1`, id)
+
+	return row.DeviceLogBundle, row.EncryptedBlob, err
+}
+
+// GetDeviceScreenshots retrieves the screenshots of a device, the most recent first.
+func (w *Wrapper) GetDeviceScreenshots(ctx context.Context, deviceID uuid.UUID) ([]DeviceScreenshot, error) {
+	var screenshots []DeviceScreenshot
+
+	err := w.db.SelectContext(ctx, &screenshots, `
+		SELECT "id", "device_id", "command_id", "created_at", "size" FROM "device_screenshot"
+		WHERE "device_id" = $1
+		ORDER BY "created_at" DESC
+	`, deviceID)
+
+	return screenshots, err
+}
+
+func (w *Wrapper) GetDeviceScreenshot(ctx context.Context, id uuid.UUID) (DeviceScreenshot, EncryptedBlob, error) {
+	var row struct {
+		DeviceScreenshot
+		EncryptedBlob
+	}
+
+	err := w.db.GetContext(ctx, &row, `SELECT * FROM "device_screenshot" WHERE "id" = $1`, id)
+
+	return row.DeviceScreenshot, row.EncryptedBlob, err
+}
+
+func (w *Wrapper) GetDeviceScreenshotByCommand(ctx context.Context,
+	commandID uuid.UUID,
+) (DeviceScreenshot, EncryptedBlob, error) {
+	var row struct {
+		DeviceScreenshot
+		EncryptedBlob
+	}
+
+	err := w.db.GetContext(ctx, &row, `SELECT * FROM "device_screenshot" WHERE "command_id" = $1`, commandID)
+
+	return row.DeviceScreenshot, row.EncryptedBlob, err
+}
+
+func (w *Wrapper) GetDeviceSchedule(ctx context.Context, id uuid.UUID) (DeviceSchedule, error) {
+	var schedule DeviceSchedule
+
+	err := w.db.GetContext(ctx, &schedule, `SELECT * FROM "device_schedule" WHERE "id" = $1`, id)
+
+	return schedule, err
+}
+
+func (w *Wrapper) GetDeviceSchedules(ctx context.Context, organizationID uuid.UUID) ([]DeviceSchedule, error) {
+	var schedules []DeviceSchedule
+
+	err := w.db.SelectContext(ctx, &schedules, `
+		SELECT * FROM "device_schedule"
+		WHERE "organization_id" = $1
+		ORDER BY "created_at"
+	`, organizationID)
+
+	return schedules, err
+}
+
+// GetEnabledDeviceSchedules retrieves the enabled schedules of all organizations.
+func (w *Wrapper) GetEnabledDeviceSchedules(ctx context.Context) ([]DeviceSchedule, error) {
+	var schedules []DeviceSchedule
+
+	err := w.db.SelectContext(ctx, &schedules, `SELECT * FROM "device_schedule" WHERE "enabled"`)
+
+	return schedules, err
+}
+
+func (w *Wrapper) GetDeviceConfigProfile(ctx context.Context, id uuid.UUID) (DeviceConfigProfile, error) {
+	var profile DeviceConfigProfile
+
+	err := w.db.GetContext(ctx, &profile, `SELECT * FROM "device_config_profile" WHERE "id" = $1`, id)
+
+	return profile, err
+}
+
+func (w *Wrapper) GetDeviceConfigProfiles(ctx context.Context, organizationID uuid.UUID) ([]DeviceConfigProfile, error) {
+	var profiles []DeviceConfigProfile
+
+	err := w.db.SelectContext(ctx, &profiles, `
+		SELECT * FROM "device_config_profile"
+		WHERE "organization_id" = $1
+		ORDER BY "name"
+	`, organizationID)
+
+	return profiles, err
+}
+
+// DeviceConfigLayers are the configs of the profiles which apply to a device, from the least to the most specific.
+// A config is nil if no profile is assigned at its level.
+type DeviceConfigLayers struct {
+	DeviceID           uuid.UUID
+	OrganizationConfig []byte
+	GroupConfig        []byte
+	DeviceConfig       []byte
+	// RetrievedConfigHash is the hash of the config which the device has retrieved last, if any.
+	RetrievedConfigHash []byte
+}
+
+const selectDeviceConfigLayers = `
+	SELECT d."id" AS "device_id",
+	       op."config" AS "organization_config",
+	       gp."config" AS "group_config",
+	       dp."config" AS "device_config",
+	       dcr."config_hash" AS "retrieved_config_hash"
+	FROM "device" AS d
+	JOIN "organization" AS o ON o."id" = d."organization_id"
+	LEFT JOIN "device_group" AS g ON g."id" = d."group_id"
+	LEFT JOIN "device_config_profile" AS op ON op."id" = o."device_config_profile_id"
+	LEFT JOIN "device_config_profile" AS gp ON gp."id" = g."device_config_profile_id"
+	LEFT JOIN "device_config_profile" AS dp ON dp."id" = d."device_config_profile_id"
+	LEFT JOIN "device_config_retrieval" AS dcr ON dcr."device_id" = d."id"
+`
+
+func (w *Wrapper) GetDeviceConfigLayers(ctx context.Context, deviceID uuid.UUID) (DeviceConfigLayers, error) {
+	var layers DeviceConfigLayers
+
+	err := w.db.GetContext(ctx, &layers, selectDeviceConfigLayers+`WHERE d."id" = $1`, deviceID)
+
+	return layers, err
+}
+
+// GetOrganizationDeviceConfigLayers retrieves the config layers of all devices in an organization.
+func (w *Wrapper) GetOrganizationDeviceConfigLayers(ctx context.Context,
+	organizationID uuid.UUID,
+) ([]DeviceConfigLayers, error) {
+	var layers []DeviceConfigLayers
+
+	err := w.db.SelectContext(ctx, &layers, selectDeviceConfigLayers+`
+		WHERE d."organization_id" = $1
+		ORDER BY d."id"
+	`, organizationID)
+
+	return layers, err
+}
+
+const selectAnnouncements = `
+	SELECT a.*,
+	       EXISTS(SELECT 1 FROM "announcement_image" AS ai WHERE ai."announcement_id" = a."id") AS "has_image"
+	FROM "announcement" AS a
+`
+
+func (w *Wrapper) GetAnnouncement(ctx context.Context, id uuid.UUID) (Announcement, error) {
+	var announcement Announcement
+
+	err := w.db.GetContext(ctx, &announcement, selectAnnouncements+`WHERE a."id" = $1`, id)
+	if err != nil {
+		return announcement, err
+	}
+
+	announcements := []Announcement{announcement}
+	err = w.getAnnouncementGroupIDs(ctx, announcements)
+
+	return announcements[0], err
+}
+
+// GetAnnouncements retrieves the announcements of an organization, the most recently started first.
+func (w *Wrapper) GetAnnouncements(ctx context.Context, organizationID uuid.UUID) ([]Announcement, error) {
+	var announcements []Announcement
+
+	err := w.db.SelectContext(ctx, &announcements, selectAnnouncements+`
+		WHERE a."organization_id" = $1
+		ORDER BY a."starts_at" DESC, a."id"
+	`, organizationID)
+	if err != nil {
+		return nil, err
+	}
+
+	return announcements, w.getAnnouncementGroupIDs(ctx, announcements)
+}
+
+// whereAnnouncementForDevice selects the announcements of organization $1 which are shown on a device
+// in group $2 and haven't ended yet at $3.
+const whereAnnouncementForDevice = `
+	WHERE a."organization_id" = $1
+	  AND (a."ends_at" IS NULL OR a."ends_at" > $3)
+	  AND (a."all_devices"
+	    OR EXISTS(SELECT 1 FROM "announcement_device_group" AS adg
+	              WHERE adg."announcement_id" = a."id" AND adg."device_group_id" = $2))
+`
+
+// GetAnnouncementsForDevice retrieves the announcements which are shown on a device in the group
+// and haven't ended yet at now, ordered by when they start.
+func (w *Wrapper) GetAnnouncementsForDevice(ctx context.Context,
+	organizationID uuid.UUID,
+	groupID *uuid.UUID,
+	now time.Time,
+) ([]Announcement, error) {
+	var announcements []Announcement
+
+	err := w.db.SelectContext(ctx, &announcements, selectAnnouncements+whereAnnouncementForDevice+`
+		ORDER BY a."starts_at", a."id"
+	`, organizationID, groupID, now)
+	if err != nil {
+		return nil, err
+	}
+
+	return announcements, w.getAnnouncementGroupIDs(ctx, announcements)
+}
+
+// GetAnnouncementForDevice retrieves an announcement if it is shown on a device in the group
+// and hasn't ended yet at now, like GetAnnouncementsForDevice. Otherwise, sql.ErrNoRows is returned.
+func (w *Wrapper) GetAnnouncementForDevice(ctx context.Context,
+	id uuid.UUID,
+	organizationID uuid.UUID,
+	groupID *uuid.UUID,
+	now time.Time,
+) (Announcement, error) {
+	var announcement Announcement
+
+	err := w.db.GetContext(ctx, &announcement, selectAnnouncements+whereAnnouncementForDevice+`
+		  AND a."id" = $4
+	`, organizationID, groupID, now, id)
+	if err != nil {
+		return announcement, err
+	}
+
+	announcements := []Announcement{announcement}
+	err = w.getAnnouncementGroupIDs(ctx, announcements)
+
+	return announcements[0], err
+}
+
+// getAnnouncementGroupIDs sets the GroupIDs of the announcements.
+func (w *Wrapper) getAnnouncementGroupIDs(ctx context.Context, announcements []Announcement) error {
+	if len(announcements) == 0 {
+		return nil
+	}
+
+	ids := make([]uuid.UUID, len(announcements))
+	indices := make(map[uuid.UUID]int, len(announcements))
+	for i, a := range announcements {
+		ids[i] = a.ID
+		indices[a.ID] = i
+	}
+
+	var rows []struct {
+		AnnouncementID uuid.UUID
+		DeviceGroupID  uuid.UUID
+	}
+	err := w.db.SelectContext(ctx, &rows, `
+		SELECT "announcement_id", "device_group_id" FROM "announcement_device_group"
+		WHERE "announcement_id" = ANY($1)
+		ORDER BY "device_group_id"
+	`, pq.Array(ids))
+	if err != nil {
+		return err
+	}
+
+	for _, row := range rows {
+		a := &announcements[indices[row.AnnouncementID]]
+		a.GroupIDs = append(a.GroupIDs, row.DeviceGroupID)
+	}
+	return nil
+}
+
+// GetAnnouncementDeviceIDs retrieves the IDs of the devices which an announcement is shown on.
+func (w *Wrapper) GetAnnouncementDeviceIDs(ctx context.Context, id uuid.UUID) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+
+	err := w.db.SelectContext(ctx, &ids, `
+		SELECT d."id" FROM "device" AS d
+		JOIN "announcement" AS a ON a."organization_id" = d."organization_id"
+		WHERE a."id" = $1
+		  AND (a."all_devices"
+		    OR EXISTS(SELECT 1 FROM "announcement_device_group" AS adg
+		              WHERE adg."announcement_id" = a."id" AND adg."device_group_id" = d."group_id"))
+		ORDER BY d."id"
+	`, id)
+
+	return ids, err
+}
+
+func (w *Wrapper) GetAnnouncementImage(ctx context.Context, id uuid.UUID) (AnnouncementImage, error) {
+	var img AnnouncementImage
+
+	err := w.db.GetContext(ctx, &img, `SELECT * FROM "announcement_image" WHERE "announcement_id" = $1`, id)
+
+	return img, err
+}
+
+func (w *Wrapper) GetSoftwareRelease(ctx context.Context, id uuid.UUID) (SoftwareRelease, error) {
+	var release SoftwareRelease
+
+	err := w.db.GetContext(ctx, &release, `SELECT * FROM "software_release" WHERE "id" = $1`, id)
+
+	return release, err
+}
+
+// GetSoftwareReleases retrieves the releases of an organization, the most recent first.
+func (w *Wrapper) GetSoftwareReleases(ctx context.Context, organizationID uuid.UUID) ([]SoftwareRelease, error) {
+	var releases []SoftwareRelease
+
+	err := w.db.SelectContext(ctx, &releases, `
+		SELECT * FROM "software_release"
+		WHERE "organization_id" = $1
+		ORDER BY "created_at" DESC
+	`, organizationID)
+
+	return releases, err
+}
+
+// GetLatestSoftwareRelease retrieves the most recently created release of a component which is available
+// in the channel. Devices in the beta channel also get stable releases.
+func (w *Wrapper) GetLatestSoftwareRelease(ctx context.Context,
+	organizationID uuid.UUID,
+	component SoftwareComponent,
+	channel UpdateChannel,
+) (SoftwareRelease, error) {
+	channels := []string{string(UpdateChannelStable)}
+	if channel == UpdateChannelBeta {
+		channels = append(channels, string(UpdateChannelBeta))
+	}
+
+	var release SoftwareRelease
+
+	err := w.db.GetContext(ctx, &release, `
+		SELECT * FROM "software_release"
+		WHERE "organization_id" = $1 AND "component" = $2 AND "channel" = ANY($3)
+		ORDER BY "created_at" DESC
+		LIMIT 1
+	`, organizationID, component, pq.Array(channels))
+
+	return release, err
+}
+
+func (w *Wrapper) GetUpdateRollout(ctx context.Context, id uuid.UUID) (UpdateRollout, error) {
+	var rollout UpdateRollout
+
+	err := w.db.GetContext(ctx, &rollout, `SELECT * FROM "update_rollout" WHERE "id" = $1`, id)
+
+	return rollout, err
+}
+
+func (w *Wrapper) GetUpdateRollouts(ctx context.Context, organizationID uuid.UUID) ([]UpdateRollout, error) {
+	var rollouts []UpdateRollout
+
+	err := w.db.SelectContext(ctx, &rollouts, `
+		SELECT * FROM "update_rollout"
+		WHERE "organization_id" = $1
+		ORDER BY "created_at"
+	`, organizationID)
+
+	return rollouts, err
+}
+
+// GetUpdateRolloutForGroup retrieves the rollout of the component in the device group.
+// sql.ErrNoRows is returned if there is none.
+func (w *Wrapper) GetUpdateRolloutForGroup(ctx context.Context,
+	groupID uuid.UUID,
+	component SoftwareComponent,
+) (UpdateRollout, error) {
+	var rollout UpdateRollout
+
+	err := w.db.GetContext(ctx, &rollout,
+		`SELECT * FROM "update_rollout" WHERE "device_group_id" = $1 AND "component" = $2`,
+		groupID, component,
+	)
+
+	return rollout, err
+}
+
+// DeviceSoftwareVersion is the version of a software component which a device has last reported.
+type DeviceSoftwareVersion struct {
+	DeviceID uuid.UUID
+	Version  sql.NullString
+}
+
+// GetDeviceSoftwareVersions retrieves the versions of a component last reported (with heartbeats)
+// by the devices in a group.
+func (w *Wrapper) GetDeviceSoftwareVersions(ctx context.Context,
+	groupID uuid.UUID,
+	component SoftwareComponent,
+) ([]DeviceSoftwareVersion, error) {
+	column := "app_version"
+	if component == SoftwareComponentTimetermOS {
+		column = "os_version"
+	}
+
+	var versions []DeviceSoftwareVersion
+
+	err := w.db.SelectContext(ctx, &versions, fmt.Sprintf(`
+		SELECT d."id" AS "device_id", s."version"
+		FROM "device" d
+		LEFT JOIN LATERAL (SELECT %q AS "version" FROM "device_status"
+		                   WHERE "device_id" = d."id" AND %[1]q IS NOT NULL
+		                   ORDER BY "reported_at" DESC
+		                   LIMIT 1) s ON true
+		WHERE d."group_id" = $1
+		ORDER BY d."id"
+	`, column), groupID)
+
+	return versions, err
+}
+
+func (w *Wrapper) GetDeviceGroup(ctx context.Context, id uuid.UUID) (DeviceGroup, error) {
+	var group DeviceGroup
+
+	err := w.db.GetContext(ctx, &group, `SELECT * FROM "device_group" WHERE "id" = $1`, id)
+
+	return group, err
+}
+
+func (w *Wrapper) GetDeviceGroups(ctx context.Context, organizationID uuid.UUID) ([]DeviceGroup, error) {
+	var groups []DeviceGroup
+
+	err := w.db.SelectContext(ctx, &groups, `
+		SELECT * FROM "device_group" WHERE "organization_id" = $1 ORDER BY "name"
+	`, organizationID)
+
+	return groups, err
+}
+
+// GetDeviceTags retrieves all tags which are used by the devices in an organization.
+func (w *Wrapper) GetDeviceTags(ctx context.Context, organizationID uuid.UUID) ([]string, error) {
+	var tags []string
+
+	err := w.db.SelectContext(ctx, &tags, `
+		SELECT DISTINCT unnest("tags") AS "tag" FROM "device"
+		WHERE "organization_id" = $1
+		ORDER BY "tag"
+	`, organizationID)
+
+	return tags, err
 }
 
 func (w *Wrapper) AreDevicesInOrganization(ctx context.Context,
@@ -301,6 +1003,130 @@ func (w *Wrapper) GetNetworkingService(ctx context.Context, id uuid.UUID) (Netwo
 	return networkingService, err
 }
 
+// GetNetworkingServicesForDevice retrieves the networking services which are assigned to a device,
+// either directly, through its group or because they are assigned to all devices, ordered by their ID.
+// The device doesn't have to exist yet, so the services for a device which is still to be registered
+// can be retrieved too.
+func (w *Wrapper) GetNetworkingServicesForDevice(ctx context.Context,
+	organizationID, deviceID uuid.UUID,
+	groupID *uuid.UUID,
+) ([]NetworkingService, error) {
+	var services []NetworkingService
+
+	err := w.db.SelectContext(ctx, &services, `
+		SELECT ns.* FROM "networking_service" AS ns
+		WHERE ns."organization_id" = $1
+		  AND (ns."all_devices"
+		    OR EXISTS(SELECT 1 FROM "networking_service_device" AS nsd
+		              WHERE nsd."networking_service_id" = ns."id" AND nsd."device_id" = $2)
+		    OR EXISTS(SELECT 1 FROM "networking_service_device_group" AS nsg
+		              WHERE nsg."networking_service_id" = ns."id" AND nsg."device_group_id" = $3))
+		ORDER BY ns."id"
+	`, organizationID, deviceID, groupID)
+
+	return services, err
+}
+
+type NetworkingServiceAssignment struct {
+	NetworkingServiceID uuid.UUID
+	AllDevices          bool
+	DeviceIDs           []uuid.UUID
+	GroupIDs            []uuid.UUID
+}
+
+func (w *Wrapper) GetNetworkingServiceAssignment(ctx context.Context, id uuid.UUID) (NetworkingServiceAssignment, error) {
+	a := NetworkingServiceAssignment{NetworkingServiceID: id}
+
+	err := w.db.GetContext(ctx, &a.AllDevices, `SELECT "all_devices" FROM "networking_service" WHERE "id" = $1`, id)
+	if err != nil {
+		return a, err
+	}
+
+	err = w.db.SelectContext(ctx, &a.DeviceIDs, `
+		SELECT "device_id" FROM "networking_service_device"
+		WHERE "networking_service_id" = $1
+		ORDER BY "device_id"
+	`, id)
+	if err != nil {
+		return a, err
+	}
+
+	err = w.db.SelectContext(ctx, &a.GroupIDs, `
+		SELECT "device_group_id" FROM "networking_service_device_group"
+		WHERE "networking_service_id" = $1
+		ORDER BY "device_group_id"
+	`, id)
+
+	return a, err
+}
+
+// GetDevicesWithOutdatedNetworkingConfig retrieves the IDs of the devices in an organization
+// whose networking config has changed since they last retrieved it.
+func (w *Wrapper) GetDevicesWithOutdatedNetworkingConfig(ctx context.Context,
+	organizationID uuid.UUID,
+) ([]uuid.UUID, error) {
+	rows, err := w.db.QueryxContext(ctx, `
+		SELECT d."id" AS "device_id", ns."id" AS "networking_service_id", ns."config_hash",
+		       dnc."config_hash" AS "retrieved_config_hash"
+		FROM "device" AS d
+		LEFT JOIN "networking_service" AS ns
+		  ON ns."organization_id" = d."organization_id"
+		 AND (ns."all_devices"
+		   OR EXISTS(SELECT 1 FROM "networking_service_device" AS nsd
+		             WHERE nsd."networking_service_id" = ns."id" AND nsd."device_id" = d."id")
+		   OR EXISTS(SELECT 1 FROM "networking_service_device_group" AS nsg
+		             WHERE nsg."networking_service_id" = ns."id" AND nsg."device_group_id" = d."group_id"))
+		LEFT JOIN "device_networking_config" AS dnc ON dnc."device_id" = d."id"
+		WHERE d."organization_id" = $1
+		ORDER BY d."id", ns."id"
+	`, organizationID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var outdated []uuid.UUID
+	var (
+		deviceID      uuid.UUID
+		services      []NetworkingService
+		retrievedHash []byte
+	)
+	flush := func() {
+		if deviceID != uuid.Nil && !bytes.Equal(NetworkingConfigHash(services), retrievedHash) {
+			outdated = append(outdated, deviceID)
+		}
+	}
+
+	for rows.Next() {
+		var row struct {
+			DeviceID            uuid.UUID
+			NetworkingServiceID *uuid.UUID
+			ConfigHash          []byte
+			RetrievedConfigHash []byte
+		}
+		if err = rows.StructScan(&row); err != nil {
+			return nil, err
+		}
+
+		if row.DeviceID != deviceID {
+			flush()
+			deviceID, services, retrievedHash = row.DeviceID, nil, row.RetrievedConfigHash
+		}
+		if row.NetworkingServiceID != nil {
+			services = append(services, NetworkingService{
+				ID:         *row.NetworkingServiceID,
+				ConfigHash: row.ConfigHash,
+			})
+		}
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	flush()
+
+	return outdated, nil
+}
+
 type GetNetworkingServicesOpts struct {
 	OrganizationID uuid.UUID
 	Limit          *uint64
@@ -373,21 +1199,39 @@ func (w *Wrapper) GetAllNetworkingServices(ctx context.Context, organizationID u
 	return networkingServices, err
 }
 
-func (w *Wrapper) GetOrganizationByDeviceRegistrationToken(ctx context.Context, token uuid.UUID) (Organization, error) {
-	var org Organization
+// GetDeviceRegistrationTokenByToken retrieves a registration token which has not expired or been used up yet.
+func (w *Wrapper) GetDeviceRegistrationTokenByToken(ctx context.Context,
+	token uuid.UUID,
+) (DeviceRegistrationToken, error) {
+	var regToken DeviceRegistrationToken
 
 	hash, err := hashToken(token)
 	if err != nil {
-		return org, err
+		return regToken, err
 	}
 
-	err = w.db.GetContext(ctx, &org, `
-		SELECT organization.* FROM device_registration_token
-		INNER JOIN organization ON organization.id = device_registration_token.organization_id
-		WHERE device_registration_token.token_hash = $1
+	err = w.db.GetContext(ctx, &regToken, `
+		SELECT * FROM "device_registration_token"
+		WHERE "token_hash" = $1 AND "expires_at" > now() AND "uses" < "max_uses"
 	`, hash)
 
-	return org, err
+	return regToken, err
+}
+
+// GetDeviceRegistrationTokens retrieves the registration tokens of an organization
+// which have not expired or been used up yet.
+func (w *Wrapper) GetDeviceRegistrationTokens(ctx context.Context,
+	organizationID uuid.UUID,
+) ([]DeviceRegistrationToken, error) {
+	var regTokens []DeviceRegistrationToken
+
+	err := w.db.SelectContext(ctx, &regTokens, `
+		SELECT * FROM "device_registration_token"
+		WHERE "organization_id" = $1 AND "expires_at" > now() AND "uses" < "max_uses"
+		ORDER BY "created_at" DESC
+	`, organizationID)
+
+	return regTokens, err
 }
 
 func (w *Wrapper) GetDeviceByToken(ctx context.Context, token uuid.UUID) (Device, error) {
@@ -402,26 +1246,131 @@ func (w *Wrapper) GetDeviceByToken(ctx context.Context, token uuid.UUID) (Device
 		SELECT device.* from device_token
 		INNER JOIN device on device.id = device_token.device_id
 		WHERE device_token.token_hash = $1
+		  AND (device_token.expires_at IS NULL OR device_token.expires_at > now())
 	`, hash)
 
 	return dev, err
 }
 
-func (w *Wrapper) GetStudentByCard(ctx context.Context, uid []byte, organizationID uuid.UUID) (Student, error) {
-	var student Student
+// GetStudentByCard retrieves the student that a card belongs to, together with the card itself.
+// The card UID is hashed with all keys, as cards may still have been hashed with an older key.
+// If the card has not been hashed with the current key yet, its hash is replaced with one made with the current key.
+// ErrConflict is returned if the card has been added more than once, which was possible before adding cards
+// checked all key versions. The state of the card is not checked, which is up to the caller.
+func (w *Wrapper) GetStudentByCard(ctx context.Context,
+	uid []byte,
+	organizationID uuid.UUID,
+	getKeys CardKeysFunc,
+) (Student, StudentCard, error) {
+	var (
+		student Student
+		card    StudentCard
+	)
+
+	tx, err := w.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return student, card, err
+	}
+	defer func() { _ = tx.Rollback() }()
 
-	hash, err := hashBytes(uid)
+	keys, err := getKeys()
 	if err != nil {
-		return student, err
+		return student, card, fmt.Errorf("could not get card keys: %w", err)
 	}
 
-	err = w.db.GetContext(ctx, &student, `
-		SELECT student.* FROM student_card
-		INNER JOIN student ON student.id = student_card.student_id
-		WHERE student_card.id_hash = $1 AND student_card.organization_id = $2
-	`, hash, organizationID)
+	// Locking the cards of the organization for every login would serialize all card logins,
+	// so they are only locked if the card has to be rehashed.
+	card, err = getStudentCardByUID(ctx, tx, organizationID, uid, keys, false)
+	if err != nil {
+		return student, card, err
+	}
 
-	return student, err
+	if card.KeyVersion != keys.Current {
+		if err = lockStudentCards(ctx, tx, organizationID); err != nil {
+			return student, card, err
+		}
+		// The keys may have been rotated and the card may have been rehashed in the meantime.
+		if keys, err = getKeys(); err != nil {
+			return student, card, fmt.Errorf("could not get card keys: %w", err)
+		}
+		if card, err = getStudentCardByUID(ctx, tx, organizationID, uid, keys, true); err != nil {
+			return student, card, err
+		}
+	}
+
+	if card.KeyVersion != keys.Current {
+		currentKey, ok := keys.Keys[keys.Current]
+		if !ok {
+			return student, card, errors.New("current card key not provided")
+		}
+
+		_, err = tx.ExecContext(ctx,
+			`UPDATE student_card SET id_hash = $1, key_version = $2 WHERE id = $3`,
+			hashCardUID(currentKey, uid), keys.Current, card.ID,
+		)
+		if err != nil {
+			return student, card, fmt.Errorf("could not rehash card UID: %w", err)
+		}
+		card.KeyVersion = keys.Current
+	}
+
+	err = tx.GetContext(ctx, &student, `SELECT * FROM student WHERE id = $1`, card.StudentID)
+	if err != nil {
+		return student, card, err
+	}
+
+	return student, card, tx.Commit()
+}
+
+// GetLegacyStudentCards retrieves the cards of an organization which are still hashed without a key,
+// because they haven't been used since keys were introduced. The students of these cards can use them
+// to have them rehashed, or have them added again once the cards have been removed.
+func (w *Wrapper) GetLegacyStudentCards(ctx context.Context, organizationID uuid.UUID) ([]StudentCard, error) {
+	var cards []StudentCard
+
+	err := w.db.SelectContext(ctx, &cards, `
+		SELECT `+studentCardColumns+` FROM student_card
+		WHERE organization_id = $1 AND key_version = $2
+		ORDER BY student_id, created_at
+	`, organizationID, legacyCardKeyVersion)
+
+	return cards, err
+}
+
+func (w *Wrapper) GetStudentCards(ctx context.Context, studentID uuid.UUID) ([]StudentCard, error) {
+	var cards []StudentCard
+
+	err := w.db.SelectContext(ctx, &cards, `
+		SELECT `+studentCardColumns+` FROM student_card
+		WHERE student_id = $1
+		ORDER BY created_at DESC
+	`, studentID)
+
+	return cards, err
+}
+
+// HasStudentPIN checks if the student has set a PIN.
+func (w *Wrapper) HasStudentPIN(ctx context.Context, studentID uuid.UUID) (bool, error) {
+	var exists bool
+
+	err := w.db.GetContext(ctx, &exists,
+		`SELECT EXISTS(SELECT 1 FROM "student_pin" WHERE "student_id" = $1)`,
+		studentID,
+	)
+
+	return exists, err
+}
+
+// GetStudentCardKeyVersions retrieves the versions of the keys that the cards of an organization are hashed with.
+func (w *Wrapper) GetStudentCardKeyVersions(ctx context.Context, organizationID uuid.UUID) ([]int, error) {
+	var versions []int
+
+	err := w.db.SelectContext(ctx, &versions,
+		`SELECT DISTINCT key_version FROM student_card WHERE organization_id = $1`,
+		organizationID,
+	)
+
+	return versions, err
 }
 
 type GetAdminMessagesOpts struct {
@@ -475,3 +1424,103 @@ func (w *Wrapper) GetAdminMessage(ctx context.Context, tm time.Time, organizatio
 
 	return message, err
 }
+
+func (w *Wrapper) GetCardEnrollmentSession(ctx context.Context, id uuid.UUID) (CardEnrollmentSession, error) {
+	var session CardEnrollmentSession
+
+	err := w.db.GetContext(ctx, &session, `SELECT * FROM card_enrollment_session WHERE id = $1`, id)
+
+	return session, err
+}
+
+// CountFailedLoginAttemptsByIP counts the failed login attempts of the provided kinds from an IP address since a time.
+func (w *Wrapper) CountFailedLoginAttemptsByIP(ctx context.Context,
+	clientIP string,
+	kinds []LoginAttemptKind,
+	since time.Time,
+) (int, error) {
+	var count int
+
+	strKinds := make([]string, len(kinds))
+	for i, kind := range kinds {
+		strKinds[i] = string(kind)
+	}
+
+	err := w.db.GetContext(ctx, &count, `
+		SELECT COUNT(*) FROM login_attempt
+		WHERE client_ip = $1 AND NOT success AND kind = ANY($2::login_attempt_kind[]) AND created_at > $3
+	`, clientIP, pq.Array(strKinds), since)
+
+	return count, err
+}
+
+// CountFailedLoginAttemptsByDevice counts the failed login attempts of a kind at a device since a time.
+func (w *Wrapper) CountFailedLoginAttemptsByDevice(ctx context.Context,
+	deviceID uuid.UUID,
+	kind LoginAttemptKind,
+	since time.Time,
+) (int, error) {
+	var count int
+
+	err := w.db.GetContext(ctx, &count, `
+		SELECT COUNT(*) FROM login_attempt
+		WHERE device_id = $1 AND NOT success AND kind = $2 AND created_at > $3
+	`, deviceID, kind, since)
+
+	return count, err
+}
+
+// UnknownCardTaps summarizes the taps of unknown cards at a device.
+type UnknownCardTaps struct {
+	DeviceID      uuid.UUID
+	DeviceName    string
+	Taps          int
+	DistinctCards int
+	LastTapAt     time.Time
+}
+
+// GetUnknownCardTaps summarizes the taps of unknown cards at the devices of an organization since a time.
+func (w *Wrapper) GetUnknownCardTaps(ctx context.Context,
+	organizationID uuid.UUID,
+	since time.Time,
+) ([]UnknownCardTaps, error) {
+	var taps []UnknownCardTaps
+
+	err := w.db.SelectContext(ctx, &taps, `
+		SELECT
+			device.id AS device_id,
+			device.name AS device_name,
+			COUNT(*) AS taps,
+			COUNT(DISTINCT login_attempt.card_hash_prefix) AS distinct_cards,
+			MAX(login_attempt.created_at) AS last_tap_at
+		FROM login_attempt
+		INNER JOIN device ON device.id = login_attempt.device_id
+		WHERE device.organization_id = $1
+		  AND login_attempt.kind = 'student_card'
+		  AND login_attempt.failure_reason = $2
+		  AND login_attempt.created_at > $3
+		GROUP BY device.id, device.name
+		ORDER BY taps DESC
+	`, organizationID, LoginFailureReasonUnknownCard, since)
+
+	return taps, err
+}
+
+// GetOrganizationBranding retrieves the branding of an organization.
+// sql.ErrNoRows is returned if the organization has never configured its branding.
+func (w *Wrapper) GetOrganizationBranding(ctx context.Context, organizationID uuid.UUID) (OrganizationBranding, error) {
+	var branding OrganizationBranding
+
+	err := w.db.GetContext(ctx, &branding,
+		`SELECT * FROM "organization_branding" WHERE "organization_id" = $1`, organizationID)
+
+	return branding, err
+}
+
+func (w *Wrapper) GetBlob(ctx context.Context, key string) (Blob, error) {
+	var blob Blob
+
+	err := w.db.GetContext(ctx, &blob, `SELECT * FROM "blob" WHERE "key" = $1`, key)
+
+	return blob, err
+}
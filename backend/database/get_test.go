@@ -2,8 +2,12 @@ package database
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"testing"
+	"time"
 
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -35,6 +39,56 @@ func TestWrapper_GetDevice(t *testing.T) {
 	assert.Equal(t, got, want)
 }
 
+func TestWrapper_GetDeviceIDsBySelector(t *testing.T) {
+	f := newFixture(t)
+	defer f.Close()
+
+	org, err := f.dbw.CreateOrganization(context.Background(), "test", "example")
+	require.NoError(t, err)
+
+	group, err := f.dbw.CreateDeviceGroup(context.Background(), org.ID, "Library")
+	require.NoError(t, err)
+
+	inGroup, _, err := f.dbw.CreateDevice(context.Background(), org.ID, "in group")
+	require.NoError(t, err)
+	inGroup.GroupID = &group.ID
+	require.NoError(t, f.dbw.ReplaceDevice(context.Background(), inGroup))
+
+	tagged, _, err := f.dbw.CreateDevice(context.Background(), org.ID, "tagged")
+	require.NoError(t, err)
+	tagged.Tags = []string{"entrance"}
+	require.NoError(t, f.dbw.ReplaceDevice(context.Background(), tagged))
+
+	_, _, err = f.dbw.CreateDevice(context.Background(), org.ID, "other")
+	require.NoError(t, err)
+
+	ids, err := f.dbw.GetDeviceIDsBySelector(context.Background(), org.ID, DeviceSelector{
+		GroupIDs: []uuid.UUID{group.ID},
+		Tags:     []string{"entrance"},
+	})
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []uuid.UUID{inGroup.ID, tagged.ID}, ids)
+
+	require.NoError(t, f.dbw.ReplaceDeviceCapabilities(context.Background(), tagged.ID,
+		[]string{DeviceCapabilityScreenPower},
+	))
+	ids, err = f.dbw.GetDeviceIDsBySelector(context.Background(), org.ID, DeviceSelector{
+		GroupIDs:   []uuid.UUID{group.ID},
+		Tags:       []string{"entrance"},
+		Capability: DeviceCapabilityScreenPower,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []uuid.UUID{tagged.ID}, ids)
+
+	devs, err := f.dbw.GetDevices(context.Background(), GetDevicesOpts{
+		OrganizationID: org.ID,
+		Tags:           []string{"entrance"},
+	})
+	require.NoError(t, err)
+	require.Len(t, devs.Devices, 1)
+	assert.Equal(t, tagged.ID, devs.Devices[0].ID)
+}
+
 func TestWrapper_GetStudent(t *testing.T) {
 	f := newFixture(t)
 	defer f.Close()
@@ -51,3 +105,495 @@ func TestWrapper_GetStudent(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, got, want)
 }
+
+func TestWrapper_GetStudentByCard(t *testing.T) {
+	f := newFixture(t)
+	defer f.Close()
+
+	org, err := f.dbw.CreateOrganization(context.Background(), "test", "example")
+	require.NoError(t, err)
+
+	student, err := f.dbw.CreateStudent(context.Background(), Student{
+		OrganizationID: org.ID,
+	})
+	require.NoError(t, err)
+
+	uid := []byte{0x04, 0x12, 0x34, 0x56}
+	keys := CardKeys{Current: 1, Keys: map[int][]byte{1: []byte("key 1")}}
+	getKeys := func() (CardKeys, error) { return keys, nil }
+
+	err = f.dbw.ReplaceStudentCard(context.Background(), org.ID, student.ID, uid, getKeys)
+	require.NoError(t, err)
+
+	// After a rotation, the card can still be found and is rehashed with the new key.
+	keys.Current = 2
+	keys.Keys[2] = []byte("key 2")
+
+	got, card, err := f.dbw.GetStudentByCard(context.Background(), uid, org.ID, getKeys)
+	require.NoError(t, err)
+	assert.Equal(t, student.ID, got.ID)
+	assert.Equal(t, 2, card.KeyVersion)
+
+	versions, err := f.dbw.GetStudentCardKeyVersions(context.Background(), org.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []int{2}, versions)
+
+	_, _, err = f.dbw.GetStudentByCard(context.Background(), []byte{0x04}, org.ID, getKeys)
+	assert.Error(t, err)
+}
+
+func TestWrapper_CreateStudentCard_acrossKeyVersions(t *testing.T) {
+	f := newFixture(t)
+	defer f.Close()
+
+	ctx := context.Background()
+
+	org, err := f.dbw.CreateOrganization(ctx, "test", "example")
+	require.NoError(t, err)
+
+	student, err := f.dbw.CreateStudent(ctx, Student{OrganizationID: org.ID})
+	require.NoError(t, err)
+
+	uid := []byte{0x04, 0x12, 0x34, 0x56}
+	keys := CardKeys{Current: 1, Keys: map[int][]byte{1: []byte("key 1")}}
+	getKeys := func() (CardKeys, error) { return keys, nil }
+
+	_, err = f.dbw.CreateStudentCard(ctx, StudentCard{OrganizationID: org.ID, StudentID: student.ID}, uid, getKeys)
+	require.NoError(t, err)
+
+	// The card is hashed with key 1 and would get a different hash with key 2.
+	keys.Current = 2
+	keys.Keys[2] = []byte("key 2")
+
+	_, err = f.dbw.CreateStudentCard(ctx, StudentCard{OrganizationID: org.ID, StudentID: student.ID}, uid, getKeys)
+	assert.True(t, errors.Is(err, ErrConflict))
+
+	err = f.dbw.RotateStudentCardKeys(ctx, org.ID, func(inUse []int) error {
+		assert.Equal(t, []int{1}, inUse)
+		return nil
+	})
+	assert.NoError(t, err)
+}
+
+func TestWrapper_GetLegacyStudentCards(t *testing.T) {
+	f := newFixture(t)
+	defer f.Close()
+
+	ctx := context.Background()
+
+	org, err := f.dbw.CreateOrganization(ctx, "test", "example")
+	require.NoError(t, err)
+
+	student, err := f.dbw.CreateStudent(ctx, Student{OrganizationID: org.ID})
+	require.NoError(t, err)
+
+	uid := []byte{0x04, 0x12, 0x34, 0x56}
+	legacyHash, err := hashBytes(uid)
+	require.NoError(t, err)
+	_, err = f.dbw.db.ExecContext(ctx, `
+		INSERT INTO student_card (organization_id, student_id, id_hash, key_version)
+		VALUES ($1, $2, $3, $4)
+	`, org.ID, student.ID, legacyHash, legacyCardKeyVersion)
+	require.NoError(t, err)
+
+	cards, err := f.dbw.GetLegacyStudentCards(ctx, org.ID)
+	require.NoError(t, err)
+	require.Len(t, cards, 1)
+	assert.Equal(t, student.ID, cards[0].StudentID)
+
+	deleted, err := f.dbw.DeleteLegacyStudentCards(ctx, org.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), deleted)
+
+	cards, err = f.dbw.GetLegacyStudentCards(ctx, org.ID)
+	require.NoError(t, err)
+	assert.Empty(t, cards)
+}
+
+func TestWrapper_GetDevicesWithOutdatedNetworkingConfig(t *testing.T) {
+	f := newFixture(t)
+	defer f.Close()
+
+	ctx := context.Background()
+
+	org, err := f.dbw.CreateOrganization(ctx, "test", "example")
+	require.NoError(t, err)
+
+	group, err := f.dbw.CreateDeviceGroup(ctx, org.ID, "Library")
+	require.NoError(t, err)
+
+	inGroup, _, err := f.dbw.CreateDevice(ctx, org.ID, "in group")
+	require.NoError(t, err)
+	inGroup.GroupID = &group.ID
+	require.NoError(t, f.dbw.ReplaceDevice(ctx, inGroup))
+
+	other, _, err := f.dbw.CreateDevice(ctx, org.ID, "other")
+	require.NoError(t, err)
+
+	ns, err := f.dbw.CreateNetworkingService(ctx, org.ID, "library wifi")
+	require.NoError(t, err)
+	require.NoError(t, f.dbw.ReplaceNetworkingServiceAssignment(ctx, NetworkingServiceAssignment{
+		NetworkingServiceID: ns.ID,
+		GroupIDs:            []uuid.UUID{group.ID},
+	}))
+
+	services, err := f.dbw.GetNetworkingServicesForDevice(ctx, org.ID, inGroup.ID, inGroup.GroupID)
+	require.NoError(t, err)
+	require.Len(t, services, 1)
+	assert.Equal(t, ns.ID, services[0].ID)
+
+	otherServices, err := f.dbw.GetNetworkingServicesForDevice(ctx, org.ID, other.ID, nil)
+	require.NoError(t, err)
+	assert.Empty(t, otherServices)
+
+	// Devices which have never retrieved their config are outdated.
+	outdated, err := f.dbw.GetDevicesWithOutdatedNetworkingConfig(ctx, org.ID)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []uuid.UUID{inGroup.ID, other.ID}, outdated)
+
+	require.NoError(t, f.dbw.ReplaceDeviceNetworkingConfigHash(ctx, inGroup.ID, NetworkingConfigHash(services)))
+	require.NoError(t, f.dbw.ReplaceDeviceNetworkingConfigHash(ctx, other.ID, NetworkingConfigHash(otherServices)))
+
+	outdated, err = f.dbw.GetDevicesWithOutdatedNetworkingConfig(ctx, org.ID)
+	require.NoError(t, err)
+	assert.Empty(t, outdated)
+
+	// Changing the service only affects the devices it is assigned to.
+	require.NoError(t, f.dbw.ReplaceNetworkingServiceConfigHash(ctx, ns.ID, []byte("changed")))
+
+	outdated, err = f.dbw.GetDevicesWithOutdatedNetworkingConfig(ctx, org.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []uuid.UUID{inGroup.ID}, outdated)
+}
+
+func TestWrapper_GetLatestSoftwareRelease(t *testing.T) {
+	f := newFixture(t)
+	defer f.Close()
+
+	ctx := context.Background()
+
+	org, err := f.dbw.CreateOrganization(ctx, "test", "example")
+	require.NoError(t, err)
+
+	hash := make([]byte, 32)
+	for _, r := range []struct {
+		version string
+		channel UpdateChannel
+	}{
+		{"1.0.0", UpdateChannelStable},
+		{"1.1.0-beta.1", UpdateChannelBeta},
+	} {
+		_, err = f.dbw.CreateSoftwareRelease(ctx, SoftwareRelease{
+			OrganizationID: org.ID,
+			Component:      SoftwareComponentTimetermOS,
+			Version:        r.version,
+			Channel:        r.channel,
+			ArtifactURL:    "https://example.com/timeterm-os-" + r.version + ".img",
+			SHA256:         hash,
+		})
+		require.NoError(t, err)
+	}
+
+	release, err := f.dbw.GetLatestSoftwareRelease(ctx, org.ID, SoftwareComponentTimetermOS, UpdateChannelStable)
+	require.NoError(t, err)
+	assert.Equal(t, "1.0.0", release.Version)
+
+	release, err = f.dbw.GetLatestSoftwareRelease(ctx, org.ID, SoftwareComponentTimetermOS, UpdateChannelBeta)
+	require.NoError(t, err)
+	assert.Equal(t, "1.1.0-beta.1", release.Version)
+
+	_, err = f.dbw.GetLatestSoftwareRelease(ctx, org.ID, SoftwareComponentFrontendEmbedded, UpdateChannelBeta)
+	assert.True(t, errors.Is(err, sql.ErrNoRows))
+}
+
+func TestWrapper_GetDeviceSoftwareVersions(t *testing.T) {
+	f := newFixture(t)
+	defer f.Close()
+
+	ctx := context.Background()
+
+	org, err := f.dbw.CreateOrganization(ctx, "test", "example")
+	require.NoError(t, err)
+
+	group, err := f.dbw.CreateDeviceGroup(ctx, org.ID, "Library")
+	require.NoError(t, err)
+	assert.Equal(t, UpdateChannelStable, group.UpdateChannel)
+
+	dev, _, err := f.dbw.CreateDevice(ctx, org.ID, "example device")
+	require.NoError(t, err)
+	dev.GroupID = &group.ID
+	require.NoError(t, f.dbw.ReplaceDevice(ctx, dev))
+
+	for _, version := range []string{"1.0.0", "1.1.0"} {
+		_, err = f.dbw.CreateDeviceStatus(ctx, DeviceStatus{
+			DeviceID:  dev.ID,
+			OsVersion: sql.NullString{Valid: true, String: version},
+		})
+		require.NoError(t, err)
+	}
+	// Statuses without the version don't hide the last reported version.
+	_, err = f.dbw.CreateDeviceStatus(ctx, DeviceStatus{DeviceID: dev.ID})
+	require.NoError(t, err)
+
+	versions, err := f.dbw.GetDeviceSoftwareVersions(ctx, group.ID, SoftwareComponentTimetermOS)
+	require.NoError(t, err)
+	require.Len(t, versions, 1)
+	assert.Equal(t, "1.1.0", versions[0].Version.String)
+
+	versions, err = f.dbw.GetDeviceSoftwareVersions(ctx, group.ID, SoftwareComponentFrontendEmbedded)
+	require.NoError(t, err)
+	require.Len(t, versions, 1)
+	assert.False(t, versions[0].Version.Valid)
+}
+
+func TestWrapper_GetAnnouncementsForDevice(t *testing.T) {
+	f := newFixture(t)
+	defer f.Close()
+
+	ctx := context.Background()
+
+	org, err := f.dbw.CreateOrganization(ctx, "test", "example")
+	require.NoError(t, err)
+
+	group, err := f.dbw.CreateDeviceGroup(ctx, org.ID, "Library")
+	require.NoError(t, err)
+
+	dev, _, err := f.dbw.CreateDevice(ctx, org.ID, "example device")
+	require.NoError(t, err)
+	dev.GroupID = &group.ID
+	require.NoError(t, f.dbw.ReplaceDevice(ctx, dev))
+
+	other, _, err := f.dbw.CreateDevice(ctx, org.ID, "other device")
+	require.NoError(t, err)
+
+	now := time.Now()
+	all, err := f.dbw.CreateAnnouncement(ctx, Announcement{
+		OrganizationID: org.ID,
+		Text:           "Aula closed today",
+		Severity:       AnnouncementSeverityWarning,
+		StartsAt:       now.Add(-time.Hour),
+		EndsAt:         sql.NullTime{Valid: true, Time: now.Add(time.Hour)},
+		AllDevices:     true,
+	})
+	require.NoError(t, err)
+
+	library, err := f.dbw.CreateAnnouncement(ctx, Announcement{
+		OrganizationID: org.ID,
+		Text:           "Library opens later tomorrow",
+		Severity:       AnnouncementSeverityInfo,
+		StartsAt:       now.Add(24 * time.Hour),
+		GroupIDs:       []uuid.UUID{group.ID},
+	})
+	require.NoError(t, err)
+
+	_, err = f.dbw.CreateAnnouncement(ctx, Announcement{
+		OrganizationID: org.ID,
+		Text:           "Ended",
+		Severity:       AnnouncementSeverityInfo,
+		StartsAt:       now.Add(-2 * time.Hour),
+		EndsAt:         sql.NullTime{Valid: true, Time: now.Add(-time.Hour)},
+		AllDevices:     true,
+	})
+	require.NoError(t, err)
+
+	announcements, err := f.dbw.GetAnnouncementsForDevice(ctx, org.ID, dev.GroupID, now)
+	require.NoError(t, err)
+	require.Len(t, announcements, 2)
+	assert.Equal(t, all.ID, announcements[0].ID)
+	assert.Equal(t, library.ID, announcements[1].ID)
+	assert.Equal(t, []uuid.UUID{group.ID}, announcements[1].GroupIDs)
+
+	announcements, err = f.dbw.GetAnnouncementsForDevice(ctx, org.ID, other.GroupID, now)
+	require.NoError(t, err)
+	require.Len(t, announcements, 1)
+	assert.Equal(t, all.ID, announcements[0].ID)
+
+	announcement, err := f.dbw.GetAnnouncementForDevice(ctx, library.ID, org.ID, dev.GroupID, now)
+	require.NoError(t, err)
+	assert.Equal(t, library.ID, announcement.ID)
+
+	_, err = f.dbw.GetAnnouncementForDevice(ctx, library.ID, org.ID, other.GroupID, now)
+	assert.True(t, errors.Is(err, sql.ErrNoRows))
+
+	deviceIDs, err := f.dbw.GetAnnouncementDeviceIDs(ctx, library.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []uuid.UUID{dev.ID}, deviceIDs)
+
+	require.NoError(t, f.dbw.ReplaceAnnouncementImage(ctx, org.ID, AnnouncementImage{
+		AnnouncementID: library.ID,
+		ContentType:    "image/png",
+		Data:           []byte{0x89, 'P', 'N', 'G'},
+	}))
+	library, err = f.dbw.GetAnnouncement(ctx, library.ID)
+	require.NoError(t, err)
+	assert.True(t, library.HasImage)
+}
+
+func TestWrapper_GetDeviceConfigLayers(t *testing.T) {
+	f := newFixture(t)
+	defer f.Close()
+
+	ctx := context.Background()
+
+	org, err := f.dbw.CreateOrganization(ctx, "test", "example")
+	require.NoError(t, err)
+
+	orgProfile, err := f.dbw.CreateDeviceConfigProfile(ctx, DeviceConfigProfile{
+		OrganizationID: org.ID,
+		Name:           "Default",
+		Config:         []byte{1},
+	})
+	require.NoError(t, err)
+	org.DeviceConfigProfileID = &orgProfile.ID
+	require.NoError(t, f.dbw.ReplaceOrganization(ctx, org))
+
+	groupProfile, err := f.dbw.CreateDeviceConfigProfile(ctx, DeviceConfigProfile{
+		OrganizationID: org.ID,
+		Name:           "Library",
+		Config:         []byte{2},
+	})
+	require.NoError(t, err)
+
+	_, err = f.dbw.CreateDeviceConfigProfile(ctx, DeviceConfigProfile{OrganizationID: org.ID, Name: "Library", Config: []byte{4}})
+	assert.True(t, errors.Is(err, ErrConflict))
+
+	group, err := f.dbw.CreateDeviceGroup(ctx, org.ID, "Library")
+	require.NoError(t, err)
+	group.DeviceConfigProfileID = &groupProfile.ID
+	require.NoError(t, f.dbw.ReplaceDeviceGroup(ctx, group))
+
+	dev, _, err := f.dbw.CreateDevice(ctx, org.ID, "example device")
+	require.NoError(t, err)
+	dev.GroupID = &group.ID
+	require.NoError(t, f.dbw.ReplaceDevice(ctx, dev))
+
+	layers, err := f.dbw.GetDeviceConfigLayers(ctx, dev.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []byte{1}, layers.OrganizationConfig)
+	assert.Equal(t, []byte{2}, layers.GroupConfig)
+	assert.Nil(t, layers.DeviceConfig)
+	assert.Nil(t, layers.RetrievedConfigHash)
+
+	require.NoError(t, f.dbw.ReplaceDeviceConfigHash(ctx, dev.ID, []byte{3}))
+	require.NoError(t, f.dbw.DeleteDeviceConfigProfile(ctx, org.ID, groupProfile.ID))
+
+	all, err := f.dbw.GetOrganizationDeviceConfigLayers(ctx, org.ID)
+	require.NoError(t, err)
+	require.Len(t, all, 1)
+	assert.Equal(t, dev.ID, all[0].DeviceID)
+	assert.Nil(t, all[0].GroupConfig)
+	assert.Equal(t, []byte{3}, all[0].RetrievedConfigHash)
+}
+
+func TestWrapper_GetOrganizationBranding(t *testing.T) {
+	f := newFixture(t)
+	defer f.Close()
+
+	ctx := context.Background()
+
+	org, err := f.dbw.CreateOrganization(ctx, "test", "example")
+	require.NoError(t, err)
+
+	_, err = f.dbw.GetOrganizationBranding(ctx, org.ID)
+	assert.True(t, errors.Is(err, sql.ErrNoRows))
+
+	err = f.dbw.ReplaceOrganizationBrandingColors(ctx, OrganizationBranding{
+		OrganizationID: org.ID,
+		PrimaryColor:   sql.NullString{String: "#ff0000", Valid: true},
+	})
+	require.NoError(t, err)
+
+	oldKey, err := f.dbw.ReplaceOrganizationBrandingLogo(ctx, org.ID, "logo/a", "image/png", []byte{1})
+	require.NoError(t, err)
+	assert.False(t, oldKey.Valid)
+
+	oldKey, err = f.dbw.ReplaceOrganizationBrandingLogo(ctx, org.ID, "logo/b", "image/png", []byte{2})
+	require.NoError(t, err)
+	assert.Equal(t, "logo/a", oldKey.String)
+
+	branding, err := f.dbw.GetOrganizationBranding(ctx, org.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "#ff0000", branding.PrimaryColor.String)
+	assert.Equal(t, "logo/b", branding.LogoKey.String)
+	assert.Equal(t, []byte{2}, branding.LogoSHA256)
+
+	key, err := f.dbw.DeleteOrganizationBrandingLogo(ctx, org.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "logo/b", key)
+
+	_, err = f.dbw.DeleteOrganizationBrandingLogo(ctx, org.ID)
+	assert.True(t, errors.Is(err, sql.ErrNoRows))
+
+	branding, err = f.dbw.GetOrganizationBranding(ctx, org.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "#ff0000", branding.PrimaryColor.String)
+	assert.False(t, branding.LogoKey.Valid)
+}
+
+func TestWrapper_GetDevices(t *testing.T) {
+	f := newFixture(t)
+	defer f.Close()
+
+	ctx := context.Background()
+
+	org, err := f.dbw.CreateOrganization(ctx, "test", "example")
+	require.NoError(t, err)
+
+	a, _, err := f.dbw.CreateDevice(ctx, org.ID, "a")
+	require.NoError(t, err)
+	a.SerialNumber = sql.NullString{Valid: true, String: "SN-001"}
+	require.NoError(t, f.dbw.ReplaceDevice(ctx, a))
+
+	b, _, err := f.dbw.CreateDevice(ctx, org.ID, "b")
+	require.NoError(t, err)
+	b.SerialNumber = sql.NullString{Valid: true, String: "SN-002"}
+	b.MacAddress = sql.NullString{Valid: true, String: "00:11:22:33:44:55"}
+	require.NoError(t, f.dbw.ReplaceDevice(ctx, b))
+
+	c, _, err := f.dbw.CreateDevice(ctx, org.ID, "c")
+	require.NoError(t, err)
+
+	for dev, version := range map[uuid.UUID]string{a.ID: "1.0.0", b.ID: "2.0.0"} {
+		_, err = f.dbw.CreateDeviceStatus(ctx, DeviceStatus{
+			DeviceID:   dev,
+			AppVersion: sql.NullString{Valid: true, String: version},
+		})
+		require.NoError(t, err)
+	}
+
+	getDeviceIDs := func(opts GetDevicesOpts) []uuid.UUID {
+		opts.OrganizationID = org.ID
+		devs, err := f.dbw.GetDevices(ctx, opts)
+		require.NoError(t, err)
+
+		ids := make([]uuid.UUID, 0, len(devs.Devices))
+		for _, dev := range devs.Devices {
+			ids = append(ids, dev.ID)
+		}
+		return ids
+	}
+
+	search := "sn-00"
+	assert.Equal(t, []uuid.UUID{a.ID, b.ID}, getDeviceIDs(GetDevicesOpts{Search: &search}))
+	search = "44:55"
+	assert.Equal(t, []uuid.UUID{b.ID}, getDeviceIDs(GetDevicesOpts{Search: &search}))
+
+	version := "2.0.0"
+	assert.Equal(t, []uuid.UUID{b.ID}, getDeviceIDs(GetDevicesOpts{AppVersion: &version}))
+
+	online, offline := true, false
+	assert.Equal(t, []uuid.UUID{a.ID, b.ID}, getDeviceIDs(GetDevicesOpts{Online: &online}))
+	assert.Equal(t, []uuid.UUID{c.ID}, getDeviceIDs(GetDevicesOpts{Online: &offline}))
+
+	after := time.Now().Add(-time.Minute)
+	assert.Equal(t, []uuid.UUID{a.ID, b.ID}, getDeviceIDs(GetDevicesOpts{LastHeartbeatAfter: &after}))
+	assert.Empty(t, getDeviceIDs(GetDevicesOpts{LastHeartbeatBefore: &after}))
+
+	assert.Equal(t, []uuid.UUID{b.ID, a.ID, c.ID}, getDeviceIDs(GetDevicesOpts{
+		SortBy:         DeviceSortFieldSerialNumber,
+		SortDescending: true,
+	}))
+
+	_, err = f.dbw.GetDevices(ctx, GetDevicesOpts{OrganizationID: org.ID, SortBy: "id; DROP TABLE device"})
+	assert.Error(t, err)
+}
@@ -24,10 +24,50 @@ func (w *Wrapper) runJanitor(ctx context.Context) {
 		Delay: time.Minute,
 	}, newDeleteOldUserTokensJob(w, w.logger))
 
+	c.Schedule(&cron.ConstantDelaySchedule{
+		Delay: time.Minute,
+	}, newDeleteOldUserSessionsJob(w, w.logger))
+
+	c.Schedule(&cron.ConstantDelaySchedule{
+		Delay: time.Minute,
+	}, newDeleteOldUserAPIKeysJob(w, w.logger))
+
+	c.Schedule(&cron.ConstantDelaySchedule{
+		Delay: time.Minute,
+	}, newExpireStudentCardsJob(w, w.logger))
+
+	c.Schedule(&cron.ConstantDelaySchedule{
+		Delay: time.Minute,
+	}, newDeleteOldCardEnrollmentSessionsJob(w, w.logger))
+
+	c.Schedule(&cron.ConstantDelaySchedule{
+		Delay: time.Minute,
+	}, newDeleteOldLoginAttemptsJob(w, w.logger))
+
 	c.Schedule(&cron.ConstantDelaySchedule{
 		Delay: time.Minute,
 	}, newDeleteOldDeviceTokensJob(w, w.logger))
 
+	c.Schedule(&cron.ConstantDelaySchedule{
+		Delay: time.Minute,
+	}, newDeleteOldDeviceRegistrationTokensJob(w, w.logger))
+
+	c.Schedule(&cron.ConstantDelaySchedule{
+		Delay: time.Minute,
+	}, newExpireDeviceCommandsJob(w, w.logger))
+
+	c.Schedule(&cron.ConstantDelaySchedule{
+		Delay: time.Minute,
+	}, newDeleteOldDeviceCommandsJob(w, w.logger))
+
+	c.Schedule(&cron.ConstantDelaySchedule{
+		Delay: time.Minute,
+	}, newDeleteOldDeviceLogBundlesJob(w, w.logger))
+
+	c.Schedule(&cron.ConstantDelaySchedule{
+		Delay: time.Minute,
+	}, newDeleteOldDeviceScreenshotsJob(w, w.logger))
+
 	go c.Run()
 
 	<-ctx.Done()
@@ -73,6 +113,51 @@ func newDeleteOldUserTokensJob(dbw *Wrapper, logger logr.Logger) cron.Job {
 	})
 }
 
+func newDeleteOldUserSessionsJob(dbw *Wrapper, logger logr.Logger) cron.Job {
+	return newJanitorJob(dbw, logger, func(ctx context.Context, j janitorJob) {
+		err := j.dbw.DeleteOldUserSessions(ctx)
+		if err != nil {
+			j.logger.Error(err, "could not delete old user sessions")
+		}
+	})
+}
+
+func newDeleteOldUserAPIKeysJob(dbw *Wrapper, logger logr.Logger) cron.Job {
+	return newJanitorJob(dbw, logger, func(ctx context.Context, j janitorJob) {
+		err := j.dbw.DeleteOldUserAPIKeys(ctx)
+		if err != nil {
+			j.logger.Error(err, "could not delete old user API keys")
+		}
+	})
+}
+
+func newExpireStudentCardsJob(dbw *Wrapper, logger logr.Logger) cron.Job {
+	return newJanitorJob(dbw, logger, func(ctx context.Context, j janitorJob) {
+		err := j.dbw.ExpireStudentCards(ctx)
+		if err != nil {
+			j.logger.Error(err, "could not expire student cards")
+		}
+	})
+}
+
+func newDeleteOldCardEnrollmentSessionsJob(dbw *Wrapper, logger logr.Logger) cron.Job {
+	return newJanitorJob(dbw, logger, func(ctx context.Context, j janitorJob) {
+		err := j.dbw.DeleteOldCardEnrollmentSessions(ctx)
+		if err != nil {
+			j.logger.Error(err, "could not delete old card enrollment sessions")
+		}
+	})
+}
+
+func newDeleteOldLoginAttemptsJob(dbw *Wrapper, logger logr.Logger) cron.Job {
+	return newJanitorJob(dbw, logger, func(ctx context.Context, j janitorJob) {
+		err := j.dbw.DeleteOldLoginAttempts(ctx)
+		if err != nil {
+			j.logger.Error(err, "could not delete old login attempts")
+		}
+	})
+}
+
 func newDeleteOldOAuth2StatesJob(dbw *Wrapper, logger logr.Logger) cron.Job {
 	return newJanitorJob(dbw, logger, func(ctx context.Context, j janitorJob) {
 		err := j.dbw.DeleteOldOAuth2States(ctx)
@@ -90,3 +175,48 @@ func newDeleteOldDeviceTokensJob(dbw *Wrapper, logger logr.Logger) cron.Job {
 		}
 	})
 }
+
+func newDeleteOldDeviceRegistrationTokensJob(dbw *Wrapper, logger logr.Logger) cron.Job {
+	return newJanitorJob(dbw, logger, func(ctx context.Context, j janitorJob) {
+		err := j.dbw.DeleteOldDeviceRegistrationTokens(ctx)
+		if err != nil {
+			j.logger.Error(err, "could not delete old device registration tokens")
+		}
+	})
+}
+
+func newExpireDeviceCommandsJob(dbw *Wrapper, logger logr.Logger) cron.Job {
+	return newJanitorJob(dbw, logger, func(ctx context.Context, j janitorJob) {
+		err := j.dbw.ExpireDeviceCommands(ctx)
+		if err != nil {
+			j.logger.Error(err, "could not expire device commands")
+		}
+	})
+}
+
+func newDeleteOldDeviceCommandsJob(dbw *Wrapper, logger logr.Logger) cron.Job {
+	return newJanitorJob(dbw, logger, func(ctx context.Context, j janitorJob) {
+		err := j.dbw.DeleteOldDeviceCommands(ctx)
+		if err != nil {
+			j.logger.Error(err, "could not delete old device commands")
+		}
+	})
+}
+
+func newDeleteOldDeviceLogBundlesJob(dbw *Wrapper, logger logr.Logger) cron.Job {
+	return newJanitorJob(dbw, logger, func(ctx context.Context, j janitorJob) {
+		err := j.dbw.DeleteOldDeviceLogBundles(ctx)
+		if err != nil {
+			j.logger.Error(err, "could not delete old device log bundles")
+		}
+	})
+}
+
+func newDeleteOldDeviceScreenshotsJob(dbw *Wrapper, logger logr.Logger) cron.Job {
+	return newJanitorJob(dbw, logger, func(ctx context.Context, j janitorJob) {
+		err := j.dbw.DeleteOldDeviceScreenshots(ctx)
+		if err != nil {
+			j.logger.Error(err, "could not delete old device screenshots")
+		}
+	})
+}
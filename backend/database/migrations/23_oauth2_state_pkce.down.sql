@@ -0,0 +1,7 @@
+BEGIN;
+
+ALTER TABLE "oauth2_state"
+    DROP COLUMN "code_verifier",
+    DROP COLUMN "nonce";
+
+COMMIT;
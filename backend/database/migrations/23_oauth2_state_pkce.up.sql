@@ -0,0 +1,11 @@
+BEGIN;
+
+-- States are short-lived, so any state created before PKCE and nonces were
+-- introduced can safely be thrown away.
+DELETE FROM "oauth2_state";
+
+ALTER TABLE "oauth2_state"
+    ADD COLUMN "code_verifier" text NOT NULL,
+    ADD COLUMN "nonce"         text NOT NULL;
+
+COMMIT;
@@ -0,0 +1,9 @@
+BEGIN;
+
+ALTER TABLE "user_token"
+    DROP COLUMN "session_id",
+    ALTER COLUMN "expires_at" SET DEFAULT now() + '24 hours';
+
+DROP TABLE "user_session";
+
+COMMIT;
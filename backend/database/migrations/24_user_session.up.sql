@@ -0,0 +1,28 @@
+BEGIN;
+
+CREATE TABLE "user_session"
+(
+    "id"                          uuid PRIMARY KEY     DEFAULT uuid_generate_v4(),
+    "user_id"                     uuid        NOT NULL,
+    "refresh_token_hash"          bytea       NOT NULL UNIQUE,
+    "previous_refresh_token_hash" bytea UNIQUE,
+    "created_at"                  timestamptz NOT NULL DEFAULT now(),
+    "last_used_at"                timestamptz NOT NULL DEFAULT now(),
+    "expires_at"                  timestamptz NOT NULL,
+    "user_agent"                  text        NOT NULL,
+    "client_ip"                   text        NOT NULL,
+
+    FOREIGN KEY ("user_id") REFERENCES "user" ("id") ON DELETE CASCADE
+);
+
+CREATE INDEX ON "user_session" ("user_id");
+
+-- Tokens without a session can not be refreshed or revoked, so users will have to log in again.
+DELETE FROM "user_token";
+
+ALTER TABLE "user_token"
+    ADD COLUMN "session_id" uuid NOT NULL,
+    ADD FOREIGN KEY ("session_id") REFERENCES "user_session" ("id") ON DELETE CASCADE,
+    ALTER COLUMN "expires_at" SET DEFAULT now() + '15 minutes';
+
+COMMIT;
@@ -0,0 +1,6 @@
+BEGIN;
+
+DROP TABLE "user_api_key";
+DROP TYPE user_api_key_scope;
+
+COMMIT;
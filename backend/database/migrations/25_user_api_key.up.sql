@@ -0,0 +1,21 @@
+BEGIN;
+
+CREATE TYPE user_api_key_scope AS ENUM ('read', 'write');
+
+CREATE TABLE "user_api_key"
+(
+    "id"           uuid PRIMARY KEY              DEFAULT uuid_generate_v4(),
+    "user_id"      uuid                 NOT NULL,
+    "name"         text                 NOT NULL,
+    "key_hash"     bytea                NOT NULL UNIQUE,
+    "scopes"       user_api_key_scope[] NOT NULL,
+    "created_at"   timestamptz          NOT NULL DEFAULT now(),
+    "last_used_at" timestamptz,
+    "expires_at"   timestamptz,
+
+    FOREIGN KEY ("user_id") REFERENCES "user" ("id") ON DELETE CASCADE
+);
+
+CREATE INDEX ON "user_api_key" ("user_id");
+
+COMMIT;
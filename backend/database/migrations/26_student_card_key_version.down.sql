@@ -0,0 +1,9 @@
+BEGIN;
+
+-- Keyed hashes can not be converted back, so cards which have been hashed with a key are lost.
+DELETE FROM student_card WHERE key_version <> 0;
+
+ALTER TABLE student_card
+    DROP COLUMN key_version;
+
+COMMIT;
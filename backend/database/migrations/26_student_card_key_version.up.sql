@@ -0,0 +1,11 @@
+BEGIN;
+
+-- Key version 0 means that the card UID has been hashed without a key.
+-- These hashes are replaced with keyed hashes when the card is used.
+ALTER TABLE student_card
+    ADD COLUMN key_version int NOT NULL DEFAULT 0;
+
+ALTER TABLE student_card
+    ALTER COLUMN key_version DROP DEFAULT;
+
+COMMIT;
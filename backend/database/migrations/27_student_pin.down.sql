@@ -0,0 +1,8 @@
+BEGIN;
+
+DROP TABLE student_pin;
+
+ALTER TABLE organization
+    DROP COLUMN require_student_pin;
+
+COMMIT;
@@ -0,0 +1,16 @@
+BEGIN;
+
+ALTER TABLE organization
+    ADD COLUMN require_student_pin bool NOT NULL DEFAULT false;
+
+CREATE TABLE student_pin
+(
+    student_id      uuid PRIMARY KEY,
+    hash            bytea NOT NULL,
+    failed_attempts int   NOT NULL DEFAULT 0,
+    locked_until    timestamptz,
+
+    FOREIGN KEY (student_id) REFERENCES student (id) ON DELETE CASCADE
+);
+
+COMMIT;
@@ -0,0 +1,24 @@
+BEGIN;
+
+-- Students could only have one card before, so keep the most recently created active card.
+DELETE FROM student_card
+WHERE state <> 'active'
+   OR id NOT IN (
+       SELECT DISTINCT ON (student_id) id FROM student_card
+       WHERE state = 'active'
+       ORDER BY student_id, created_at DESC
+   );
+
+DROP INDEX student_card_student_id_idx;
+
+ALTER TABLE student_card
+    DROP COLUMN id,
+    DROP COLUMN state,
+    DROP COLUMN label,
+    DROP COLUMN created_at,
+    DROP COLUMN valid_from,
+    DROP COLUMN valid_until;
+
+DROP TYPE student_card_state;
+
+COMMIT;
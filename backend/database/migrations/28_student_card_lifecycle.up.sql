@@ -0,0 +1,15 @@
+BEGIN;
+
+CREATE TYPE student_card_state AS ENUM ('active', 'blocked', 'lost', 'expired');
+
+ALTER TABLE student_card
+    ADD COLUMN id          uuid               NOT NULL UNIQUE DEFAULT uuid_generate_v4(),
+    ADD COLUMN state       student_card_state NOT NULL        DEFAULT 'active',
+    ADD COLUMN label       text               NOT NULL        DEFAULT '',
+    ADD COLUMN created_at  timestamptz        NOT NULL        DEFAULT now(),
+    ADD COLUMN valid_from  timestamptz        NOT NULL        DEFAULT now(),
+    ADD COLUMN valid_until timestamptz;
+
+CREATE INDEX ON student_card (student_id);
+
+COMMIT;
@@ -0,0 +1,6 @@
+BEGIN;
+
+DROP TABLE card_enrollment_session;
+DROP TYPE card_enrollment_session_state;
+
+COMMIT;
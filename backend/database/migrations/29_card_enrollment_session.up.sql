@@ -0,0 +1,27 @@
+BEGIN;
+
+CREATE TYPE card_enrollment_session_state AS ENUM ('pending', 'completed', 'failed', 'cancelled');
+
+CREATE TABLE card_enrollment_session
+(
+    id              uuid PRIMARY KEY                       DEFAULT uuid_generate_v4(),
+    organization_id uuid                          NOT NULL,
+    student_id      uuid                          NOT NULL,
+    device_id       uuid                          NOT NULL,
+    label           text                          NOT NULL DEFAULT '',
+    valid_until     timestamptz,
+    state           card_enrollment_session_state NOT NULL DEFAULT 'pending',
+    error           text                          NOT NULL DEFAULT '',
+    card_id         uuid,
+    created_at      timestamptz                   NOT NULL DEFAULT now(),
+    expires_at      timestamptz                   NOT NULL,
+
+    FOREIGN KEY (organization_id) REFERENCES organization (id) ON DELETE CASCADE,
+    FOREIGN KEY (student_id) REFERENCES student (id) ON DELETE CASCADE,
+    FOREIGN KEY (device_id) REFERENCES device (id) ON DELETE CASCADE,
+    FOREIGN KEY (card_id) REFERENCES student_card (id) ON DELETE SET NULL
+);
+
+CREATE INDEX ON card_enrollment_session (device_id) WHERE state = 'pending';
+
+COMMIT;
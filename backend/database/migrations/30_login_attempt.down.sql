@@ -0,0 +1,6 @@
+BEGIN;
+
+DROP TABLE login_attempt;
+DROP TYPE login_attempt_kind;
+
+COMMIT;
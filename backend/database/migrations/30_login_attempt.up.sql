@@ -0,0 +1,25 @@
+BEGIN;
+
+CREATE TYPE login_attempt_kind AS ENUM ('device', 'device_registration', 'student_card');
+
+CREATE TABLE login_attempt
+(
+    id               bigserial PRIMARY KEY,
+    kind             login_attempt_kind NOT NULL,
+    success          bool               NOT NULL,
+    failure_reason   text               NOT NULL DEFAULT '',
+    organization_id  uuid,
+    device_id        uuid,
+    card_hash_prefix bytea,
+    client_ip        text               NOT NULL,
+    created_at       timestamptz        NOT NULL DEFAULT now(),
+
+    FOREIGN KEY (organization_id) REFERENCES organization (id) ON DELETE CASCADE,
+    FOREIGN KEY (device_id) REFERENCES device (id) ON DELETE CASCADE
+);
+
+CREATE INDEX ON login_attempt (client_ip, created_at) WHERE NOT success;
+CREATE INDEX ON login_attempt (device_id, created_at) WHERE NOT success;
+CREATE INDEX ON login_attempt (created_at);
+
+COMMIT;
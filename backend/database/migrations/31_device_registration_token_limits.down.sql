@@ -0,0 +1,13 @@
+BEGIN;
+
+DROP INDEX "device_registration_token_organization_id_idx";
+
+ALTER TABLE "device_registration_token"
+    DROP COLUMN "id",
+    DROP COLUMN "max_uses",
+    DROP COLUMN "uses",
+    DROP COLUMN "device_name",
+    ALTER COLUMN "organization_id" DROP NOT NULL,
+    ALTER COLUMN "expires_at" DROP NOT NULL;
+
+COMMIT;
@@ -0,0 +1,17 @@
+BEGIN;
+
+-- Tokens were minted without an expiry time, so they can't be trusted any longer.
+DELETE FROM "device_registration_token"
+WHERE "expires_at" IS NULL OR "organization_id" IS NULL;
+
+ALTER TABLE "device_registration_token"
+    ADD COLUMN "id"          uuid    NOT NULL UNIQUE DEFAULT uuid_generate_v4(),
+    ADD COLUMN "max_uses"    integer NOT NULL DEFAULT 1 CHECK ("max_uses" > 0),
+    ADD COLUMN "uses"        integer NOT NULL DEFAULT 0,
+    ADD COLUMN "device_name" text,
+    ALTER COLUMN "organization_id" SET NOT NULL,
+    ALTER COLUMN "expires_at" SET NOT NULL;
+
+CREATE INDEX ON "device_registration_token" ("organization_id");
+
+COMMIT;
@@ -0,0 +1,12 @@
+BEGIN;
+
+ALTER TABLE "device_registration_token"
+    DROP COLUMN "device_group_id";
+
+ALTER TABLE "device"
+    DROP COLUMN "group_id",
+    DROP COLUMN "tags";
+
+DROP TABLE "device_group";
+
+COMMIT;
@@ -0,0 +1,26 @@
+BEGIN;
+
+CREATE TABLE "device_group"
+(
+    "id"              uuid PRIMARY KEY     DEFAULT uuid_generate_v4(),
+    "organization_id" uuid        NOT NULL,
+    "name"            text        NOT NULL,
+    "created_at"      timestamptz NOT NULL DEFAULT now(),
+
+    UNIQUE ("organization_id", "name"),
+    FOREIGN KEY ("organization_id") REFERENCES "organization" ("id") ON DELETE CASCADE
+);
+
+ALTER TABLE "device"
+    ADD COLUMN "group_id" uuid,
+    ADD COLUMN "tags"     text[] NOT NULL DEFAULT '{}',
+    ADD FOREIGN KEY ("group_id") REFERENCES "device_group" ("id") ON DELETE SET NULL;
+
+CREATE INDEX ON "device" ("group_id");
+CREATE INDEX ON "device" USING gin ("tags");
+
+ALTER TABLE "device_registration_token"
+    ADD COLUMN "device_group_id" uuid,
+    ADD FOREIGN KEY ("device_group_id") REFERENCES "device_group" ("id") ON DELETE SET NULL;
+
+COMMIT;
@@ -0,0 +1,11 @@
+BEGIN;
+
+DROP TABLE "device_networking_config";
+DROP TABLE "networking_service_device_group";
+DROP TABLE "networking_service_device";
+
+ALTER TABLE "networking_service"
+    DROP COLUMN "all_devices",
+    DROP COLUMN "config_hash";
+
+COMMIT;
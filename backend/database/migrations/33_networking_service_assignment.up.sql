@@ -0,0 +1,39 @@
+BEGIN;
+
+-- Existing networking services stay assigned to all devices in their organization.
+ALTER TABLE "networking_service"
+    ADD COLUMN "all_devices" boolean NOT NULL DEFAULT true,
+    ADD COLUMN "config_hash" bytea;
+
+CREATE TABLE "networking_service_device"
+(
+    "networking_service_id" uuid NOT NULL,
+    "device_id"             uuid NOT NULL,
+
+    PRIMARY KEY ("networking_service_id", "device_id"),
+    FOREIGN KEY ("networking_service_id") REFERENCES "networking_service" ("id") ON DELETE CASCADE,
+    FOREIGN KEY ("device_id") REFERENCES "device" ("id") ON DELETE CASCADE
+);
+
+CREATE TABLE "networking_service_device_group"
+(
+    "networking_service_id" uuid NOT NULL,
+    "device_group_id"       uuid NOT NULL,
+
+    PRIMARY KEY ("networking_service_id", "device_group_id"),
+    FOREIGN KEY ("networking_service_id") REFERENCES "networking_service" ("id") ON DELETE CASCADE,
+    FOREIGN KEY ("device_group_id") REFERENCES "device_group" ("id") ON DELETE CASCADE
+);
+
+-- The hash of the networking config that a device has retrieved last,
+-- so devices are only asked to retrieve their config again when it has changed.
+CREATE TABLE "device_networking_config"
+(
+    "device_id"    uuid PRIMARY KEY,
+    "config_hash"  bytea       NOT NULL,
+    "retrieved_at" timestamptz NOT NULL DEFAULT now(),
+
+    FOREIGN KEY ("device_id") REFERENCES "device" ("id") ON DELETE CASCADE
+);
+
+COMMIT;
@@ -0,0 +1,25 @@
+BEGIN;
+
+-- The status that devices report with their heartbeats.
+-- Only the last few reports of every device are kept (see DeviceStatusHistoryLength).
+CREATE TABLE "device_status"
+(
+    "id"                 uuid PRIMARY KEY     DEFAULT uuid_generate_v4(),
+    "device_id"          uuid        NOT NULL,
+    "reported_at"        timestamptz NOT NULL DEFAULT clock_timestamp(),
+    "os_version"         text,
+    "app_version"        text,
+    "uptime_seconds"     bigint,
+    "ip_address"         text,
+    "ssid"               text,
+    "signal_strength"    integer,
+    "card_reader_status" text,
+    "free_disk_bytes"    bigint,
+    "last_error"         text,
+
+    FOREIGN KEY ("device_id") REFERENCES "device" ("id") ON DELETE CASCADE
+);
+
+CREATE INDEX ON "device_status" ("device_id", "reported_at" DESC);
+
+COMMIT;
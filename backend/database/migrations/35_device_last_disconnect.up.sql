@@ -0,0 +1,8 @@
+BEGIN;
+
+-- Set when the NATS server reports that the device has disconnected,
+-- so the device is shown as offline without waiting for its heartbeat to expire.
+ALTER TABLE "device"
+    ADD COLUMN "last_disconnect" timestamptz;
+
+COMMIT;
@@ -0,0 +1,11 @@
+BEGIN;
+
+ALTER TABLE "device"
+    DROP COLUMN "offline_alerted_at";
+
+ALTER TABLE "organization"
+    DROP COLUMN "device_offline_alert_threshold_seconds",
+    DROP COLUMN "device_alert_webhook_url",
+    DROP COLUMN "device_alert_email";
+
+COMMIT;
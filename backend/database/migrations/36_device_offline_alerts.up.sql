@@ -0,0 +1,15 @@
+BEGIN;
+
+-- Devices which have been offline for longer than the threshold are reported to administrators.
+-- Alerting is disabled for organizations without a threshold.
+ALTER TABLE "organization"
+    ADD COLUMN "device_offline_alert_threshold_seconds" integer DEFAULT 3600
+        CHECK ("device_offline_alert_threshold_seconds" > 0),
+    ADD COLUMN "device_alert_webhook_url" text,
+    ADD COLUMN "device_alert_email" text;
+
+-- Set when an alert has been sent that the device is offline, and cleared when it has recovered.
+ALTER TABLE "device"
+    ADD COLUMN "offline_alerted_at" timestamptz;
+
+COMMIT;
@@ -0,0 +1,6 @@
+BEGIN;
+
+DROP TABLE "device_command";
+DROP TYPE "device_command_status";
+
+COMMIT;
@@ -0,0 +1,26 @@
+BEGIN;
+
+CREATE TYPE "device_command_status" AS ENUM ('queued', 'delivered', 'acked', 'succeeded', 'failed');
+
+CREATE TABLE "device_command"
+(
+    "id"                 uuid PRIMARY KEY                 DEFAULT uuid_generate_v4(),
+    "device_id"          uuid                    NOT NULL,
+    "type"               text                    NOT NULL,
+    "status"             "device_command_status" NOT NULL DEFAULT 'queued',
+    "created_by_user_id" uuid,
+    "created_at"         timestamptz             NOT NULL DEFAULT now(),
+    "expires_at"         timestamptz             NOT NULL,
+    "delivered_at"       timestamptz,
+    "acked_at"           timestamptz,
+    "completed_at"       timestamptz,
+    "error"              text,
+    "output"             text,
+
+    FOREIGN KEY ("device_id") REFERENCES "device" ("id") ON DELETE CASCADE,
+    FOREIGN KEY ("created_by_user_id") REFERENCES "user" ("id") ON DELETE SET NULL
+);
+
+CREATE INDEX ON "device_command" ("device_id", "created_at" DESC);
+
+COMMIT;
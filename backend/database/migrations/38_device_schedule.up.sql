@@ -0,0 +1,23 @@
+BEGIN;
+
+CREATE TABLE "device_schedule"
+(
+    "id"              uuid PRIMARY KEY     DEFAULT uuid_generate_v4(),
+    "organization_id" uuid        NOT NULL,
+    "device_group_id" uuid        NOT NULL,
+    -- The type of the command which is sent to the devices in the group.
+    "action"          text        NOT NULL,
+    "cron_expression" text        NOT NULL,
+    "timezone"        text        NOT NULL DEFAULT 'UTC',
+    "enabled"         bool        NOT NULL DEFAULT true,
+    "created_at"      timestamptz NOT NULL DEFAULT now(),
+    "updated_at"      timestamptz NOT NULL DEFAULT now(),
+    "last_run_at"     timestamptz,
+
+    FOREIGN KEY ("organization_id") REFERENCES "organization" ("id") ON DELETE CASCADE,
+    FOREIGN KEY ("device_group_id") REFERENCES "device_group" ("id") ON DELETE CASCADE
+);
+
+CREATE INDEX ON "device_schedule" ("organization_id");
+
+COMMIT;
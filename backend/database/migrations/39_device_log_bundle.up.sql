@@ -0,0 +1,21 @@
+BEGIN;
+
+CREATE TABLE "device_log_bundle"
+(
+    "id"         uuid PRIMARY KEY     DEFAULT uuid_generate_v4(),
+    "device_id"  uuid        NOT NULL,
+    "command_id" uuid UNIQUE,
+    "created_at" timestamptz NOT NULL DEFAULT now(),
+    -- Size of the (compressed, unencrypted) bundle in bytes.
+    "size"       bigint      NOT NULL,
+    -- The bundle is encrypted with the logs key of the organization.
+    "nonce"      bytea       NOT NULL,
+    "data"       bytea       NOT NULL,
+
+    FOREIGN KEY ("device_id") REFERENCES "device" ("id") ON DELETE CASCADE,
+    FOREIGN KEY ("command_id") REFERENCES "device_command" ("id") ON DELETE SET NULL
+);
+
+CREATE INDEX ON "device_log_bundle" ("device_id", "created_at" DESC);
+
+COMMIT;
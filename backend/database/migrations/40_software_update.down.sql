@@ -0,0 +1,9 @@
+BEGIN;
+
+DROP TABLE "update_rollout";
+DROP TABLE "software_release";
+
+ALTER TABLE "device_group"
+    DROP COLUMN "update_channel";
+
+COMMIT;
@@ -0,0 +1,44 @@
+BEGIN;
+
+ALTER TABLE "device_group"
+    ADD COLUMN "update_channel" text NOT NULL DEFAULT 'stable' CHECK ("update_channel" IN ('stable', 'beta'));
+
+CREATE TABLE "software_release"
+(
+    "id"              uuid PRIMARY KEY     DEFAULT uuid_generate_v4(),
+    "organization_id" uuid        NOT NULL,
+    "component"       text        NOT NULL CHECK ("component" IN ('timeterm_os', 'frontend_embedded')),
+    "version"         text        NOT NULL,
+    "channel"         text        NOT NULL CHECK ("channel" IN ('stable', 'beta')),
+    "artifact_url"    text        NOT NULL,
+    "sha256"          bytea       NOT NULL CHECK (length("sha256") = 32),
+    "created_at"      timestamptz NOT NULL DEFAULT now(),
+
+    UNIQUE ("organization_id", "component", "version"),
+    FOREIGN KEY ("organization_id") REFERENCES "organization" ("id") ON DELETE CASCADE
+);
+
+CREATE INDEX ON "software_release" ("organization_id", "component", "created_at" DESC);
+
+-- A rollout pins the devices in a group to a release, instead of the latest release in the channel of the group.
+-- Only a percentage of the devices in the group gets the release, the others stay at their current version.
+CREATE TABLE "update_rollout"
+(
+    "id"              uuid PRIMARY KEY     DEFAULT uuid_generate_v4(),
+    "organization_id" uuid        NOT NULL,
+    "device_group_id" uuid        NOT NULL,
+    "component"       text        NOT NULL,
+    "release_id"      uuid        NOT NULL,
+    "percentage"      integer     NOT NULL CHECK ("percentage" BETWEEN 0 AND 100),
+    "created_at"      timestamptz NOT NULL DEFAULT now(),
+    "updated_at"      timestamptz NOT NULL DEFAULT now(),
+
+    UNIQUE ("device_group_id", "component"),
+    FOREIGN KEY ("organization_id") REFERENCES "organization" ("id") ON DELETE CASCADE,
+    FOREIGN KEY ("device_group_id") REFERENCES "device_group" ("id") ON DELETE CASCADE,
+    FOREIGN KEY ("release_id") REFERENCES "software_release" ("id") ON DELETE CASCADE
+);
+
+CREATE INDEX ON "update_rollout" ("organization_id");
+
+COMMIT;
@@ -0,0 +1,7 @@
+BEGIN;
+
+DROP TABLE "announcement_image";
+DROP TABLE "announcement_device_group";
+DROP TABLE "announcement";
+
+COMMIT;
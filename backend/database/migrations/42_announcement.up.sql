@@ -0,0 +1,45 @@
+BEGIN;
+
+CREATE TABLE "announcement"
+(
+    "id"                 uuid PRIMARY KEY     DEFAULT uuid_generate_v4(),
+    "organization_id"    uuid        NOT NULL,
+    "text"               text        NOT NULL,
+    "severity"           text        NOT NULL,
+    "starts_at"          timestamptz NOT NULL,
+    -- The announcement is shown until it is deleted if it has no end.
+    "ends_at"            timestamptz,
+    -- Otherwise, the announcement is only shown on the devices in the groups in announcement_device_group.
+    "all_devices"        bool        NOT NULL DEFAULT false,
+    "created_by_user_id" uuid,
+    "created_at"         timestamptz NOT NULL DEFAULT now(),
+    "updated_at"         timestamptz NOT NULL DEFAULT now(),
+
+    FOREIGN KEY ("organization_id") REFERENCES "organization" ("id") ON DELETE CASCADE,
+    FOREIGN KEY ("created_by_user_id") REFERENCES "user" ("id") ON DELETE SET NULL,
+    CHECK ("severity" IN ('info', 'warning', 'critical')),
+    CHECK ("ends_at" IS NULL OR "ends_at" > "starts_at")
+);
+
+CREATE INDEX ON "announcement" ("organization_id", "starts_at");
+
+CREATE TABLE "announcement_device_group"
+(
+    "announcement_id" uuid NOT NULL,
+    "device_group_id" uuid NOT NULL,
+
+    PRIMARY KEY ("announcement_id", "device_group_id"),
+    FOREIGN KEY ("announcement_id") REFERENCES "announcement" ("id") ON DELETE CASCADE,
+    FOREIGN KEY ("device_group_id") REFERENCES "device_group" ("id") ON DELETE CASCADE
+);
+
+CREATE TABLE "announcement_image"
+(
+    "announcement_id" uuid PRIMARY KEY,
+    "content_type"    text  NOT NULL,
+    "data"            bytea NOT NULL,
+
+    FOREIGN KEY ("announcement_id") REFERENCES "announcement" ("id") ON DELETE CASCADE
+);
+
+COMMIT;
@@ -0,0 +1,14 @@
+BEGIN;
+
+DROP TABLE "device_config_retrieval";
+
+ALTER TABLE "device"
+    DROP COLUMN "device_config_profile_id";
+ALTER TABLE "device_group"
+    DROP COLUMN "device_config_profile_id";
+ALTER TABLE "organization"
+    DROP COLUMN "device_config_profile_id";
+
+DROP TABLE "device_config_profile";
+
+COMMIT;
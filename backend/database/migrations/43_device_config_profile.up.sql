@@ -0,0 +1,37 @@
+BEGIN;
+
+CREATE TABLE "device_config_profile"
+(
+    "id"              uuid PRIMARY KEY     DEFAULT uuid_generate_v4(),
+    "organization_id" uuid        NOT NULL,
+    "name"            text        NOT NULL,
+    -- Serialized devcfg.DeviceConfig protobuf message.
+    "config"          bytea       NOT NULL,
+    "created_at"      timestamptz NOT NULL DEFAULT now(),
+    "updated_at"      timestamptz NOT NULL DEFAULT now(),
+
+    UNIQUE ("organization_id", "name"),
+    FOREIGN KEY ("organization_id") REFERENCES "organization" ("id") ON DELETE CASCADE
+);
+
+-- Profiles are inherited: the profile of a device overrides that of its group,
+-- which overrides that of the organization.
+ALTER TABLE "organization"
+    ADD COLUMN "device_config_profile_id" uuid REFERENCES "device_config_profile" ("id") ON DELETE SET NULL;
+ALTER TABLE "device_group"
+    ADD COLUMN "device_config_profile_id" uuid REFERENCES "device_config_profile" ("id") ON DELETE SET NULL;
+ALTER TABLE "device"
+    ADD COLUMN "device_config_profile_id" uuid REFERENCES "device_config_profile" ("id") ON DELETE SET NULL;
+
+-- The hash of the device config that a device has retrieved last,
+-- so devices are only asked to retrieve their config again when it has changed.
+CREATE TABLE "device_config_retrieval"
+(
+    "device_id"    uuid PRIMARY KEY,
+    "config_hash"  bytea       NOT NULL,
+    "retrieved_at" timestamptz NOT NULL DEFAULT now(),
+
+    FOREIGN KEY ("device_id") REFERENCES "device" ("id") ON DELETE CASCADE
+);
+
+COMMIT;
@@ -0,0 +1,6 @@
+BEGIN;
+
+DROP TABLE "organization_branding";
+DROP TABLE "blob";
+
+COMMIT;
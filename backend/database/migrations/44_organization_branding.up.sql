@@ -0,0 +1,29 @@
+BEGIN;
+
+-- Blobs are only stored in the database if no other blob store has been configured.
+CREATE TABLE "blob"
+(
+    "key"        text PRIMARY KEY,
+    "data"       bytea       NOT NULL,
+    "created_at" timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE TABLE "organization_branding"
+(
+    "organization_id"   uuid PRIMARY KEY,
+    -- Colours are hexadecimal RGB colours (#rrggbb). Devices use their default colour if a colour is not set.
+    "primary_color"     text,
+    "accent_color"      text,
+    "background_color"  text,
+    "text_color"        text,
+    -- Key of the logo in the blob store. The key contains the hash of the logo, so it changes with the logo.
+    "logo_key"          text,
+    "logo_content_type" text,
+    "logo_sha256"       bytea,
+    "updated_at"        timestamptz NOT NULL DEFAULT now(),
+
+    FOREIGN KEY ("organization_id") REFERENCES "organization" ("id") ON DELETE CASCADE,
+    CHECK (("logo_key" IS NULL) = ("logo_sha256" IS NULL) AND ("logo_key" IS NULL) = ("logo_content_type" IS NULL))
+);
+
+COMMIT;
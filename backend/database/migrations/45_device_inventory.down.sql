@@ -0,0 +1,11 @@
+BEGIN;
+
+ALTER TABLE "device"
+    DROP COLUMN "location_description",
+    DROP COLUMN "serial_number",
+    DROP COLUMN "hardware_revision",
+    DROP COLUMN "mac_address",
+    DROP COLUMN "install_date",
+    DROP COLUMN "notes";
+
+COMMIT;
@@ -0,0 +1,19 @@
+BEGIN;
+
+-- Inventory information which administrators keep about their devices. All fields are optional.
+ALTER TABLE "device"
+    ADD COLUMN "location_description" text,
+    ADD COLUMN "serial_number"        text,
+    ADD COLUMN "hardware_revision"    text,
+    -- MAC addresses are stored in their canonical form (lowercase, separated by colons).
+    ADD COLUMN "mac_address"          text,
+    ADD COLUMN "install_date"         date,
+    ADD COLUMN "notes"                text;
+
+-- Devices are listed per organization, filtered and sorted on these columns.
+CREATE INDEX ON "device" ("organization_id", "last_heartbeat");
+CREATE INDEX ON "device" ("organization_id", "serial_number");
+CREATE INDEX ON "device" ("organization_id", "mac_address");
+CREATE INDEX ON "device" ("organization_id", "install_date");
+
+COMMIT;
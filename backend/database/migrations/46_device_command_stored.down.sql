@@ -0,0 +1,8 @@
+BEGIN;
+
+ALTER TABLE "device_command"
+    RENAME COLUMN "stored_at" TO "delivered_at";
+
+ALTER TYPE "device_command_status" RENAME VALUE 'stored' TO 'delivered';
+
+COMMIT;
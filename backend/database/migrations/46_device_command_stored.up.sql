@@ -0,0 +1,10 @@
+BEGIN;
+
+-- Commands are considered sent when JetStream has stored them, which doesn't mean that the device has received them.
+-- Devices acknowledge commands when they receive them.
+ALTER TYPE "device_command_status" RENAME VALUE 'delivered' TO 'stored';
+
+ALTER TABLE "device_command"
+    RENAME COLUMN "delivered_at" TO "stored_at";
+
+COMMIT;
@@ -0,0 +1,7 @@
+BEGIN;
+
+-- This migration used to delete the schedules and fail the commands which turn screens on or off.
+-- Those are kept now, as screen power commands are only sent to devices which report that they support them
+-- (see migration 50). The version is kept so databases which have already been migrated to it can still be migrated.
+
+COMMIT;
@@ -0,0 +1,10 @@
+BEGIN;
+
+DROP INDEX "device_name_trgm_idx";
+DROP INDEX "device_serial_number_trgm_idx";
+DROP INDEX "device_mac_address_trgm_idx";
+DROP INDEX "device_location_description_trgm_idx";
+
+-- The pg_trgm extension is kept, as it may have been installed before.
+
+COMMIT;
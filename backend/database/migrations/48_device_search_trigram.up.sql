@@ -0,0 +1,14 @@
+BEGIN;
+
+CREATE EXTENSION IF NOT EXISTS "pg_trgm";
+
+-- The search of GetDevices matches these columns with ILIKE '%...%', which B-tree indexes can't be used for.
+-- The name is searched with the default collation, as the collation of the column is nondeterministic.
+-- The last status of devices is looked up with the ("device_id", "reported_at" DESC) index of "device_status",
+-- which has existed since migration 34.
+CREATE INDEX "device_name_trgm_idx" ON "device" USING gin (("name" COLLATE "default") gin_trgm_ops);
+CREATE INDEX "device_serial_number_trgm_idx" ON "device" USING gin ("serial_number" gin_trgm_ops);
+CREATE INDEX "device_mac_address_trgm_idx" ON "device" USING gin ("mac_address" gin_trgm_ops);
+CREATE INDEX "device_location_description_trgm_idx" ON "device" USING gin ("location_description" gin_trgm_ops);
+
+COMMIT;
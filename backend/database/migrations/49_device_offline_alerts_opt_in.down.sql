@@ -0,0 +1,8 @@
+BEGIN;
+
+-- Thresholds which have been removed by the up migration are not restored,
+-- as they can't be told apart from thresholds which were never set.
+ALTER TABLE "organization"
+    ALTER COLUMN "device_offline_alert_threshold_seconds" SET DEFAULT 3600;
+
+COMMIT;
@@ -0,0 +1,16 @@
+BEGIN;
+
+-- Alerting is opt-in: new organizations don't have a threshold.
+ALTER TABLE "organization"
+    ALTER COLUMN "device_offline_alert_threshold_seconds" SET DEFAULT NULL;
+
+-- Organizations which have configured a webhook or an email address have opted in,
+-- so their threshold (whether it's the old default or not) is kept.
+-- The others couldn't have received any alerts, so their threshold was only the old default
+-- and is removed, which makes them opt in like new organizations.
+UPDATE "organization"
+SET "device_offline_alert_threshold_seconds" = NULL
+WHERE COALESCE("device_alert_webhook_url", '') = ''
+  AND COALESCE("device_alert_email", '') = '';
+
+COMMIT;
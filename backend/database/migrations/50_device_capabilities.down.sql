@@ -0,0 +1,6 @@
+BEGIN;
+
+ALTER TABLE "device"
+    DROP COLUMN "capabilities";
+
+COMMIT;
@@ -0,0 +1,8 @@
+BEGIN;
+
+-- The optional features which a device has reported that it supports, such as 'screen_power'.
+-- Devices report their capabilities after they have connected, so existing devices have none until then.
+ALTER TABLE "device"
+    ADD COLUMN "capabilities" text[] NOT NULL DEFAULT '{}';
+
+COMMIT;
@@ -0,0 +1,25 @@
+package database
+
+import (
+	"crypto/sha256"
+	"sort"
+)
+
+// NetworkingConfigHash computes a hash of the networking config of a device, which consists of the services.
+// Only the IDs and configuration hashes of the services are used, so the services don't have to be complete.
+func NetworkingConfigHash(services []NetworkingService) []byte {
+	sorted := make([]NetworkingService, len(services))
+	copy(sorted, services)
+	sort.Slice(sorted, func(i, j int) bool {
+		return string(sorted[i].ID[:]) < string(sorted[j].ID[:])
+	})
+
+	h := sha256.New()
+	for _, service := range sorted {
+		_, _ = h.Write(service.ID[:])
+		// The configuration hash is length-prefixed, as it may be missing for old services.
+		_, _ = h.Write([]byte{byte(len(service.ConfigHash))})
+		_, _ = h.Write(service.ConfigHash)
+	}
+	return h.Sum(nil)
+}
@@ -2,15 +2,27 @@ package database
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"golang.org/x/crypto/bcrypt"
 )
 
 func (w *Wrapper) ReplaceOrganization(ctx context.Context, org Organization) error {
 	_, err := w.db.ExecContext(ctx,
-		`UPDATE "organization" SET "name" = $1, "zermelo_institution" = $2 WHERE "id" = $3`,
-		org.Name, org.ZermeloInstitution, org.ID,
+		`UPDATE "organization"
+		SET "name" = $1, "zermelo_institution" = $2, "require_student_pin" = $3,
+		    "device_offline_alert_threshold_seconds" = $4, "device_alert_webhook_url" = $5, "device_alert_email" = $6,
+		    "device_config_profile_id" = $7
+		WHERE "id" = $8`,
+		org.Name, org.ZermeloInstitution, org.RequireStudentPIN,
+		org.DeviceOfflineAlertThresholdSeconds, org.DeviceAlertWebhookURL, org.DeviceAlertEmail,
+		org.DeviceConfigProfileID, org.ID,
 	)
 
 	return err
@@ -18,13 +30,234 @@ func (w *Wrapper) ReplaceOrganization(ctx context.Context, org Organization) err
 
 func (w *Wrapper) ReplaceDevice(ctx context.Context, dev Device) error {
 	_, err := w.db.ExecContext(ctx,
-		`UPDATE "device" SET "name" = $1, "organization_id" = $2 WHERE "id" = $3`,
-		dev.Name, dev.OrganizationID, dev.ID,
+		`UPDATE "device"
+		SET "name" = $1, "organization_id" = $2, "group_id" = $3, "tags" = $4, "device_config_profile_id" = $5,
+		    "location_description" = $6, "serial_number" = $7, "hardware_revision" = $8, "mac_address" = $9,
+		    "install_date" = $10, "notes" = $11
+		WHERE "id" = $12`,
+		dev.Name, dev.OrganizationID, dev.GroupID, dev.Tags, dev.DeviceConfigProfileID,
+		dev.LocationDescription, dev.SerialNumber, dev.HardwareRevision, dev.MacAddress,
+		dev.InstallDate, dev.Notes, dev.ID,
 	)
 
 	return err
 }
 
+// ReplaceDeviceGroup changes the name, update channel and device config profile of a device group.
+// ErrConflict is returned if the organization already has a group with the new name.
+func (w *Wrapper) ReplaceDeviceGroup(ctx context.Context, group DeviceGroup) error {
+	res, err := w.db.ExecContext(ctx, `
+		UPDATE "device_group" SET "name" = $1, "update_channel" = $2, "device_config_profile_id" = $3
+		WHERE "id" = $4 AND "organization_id" = $5
+	`, group.Name, group.UpdateChannel, group.DeviceConfigProfileID, group.ID, group.OrganizationID)
+	if isUniqueViolation(err) {
+		return fmt.Errorf("device group already exists: %w", ErrConflict.withUnderlying(err))
+	}
+	if err != nil {
+		return err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func (w *Wrapper) ReplaceDeviceSchedule(ctx context.Context, s DeviceSchedule) error {
+	res, err := w.db.ExecContext(ctx, `
+		UPDATE "device_schedule"
+		SET "device_group_id" = $3,
+		    "action"          = $4,
+		    "cron_expression" = $5,
+		    "timezone"        = $6,
+		    "enabled"         = $7,
+		    "updated_at"      = now()
+		WHERE "id" = $1 AND "organization_id" = $2
+	`, s.ID, s.OrganizationID, s.DeviceGroupID, s.Action, s.CronExpression, s.Timezone, s.Enabled)
+	if err != nil {
+		return err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// ReplaceDeviceConfigProfile changes the name and config of a profile in the organization.
+// ErrConflict is returned if the organization already has a profile with the new name.
+func (w *Wrapper) ReplaceDeviceConfigProfile(ctx context.Context, p DeviceConfigProfile) error {
+	res, err := w.db.ExecContext(ctx, `
+		UPDATE "device_config_profile"
+		SET "name"       = $3,
+		    "config"     = $4,
+		    "updated_at" = now()
+		WHERE "id" = $1 AND "organization_id" = $2
+	`, p.ID, p.OrganizationID, p.Name, p.Config)
+	if isUniqueViolation(err) {
+		return fmt.Errorf("device config profile already exists: %w", ErrConflict.withUnderlying(err))
+	}
+	if err != nil {
+		return err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// ReplaceDeviceConfigHash stores the hash of the device config that a device has retrieved.
+func (w *Wrapper) ReplaceDeviceConfigHash(ctx context.Context, deviceID uuid.UUID, hash []byte) error {
+	_, err := w.db.ExecContext(ctx, `
+		INSERT INTO "device_config_retrieval" ("device_id", "config_hash")
+		VALUES ($1, $2)
+		ON CONFLICT ("device_id") DO UPDATE
+		SET "config_hash"  = excluded."config_hash",
+		    "retrieved_at" = now()
+	`, deviceID, hash)
+
+	return err
+}
+
+// ReplaceAnnouncement replaces an announcement in the organization, including the groups which it is shown in.
+func (w *Wrapper) ReplaceAnnouncement(ctx context.Context, a Announcement) error {
+	tx, err := w.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	res, err := tx.ExecContext(ctx, `
+		UPDATE "announcement"
+		SET "text"        = $3,
+		    "severity"    = $4,
+		    "starts_at"   = $5,
+		    "ends_at"     = $6,
+		    "all_devices" = $7,
+		    "updated_at"  = now()
+		WHERE "id" = $1 AND "organization_id" = $2
+	`, a.ID, a.OrganizationID, a.Text, a.Severity, a.StartsAt, a.EndsAt, a.AllDevices)
+	if err != nil {
+		return err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return sql.ErrNoRows
+	}
+
+	if err = replaceAnnouncementGroups(ctx, tx, a.ID, a.GroupIDs); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func replaceAnnouncementGroups(ctx context.Context, tx *sqlx.Tx, announcementID uuid.UUID, groupIDs []uuid.UUID) error {
+	_, err := tx.ExecContext(ctx, `
+		DELETE FROM "announcement_device_group" WHERE "announcement_id" = $1
+	`, announcementID)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO "announcement_device_group" ("announcement_id", "device_group_id")
+		SELECT $1, unnest($2::uuid[])
+		ON CONFLICT DO NOTHING
+	`, announcementID, pq.Array(groupIDs))
+
+	return err
+}
+
+// ReplaceAnnouncementImage sets the image of an announcement in the organization.
+func (w *Wrapper) ReplaceAnnouncementImage(ctx context.Context, organizationID uuid.UUID, img AnnouncementImage) error {
+	tx, err := w.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	res, err := tx.ExecContext(ctx, `
+		UPDATE "announcement" SET "updated_at" = now()
+		WHERE "id" = $1 AND "organization_id" = $2
+	`, img.AnnouncementID, organizationID)
+	if err != nil {
+		return err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return sql.ErrNoRows
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO "announcement_image" ("announcement_id", "content_type", "data")
+		VALUES ($1, $2, $3)
+		ON CONFLICT ("announcement_id") DO UPDATE
+		SET "content_type" = excluded."content_type",
+		    "data"         = excluded."data"
+	`, img.AnnouncementID, img.ContentType, img.Data)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// ClaimDeviceScheduleRun records that the schedule is run at runAt and returns the schedule.
+// When multiple backends try to run the schedule for the same time, only one of them succeeds;
+// the others (and runs of disabled schedules) get sql.ErrNoRows.
+func (w *Wrapper) ClaimDeviceScheduleRun(ctx context.Context, id uuid.UUID, runAt time.Time) (DeviceSchedule, error) {
+	var schedule DeviceSchedule
+
+	err := w.db.GetContext(ctx, &schedule, `
+		UPDATE "device_schedule" SET "last_run_at" = $2
+		WHERE "id" = $1
+		  AND "enabled"
+		  AND ("last_run_at" IS NULL OR "last_run_at" < $2)
+		RETURNING *
+	`, id, runAt)
+
+	return schedule, err
+}
+
+// ReplaceUpdateRollout creates or replaces the rollout of a component in a device group.
+func (w *Wrapper) ReplaceUpdateRollout(ctx context.Context, r UpdateRollout) (UpdateRollout, error) {
+	var rollout UpdateRollout
+
+	err := w.db.GetContext(ctx, &rollout, `
+		INSERT INTO "update_rollout" ("organization_id", "device_group_id", "component", "release_id", "percentage")
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT ("device_group_id", "component") DO UPDATE
+		SET "release_id" = excluded."release_id",
+		    "percentage" = excluded."percentage",
+		    "updated_at" = now()
+		RETURNING *
+	`, r.OrganizationID, r.DeviceGroupID, r.Component, r.ReleaseID, r.Percentage)
+
+	return rollout, err
+}
+
 func (w *Wrapper) ReplaceStudent(ctx context.Context, s Student) error {
 	_, err := w.db.ExecContext(ctx,
 		`UPDATE "student" SET "zermelo_user" = $1, "organization_id" = $2 WHERE "id" = $3`,
@@ -52,6 +285,36 @@ func (w *Wrapper) ReplaceDeviceHeartbeat(ctx context.Context, id uuid.UUID) erro
 	return err
 }
 
+// ReplaceDeviceCapabilities replaces the capabilities which a device has reported.
+func (w *Wrapper) ReplaceDeviceCapabilities(ctx context.Context, id uuid.UUID, capabilities []string) error {
+	_, err := w.db.ExecContext(ctx,
+		`UPDATE "device" SET "capabilities" = $1 WHERE "id" = $2`,
+		pq.Array(capabilities), id,
+	)
+
+	return err
+}
+
+// ReplaceDeviceHeartbeats updates the last heartbeat of multiple devices at once.
+func (w *Wrapper) ReplaceDeviceHeartbeats(ctx context.Context, ids []uuid.UUID) error {
+	_, err := w.db.ExecContext(ctx,
+		`UPDATE "device" SET "last_heartbeat" = clock_timestamp() WHERE "id" = ANY($1)`,
+		pq.Array(ids),
+	)
+
+	return err
+}
+
+// ReplaceDeviceDisconnect records that a device has disconnected from NATS.
+func (w *Wrapper) ReplaceDeviceDisconnect(ctx context.Context, id uuid.UUID) error {
+	_, err := w.db.ExecContext(ctx,
+		`UPDATE "device" SET "last_disconnect" = clock_timestamp() WHERE "id" = $1`,
+		id,
+	)
+
+	return err
+}
+
 func (w *Wrapper) ReplaceNetworkingService(ctx context.Context, s NetworkingService) error {
 	_, err := w.db.ExecContext(ctx,
 		`UPDATE "networking_service" SET "name" = $1 WHERE "id" = $2`,
@@ -61,13 +324,25 @@ func (w *Wrapper) ReplaceNetworkingService(ctx context.Context, s NetworkingServ
 	return err
 }
 
-func (w *Wrapper) ReplaceStudentCard(ctx context.Context, organizationID, studentID uuid.UUID, cardUID []byte) error {
+// ReplaceStudentCard replaces the card of the student. The card UID is hashed with the current key.
+// ErrConflict is returned if the card is in use by another student.
+func (w *Wrapper) ReplaceStudentCard(ctx context.Context,
+	organizationID, studentID uuid.UUID,
+	cardUID []byte,
+	getKeys CardKeysFunc,
+) error {
 	tx, err := w.db.BeginTxx(ctx, nil)
 	if err != nil {
 		return err
 	}
 	defer func() { _ = tx.Rollback() }()
 
+	// The cards are locked by createStudentCard, but the lock must already be held here
+	// so no card can be added between deleting the old cards and adding the new one.
+	if err = lockStudentCards(ctx, tx, organizationID); err != nil {
+		return err
+	}
+
 	if _, err = tx.ExecContext(ctx,
 		`DELETE FROM "student_card" WHERE student_id = $1 AND organization_id = $2`,
 		studentID, organizationID,
@@ -75,17 +350,597 @@ func (w *Wrapper) ReplaceStudentCard(ctx context.Context, organizationID, studen
 		return err
 	}
 
-	cardHash, err := hashBytes(cardUID)
+	if _, err = createStudentCard(ctx, tx, StudentCard{
+		OrganizationID: organizationID,
+		StudentID:      studentID,
+		ValidFrom:      time.Now(),
+	}, cardUID, getKeys); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// RotateStudentCardKeys calls rotate with the key versions that the cards of an organization are hashed with.
+// The cards are locked while rotate runs, so no card can be added or rehashed with a key that rotate removes.
+func (w *Wrapper) RotateStudentCardKeys(ctx context.Context,
+	organizationID uuid.UUID,
+	rotate func(inUse []int) error,
+) error {
+	tx, err := w.db.BeginTxx(ctx, nil)
 	if err != nil {
-		return fmt.Errorf("could not hash card UID: %w", err)
+		return err
 	}
+	defer func() { _ = tx.Rollback() }()
 
-	if _, err = w.db.ExecContext(ctx, `
-		INSERT INTO "student_card" (id_hash, organization_id, student_id)
-		VALUES ($1, $2, $3)
-	`, cardHash, organizationID, studentID); err != nil {
+	if err = lockStudentCards(ctx, tx, organizationID); err != nil {
+		return err
+	}
+
+	var versions []int
+	if err = tx.SelectContext(ctx, &versions,
+		`SELECT DISTINCT key_version FROM student_card WHERE organization_id = $1`,
+		organizationID,
+	); err != nil {
 		return err
 	}
 
+	if err = rotate(versions); err != nil {
+		return err
+	}
 	return tx.Commit()
 }
+
+// ReplaceStudentCardState changes the state of a card of a student.
+// It returns sql.ErrNoRows if the student has no card with the provided ID.
+func (w *Wrapper) ReplaceStudentCardState(ctx context.Context, studentID, id uuid.UUID, state StudentCardState) error {
+	res, err := w.db.ExecContext(ctx,
+		`UPDATE student_card SET state = $1 WHERE id = $2 AND student_id = $3`,
+		state, id, studentID,
+	)
+	if err != nil {
+		return err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// ExpireStudentCards marks active cards of which the validity period has ended as expired.
+func (w *Wrapper) ExpireStudentCards(ctx context.Context) error {
+	_, err := w.db.ExecContext(ctx, `
+		UPDATE student_card SET state = 'expired'
+		WHERE state = 'active' AND valid_until < now()
+	`)
+	return err
+}
+
+// ReplaceUserSessionLastUsed marks the session as used. To prevent a write on every request,
+// the time of last use is only updated when it is more than a minute ago.
+func (w *Wrapper) ReplaceUserSessionLastUsed(ctx context.Context, id uuid.UUID) error {
+	_, err := w.db.ExecContext(ctx, `
+		UPDATE "user_session" SET "last_used_at" = now()
+		WHERE "id" = $1 AND "last_used_at" < now() - interval '1 minute'
+	`, id)
+
+	return err
+}
+
+// ReplaceUserAPIKeyLastUsed marks the API key as used. Like ReplaceUserSessionLastUsed,
+// the time of last use is only updated when it is more than a minute ago.
+func (w *Wrapper) ReplaceUserAPIKeyLastUsed(ctx context.Context, id uuid.UUID) error {
+	_, err := w.db.ExecContext(ctx, `
+		UPDATE "user_api_key" SET "last_used_at" = now()
+		WHERE "id" = $1 AND ("last_used_at" IS NULL OR "last_used_at" < now() - interval '1 minute')
+	`, id)
+
+	return err
+}
+
+// RefreshUserSession rotates the refresh token of the session that the refresh token belongs to,
+// and creates a new access token for the session.
+// If a refresh token is used which has already been rotated, the session is revoked as the refresh token
+// has most likely been stolen. ErrRefreshTokenReused is returned in this case.
+func (w *Wrapper) RefreshUserSession(ctx context.Context, refreshToken uuid.UUID) (UserTokens, error) {
+	var tokens UserTokens
+
+	refreshTokenHash, err := hashToken(refreshToken)
+	if err != nil {
+		return tokens, err
+	}
+
+	tx, err := w.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return tokens, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var session UserSession
+	err = tx.GetContext(ctx, &session, `
+		SELECT * FROM "user_session"
+		WHERE "refresh_token_hash" = $1 AND "expires_at" > now()
+		FOR UPDATE
+	`, refreshTokenHash)
+	if errors.Is(err, sql.ErrNoRows) {
+		res, err := tx.ExecContext(ctx,
+			`DELETE FROM "user_session" WHERE "previous_refresh_token_hash" = $1`,
+			refreshTokenHash,
+		)
+		if err != nil {
+			return tokens, err
+		}
+		if n, err := res.RowsAffected(); err != nil || n == 0 {
+			return tokens, sql.ErrNoRows
+		}
+
+		if err = tx.Commit(); err != nil {
+			return tokens, err
+		}
+		return tokens, fmt.Errorf("session revoked: %w", ErrRefreshTokenReused.withUnderlying(sql.ErrNoRows))
+	}
+	if err != nil {
+		return tokens, err
+	}
+
+	newRefreshToken := uuid.New()
+	newRefreshTokenHash, err := hashToken(newRefreshToken)
+	if err != nil {
+		return tokens, err
+	}
+	newRefreshTokenExpiresAt := time.Now().Add(DefaultRefreshTokenExpiration)
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE "user_session"
+		SET "previous_refresh_token_hash" = "refresh_token_hash",
+		    "refresh_token_hash"          = $1,
+		    "expires_at"                  = $2,
+		    "last_used_at"                = now()
+		WHERE "id" = $3
+	`, newRefreshTokenHash, newRefreshTokenExpiresAt, session.ID)
+	if err != nil {
+		return tokens, err
+	}
+
+	accessToken, accessTokenExpiresAt, err := createUserToken(ctx, tx, session.UserID, session.ID)
+	if err != nil {
+		return tokens, err
+	}
+
+	tokens = UserTokens{
+		SessionID:             session.ID,
+		AccessToken:           accessToken,
+		AccessTokenExpiresAt:  accessTokenExpiresAt,
+		RefreshToken:          newRefreshToken,
+		RefreshTokenExpiresAt: newRefreshTokenExpiresAt,
+	}
+	return tokens, tx.Commit()
+}
+
+// ReplaceStudentPIN sets the PIN of a student, resetting any failed attempts.
+func (w *Wrapper) ReplaceStudentPIN(ctx context.Context, studentID uuid.UUID, pin string) error {
+	hash, err := bcrypt.GenerateFromPassword([]byte(pin), bcrypt.DefaultCost)
+	if err != nil {
+		return fmt.Errorf("could not hash PIN: %w", err)
+	}
+
+	_, err = w.db.ExecContext(ctx, `
+		INSERT INTO "student_pin" ("student_id", "hash")
+		VALUES ($1, $2)
+		ON CONFLICT ("student_id") DO UPDATE
+		SET "hash" = excluded."hash", "failed_attempts" = 0, "locked_until" = NULL
+	`, studentID, hash)
+
+	return err
+}
+
+// VerifyStudentPIN checks the PIN of a student. After MaxStudentPINAttempts consecutive incorrect attempts,
+// the PIN is locked for StudentPINLockoutDuration. ErrIncorrectPIN is returned if the PIN is incorrect,
+// and ErrPINLocked if the PIN is locked. sql.ErrNoRows is returned if the student has no PIN.
+func (w *Wrapper) VerifyStudentPIN(ctx context.Context, studentID uuid.UUID, pin string) error {
+	tx, err := w.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	// Lock the row so concurrent attempts can't get around the lockout.
+	var studentPIN StudentPIN
+	err = tx.GetContext(ctx, &studentPIN,
+		`SELECT * FROM "student_pin" WHERE "student_id" = $1 FOR UPDATE`,
+		studentID,
+	)
+	if err != nil {
+		return err
+	}
+
+	if studentPIN.LockedUntil.Valid && studentPIN.LockedUntil.Time.After(time.Now()) {
+		return ErrPINLocked.withUnderlying(fmt.Errorf("locked until %s", studentPIN.LockedUntil.Time))
+	}
+
+	cmpErr := bcrypt.CompareHashAndPassword(studentPIN.Hash, []byte(pin))
+	if errors.Is(cmpErr, bcrypt.ErrMismatchedHashAndPassword) {
+		failedAttempts := studentPIN.FailedAttempts + 1
+
+		var lockedUntil sql.NullTime
+		if failedAttempts >= MaxStudentPINAttempts {
+			failedAttempts = 0
+			lockedUntil = sql.NullTime{Valid: true, Time: time.Now().Add(StudentPINLockoutDuration)}
+		}
+
+		if _, err = tx.ExecContext(ctx, `
+			UPDATE "student_pin" SET "failed_attempts" = $1, "locked_until" = $2
+			WHERE "student_id" = $3
+		`, failedAttempts, lockedUntil, studentID); err != nil {
+			return err
+		}
+		if err = tx.Commit(); err != nil {
+			return err
+		}
+
+		if lockedUntil.Valid {
+			return ErrPINLocked.withUnderlying(fmt.Errorf("locked until %s", lockedUntil.Time))
+		}
+		return ErrIncorrectPIN.withUnderlying(cmpErr)
+	}
+	if cmpErr != nil {
+		return cmpErr
+	}
+
+	if studentPIN.FailedAttempts != 0 || studentPIN.LockedUntil.Valid {
+		if _, err = tx.ExecContext(ctx, `
+			UPDATE "student_pin" SET "failed_attempts" = 0, "locked_until" = NULL
+			WHERE "student_id" = $1
+		`, studentID); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// CompleteCardEnrollmentSession adds the card tapped at the device to the student of the session.
+// It returns sql.ErrNoRows if the device has no pending session with the provided ID.
+// If the card is already in use, the session fails and ErrConflict is returned.
+func (w *Wrapper) CompleteCardEnrollmentSession(ctx context.Context,
+	id, deviceID uuid.UUID,
+	uid []byte,
+	getKeys CardKeysFunc,
+) (CardEnrollmentSession, error) {
+	var session CardEnrollmentSession
+
+	tx, err := w.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return session, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	err = tx.GetContext(ctx, &session, `
+		SELECT * FROM card_enrollment_session
+		WHERE id = $1 AND device_id = $2 AND state = 'pending' AND expires_at > now()
+		FOR UPDATE
+	`, id, deviceID)
+	if err != nil {
+		return session, err
+	}
+
+	card, err := createStudentCard(ctx, tx, StudentCard{
+		OrganizationID: session.OrganizationID,
+		StudentID:      session.StudentID,
+		Label:          session.Label,
+		ValidFrom:      time.Now(),
+		ValidUntil:     session.ValidUntil,
+	}, uid, getKeys)
+	if errors.Is(err, ErrConflict) {
+		session.State = CardEnrollmentSessionStateFailed
+		session.Error = "Card is already in use"
+	} else if err != nil {
+		return session, err
+	} else {
+		session.State = CardEnrollmentSessionStateCompleted
+		session.CardID = &card.ID
+	}
+
+	if _, err = tx.ExecContext(ctx, `
+		UPDATE card_enrollment_session SET state = $1, error = $2, card_id = $3
+		WHERE id = $4
+	`, session.State, session.Error, session.CardID, session.ID); err != nil {
+		return session, err
+	}
+	if err = tx.Commit(); err != nil {
+		return session, err
+	}
+
+	if session.State == CardEnrollmentSessionStateFailed {
+		return session, ErrConflict.withUnderlying(errors.New(session.Error))
+	}
+	return session, nil
+}
+
+// CancelCardEnrollmentSession cancels a card enrollment session.
+// It returns sql.ErrNoRows if there is no pending session with the provided ID.
+func (w *Wrapper) CancelCardEnrollmentSession(ctx context.Context, id uuid.UUID) error {
+	res, err := w.db.ExecContext(ctx,
+		`UPDATE card_enrollment_session SET state = 'cancelled' WHERE id = $1 AND state = 'pending'`,
+		id,
+	)
+	if err != nil {
+		return err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// RotateDeviceToken creates a new token for the device. All other tokens of the device
+// expire after DeviceTokenRotationGracePeriod, unless they expire earlier already.
+func (w *Wrapper) RotateDeviceToken(ctx context.Context, deviceID uuid.UUID) (uuid.UUID, error) {
+	token := uuid.New()
+	tokenHash, err := hashToken(token)
+	if err != nil {
+		return token, err
+	}
+
+	tx, err := w.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return token, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	expiresAt := time.Now().Add(DeviceTokenRotationGracePeriod)
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE "device_token" SET "expires_at" = $1
+		WHERE "device_id" = $2 AND ("expires_at" IS NULL OR "expires_at" > $1)
+	`, expiresAt, deviceID)
+	if err != nil {
+		return token, err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO "device_token" ("token_hash", "device_id")
+		VALUES ($1, $2)
+	`, tokenHash, deviceID)
+	if err != nil {
+		return token, err
+	}
+
+	return token, tx.Commit()
+}
+
+// ReplaceNetworkingServiceConfigHash stores the hash of the configuration of a networking service,
+// which is used to detect which devices have an outdated networking config.
+func (w *Wrapper) ReplaceNetworkingServiceConfigHash(ctx context.Context, id uuid.UUID, hash []byte) error {
+	_, err := w.db.ExecContext(ctx,
+		`UPDATE "networking_service" SET "config_hash" = $1 WHERE "id" = $2`,
+		hash, id,
+	)
+
+	return err
+}
+
+// ReplaceNetworkingServiceAssignment replaces the devices and groups which a networking service is assigned to.
+func (w *Wrapper) ReplaceNetworkingServiceAssignment(ctx context.Context, a NetworkingServiceAssignment) error {
+	tx, err := w.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	_, err = tx.ExecContext(ctx,
+		`UPDATE "networking_service" SET "all_devices" = $1 WHERE "id" = $2`,
+		a.AllDevices, a.NetworkingServiceID,
+	)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		DELETE FROM "networking_service_device" WHERE "networking_service_id" = $1
+	`, a.NetworkingServiceID)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO "networking_service_device" ("networking_service_id", "device_id")
+		SELECT $1, unnest($2::uuid[])
+		ON CONFLICT DO NOTHING
+	`, a.NetworkingServiceID, pq.Array(a.DeviceIDs))
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		DELETE FROM "networking_service_device_group" WHERE "networking_service_id" = $1
+	`, a.NetworkingServiceID)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO "networking_service_device_group" ("networking_service_id", "device_group_id")
+		SELECT $1, unnest($2::uuid[])
+		ON CONFLICT DO NOTHING
+	`, a.NetworkingServiceID, pq.Array(a.GroupIDs))
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// ReplaceDeviceNetworkingConfigHash stores the hash of the networking config that a device has retrieved.
+func (w *Wrapper) ReplaceDeviceNetworkingConfigHash(ctx context.Context, deviceID uuid.UUID, hash []byte) error {
+	_, err := w.db.ExecContext(ctx, `
+		INSERT INTO "device_networking_config" ("device_id", "config_hash")
+		VALUES ($1, $2)
+		ON CONFLICT ("device_id") DO UPDATE SET "config_hash" = $2, "retrieved_at" = now()
+	`, deviceID, hash)
+
+	return err
+}
+
+// DeviceAlert is the information about a device which administrators are alerted about.
+type DeviceAlert struct {
+	DeviceID       uuid.UUID
+	OrganizationID uuid.UUID
+	DeviceName     string
+	LastHeartbeat  time.Time
+}
+
+// ClaimOfflineDeviceAlerts marks the devices which have been offline for longer than the threshold
+// of their organization as alerted, and returns them. Devices are only returned once until they have recovered,
+// so the caller must send the alerts.
+func (w *Wrapper) ClaimOfflineDeviceAlerts(ctx context.Context) ([]DeviceAlert, error) {
+	var alerts []DeviceAlert
+
+	err := w.db.SelectContext(ctx, &alerts, `
+		UPDATE "device" AS d SET "offline_alerted_at" = now()
+		FROM "organization" AS o
+		WHERE o."id" = d."organization_id"
+		  AND o."device_offline_alert_threshold_seconds" IS NOT NULL
+		  AND d."offline_alerted_at" IS NULL
+		  AND d."last_heartbeat" < now() - make_interval(secs => o."device_offline_alert_threshold_seconds")
+		RETURNING d."id" AS "device_id", d."organization_id", d."name" AS "device_name", d."last_heartbeat"
+	`)
+
+	return alerts, err
+}
+
+// ClaimRecoveredDeviceAlerts clears the alerts of devices which have sent a heartbeat since they were reported
+// as offline, and returns them.
+func (w *Wrapper) ClaimRecoveredDeviceAlerts(ctx context.Context) ([]DeviceAlert, error) {
+	var alerts []DeviceAlert
+
+	err := w.db.SelectContext(ctx, &alerts, `
+		UPDATE "device" SET "offline_alerted_at" = NULL
+		WHERE "offline_alerted_at" IS NOT NULL
+		  AND "last_heartbeat" > "offline_alerted_at"
+		RETURNING "id" AS "device_id", "organization_id", "name" AS "device_name", "last_heartbeat"
+	`)
+
+	return alerts, err
+}
+
+// ReplaceDeviceCommandStatus updates the status of a command of a device.
+// The status of a command can only progress (queued, stored, acked and finally succeeded or failed),
+// so results which arrive out of order are ignored. sql.ErrNoRows is returned if the device has no such command
+// or its status has already progressed further.
+func (w *Wrapper) ReplaceDeviceCommandStatus(ctx context.Context,
+	deviceID, commandID uuid.UUID,
+	status DeviceCommandStatus,
+	errorMessage, output sql.NullString,
+) error {
+	res, err := w.db.ExecContext(ctx, `
+		UPDATE "device_command"
+		SET "status"       = $3,
+		    "stored_at"    = CASE WHEN $3 = 'stored' THEN now() ELSE "stored_at" END,
+		    "acked_at"     = CASE WHEN $3 = 'acked' THEN now() ELSE "acked_at" END,
+		    "completed_at" = CASE WHEN $3 IN ('succeeded', 'failed') THEN now() ELSE "completed_at" END,
+		    "error"        = COALESCE($4, "error"),
+		    "output"       = COALESCE($5, "output")
+		WHERE "id" = $2
+		  AND "device_id" = $1
+		  AND "status" < $3
+		  AND "status" NOT IN ('succeeded', 'failed')
+	`, deviceID, commandID, status, errorMessage, output)
+	if err != nil {
+		return err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// ExpireDeviceCommands marks commands which have not been completed before they expired as failed.
+func (w *Wrapper) ExpireDeviceCommands(ctx context.Context) error {
+	_, err := w.db.ExecContext(ctx, `
+		UPDATE "device_command"
+		SET "status" = 'failed', "error" = 'Command expired', "completed_at" = now()
+		WHERE "status" NOT IN ('succeeded', 'failed') AND "expires_at" < now()
+	`)
+	return err
+}
+
+// ReplaceOrganizationBrandingColors replaces the colour scheme of an organization, leaving its logo untouched.
+func (w *Wrapper) ReplaceOrganizationBrandingColors(ctx context.Context, b OrganizationBranding) error {
+	_, err := w.db.ExecContext(ctx, `
+		INSERT INTO "organization_branding" ("organization_id", "primary_color", "accent_color", "background_color", "text_color")
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT ("organization_id") DO UPDATE
+		SET "primary_color"    = excluded."primary_color",
+		    "accent_color"     = excluded."accent_color",
+		    "background_color" = excluded."background_color",
+		    "text_color"       = excluded."text_color",
+		    "updated_at"       = now()
+	`, b.OrganizationID, b.PrimaryColor, b.AccentColor, b.BackgroundColor, b.TextColor)
+
+	return err
+}
+
+// ReplaceOrganizationBrandingLogo replaces the logo of an organization, leaving its colour scheme untouched.
+// The key of the previous logo is returned, so it can be removed from the blob store.
+func (w *Wrapper) ReplaceOrganizationBrandingLogo(ctx context.Context,
+	organizationID uuid.UUID, key, contentType string, sha256 []byte,
+) (sql.NullString, error) {
+	var oldKey sql.NullString
+
+	tx, err := w.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return oldKey, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	err = tx.GetContext(ctx, &oldKey, `
+		SELECT "logo_key" FROM "organization_branding" WHERE "organization_id" = $1 FOR UPDATE
+	`, organizationID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return oldKey, err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO "organization_branding" ("organization_id", "logo_key", "logo_content_type", "logo_sha256")
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT ("organization_id") DO UPDATE
+		SET "logo_key"          = excluded."logo_key",
+		    "logo_content_type" = excluded."logo_content_type",
+		    "logo_sha256"       = excluded."logo_sha256",
+		    "updated_at"        = now()
+	`, organizationID, key, contentType, sha256)
+	if err != nil {
+		return oldKey, err
+	}
+
+	return oldKey, tx.Commit()
+}
+
+// ReplaceBlob stores a blob, overwriting the blob with the same key if it exists.
+func (w *Wrapper) ReplaceBlob(ctx context.Context, key string, data []byte) error {
+	_, err := w.db.ExecContext(ctx, `
+		INSERT INTO "blob" ("key", "data")
+		VALUES ($1, $2)
+		ON CONFLICT ("key") DO UPDATE
+		SET "data"       = excluded."data",
+		    "created_at" = now()
+	`, key, data)
+
+	return err
+}
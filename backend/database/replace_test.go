@@ -2,8 +2,12 @@ package database
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"testing"
+	"time"
 
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -51,3 +55,228 @@ func TestWrapper_ReplaceDevice(t *testing.T) {
 
 	assert.Equal(t, dev, gotDev)
 }
+
+func TestWrapper_RefreshUserSession(t *testing.T) {
+	f := newFixture(t)
+	defer f.Close()
+
+	org, err := f.dbw.CreateOrganization(context.Background(), "name", "institution")
+	require.NoError(t, err)
+
+	user, err := f.dbw.CreateUser(context.Background(), "name", "user@example.com", org.ID)
+	require.NoError(t, err)
+
+	tokens, err := f.dbw.CreateUserSession(context.Background(), user.ID, "user agent", "127.0.0.1")
+	require.NoError(t, err)
+
+	newTokens, err := f.dbw.RefreshUserSession(context.Background(), tokens.RefreshToken)
+	require.NoError(t, err)
+	assert.Equal(t, tokens.SessionID, newTokens.SessionID)
+	assert.NotEqual(t, tokens.RefreshToken, newTokens.RefreshToken)
+
+	gotUser, sessionID, err := f.dbw.GetUserByToken(context.Background(), newTokens.AccessToken)
+	require.NoError(t, err)
+	assert.Equal(t, user.ID, gotUser.ID)
+	assert.Equal(t, tokens.SessionID, sessionID)
+
+	// Using the rotated refresh token again must revoke the session.
+	_, err = f.dbw.RefreshUserSession(context.Background(), tokens.RefreshToken)
+	assert.True(t, errors.Is(err, ErrRefreshTokenReused))
+
+	_, _, err = f.dbw.GetUserByToken(context.Background(), newTokens.AccessToken)
+	assert.True(t, errors.Is(err, sql.ErrNoRows))
+}
+
+func TestWrapper_VerifyStudentPIN(t *testing.T) {
+	f := newFixture(t)
+	defer f.Close()
+
+	org, err := f.dbw.CreateOrganization(context.Background(), "name", "institution")
+	require.NoError(t, err)
+
+	student, err := f.dbw.CreateStudent(context.Background(), Student{
+		OrganizationID: org.ID,
+	})
+	require.NoError(t, err)
+
+	err = f.dbw.VerifyStudentPIN(context.Background(), student.ID, "1234")
+	assert.True(t, errors.Is(err, sql.ErrNoRows))
+
+	require.NoError(t, f.dbw.ReplaceStudentPIN(context.Background(), student.ID, "1234"))
+	assert.NoError(t, f.dbw.VerifyStudentPIN(context.Background(), student.ID, "1234"))
+
+	for i := 1; i < MaxStudentPINAttempts; i++ {
+		err = f.dbw.VerifyStudentPIN(context.Background(), student.ID, "0000")
+		assert.True(t, errors.Is(err, ErrIncorrectPIN))
+	}
+	err = f.dbw.VerifyStudentPIN(context.Background(), student.ID, "0000")
+	assert.True(t, errors.Is(err, ErrPINLocked))
+
+	// The correct PIN is not accepted either while the PIN is locked.
+	err = f.dbw.VerifyStudentPIN(context.Background(), student.ID, "1234")
+	assert.True(t, errors.Is(err, ErrPINLocked))
+}
+
+func TestWrapper_RotateDeviceToken(t *testing.T) {
+	f := newFixture(t)
+	defer f.Close()
+
+	org, err := f.dbw.CreateOrganization(context.Background(), "test", "example")
+	require.NoError(t, err)
+
+	dev, oldToken, err := f.dbw.CreateDevice(context.Background(), org.ID, "example device")
+	require.NoError(t, err)
+
+	newToken, err := f.dbw.RotateDeviceToken(context.Background(), dev.ID)
+	require.NoError(t, err)
+
+	// The old token can still be used during the grace period.
+	for _, token := range []uuid.UUID{oldToken, newToken} {
+		got, err := f.dbw.GetDeviceByToken(context.Background(), token)
+		assert.NoError(t, err)
+		assert.Equal(t, dev.ID, got.ID)
+	}
+
+	err = f.dbw.DeleteDeviceTokens(context.Background(), dev.ID)
+	require.NoError(t, err)
+
+	_, err = f.dbw.GetDeviceByToken(context.Background(), newToken)
+	assert.True(t, errors.Is(err, sql.ErrNoRows))
+}
+
+func TestWrapper_ClaimOfflineDeviceAlerts(t *testing.T) {
+	f := newFixture(t)
+	defer f.Close()
+
+	ctx := context.Background()
+
+	org, err := f.dbw.CreateOrganization(ctx, "test", "example")
+	require.NoError(t, err)
+	// Alerting is opt-in.
+	assert.False(t, org.DeviceOfflineAlertThresholdSeconds.Valid)
+
+	dev, _, err := f.dbw.CreateDevice(ctx, org.ID, "example device")
+	require.NoError(t, err)
+
+	_, err = f.dbw.db.ExecContext(ctx,
+		`UPDATE "device" SET "last_heartbeat" = now() - interval '2 hours' WHERE "id" = $1`, dev.ID,
+	)
+	require.NoError(t, err)
+
+	alerts, err := f.dbw.ClaimOfflineDeviceAlerts(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, alerts)
+
+	_, err = f.dbw.db.ExecContext(ctx,
+		`UPDATE "organization" SET "device_offline_alert_threshold_seconds" = 3600 WHERE "id" = $1`, org.ID,
+	)
+	require.NoError(t, err)
+
+	alerts, err = f.dbw.ClaimOfflineDeviceAlerts(ctx)
+	require.NoError(t, err)
+	require.Len(t, alerts, 1)
+	assert.Equal(t, dev.ID, alerts[0].DeviceID)
+
+	// Devices are only reported once.
+	alerts, err = f.dbw.ClaimOfflineDeviceAlerts(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, alerts)
+
+	require.NoError(t, f.dbw.ReplaceDeviceHeartbeat(ctx, dev.ID))
+
+	alerts, err = f.dbw.ClaimRecoveredDeviceAlerts(ctx)
+	require.NoError(t, err)
+	require.Len(t, alerts, 1)
+	assert.Equal(t, dev.ID, alerts[0].DeviceID)
+
+	alerts, err = f.dbw.ClaimRecoveredDeviceAlerts(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, alerts)
+}
+
+func TestWrapper_ReplaceDeviceCommandStatus(t *testing.T) {
+	f := newFixture(t)
+	defer f.Close()
+
+	ctx := context.Background()
+
+	org, err := f.dbw.CreateOrganization(ctx, "test", "example")
+	require.NoError(t, err)
+
+	dev, _, err := f.dbw.CreateDevice(ctx, org.ID, "example device")
+	require.NoError(t, err)
+
+	cmd, err := f.dbw.CreateDeviceCommand(ctx, dev.ID, DeviceCommandTypeReboot, nil, DeviceCommandTTL)
+	require.NoError(t, err)
+	assert.Equal(t, DeviceCommandStatusQueued, cmd.Status)
+	assert.True(t, cmd.ExpiresAt.After(cmd.CreatedAt))
+
+	err = f.dbw.ReplaceDeviceCommandStatus(ctx, dev.ID, cmd.ID, DeviceCommandStatusAcked,
+		sql.NullString{}, sql.NullString{},
+	)
+	require.NoError(t, err)
+
+	// The status of a command cannot go back.
+	err = f.dbw.ReplaceDeviceCommandStatus(ctx, dev.ID, cmd.ID, DeviceCommandStatusStored,
+		sql.NullString{}, sql.NullString{},
+	)
+	assert.True(t, errors.Is(err, sql.ErrNoRows))
+
+	err = f.dbw.ReplaceDeviceCommandStatus(ctx, dev.ID, cmd.ID, DeviceCommandStatusFailed,
+		sql.NullString{Valid: true, String: "could not reboot"}, sql.NullString{},
+	)
+	require.NoError(t, err)
+
+	// Completed commands cannot be changed anymore.
+	err = f.dbw.ReplaceDeviceCommandStatus(ctx, dev.ID, cmd.ID, DeviceCommandStatusSucceeded,
+		sql.NullString{}, sql.NullString{},
+	)
+	assert.True(t, errors.Is(err, sql.ErrNoRows))
+
+	cmds, err := f.dbw.GetDeviceCommands(ctx, GetDeviceCommandsOpts{DeviceID: dev.ID})
+	require.NoError(t, err)
+	require.Len(t, cmds.DeviceCommands, 1)
+	assert.Equal(t, DeviceCommandStatusFailed, cmds.DeviceCommands[0].Status)
+	assert.Equal(t, "could not reboot", cmds.DeviceCommands[0].Error.String)
+	assert.True(t, cmds.DeviceCommands[0].AckedAt.Valid)
+	assert.True(t, cmds.DeviceCommands[0].CompletedAt.Valid)
+}
+
+func TestWrapper_ClaimDeviceScheduleRun(t *testing.T) {
+	f := newFixture(t)
+	defer f.Close()
+
+	ctx := context.Background()
+
+	org, err := f.dbw.CreateOrganization(ctx, "test", "example")
+	require.NoError(t, err)
+
+	group, err := f.dbw.CreateDeviceGroup(ctx, org.ID, "lobby")
+	require.NoError(t, err)
+
+	schedule, err := f.dbw.CreateDeviceSchedule(ctx, DeviceSchedule{
+		OrganizationID: org.ID,
+		DeviceGroupID:  group.ID,
+		Action:         DeviceCommandTypeReboot,
+		CronExpression: "0 3 * * *",
+		Timezone:       "Europe/Amsterdam",
+		Enabled:        true,
+	})
+	require.NoError(t, err)
+
+	runAt := time.Now().Truncate(time.Minute)
+
+	claimed, err := f.dbw.ClaimDeviceScheduleRun(ctx, schedule.ID, runAt)
+	require.NoError(t, err)
+	assert.Equal(t, DeviceCommandTypeReboot, claimed.Action)
+
+	// Another backend can't run the schedule for the same time.
+	_, err = f.dbw.ClaimDeviceScheduleRun(ctx, schedule.ID, runAt)
+	assert.True(t, errors.Is(err, sql.ErrNoRows))
+
+	schedule.Enabled = false
+	require.NoError(t, f.dbw.ReplaceDeviceSchedule(ctx, schedule))
+
+	_, err = f.dbw.ClaimDeviceScheduleRun(ctx, schedule.ID, runAt.Add(time.Minute))
+	assert.True(t, errors.Is(err, sql.ErrNoRows))
+}
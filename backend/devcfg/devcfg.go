@@ -0,0 +1,123 @@
+// Package devcfg combines and validates the device config profiles which are assigned to devices.
+package devcfg
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	devcfgpb "gitlab.com/timeterm/timeterm/proto/go/devcfg"
+	"golang.org/x/text/language"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+const (
+	minIdleTimeout = 10 * time.Second
+	maxIdleTimeout = 24 * time.Hour
+	// maxBrightnessPeriods limits the brightness schedule to a change every half hour.
+	maxBrightnessPeriods = 48
+	maxLanguageLength    = 35
+	maxTitleLength       = 100
+	minutesPerDay        = 24 * 60
+)
+
+// Merge combines serialized device configs, ordered from the least to the most specific.
+// A field which is set in a config replaces the field of the configs before it, messages (such as the
+// brightness schedule) are replaced as a whole. Empty configs (of devices without a profile) are skipped.
+func Merge(configs ...[]byte) (*devcfgpb.DeviceConfig, error) {
+	merged := new(devcfgpb.DeviceConfig)
+	dst := merged.ProtoReflect()
+
+	for _, config := range configs {
+		if len(config) == 0 {
+			continue
+		}
+
+		var cfg devcfgpb.DeviceConfig
+		if err := proto.Unmarshal(config, &cfg); err != nil {
+			return nil, fmt.Errorf("could not unmarshal device config: %w", err)
+		}
+
+		cfg.ProtoReflect().Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+			dst.Set(fd, v)
+			return true
+		})
+	}
+
+	return merged, nil
+}
+
+// Hash computes a hash of a device config, so devices are only asked to retrieve their config
+// when it has actually changed.
+func Hash(cfg *devcfgpb.DeviceConfig) ([]byte, error) {
+	data, err := proto.MarshalOptions{Deterministic: true}.Marshal(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	h := sha256.Sum256(data)
+	return h[:], nil
+}
+
+// Validate checks if the fields which are set in the config are valid.
+// It sorts the periods of the brightness schedule by their start and canonicalizes the language.
+func Validate(cfg *devcfgpb.DeviceConfig) error {
+	if cfg.Language != nil {
+		if len(*cfg.Language) > maxLanguageLength {
+			return errors.New("invalid language")
+		}
+		tag, err := language.Parse(*cfg.Language)
+		if err != nil || tag == language.Und {
+			return errors.New("invalid language")
+		}
+		*cfg.Language = tag.String()
+	}
+
+	if cfg.Timezone != nil {
+		// LoadLocation returns UTC for an empty name and the time zone of the backend for Local,
+		// which are not names that devices know.
+		if *cfg.Timezone == "" || *cfg.Timezone == "Local" {
+			return errors.New("invalid timezone")
+		}
+		if _, err := time.LoadLocation(*cfg.Timezone); err != nil {
+			return errors.New("invalid timezone")
+		}
+	}
+
+	if cfg.IdleTimeoutSeconds != nil {
+		idleTimeout := time.Duration(*cfg.IdleTimeoutSeconds) * time.Second
+		if idleTimeout < minIdleTimeout || idleTimeout > maxIdleTimeout {
+			return fmt.Errorf("idle timeout must be between %s and %s", minIdleTimeout, maxIdleTimeout)
+		}
+	}
+
+	if schedule := cfg.BrightnessSchedule; schedule != nil {
+		if len(schedule.Periods) > maxBrightnessPeriods {
+			return errors.New("too many brightness periods")
+		}
+
+		sort.Slice(schedule.Periods, func(i, j int) bool {
+			return schedule.Periods[i].StartMinute < schedule.Periods[j].StartMinute
+		})
+		for i, period := range schedule.Periods {
+			if period.StartMinute >= minutesPerDay {
+				return errors.New("brightness period must start before midnight")
+			}
+			if period.Brightness > 100 {
+				return errors.New("brightness must be a percentage")
+			}
+			if i > 0 && schedule.Periods[i-1].StartMinute == period.StartMinute {
+				return errors.New("brightness periods must start at different times")
+			}
+		}
+	}
+
+	if cfg.Branding != nil && len([]rune(cfg.Branding.Title)) > maxTitleLength {
+		return errors.New("title is too long")
+	}
+
+	return nil
+}
@@ -0,0 +1,95 @@
+package devcfg
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	devcfgpb "gitlab.com/timeterm/timeterm/proto/go/devcfg"
+	"google.golang.org/protobuf/proto"
+)
+
+func marshal(t *testing.T, cfg *devcfgpb.DeviceConfig) []byte {
+	t.Helper()
+
+	data, err := proto.Marshal(cfg)
+	require.NoError(t, err)
+	return data
+}
+
+func TestMerge(t *testing.T) {
+	organization := marshal(t, &devcfgpb.DeviceConfig{
+		Language:           proto.String("nl-NL"),
+		IdleTimeoutSeconds: proto.Uint32(60),
+		BrightnessSchedule: &devcfgpb.BrightnessSchedule{
+			Periods: []*devcfgpb.BrightnessPeriod{
+				{StartMinute: 7 * 60, Brightness: 100},
+				{StartMinute: 18 * 60, Brightness: 20},
+			},
+		},
+	})
+	group := marshal(t, &devcfgpb.DeviceConfig{
+		IdleTimeoutSeconds: proto.Uint32(300),
+		BrightnessSchedule: &devcfgpb.BrightnessSchedule{
+			Periods: []*devcfgpb.BrightnessPeriod{{StartMinute: 0, Brightness: 50}},
+		},
+	})
+	device := marshal(t, &devcfgpb.DeviceConfig{
+		Language: proto.String("en-GB"),
+	})
+
+	cfg, err := Merge(organization, group, nil, device)
+	require.NoError(t, err)
+
+	assert.Equal(t, "en-GB", cfg.GetLanguage())
+	assert.Equal(t, uint32(300), cfg.GetIdleTimeoutSeconds())
+	// Messages are replaced as a whole.
+	require.Len(t, cfg.BrightnessSchedule.Periods, 1)
+	assert.Equal(t, uint32(50), cfg.BrightnessSchedule.Periods[0].Brightness)
+	assert.Nil(t, cfg.Timezone)
+}
+
+func TestHash(t *testing.T) {
+	a, err := Hash(&devcfgpb.DeviceConfig{Language: proto.String("nl-NL")})
+	require.NoError(t, err)
+	b, err := Hash(&devcfgpb.DeviceConfig{Language: proto.String("nl-NL")})
+	require.NoError(t, err)
+	c, err := Hash(&devcfgpb.DeviceConfig{Language: proto.String("en-GB")})
+	require.NoError(t, err)
+
+	assert.Equal(t, a, b)
+	assert.NotEqual(t, a, c)
+}
+
+func TestValidate(t *testing.T) {
+	cfg := &devcfgpb.DeviceConfig{
+		Timezone: proto.String("Europe/Amsterdam"),
+		BrightnessSchedule: &devcfgpb.BrightnessSchedule{
+			Periods: []*devcfgpb.BrightnessPeriod{
+				{StartMinute: 18 * 60, Brightness: 20},
+				{StartMinute: 7 * 60, Brightness: 100},
+			},
+		},
+	}
+	require.NoError(t, Validate(cfg))
+	assert.Equal(t, uint32(7*60), cfg.BrightnessSchedule.Periods[0].StartMinute)
+
+	assert.Error(t, Validate(&devcfgpb.DeviceConfig{Timezone: proto.String("Mars/Olympus_Mons")}))
+	assert.Error(t, Validate(&devcfgpb.DeviceConfig{Timezone: proto.String("Local")}))
+	assert.Error(t, Validate(&devcfgpb.DeviceConfig{Language: proto.String("not a language")}))
+
+	cfg = &devcfgpb.DeviceConfig{Language: proto.String("nl-nl")}
+	require.NoError(t, Validate(cfg))
+	assert.Equal(t, "nl-NL", cfg.GetLanguage())
+	assert.Error(t, Validate(&devcfgpb.DeviceConfig{IdleTimeoutSeconds: proto.Uint32(1)}))
+	assert.Error(t, Validate(&devcfgpb.DeviceConfig{
+		BrightnessSchedule: &devcfgpb.BrightnessSchedule{
+			Periods: []*devcfgpb.BrightnessPeriod{{StartMinute: minutesPerDay, Brightness: 100}},
+		},
+	}))
+	assert.Error(t, Validate(&devcfgpb.DeviceConfig{
+		BrightnessSchedule: &devcfgpb.BrightnessSchedule{
+			Periods: []*devcfgpb.BrightnessPeriod{{StartMinute: 0, Brightness: 101}},
+		},
+	}))
+}
@@ -203,45 +203,117 @@ func (d encryptedData) encrypt(key []byte) ([]byte, []byte, error) {
 		return nil, nil, fmt.Errorf("could not marshal message data as JSON: %w", err)
 	}
 
-	block, err := aes.NewCipher(key)
+	return seal(bytes, key)
+}
+
+func decrypt(nonce, data, key []byte) (encryptedData, error) {
+	var ed encryptedData
+
+	bytes, err := open(nonce, data, key)
 	if err != nil {
-		return nil, nil, fmt.Errorf("could not create AES cipher: %w", err)
+		return ed, err
 	}
 
-	aesgcm, err := cipher.NewGCM(block)
-	if err != nil {
-		return nil, nil, fmt.Errorf("could not create AES GCM cipher: %w", err)
+	if err = json.Unmarshal(bytes, &ed); err != nil {
+		return ed, fmt.Errorf("could not unmarshal decrypted data: %w", err)
 	}
 
-	nonce := make([]byte, aesgcm.NonceSize())
-	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
-		return nil, nil, fmt.Errorf("could not generate nonce: %w", err)
+	return ed, nil
+}
+
+// BlobKind is the kind of data which is encrypted by EncryptBlob.
+type BlobKind string
+
+const (
+	BlobKindDeviceLogBundle  BlobKind = "device_log_bundle"
+	BlobKindDeviceScreenshot BlobKind = "device_screenshot"
+)
+
+// blobAdditionalData binds an encrypted blob to its kind and ID, so it can't be decrypted as another blob.
+func blobAdditionalData(kind BlobKind, id uuid.UUID) []byte {
+	return []byte(string(kind) + ":" + id.String())
+}
+
+// EncryptBlob encrypts data (such as log bundles of devices) with the logs key of the organization.
+// The blob can only be decrypted again with the same kind and ID.
+func (w *Wrapper) EncryptBlob(organizationID uuid.UUID,
+	kind BlobKind,
+	id uuid.UUID,
+	data []byte,
+) (nonce, encrypted []byte, err error) {
+	logsKey, err := w.secr.GetOrganizationLogsKeySecret(organizationID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("could not get organization logs secret: %w", err)
 	}
 
-	return nonce, aesgcm.Seal(nil, nonce, bytes, nil), nil
+	return sealWithAdditionalData(data, logsKey, blobAdditionalData(kind, id))
 }
 
-func decrypt(nonce, data, key []byte) (encryptedData, error) {
-	var ed encryptedData
+// DecryptBlob decrypts data which was encrypted by EncryptBlob.
+func (w *Wrapper) DecryptBlob(organizationID uuid.UUID, kind BlobKind, id uuid.UUID, nonce, encrypted []byte) ([]byte, error) {
+	logsKey, err := w.secr.GetOrganizationLogsKeySecret(organizationID)
+	if err != nil {
+		return nil, fmt.Errorf("could not get organization logs secret: %w", err)
+	}
+
+	data, err := openWithAdditionalData(nonce, encrypted, logsKey, blobAdditionalData(kind, id))
+	if err != nil {
+		// Blobs stored before they were bound to their kind and ID were encrypted without additional data.
+		// Only a few log bundles and screenshots are kept per device, so these are only around for a while.
+		if legacyData, legacyErr := open(nonce, encrypted, logsKey); legacyErr == nil {
+			return legacyData, nil
+		}
+		return nil, err
+	}
+	return data, nil
+}
 
+func newGCM(key []byte) (cipher.AEAD, error) {
 	block, err := aes.NewCipher(key)
 	if err != nil {
-		return ed, fmt.Errorf("could not create AES cipher: %w", err)
+		return nil, fmt.Errorf("could not create AES cipher: %w", err)
 	}
 
 	aesgcm, err := cipher.NewGCM(block)
 	if err != nil {
-		return ed, fmt.Errorf("could not create AES GCM cipher: %w", err)
+		return nil, fmt.Errorf("could not create AES GCM cipher: %w", err)
 	}
 
-	bytes, err := aesgcm.Open(nil, nonce, data, nil)
+	return aesgcm, nil
+}
+
+func seal(data, key []byte) ([]byte, []byte, error) {
+	return sealWithAdditionalData(data, key, nil)
+}
+
+func sealWithAdditionalData(data, key, additionalData []byte) ([]byte, []byte, error) {
+	aesgcm, err := newGCM(key)
 	if err != nil {
-		return ed, fmt.Errorf("could not decrypt data: %w", err)
+		return nil, nil, err
 	}
 
-	if err = json.Unmarshal(bytes, &ed); err != nil {
-		return ed, fmt.Errorf("could not unmarshal decrypted data: %w", err)
+	nonce := make([]byte, aesgcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, nil, fmt.Errorf("could not generate nonce: %w", err)
 	}
 
-	return ed, nil
+	return nonce, aesgcm.Seal(nil, nonce, data, additionalData), nil
+}
+
+func open(nonce, data, key []byte) ([]byte, error) {
+	return openWithAdditionalData(nonce, data, key, nil)
+}
+
+func openWithAdditionalData(nonce, data, key, additionalData []byte) ([]byte, error) {
+	aesgcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	bytes, err := aesgcm.Open(nil, nonce, data, additionalData)
+	if err != nil {
+		return nil, fmt.Errorf("could not decrypt data: %w", err)
+	}
+
+	return bytes, nil
 }
@@ -0,0 +1,80 @@
+package mq
+
+import (
+	"fmt"
+
+	"github.com/google/uuid"
+	mqpb "gitlab.com/timeterm/timeterm/proto/go/mq"
+
+	"gitlab.com/timeterm/timeterm/backend/database"
+)
+
+func announcementSeverityToProto(s database.AnnouncementSeverity) mqpb.AnnouncementSeverity {
+	switch s {
+	case database.AnnouncementSeverityInfo:
+		return mqpb.AnnouncementSeverity_ANNOUNCEMENT_SEVERITY_INFO
+	case database.AnnouncementSeverityWarning:
+		return mqpb.AnnouncementSeverity_ANNOUNCEMENT_SEVERITY_WARNING
+	case database.AnnouncementSeverityCritical:
+		return mqpb.AnnouncementSeverity_ANNOUNCEMENT_SEVERITY_CRITICAL
+	default:
+		return mqpb.AnnouncementSeverity_ANNOUNCEMENT_SEVERITY_UNSPECIFIED
+	}
+}
+
+func announcementMessageFromDB(a database.Announcement) *mqpb.AnnouncementMessage {
+	msg := &mqpb.AnnouncementMessage{
+		AnnouncementId: a.ID.String(),
+		Text:           a.Text,
+		Severity:       announcementSeverityToProto(a.Severity),
+		StartsAt:       a.StartsAt.Unix(),
+		HasImage:       a.HasImage,
+	}
+	if a.EndsAt.Valid {
+		msg.EndsAt = a.EndsAt.Time.Unix()
+	}
+	return msg
+}
+
+func (w *Wrapper) publishAnnouncement(deviceID uuid.UUID, msg *mqpb.AnnouncementMessage) error {
+	log := w.log.WithValues("deviceId", deviceID, "announcementId", msg.AnnouncementId)
+
+	subj := fmt.Sprintf("EMDEV.%s.ANNOUNCEMENT", deviceID)
+	log = log.V(1).WithValues("subject", subj)
+	log.Info("publishing announcement message")
+	err := w.publishToStream(subj, msg)
+	if err != nil {
+		log.Error(err, "publishing failed")
+	} else {
+		log.Info("publishing succeeded")
+	}
+
+	return err
+}
+
+// AnnouncementChanged sends the announcement to the devices in deviceIDs, which it is shown on,
+// and tells the devices in removedFrom (which it was shown on before) to remove it.
+// Devices which are offline retrieve the messages from the EMDEV-ANNOUNCEMENTS stream when they come back online.
+func (w *Wrapper) AnnouncementChanged(a database.Announcement, deviceIDs, removedFrom []uuid.UUID) {
+	msg := announcementMessageFromDB(a)
+	for _, deviceID := range deviceIDs {
+		if err := w.publishAnnouncement(deviceID, msg); err != nil {
+			w.log.Error(err, "could not send announcement to device", "deviceId", deviceID)
+		}
+	}
+
+	w.AnnouncementRemoved(a.ID, removedFrom)
+}
+
+// AnnouncementRemoved tells the devices in deviceIDs to no longer show the announcement.
+func (w *Wrapper) AnnouncementRemoved(id uuid.UUID, deviceIDs []uuid.UUID) {
+	msg := &mqpb.AnnouncementMessage{
+		AnnouncementId: id.String(),
+		Removed:        true,
+	}
+	for _, deviceID := range deviceIDs {
+		if err := w.publishAnnouncement(deviceID, msg); err != nil {
+			w.log.Error(err, "could not send announcement removal to device", "deviceId", deviceID)
+		}
+	}
+}
@@ -0,0 +1,234 @@
+package mq
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	mqpb "gitlab.com/timeterm/timeterm/proto/go/mq"
+	"google.golang.org/protobuf/proto"
+
+	"gitlab.com/timeterm/timeterm/backend/database"
+)
+
+// streamPublishTimeout is the maximum time JetStream may take to store a message.
+const streamPublishTimeout = 5 * time.Second
+
+func commandMessageFromDB(cmd database.DeviceCommand) (*mqpb.CommandMessage, error) {
+	msg := &mqpb.CommandMessage{
+		CommandId: cmd.ID.String(),
+		ExpiresAt: cmd.ExpiresAt.Unix(),
+	}
+
+	switch cmd.Type {
+	case database.DeviceCommandTypeReboot:
+		msg.Command = &mqpb.CommandMessage_Reboot{Reboot: new(mqpb.RebootMessage)}
+	case database.DeviceCommandTypeRetrieveNewNetworkingConfig:
+		msg.Command = &mqpb.CommandMessage_RetrieveNewNetworkingConfig{
+			RetrieveNewNetworkingConfig: new(mqpb.RetrieveNewNetworkingConfigMessage),
+		}
+	case database.DeviceCommandTypeScreenOff, database.DeviceCommandTypeScreenOn:
+		msg.Command = &mqpb.CommandMessage_SetScreenPower{
+			SetScreenPower: &mqpb.SetScreenPowerMessage{On: cmd.Type == database.DeviceCommandTypeScreenOn},
+		}
+	case database.DeviceCommandTypeUploadLogs:
+		msg.Command = &mqpb.CommandMessage_UploadLogs{UploadLogs: new(mqpb.UploadLogsMessage)}
+	case database.DeviceCommandTypeTakeScreenshot:
+		msg.Command = &mqpb.CommandMessage_TakeScreenshot{TakeScreenshot: new(mqpb.TakeScreenshotMessage)}
+	default:
+		return nil, fmt.Errorf("unknown command type %q", cmd.Type)
+	}
+
+	return msg, nil
+}
+
+// pubAck is the response of JetStream when a message is published to a stream.
+type pubAck struct {
+	Error *struct {
+		Description string `json:"description"`
+	} `json:"error"`
+}
+
+// publishToStream publishes a message to a JetStream stream and waits until JetStream has stored it.
+func (w *Wrapper) publishToStream(subj string, msg proto.Message) error {
+	data, err := proto.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("could not marshal message: %w", err)
+	}
+
+	rsp, err := w.enc.Conn.Request(subj, data, streamPublishTimeout)
+	if err != nil {
+		return fmt.Errorf("could not publish message: %w", err)
+	}
+
+	var ack pubAck
+	if err = json.Unmarshal(rsp.Data, &ack); err == nil && ack.Error != nil {
+		return fmt.Errorf("could not store message in stream: %s", ack.Error.Description)
+	}
+	return nil
+}
+
+// SendCommand stores a command in the EMDEV-COMMANDS stream, from which the device retrieves it.
+// The command is marked as stored once JetStream has stored it; the device acknowledges it when it has received it.
+func (w *Wrapper) SendCommand(ctx context.Context, cmd database.DeviceCommand) error {
+	log := w.log.WithValues("deviceId", cmd.DeviceID, "commandId", cmd.ID, "type", cmd.Type)
+
+	msg, err := commandMessageFromDB(cmd)
+	if err != nil {
+		return err
+	}
+
+	subj := fmt.Sprintf("EMDEV.%s.COMMAND", cmd.DeviceID)
+	log = log.V(1).WithValues("subject", subj)
+	log.Info("publishing command")
+
+	if err = w.publishToStream(subj, msg); err != nil {
+		log.Error(err, "publishing failed")
+		return fmt.Errorf("could not send command: %w", err)
+	}
+	log.Info("publishing succeeded")
+
+	err = w.dbw.ReplaceDeviceCommandStatus(ctx, cmd.DeviceID, cmd.ID,
+		database.DeviceCommandStatusStored, sql.NullString{}, sql.NullString{},
+	)
+	// The device may already have reported a result.
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("could not mark command as stored: %w", err)
+	}
+
+	if cmd.Type == database.DeviceCommandTypeReboot {
+		w.publishLegacyReboot(cmd.DeviceID)
+	}
+	return nil
+}
+
+// publishLegacyReboot publishes a reboot on EMDEV.<id>.REBOOT. Devices which don't retrieve commands
+// from EMDEV-COMMANDS yet only listen on this subject. These devices don't acknowledge the reboot,
+// so the reboot command expires and is marked as failed even if the device has rebooted.
+func (w *Wrapper) publishLegacyReboot(deviceID uuid.UUID) {
+	subj := fmt.Sprintf("EMDEV.%s.REBOOT", deviceID)
+	if err := w.enc.Publish(subj, new(mqpb.RebootMessage)); err != nil {
+		w.log.Error(err, "could not publish legacy reboot", "deviceId", deviceID, "subject", subj)
+	}
+}
+
+// RequestCommand sends a command and waits until the device reports that it has succeeded or failed,
+// like a NATS request. All backends receive the results of commands, so the result is also received
+// if it's reported to another backend (see PublishCommandResult).
+func (w *Wrapper) RequestCommand(ctx context.Context, cmd database.DeviceCommand) (*mqpb.CommandResultMessage, error) {
+	resc := make(chan *mqpb.CommandResultMessage, 1)
+
+	w.cmdmu.Lock()
+	w.cmdWaiters[cmd.ID] = resc
+	w.cmdmu.Unlock()
+
+	defer func() {
+		w.cmdmu.Lock()
+		delete(w.cmdWaiters, cmd.ID)
+		w.cmdmu.Unlock()
+	}()
+
+	if err := w.SendCommand(ctx, cmd); err != nil {
+		return nil, err
+	}
+
+	select {
+	case res := <-resc:
+		return res, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// PublishCommandResult publishes the result of a command on behalf of a device, e.g. when the backend
+// has received data which the device uploaded for the command.
+func (w *Wrapper) PublishCommandResult(deviceID uuid.UUID, msg *mqpb.CommandResultMessage) error {
+	return w.enc.Publish(fmt.Sprintf("EMDEV.%s.COMMAND-RESULT", deviceID), msg)
+}
+
+// notifyCommandWaiter passes the result of a command to RequestCommand, if it's waiting for it.
+func (w *Wrapper) notifyCommandWaiter(commandID uuid.UUID, msg *mqpb.CommandResultMessage) {
+	if msg.GetStatus() != mqpb.CommandStatus_COMMAND_STATUS_SUCCEEDED &&
+		msg.GetStatus() != mqpb.CommandStatus_COMMAND_STATUS_FAILED {
+		return
+	}
+
+	w.cmdmu.Lock()
+	defer w.cmdmu.Unlock()
+
+	if resc, ok := w.cmdWaiters[commandID]; ok {
+		select {
+		case resc <- msg:
+		default:
+		}
+	}
+}
+
+func commandStatusFromProto(s mqpb.CommandStatus) (database.DeviceCommandStatus, bool) {
+	switch s {
+	case mqpb.CommandStatus_COMMAND_STATUS_ACKED:
+		return database.DeviceCommandStatusAcked, true
+	case mqpb.CommandStatus_COMMAND_STATUS_SUCCEEDED:
+		return database.DeviceCommandStatusSucceeded, true
+	case mqpb.CommandStatus_COMMAND_STATUS_FAILED:
+		return database.DeviceCommandStatusFailed, true
+	default:
+		return "", false
+	}
+}
+
+func nullStringIfNotEmpty(s string) sql.NullString {
+	if s == "" {
+		return sql.NullString{}
+	}
+	if len(s) > maxCommandResultLength {
+		// Cut before the rune which would be split, so the string stays valid UTF-8 (as PostgreSQL requires).
+		n := maxCommandResultLength
+		for n > 0 && !utf8.RuneStart(s[n]) {
+			n--
+		}
+		s = s[:n]
+	}
+	return sql.NullString{Valid: true, String: s}
+}
+
+// maxCommandResultLength is the maximum length of the error and output of a command which are stored.
+const maxCommandResultLength = 64 * 1024
+
+func (w *Wrapper) handleCommandResult(subj string, msg *mqpb.CommandResultMessage) {
+	deviceID, err := deviceIDFromSubject(subj)
+	if err != nil {
+		w.log.Error(err, "could not get device ID from command result subject", "subject", subj)
+		return
+	}
+
+	log := w.log.WithValues("deviceId", deviceID, "commandId", msg.GetCommandId())
+
+	commandID, err := uuid.Parse(msg.GetCommandId())
+	if err != nil {
+		log.Error(err, "invalid command ID in command result")
+		return
+	}
+
+	w.notifyCommandWaiter(commandID, msg)
+
+	status, ok := commandStatusFromProto(msg.GetStatus())
+	if !ok {
+		log.Info("invalid status in command result", "status", msg.GetStatus())
+		return
+	}
+
+	err = w.dbw.ReplaceDeviceCommandStatus(context.Background(), deviceID, commandID, status,
+		nullStringIfNotEmpty(msg.GetError()), nullStringIfNotEmpty(msg.GetOutput()),
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		log.V(1).Info("ignoring command result of unknown or completed command", "status", status)
+	} else if err != nil {
+		log.Error(err, "could not update command status", "status", status)
+	}
+}
@@ -0,0 +1,51 @@
+package mq
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/go-logr/logr"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	mqpb "gitlab.com/timeterm/timeterm/proto/go/mq"
+)
+
+func TestWrapper_notifyCommandWaiter(t *testing.T) {
+	w := &Wrapper{
+		log:        logr.Discard(),
+		cmdWaiters: make(map[uuid.UUID]chan *mqpb.CommandResultMessage),
+	}
+
+	commandID := uuid.New()
+	resc := make(chan *mqpb.CommandResultMessage, 1)
+	w.cmdWaiters[commandID] = resc
+
+	// Acknowledgements don't complete the request.
+	w.notifyCommandWaiter(commandID, &mqpb.CommandResultMessage{Status: mqpb.CommandStatus_COMMAND_STATUS_ACKED})
+	assert.Len(t, resc, 0)
+
+	// Results of other commands are ignored.
+	w.notifyCommandWaiter(uuid.New(), &mqpb.CommandResultMessage{Status: mqpb.CommandStatus_COMMAND_STATUS_SUCCEEDED})
+	assert.Len(t, resc, 0)
+
+	w.notifyCommandWaiter(commandID, &mqpb.CommandResultMessage{
+		Status: mqpb.CommandStatus_COMMAND_STATUS_FAILED,
+		Error:  "no display",
+	})
+	// Duplicate results don't block.
+	w.notifyCommandWaiter(commandID, &mqpb.CommandResultMessage{Status: mqpb.CommandStatus_COMMAND_STATUS_FAILED})
+
+	res := <-resc
+	assert.Equal(t, "no display", res.GetError())
+}
+
+func TestNullStringIfNotEmpty(t *testing.T) {
+	assert.False(t, nullStringIfNotEmpty("").Valid)
+	assert.Equal(t, "output", nullStringIfNotEmpty("output").String)
+
+	// The last 'é' (2 bytes) would be split at the maximum length.
+	s := nullStringIfNotEmpty("a" + strings.Repeat("é", maxCommandResultLength/2))
+	assert.True(t, utf8.ValidString(s.String))
+	assert.Equal(t, maxCommandResultLength-1, len(s.String))
+}
@@ -0,0 +1,81 @@
+package mq
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	mqpb "gitlab.com/timeterm/timeterm/proto/go/mq"
+
+	"gitlab.com/timeterm/timeterm/backend/devcfg"
+)
+
+func (w *Wrapper) RetrieveNewDeviceConfig(deviceID uuid.UUID) error {
+	log := w.log.WithValues("deviceId", deviceID)
+
+	subj := fmt.Sprintf("EMDEV.%s.RETRIEVE-NEW-DEVICE-CONFIG", deviceID)
+	log = log.V(1).WithValues("subject", subj)
+	log.Info("publishing new device config retrieval message")
+	err := w.enc.Publish(subj, new(mqpb.RetrieveNewDeviceConfigMessage))
+	if err != nil {
+		log.Error(err, "publishing failed")
+	} else {
+		log.Info("publishing succeeded")
+	}
+
+	return err
+}
+
+// DeviceConfigUpdated asks the devices in the organization whose effective device config has changed
+// to retrieve it again. Calls in quick succession are debounced.
+func (w *Wrapper) DeviceConfigUpdated(organizationID uuid.UUID) {
+	w.getDeviceConfigUpdatedDebounce(organizationID)()
+}
+
+func (w *Wrapper) getDeviceConfigUpdatedDebounce(organizationID uuid.UUID) func() {
+	return w.getDebounce(debounceKey{name: "DeviceConfigUpdated", organizationID: organizationID}, func() {
+		log := w.log.WithValues("organizationId", organizationID)
+
+		deviceIDs, err := w.getDevicesWithOutdatedDeviceConfig(context.Background(), organizationID)
+		if err != nil {
+			log.Error(err, "could not get devices with outdated device config")
+			return
+		}
+
+		for _, deviceID := range deviceIDs {
+			if err := w.RetrieveNewDeviceConfig(deviceID); err != nil {
+				log.Error(err, "could not send message to device to retrieve new device config",
+					"deviceId", deviceID,
+				)
+			}
+		}
+	})
+}
+
+// getDevicesWithOutdatedDeviceConfig retrieves the IDs of the devices in an organization
+// whose effective device config has changed since they last retrieved it.
+func (w *Wrapper) getDevicesWithOutdatedDeviceConfig(ctx context.Context, organizationID uuid.UUID) ([]uuid.UUID, error) {
+	layers, err := w.dbw.GetOrganizationDeviceConfigLayers(ctx, organizationID)
+	if err != nil {
+		return nil, err
+	}
+
+	var outdated []uuid.UUID
+	for _, l := range layers {
+		cfg, err := devcfg.Merge(l.OrganizationConfig, l.GroupConfig, l.DeviceConfig)
+		if err != nil {
+			return nil, fmt.Errorf("could not merge device config of device %s: %w", l.DeviceID, err)
+		}
+
+		hash, err := devcfg.Hash(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("could not hash device config of device %s: %w", l.DeviceID, err)
+		}
+
+		if !bytes.Equal(hash, l.RetrievedConfigHash) {
+			outdated = append(outdated, l.DeviceID)
+		}
+	}
+	return outdated, nil
+}
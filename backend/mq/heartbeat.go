@@ -0,0 +1,258 @@
+package mq
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nats-io/nats.go"
+	mqpb "gitlab.com/timeterm/timeterm/proto/go/mq"
+
+	"gitlab.com/timeterm/timeterm/backend/database"
+)
+
+const (
+	// heartbeatFlushInterval is the interval in which received heartbeats are written to the database.
+	heartbeatFlushInterval = 5 * time.Second
+	// deviceUserNamePrefix is the prefix of the names of the NATS users of devices (in the EMDEVS account).
+	deviceUserNamePrefix = "emdev-"
+	// heartbeatQueueGroup is the queue group of the backends, so every heartbeat is only stored by one of them.
+	heartbeatQueueGroup = "backend-heartbeats"
+)
+
+// Run processes the heartbeats, command results and (dis)connect events of devices, until the context is canceled.
+func (w *Wrapper) Run(ctx context.Context) error {
+	hbSub, err := w.enc.QueueSubscribe("EMDEV.*.HEARTBEAT", heartbeatQueueGroup, w.handleHeartbeat)
+	if err != nil {
+		return fmt.Errorf("could not subscribe to heartbeats: %w", err)
+	}
+	defer func() { _ = hbSub.Unsubscribe() }()
+
+	cmdSub, err := w.enc.Subscribe("EMDEV.*.COMMAND-RESULT", w.handleCommandResult)
+	if err != nil {
+		return fmt.Errorf("could not subscribe to command results: %w", err)
+	}
+	defer func() { _ = cmdSub.Unsubscribe() }()
+
+	connSub, err := w.sys.Subscribe("$SYS.ACCOUNT.*.CONNECT", w.handleConnect)
+	if err != nil {
+		return fmt.Errorf("could not subscribe to connect events: %w", err)
+	}
+	defer func() { _ = connSub.Unsubscribe() }()
+
+	disconnSub, err := w.sys.Subscribe("$SYS.ACCOUNT.*.DISCONNECT", w.handleDisconnect)
+	if err != nil {
+		return fmt.Errorf("could not subscribe to disconnect events: %w", err)
+	}
+	defer func() { _ = disconnSub.Unsubscribe() }()
+
+	ticker := time.NewTicker(heartbeatFlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			w.flushHeartbeats()
+		case <-ctx.Done():
+			w.flushHeartbeats()
+			return ctx.Err()
+		}
+	}
+}
+
+func deviceIDFromSubject(subj string) (uuid.UUID, error) {
+	parts := strings.Split(subj, ".")
+	if len(parts) != 3 {
+		return uuid.Nil, errors.New("unexpected subject")
+	}
+	return uuid.Parse(parts[1])
+}
+
+func (w *Wrapper) handleHeartbeat(subj string, msg *mqpb.HeartbeatMessage) {
+	deviceID, err := deviceIDFromSubject(subj)
+	if err != nil {
+		w.log.Error(err, "could not get device ID from heartbeat subject", "subject", subj)
+		return
+	}
+
+	// Devices only report their capabilities after they have connected, so they are stored right away.
+	if msg.GetCapabilities() != nil {
+		capabilities := deviceCapabilitiesFromProto(msg.GetCapabilities())
+		if err = w.dbw.ReplaceDeviceCapabilities(context.Background(), deviceID, capabilities); err != nil {
+			w.log.Error(err, "could not store device capabilities", "deviceId", deviceID)
+		}
+	}
+
+	var status *database.DeviceStatus
+	if msg.GetStatus() != nil {
+		dbStatus, err := deviceStatusFromProto(deviceID, msg.GetStatus())
+		if err != nil {
+			w.log.Error(err, "invalid device status in heartbeat", "deviceId", deviceID)
+		} else {
+			status = &dbStatus
+		}
+	}
+
+	w.hbmu.Lock()
+	defer w.hbmu.Unlock()
+
+	// Only the last status is kept when a device sends multiple heartbeats in one interval.
+	if _, ok := w.heartbeats[deviceID]; !ok || status != nil {
+		w.heartbeats[deviceID] = status
+	}
+}
+
+// flushHeartbeats writes the heartbeats which have been received since the last flush to the database.
+// Heartbeats without a status are written in a single batch.
+func (w *Wrapper) flushHeartbeats() {
+	w.hbmu.Lock()
+	heartbeats := w.heartbeats
+	w.heartbeats = make(map[uuid.UUID]*database.DeviceStatus)
+	w.hbmu.Unlock()
+
+	if len(heartbeats) == 0 {
+		return
+	}
+
+	ctx := context.Background()
+
+	var deviceIDs []uuid.UUID
+	for deviceID, status := range heartbeats {
+		if status == nil {
+			deviceIDs = append(deviceIDs, deviceID)
+			continue
+		}
+
+		if _, err := w.dbw.CreateDeviceStatus(ctx, *status); err != nil {
+			w.log.Error(err, "could not store device status", "deviceId", deviceID)
+		}
+	}
+
+	if len(deviceIDs) > 0 {
+		if err := w.dbw.ReplaceDeviceHeartbeats(ctx, deviceIDs); err != nil {
+			w.log.Error(err, "could not update last heartbeats", "devices", len(deviceIDs))
+		}
+	}
+}
+
+// clientEvent is the part of the (dis)connect events published by the NATS server which the backend uses.
+type clientEvent struct {
+	Client struct {
+		// NameTag is the name of the user in the JWT, which is only set by NATS server 2.2 and newer.
+		NameTag string `json:"name_tag"`
+	} `json:"client"`
+}
+
+// deviceIDFromClientEvent retrieves the ID of the device which has (dis)connected.
+// The event is ignored when it is not about a device.
+func deviceIDFromClientEvent(msg *nats.Msg) (uuid.UUID, bool) {
+	var ev clientEvent
+	if err := json.Unmarshal(msg.Data, &ev); err != nil {
+		return uuid.Nil, false
+	}
+	if !strings.HasPrefix(ev.Client.NameTag, deviceUserNamePrefix) {
+		return uuid.Nil, false
+	}
+
+	id, err := uuid.Parse(strings.TrimPrefix(ev.Client.NameTag, deviceUserNamePrefix))
+	if err != nil {
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+func (w *Wrapper) handleConnect(msg *nats.Msg) {
+	deviceID, ok := deviceIDFromClientEvent(msg)
+	if !ok {
+		return
+	}
+
+	w.log.V(1).Info("device connected", "deviceId", deviceID)
+	if err := w.dbw.ReplaceDeviceHeartbeat(context.Background(), deviceID); err != nil {
+		w.log.Error(err, "could not update last heartbeat of connected device", "deviceId", deviceID)
+	}
+}
+
+func (w *Wrapper) handleDisconnect(msg *nats.Msg) {
+	deviceID, ok := deviceIDFromClientEvent(msg)
+	if !ok {
+		return
+	}
+
+	w.log.V(1).Info("device disconnected", "deviceId", deviceID)
+	if err := w.dbw.ReplaceDeviceDisconnect(context.Background(), deviceID); err != nil {
+		w.log.Error(err, "could not mark device as disconnected", "deviceId", deviceID)
+	}
+}
+
+func optionalString(s *string) sql.NullString {
+	if s == nil {
+		return sql.NullString{}
+	}
+	return sql.NullString{Valid: true, String: *s}
+}
+
+func optionalInt64(i *int64) sql.NullInt64 {
+	if i == nil {
+		return sql.NullInt64{}
+	}
+	return sql.NullInt64{Valid: true, Int64: *i}
+}
+
+// deviceCapabilitiesFromProto converts the reported capabilities, ignoring capabilities which are unknown.
+func deviceCapabilitiesFromProto(c *mqpb.DeviceCapabilities) []string {
+	capabilities := make([]string, 0, len(c.GetCapabilities()))
+	for _, capability := range c.GetCapabilities() {
+		if capability == mqpb.DeviceCapability_DEVICE_CAPABILITY_SCREEN_POWER {
+			capabilities = append(capabilities, database.DeviceCapabilityScreenPower)
+		}
+	}
+	return capabilities
+}
+
+func cardReaderStatusFromProto(s *mqpb.CardReaderStatus) (sql.NullString, error) {
+	if s == nil {
+		return sql.NullString{}, nil
+	}
+
+	var status database.CardReaderStatus
+	switch *s {
+	case mqpb.CardReaderStatus_CARD_READER_STATUS_OK:
+		status = database.CardReaderStatusOk
+	case mqpb.CardReaderStatus_CARD_READER_STATUS_NOT_CONNECTED:
+		status = database.CardReaderStatusNotConnected
+	case mqpb.CardReaderStatus_CARD_READER_STATUS_ERROR:
+		status = database.CardReaderStatusError
+	default:
+		return sql.NullString{}, errors.New("invalid card reader status")
+	}
+	return sql.NullString{Valid: true, String: string(status)}, nil
+}
+
+func deviceStatusFromProto(deviceID uuid.UUID, s *mqpb.DeviceStatus) (database.DeviceStatus, error) {
+	status := database.DeviceStatus{
+		DeviceID:      deviceID,
+		OsVersion:     optionalString(s.OsVersion),
+		AppVersion:    optionalString(s.AppVersion),
+		UptimeSeconds: optionalInt64(s.UptimeSeconds),
+		IpAddress:     optionalString(s.IpAddress),
+		SSID:          optionalString(s.Ssid),
+		FreeDiskBytes: optionalInt64(s.FreeDiskBytes),
+		LastError:     optionalString(s.LastError),
+	}
+	if s.SignalStrength != nil {
+		status.SignalStrength = sql.NullInt32{Valid: true, Int32: *s.SignalStrength}
+	}
+
+	var err error
+	if status.CardReaderStatus, err = cardReaderStatusFromProto(s.CardReaderStatus); err != nil {
+		return status, err
+	}
+
+	return status, status.Validate()
+}
@@ -0,0 +1,53 @@
+package mq
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	mqpb "gitlab.com/timeterm/timeterm/proto/go/mq"
+
+	"gitlab.com/timeterm/timeterm/backend/database"
+)
+
+func TestDeviceStatusFromProto(t *testing.T) {
+	str := func(s string) *string { return &s }
+	deviceID := uuid.New()
+
+	status, err := deviceStatusFromProto(deviceID, &mqpb.DeviceStatus{
+		IpAddress:        str("192.0.2.1"),
+		CardReaderStatus: mqpb.CardReaderStatus_CARD_READER_STATUS_NOT_CONNECTED.Enum(),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, deviceID, status.DeviceID)
+	assert.Equal(t, "192.0.2.1", status.IpAddress.String)
+	assert.Equal(t, string(database.CardReaderStatusNotConnected), status.CardReaderStatus.String)
+
+	// The same validation as for statuses reported over HTTP is used.
+	_, err = deviceStatusFromProto(deviceID, &mqpb.DeviceStatus{IpAddress: str("not an IP address")})
+	assert.Error(t, err)
+
+	_, err = deviceStatusFromProto(deviceID, &mqpb.DeviceStatus{
+		LastError: str(strings.Repeat("a", database.MaxDeviceStatusStringLength+1)),
+	})
+	assert.Error(t, err)
+
+	_, err = deviceStatusFromProto(deviceID, &mqpb.DeviceStatus{
+		CardReaderStatus: mqpb.CardReaderStatus_CARD_READER_STATUS_UNSPECIFIED.Enum(),
+	})
+	assert.Error(t, err)
+}
+
+func TestDeviceCapabilitiesFromProto(t *testing.T) {
+	assert.Equal(t, []string{database.DeviceCapabilityScreenPower}, deviceCapabilitiesFromProto(&mqpb.DeviceCapabilities{
+		Capabilities: []mqpb.DeviceCapability{
+			mqpb.DeviceCapability_DEVICE_CAPABILITY_UNSPECIFIED,
+			mqpb.DeviceCapability_DEVICE_CAPABILITY_SCREEN_POWER,
+		},
+	}))
+
+	// Devices without capabilities clear the stored capabilities, which can't be NULL.
+	assert.NotNil(t, deviceCapabilitiesFromProto(&mqpb.DeviceCapabilities{}))
+}
@@ -21,8 +21,19 @@ type Wrapper struct {
 	log logr.Logger
 	enc *nats.EncodedConn
 	dbw *database.Wrapper
+	// sys is connected to the system account, to receive (dis)connect events of devices.
+	sys *nats.Conn
 
+	// debounces contains the debounced functions by their debounceKey.
 	debounces sync.Map
+
+	hbmu sync.Mutex
+	// heartbeats contains the devices which have sent a heartbeat since the last flush, with their last status.
+	heartbeats map[uuid.UUID]*database.DeviceStatus
+
+	cmdmu sync.Mutex
+	// cmdWaiters contains the commands which are waited for by RequestCommand, by their ID.
+	cmdWaiters map[uuid.UUID]chan *mqpb.CommandResultMessage
 }
 
 func NewWrapper(log logr.Logger, dbw *database.Wrapper) (*Wrapper, error) {
@@ -40,23 +51,40 @@ func NewWrapper(log logr.Logger, dbw *database.Wrapper) (*Wrapper, error) {
 		return nil, fmt.Errorf("could not connect to NATS: %w", err)
 	}
 
+	sysAcr, err := nmsdk.NewAppCredsRetrieverFromEnv("backend-sys")
+	if err != nil {
+		return nil, fmt.Errorf("could not create (NATS) app credentials retriever: %w", err)
+	}
+
+	sysnc, err := nats.Connect(os.Getenv("NATS_URL"),
+		nats.UserJWT(sysAcr.NatsCredsCBs()),
+		// Never stop trying to reconnect.
+		nats.MaxReconnects(-1),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("could not connect to NATS (system account): %w", err)
+	}
+
 	return &Wrapper{
 		log: log.WithName("MqWrapper"),
 		enc: &nats.EncodedConn{
 			Conn: nc,
 			Enc:  natspb.NewEncoder(),
 		},
-		dbw: dbw,
+		dbw:        dbw,
+		sys:        sysnc,
+		heartbeats: make(map[uuid.UUID]*database.DeviceStatus),
+		cmdWaiters: make(map[uuid.UUID]chan *mqpb.CommandResultMessage),
 	}, nil
 }
 
-func (w *Wrapper) RebootDevice(id uuid.UUID) error {
-	log := w.log.WithValues("deviceId", id)
+func (w *Wrapper) RetrieveNewNetworkingConfig(deviceID uuid.UUID) error {
+	log := w.log.WithValues("deviceId", deviceID)
 
-	subj := fmt.Sprintf("EMDEV.%s.REBOOT", id)
+	subj := fmt.Sprintf("EMDEV.%s.RETRIEVE-NEW-NETWORKING-CONFIG", deviceID)
 	log = log.V(1).WithValues("subject", subj)
-	log.Info("publishing reboot message")
-	err := w.enc.Publish(subj, new(mqpb.RebootMessage))
+	log.Info("publishing new networking config retrieval message")
+	err := w.enc.Publish(subj, new(mqpb.RetrieveNewNetworkingConfigMessage))
 	if err != nil {
 		log.Error(err, "publishing failed")
 	} else {
@@ -66,13 +94,34 @@ func (w *Wrapper) RebootDevice(id uuid.UUID) error {
 	return err
 }
 
-func (w *Wrapper) RetrieveNewNetworkingConfig(deviceID uuid.UUID) error {
-	log := w.log.WithValues("deviceId", deviceID)
+func (w *Wrapper) StartCardEnrollment(deviceID, sessionID uuid.UUID, expiresAt time.Time) error {
+	log := w.log.WithValues("deviceId", deviceID, "sessionId", sessionID)
 
-	subj := fmt.Sprintf("EMDEV.%s.RETRIEVE-NEW-NETWORKING-CONFIG", deviceID)
+	subj := fmt.Sprintf("EMDEV.%s.START-CARD-ENROLLMENT", deviceID)
 	log = log.V(1).WithValues("subject", subj)
-	log.Info("publishing new networking config retrieval message")
-	err := w.enc.Publish(subj, new(mqpb.RebootMessage))
+	log.Info("publishing start card enrollment message")
+	err := w.enc.Publish(subj, &mqpb.StartCardEnrollmentMessage{
+		SessionId: sessionID.String(),
+		ExpiresAt: expiresAt.Unix(),
+	})
+	if err != nil {
+		log.Error(err, "publishing failed")
+	} else {
+		log.Info("publishing succeeded")
+	}
+
+	return err
+}
+
+func (w *Wrapper) CancelCardEnrollment(deviceID, sessionID uuid.UUID) error {
+	log := w.log.WithValues("deviceId", deviceID, "sessionId", sessionID)
+
+	subj := fmt.Sprintf("EMDEV.%s.CANCEL-CARD-ENROLLMENT", deviceID)
+	log = log.V(1).WithValues("subject", subj)
+	log.Info("publishing cancel card enrollment message")
+	err := w.enc.Publish(subj, &mqpb.CancelCardEnrollmentMessage{
+		SessionId: sessionID.String(),
+	})
 	if err != nil {
 		log.Error(err, "publishing failed")
 	} else {
@@ -87,31 +136,46 @@ func (w *Wrapper) NetworkingConfigUpdated(organizationID uuid.UUID) {
 }
 
 func (w *Wrapper) GetNetworkConfigUpdatedDebounce(organizationID uuid.UUID) func() {
-	if d, ok := w.debounces.Load(organizationID); ok {
+	return w.getDebounce(debounceKey{name: "NetworkingConfigUpdated", organizationID: organizationID}, func() {
+		log := w.log.WithValues("organizationId", organizationID)
+
+		// Only devices whose effective networking config has changed have to retrieve it again.
+		deviceIDs, err := w.dbw.GetDevicesWithOutdatedNetworkingConfig(context.Background(), organizationID)
+		if err != nil {
+			log.Error(err, "could not get devices with outdated networking config")
+			return
+		}
+
+		for _, deviceID := range deviceIDs {
+			if err := w.RetrieveNewNetworkingConfig(deviceID); err != nil {
+				log.Error(err, "could not send message to device to retrieve new networking config",
+					"deviceId", deviceID,
+				)
+			}
+		}
+	})
+}
+
+// debounceKey identifies a debounced function of an organization.
+type debounceKey struct {
+	name           string
+	organizationID uuid.UUID
+}
+
+// getDebounce returns the debounced function for the key, which calls f a second after the last call.
+// The debounced function is created if it doesn't exist yet, and is removed again after 30 seconds.
+func (w *Wrapper) getDebounce(key debounceKey, f func()) func() {
+	if d, ok := w.debounces.Load(key); ok {
 		return d.(func())
 	}
 
 	ctx, cancel := context.WithCancel(context.Background())
 	time.AfterFunc(30*time.Second, func() {
 		cancel()
-		w.debounces.Delete(organizationID)
+		w.debounces.Delete(key)
 	})
 
-	debfn := debounce(ctx, func() {
-		log := w.log.WithValues("organizationId", organizationID)
-
-		if err := w.dbw.WalkDevices(context.Background(), organizationID, func(d database.Device) bool {
-			log = log.WithValues("deviceId", d.ID)
-			if err := w.RetrieveNewNetworkingConfig(d.ID); err != nil {
-				log.Error(err, "could not send message to device to retrieve new networking config")
-			}
-			return true
-		}); err != nil {
-			log.Error(err, "could not walk devices in organization (to send RetrieveNewNetworkingConfig messages)")
-		}
-	}, time.Second)
-
-	d, _ := w.debounces.LoadOrStore(organizationID, debfn)
+	d, _ := w.debounces.LoadOrStore(key, debounce(ctx, f, time.Second))
 	return d.(func())
 }
 
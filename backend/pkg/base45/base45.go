@@ -0,0 +1,70 @@
+// Package base45 implements the Base45 encoding (RFC 9285), which is designed to be compact
+// in the alphanumeric mode of QR codes.
+package base45
+
+import (
+	"errors"
+	"strings"
+)
+
+const alphabet = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZ $%*+-./:"
+
+var ErrInvalidInput = errors.New("base45: invalid input")
+
+// EncodeToString encodes every two bytes of src as three characters, and a trailing byte as two characters.
+func EncodeToString(src []byte) string {
+	var sb strings.Builder
+	sb.Grow((len(src)/2)*3 + (len(src)%2)*2)
+
+	for i := 0; i+1 < len(src); i += 2 {
+		n := int(src[i])<<8 | int(src[i+1])
+		sb.WriteByte(alphabet[n%45])
+		sb.WriteByte(alphabet[n/45%45])
+		sb.WriteByte(alphabet[n/(45*45)])
+	}
+	if len(src)%2 == 1 {
+		n := int(src[len(src)-1])
+		sb.WriteByte(alphabet[n%45])
+		sb.WriteByte(alphabet[n/45])
+	}
+
+	return sb.String()
+}
+
+// DecodeString decodes a string encoded by EncodeToString.
+func DecodeString(s string) ([]byte, error) {
+	if len(s)%3 == 1 {
+		return nil, ErrInvalidInput
+	}
+
+	dst := make([]byte, 0, (len(s)/3)*2+(len(s)%3)/2)
+	for i := 0; i < len(s); i += 3 {
+		n, factor := 0, 1
+		end := i + 3
+		if end > len(s) {
+			end = len(s)
+		}
+		for j := i; j < end; j++ {
+			v := strings.IndexByte(alphabet, s[j])
+			if v < 0 {
+				return nil, ErrInvalidInput
+			}
+			n += v * factor
+			factor *= 45
+		}
+
+		if end-i == 3 {
+			if n > 0xFFFF {
+				return nil, ErrInvalidInput
+			}
+			dst = append(dst, byte(n>>8), byte(n))
+		} else {
+			if n > 0xFF {
+				return nil, ErrInvalidInput
+			}
+			dst = append(dst, byte(n))
+		}
+	}
+
+	return dst, nil
+}
@@ -0,0 +1,37 @@
+package base45
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncodeDecode(t *testing.T) {
+	// Examples from RFC 9285.
+	tests := []struct {
+		decoded string
+		encoded string
+	}{
+		{decoded: "AB", encoded: "BB8"},
+		{decoded: "Hello!!", encoded: "%69 VD92EX0"},
+		{decoded: "base-45", encoded: "UJCLQE7W581"},
+		{decoded: "ietf!", encoded: "QED8WEX0"},
+		{decoded: "", encoded: ""},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.encoded, EncodeToString([]byte(tt.decoded)))
+
+		got, err := DecodeString(tt.encoded)
+		require.NoError(t, err)
+		assert.Equal(t, tt.decoded, string(got))
+	}
+}
+
+func TestDecodeString_Invalid(t *testing.T) {
+	for _, s := range []string{"A", "GGW", "ZZ", "ab"} {
+		_, err := DecodeString(s)
+		assert.Equal(t, ErrInvalidInput, err, s)
+	}
+}
@@ -0,0 +1,195 @@
+// Package scheduler sends the commands of the schedules of device groups, such as nightly reboots.
+package scheduler
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/google/uuid"
+	"github.com/robfig/cron/v3"
+
+	"gitlab.com/timeterm/timeterm/backend/database"
+	"gitlab.com/timeterm/timeterm/backend/mq"
+)
+
+const (
+	// syncInterval is the interval in which schedules are reloaded from the database,
+	// so changes made by other backends are picked up.
+	syncInterval = time.Minute
+	maxStopTime  = time.Second * 30
+	maxRunTime   = time.Minute
+	// commandTTL is the time after which scheduled commands expire, so devices which were offline
+	// don't execute them long after they were scheduled.
+	commandTTL = time.Hour
+)
+
+// ParseSchedule parses a standard (five field) cron expression, which is evaluated in the timezone.
+func ParseSchedule(expr, timezone string) (cron.Schedule, error) {
+	expr = strings.TrimSpace(expr)
+	if strings.HasPrefix(expr, "TZ=") || strings.HasPrefix(expr, "CRON_TZ=") {
+		return nil, errors.New("timezone must not be part of the cron expression")
+	}
+	if strings.HasPrefix(expr, "@every") {
+		return nil, errors.New("@every is not supported")
+	}
+
+	if _, err := time.LoadLocation(timezone); err != nil {
+		return nil, fmt.Errorf("invalid timezone: %w", err)
+	}
+
+	return cron.ParseStandard(fmt.Sprintf("CRON_TZ=%s %s", timezone, expr))
+}
+
+type entry struct {
+	id        cron.EntryID
+	updatedAt time.Time
+}
+
+type Wrapper struct {
+	log     logr.Logger
+	dbw     *database.Wrapper
+	mqw     *mq.Wrapper
+	updated chan struct{}
+}
+
+func NewWrapper(log logr.Logger, dbw *database.Wrapper, mqw *mq.Wrapper) *Wrapper {
+	return &Wrapper{
+		log:     log.WithName("Scheduler"),
+		dbw:     dbw,
+		mqw:     mqw,
+		updated: make(chan struct{}, 1),
+	}
+}
+
+// SchedulesUpdated makes the scheduler reload the schedules from the database.
+func (w *Wrapper) SchedulesUpdated() {
+	select {
+	case w.updated <- struct{}{}:
+	default:
+	}
+}
+
+// Run runs the schedules until the context is canceled.
+func (w *Wrapper) Run(ctx context.Context) error {
+	c := cron.New(cron.WithLogger(w.log))
+	entries := make(map[uuid.UUID]entry)
+
+	c.Start()
+	defer func() {
+		stopCtx, cancel := context.WithTimeout(context.Background(), maxStopTime)
+		defer cancel()
+
+		select {
+		case <-c.Stop().Done():
+		case <-stopCtx.Done():
+		}
+	}()
+
+	ticker := time.NewTicker(syncInterval)
+	defer ticker.Stop()
+
+	for {
+		w.sync(ctx, c, entries)
+
+		select {
+		case <-ticker.C:
+		case <-w.updated:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// sync makes the entries of the cron scheduler match the enabled schedules in the database.
+func (w *Wrapper) sync(ctx context.Context, c *cron.Cron, entries map[uuid.UUID]entry) {
+	schedules, err := w.dbw.GetEnabledDeviceSchedules(ctx)
+	if err != nil {
+		w.log.Error(err, "could not get device schedules")
+		return
+	}
+
+	enabled := make(map[uuid.UUID]struct{}, len(schedules))
+	for _, schedule := range schedules {
+		enabled[schedule.ID] = struct{}{}
+
+		e, ok := entries[schedule.ID]
+		if ok && e.updatedAt.Equal(schedule.UpdatedAt) {
+			continue
+		}
+		if ok {
+			c.Remove(e.id)
+			delete(entries, schedule.ID)
+		}
+
+		sched, err := ParseSchedule(schedule.CronExpression, schedule.Timezone)
+		if err != nil {
+			w.log.Error(err, "invalid device schedule", "scheduleId", schedule.ID)
+			continue
+		}
+
+		id := c.Schedule(sched, w.newJob(schedule.ID))
+		entries[schedule.ID] = entry{id: id, updatedAt: schedule.UpdatedAt}
+	}
+
+	for scheduleID, e := range entries {
+		if _, ok := enabled[scheduleID]; !ok {
+			c.Remove(e.id)
+			delete(entries, scheduleID)
+		}
+	}
+}
+
+func (w *Wrapper) newJob(scheduleID uuid.UUID) cron.Job {
+	return cron.FuncJob(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), maxRunTime)
+		defer cancel()
+
+		w.run(ctx, scheduleID, time.Now().Truncate(time.Minute))
+	})
+}
+
+// run sends the command of the schedule to all devices in its device group.
+func (w *Wrapper) run(ctx context.Context, scheduleID uuid.UUID, runAt time.Time) {
+	log := w.log.WithValues("scheduleId", scheduleID)
+
+	schedule, err := w.dbw.ClaimDeviceScheduleRun(ctx, scheduleID, runAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		log.V(1).Info("schedule is disabled or already run by another backend")
+		return
+	}
+	if err != nil {
+		log.Error(err, "could not claim schedule run")
+		return
+	}
+
+	// Devices which don't support the action are skipped, so the schedule is run for them
+	// once they have been updated and report that they support it.
+	capability, _ := database.DeviceCommandCapability(schedule.Action)
+	deviceIDs, err := w.dbw.GetDeviceIDsBySelector(ctx, schedule.OrganizationID, database.DeviceSelector{
+		GroupIDs:   []uuid.UUID{schedule.DeviceGroupID},
+		Capability: capability,
+	})
+	if err != nil {
+		log.Error(err, "could not get devices in device group")
+		return
+	}
+
+	log.Info("running schedule", "action", schedule.Action, "devices", len(deviceIDs))
+	for _, deviceID := range deviceIDs {
+		cmd, err := w.dbw.CreateDeviceCommand(ctx, deviceID, schedule.Action, nil, commandTTL)
+		if err != nil {
+			log.Error(err, "could not create device command", "deviceId", deviceID)
+			continue
+		}
+
+		// Commands which could not be sent are marked as failed once they expire.
+		if err = w.mqw.SendCommand(ctx, cmd); err != nil {
+			log.Error(err, "could not send device command", "deviceId", deviceID, "commandId", cmd.ID)
+		}
+	}
+}
@@ -0,0 +1,33 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSchedule(t *testing.T) {
+	sched, err := ParseSchedule("0 3 * * *", "Europe/Amsterdam")
+	require.NoError(t, err)
+
+	loc, err := time.LoadLocation("Europe/Amsterdam")
+	require.NoError(t, err)
+
+	next := sched.Next(time.Date(2021, 3, 1, 12, 0, 0, 0, loc))
+	assert.True(t, next.Equal(time.Date(2021, 3, 2, 3, 0, 0, 0, loc)))
+
+	for _, tc := range []struct {
+		expr     string
+		timezone string
+	}{
+		{"0 3 * * *", "Nowhere/Invalid"},
+		{"CRON_TZ=UTC 0 3 * * *", "UTC"},
+		{"@every 1s", "UTC"},
+		{"0 3 * *", "UTC"},
+	} {
+		_, err = ParseSchedule(tc.expr, tc.timezone)
+		assert.Error(t, err, tc.expr)
+	}
+}
@@ -1,16 +1,20 @@
 package secrets
 
 import (
+	"crypto/ed25519"
 	"crypto/rand"
 	"encoding/base64"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"strconv"
 
 	"github.com/google/uuid"
 	vault "github.com/hashicorp/vault/api"
 	"google.golang.org/protobuf/proto"
 
+	"gitlab.com/timeterm/timeterm/backend/database"
 	devcfgpb "gitlab.com/timeterm/timeterm/proto/go/devcfg"
 )
 
@@ -45,6 +49,18 @@ func (w *Wrapper) createOrganizationLogsKeySecretPath(organizationID uuid.UUID)
 	return fmt.Sprintf("%s/data/%s/logskey/%s", w.mount, w.prefix, organizationID)
 }
 
+func (w *Wrapper) createOrganizationCardKeysSecretPath(organizationID uuid.UUID) string {
+	return fmt.Sprintf("%s/data/%s/cardkeys/%s", w.mount, w.prefix, organizationID)
+}
+
+func (w *Wrapper) createProvisioningSigningKeySecretPath() string {
+	return fmt.Sprintf("%s/data/%s/provisioning/signingkey", w.mount, w.prefix)
+}
+
+func (w *Wrapper) createUpdateSigningKeySecretPath() string {
+	return fmt.Sprintf("%s/data/%s/update/signingkey", w.mount, w.prefix)
+}
+
 func (w *Wrapper) GetNetworkingService(id uuid.UUID) (*devcfgpb.NetworkingService, error) {
 	secretPath := w.createNetworkingServiceSecretPath(id)
 	secret, err := w.c.Logical().Read(secretPath)
@@ -190,3 +206,280 @@ func (w *Wrapper) DeleteOrganizationLogsKeySecret(organizationID uuid.UUID) erro
 	_, err := w.c.Logical().Delete(secretPath)
 	return err
 }
+
+// OrganizationCardKeys contains the keys which are used to hash the UIDs of the student cards of an organization.
+// After a rotation, older versions are kept until no cards hashed with them are left.
+type OrganizationCardKeys struct {
+	Current int
+	Keys    map[int][]byte
+}
+
+// readOrganizationCardKeys reads the card keys of an organization.
+// It also returns the version of the secret, which is 0 if the secret does not exist.
+func (w *Wrapper) readOrganizationCardKeys(organizationID uuid.UUID) (OrganizationCardKeys, int, error) {
+	keys := OrganizationCardKeys{Keys: make(map[int][]byte)}
+
+	secretPath := w.createOrganizationCardKeysSecretPath(organizationID)
+	secret, err := w.c.Logical().Read(secretPath)
+	if err != nil {
+		return keys, 0, err
+	}
+	if secret == nil {
+		return keys, 0, nil
+	}
+
+	secretData, ok := secret.Data["data"].(map[string]interface{})
+	if !ok {
+		// The secret has been deleted, but the metadata is still there.
+		return keys, 0, nil
+	}
+	secretMetadata, ok := secret.Data["metadata"].(map[string]interface{})
+	if !ok {
+		return keys, 0, errors.New("card keys secret metadata not present")
+	}
+	version, ok := secretMetadata["version"].(json.Number)
+	if !ok {
+		return keys, 0, errors.New("card keys secret version not present")
+	}
+	secretVersion, err := version.Int64()
+	if err != nil {
+		return keys, 0, fmt.Errorf("invalid card keys secret version: %w", err)
+	}
+
+	current, ok := secretData["current"].(string)
+	if !ok {
+		return keys, 0, errors.New("current card key version not present in secret")
+	}
+	if keys.Current, err = strconv.Atoi(current); err != nil {
+		return keys, 0, fmt.Errorf("invalid current card key version: %w", err)
+	}
+
+	encodedKeys, ok := secretData["keys"].(map[string]interface{})
+	if !ok {
+		return keys, 0, errors.New("card keys not present in secret")
+	}
+	for version, encodedKey := range encodedKeys {
+		v, err := strconv.Atoi(version)
+		if err != nil {
+			return keys, 0, fmt.Errorf("invalid card key version: %w", err)
+		}
+		s, ok := encodedKey.(string)
+		if !ok {
+			return keys, 0, fmt.Errorf("invalid card key with version %d", v)
+		}
+		if keys.Keys[v], err = base64.StdEncoding.DecodeString(s); err != nil {
+			return keys, 0, fmt.Errorf("could not decode card key with version %d", v)
+		}
+	}
+	if _, ok = keys.Keys[keys.Current]; !ok {
+		return keys, 0, errors.New("current card key not present in secret")
+	}
+
+	return keys, int(secretVersion), nil
+}
+
+// writeOrganizationCardKeys writes the card keys of an organization. The write only succeeds if the
+// version of the secret is still secretVersion (check-and-set), so concurrent rotations can not lose keys.
+func (w *Wrapper) writeOrganizationCardKeys(organizationID uuid.UUID, keys OrganizationCardKeys, secretVersion int) error {
+	encodedKeys := make(map[string]interface{}, len(keys.Keys))
+	for version, key := range keys.Keys {
+		encodedKeys[strconv.Itoa(version)] = base64.StdEncoding.EncodeToString(key)
+	}
+
+	secretPath := w.createOrganizationCardKeysSecretPath(organizationID)
+
+	_, err := w.c.Logical().Write(secretPath, map[string]interface{}{
+		"options": map[string]interface{}{
+			"cas": secretVersion,
+		},
+		"data": map[string]interface{}{
+			"current": strconv.Itoa(keys.Current),
+			"keys":    encodedKeys,
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("could not write card keys to Vault: %w", err)
+	}
+	return nil
+}
+
+func newCardKey() ([]byte, error) {
+	// The key is used for HMAC-SHA256, so we're going for 32 bytes.
+	key := make([]byte, 32)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return nil, fmt.Errorf("could not generate key: %w", err)
+	}
+	return key, nil
+}
+
+func (w *Wrapper) NewOrganizationCardKeys(organizationID uuid.UUID) error {
+	key, err := newCardKey()
+	if err != nil {
+		return err
+	}
+	return w.writeOrganizationCardKeys(organizationID, OrganizationCardKeys{
+		Current: 1,
+		Keys:    map[int][]byte{1: key},
+	}, 0)
+}
+
+// GetOrganizationCardKeys retrieves the card keys of an organization.
+// Organizations which were created before card keys were introduced get new keys on first use.
+func (w *Wrapper) GetOrganizationCardKeys(organizationID uuid.UUID) (OrganizationCardKeys, error) {
+	keys, secretVersion, err := w.readOrganizationCardKeys(organizationID)
+	if err != nil || secretVersion != 0 {
+		return keys, err
+	}
+
+	if err = w.NewOrganizationCardKeys(organizationID); err != nil {
+		// Someone else may have created the keys in the meantime.
+		var readErr error
+		if keys, secretVersion, readErr = w.readOrganizationCardKeys(organizationID); readErr != nil || secretVersion == 0 {
+			return keys, err
+		}
+		return keys, nil
+	}
+
+	keys, _, err = w.readOrganizationCardKeys(organizationID)
+	return keys, err
+}
+
+// CardKeysFunc returns a function which retrieves the card keys of an organization, for use with the database.
+func (w *Wrapper) CardKeysFunc(organizationID uuid.UUID) database.CardKeysFunc {
+	return func() (database.CardKeys, error) {
+		keys, err := w.GetOrganizationCardKeys(organizationID)
+		return database.CardKeys(keys), err
+	}
+}
+
+// RotateOrganizationCardKey generates a new current card key for an organization.
+// The previous keys are kept, so cards hashed with them can still be found.
+func (w *Wrapper) RotateOrganizationCardKey(organizationID uuid.UUID) (OrganizationCardKeys, error) {
+	keys, secretVersion, err := w.readOrganizationCardKeys(organizationID)
+	if err != nil {
+		return keys, err
+	}
+	if secretVersion == 0 {
+		if err = w.NewOrganizationCardKeys(organizationID); err != nil {
+			return keys, err
+		}
+		keys, _, err = w.readOrganizationCardKeys(organizationID)
+		return keys, err
+	}
+
+	key, err := newCardKey()
+	if err != nil {
+		return keys, err
+	}
+
+	newVersion := keys.Current
+	for version := range keys.Keys {
+		if version > newVersion {
+			newVersion = version
+		}
+	}
+	newVersion++
+
+	keys.Current = newVersion
+	keys.Keys[newVersion] = key
+
+	return keys, w.writeOrganizationCardKeys(organizationID, keys, secretVersion)
+}
+
+// PruneOrganizationCardKeys removes all card keys of an organization which are not current and have
+// a version which is not in inUse.
+func (w *Wrapper) PruneOrganizationCardKeys(organizationID uuid.UUID, inUse []int) error {
+	keys, secretVersion, err := w.readOrganizationCardKeys(organizationID)
+	if err != nil || secretVersion == 0 {
+		return err
+	}
+
+	keep := map[int]struct{}{keys.Current: {}}
+	for _, version := range inUse {
+		keep[version] = struct{}{}
+	}
+
+	pruned := false
+	for version := range keys.Keys {
+		if _, ok := keep[version]; !ok {
+			delete(keys.Keys, version)
+			pruned = true
+		}
+	}
+	if !pruned {
+		return nil
+	}
+
+	return w.writeOrganizationCardKeys(organizationID, keys, secretVersion)
+}
+
+// readSigningKey reads an Ed25519 signing key. It returns nil if the key does not exist yet.
+func (w *Wrapper) readSigningKey(secretPath, name string) (ed25519.PrivateKey, error) {
+	secret, err := w.c.Logical().Read(secretPath)
+	if err != nil {
+		return nil, err
+	}
+	if secret == nil {
+		return nil, nil
+	}
+
+	secretData, ok := secret.Data["data"].(map[string]interface{})
+	if !ok {
+		return nil, nil
+	}
+	encodedSeed, ok := secretData["seed"].(string)
+	if !ok {
+		return nil, fmt.Errorf("%s signing key seed not present in secret", name)
+	}
+
+	seed, err := base64.StdEncoding.DecodeString(encodedSeed)
+	if err != nil || len(seed) != ed25519.SeedSize {
+		return nil, fmt.Errorf("invalid %s signing key seed", name)
+	}
+
+	return ed25519.NewKeyFromSeed(seed), nil
+}
+
+// getSigningKey retrieves an Ed25519 signing key, which is generated on first use.
+func (w *Wrapper) getSigningKey(secretPath, name string) (ed25519.PrivateKey, error) {
+	key, err := w.readSigningKey(secretPath, name)
+	if err != nil || key != nil {
+		return key, err
+	}
+
+	seed := make([]byte, ed25519.SeedSize)
+	if _, err = io.ReadFull(rand.Reader, seed); err != nil {
+		return nil, fmt.Errorf("could not generate key: %w", err)
+	}
+
+	// Only write the key if it doesn't exist yet, so the key which other instances may have
+	// generated in the meantime isn't overwritten.
+	_, err = w.c.Logical().Write(secretPath, map[string]interface{}{
+		"options": map[string]interface{}{
+			"cas": 0,
+		},
+		"data": map[string]interface{}{
+			"seed": base64.StdEncoding.EncodeToString(seed),
+		},
+	})
+	if err != nil {
+		if key, readErr := w.readSigningKey(secretPath, name); readErr == nil && key != nil {
+			return key, nil
+		}
+		return nil, fmt.Errorf("could not write %s signing key to Vault: %w", name, err)
+	}
+
+	return ed25519.NewKeyFromSeed(seed), nil
+}
+
+// GetProvisioningSigningKey retrieves the Ed25519 key which provisioning bundles are signed with.
+// The key is generated on first use.
+func (w *Wrapper) GetProvisioningSigningKey() (ed25519.PrivateKey, error) {
+	return w.getSigningKey(w.createProvisioningSigningKeySecretPath(), "provisioning")
+}
+
+// GetUpdateSigningKey retrieves the Ed25519 key which update manifests are signed with.
+// The key is generated on first use.
+func (w *Wrapper) GetUpdateSigningKey() (ed25519.PrivateKey, error) {
+	return w.getSigningKey(w.createUpdateSigningKeySecretPath(), "update")
+}
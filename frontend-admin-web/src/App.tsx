@@ -14,7 +14,7 @@ import StudentsPage from "./StudentsPage";
 import LoginPage from "./LoginPage";
 import { useLocation } from "react-router-dom";
 import LoginDonePage from "./LoginDonePage";
-import Cookies from "universal-cookie";
+import { isLoggedIn } from "./session";
 import { QueryCache, ReactQueryCacheProvider } from "react-query";
 import { SnackbarQueue } from "@rmwc/snackbar";
 import { snackbarQueue } from "./snackbarQueue";
@@ -53,8 +53,7 @@ const App: React.FC = () => {
 
 const AppContents: React.FC = () => {
   const location = useLocation();
-  const session = new Cookies().get("ttsess");
-  const loggedIn = !!session;
+  const loggedIn = isLoggedIn();
 
   return (
     <>
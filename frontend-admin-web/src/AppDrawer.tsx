@@ -11,7 +11,7 @@ import {
 } from "@rmwc/list";
 import React, { useEffect } from "react";
 import { Link, useHistory, useLocation } from "react-router-dom";
-import Cookies from "universal-cookie";
+import { clearSession } from "./session";
 import { useQuery } from "react-query";
 import { fetchAuthnd } from "./DevicesPage";
 import { snackbarQueue } from "./snackbarQueue";
@@ -198,9 +198,7 @@ const AppDrawer: React.FC = () => {
               <Theme use="onPrimary" wrap>
                 <ListItem
                   onClick={() => {
-                    new Cookies().remove("ttsess", {
-                      path: "/",
-                    });
+                    clearSession();
                     history.push("/");
                   }}
                 >
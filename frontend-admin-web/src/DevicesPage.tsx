@@ -4,16 +4,18 @@ import { Elevation } from "@rmwc/elevation";
 import DevicesTable, { Device } from "./DevicesTable";
 import React, { useState } from "react";
 import { useMutation } from "react-query";
-import Cookies from "universal-cookie";
+import { getAccessToken, refreshSession } from "./session";
 import { queryCache } from "./App";
 import { saveAs } from "file-saver";
 
 const exportConfiguration = () =>
   fetchAuthnd(`/device/registrationconfig`, {
-    method: "GET",
+    method: "POST",
     headers: {
       Accept: "application/json",
+      "Content-Type": "application/json",
     },
+    body: JSON.stringify({}),
   })
     .then((response) => response.blob())
     .then((blob) => saveAs(blob, "timeterm-config.json"));
@@ -42,17 +44,24 @@ const restartDevices = (devices: Device[]) =>
     }),
   });
 
-export const fetchAuthnd = (path: string, init?: RequestInit) => {
-  const session = new Cookies().get("ttsess");
-  const headers = {
-    ...init?.headers,
-    "X-Api-Key": session,
-  };
+export const fetchAuthnd = async (path: string, init?: RequestInit) => {
+  const doFetch = (token?: string) =>
+    fetch(new URL(path, process.env.REACT_APP_API_ENDPOINT).toString(), {
+      ...init,
+      headers: {
+        ...init?.headers,
+        ...(token ? { "X-Api-Key": token } : {}),
+      },
+    });
 
-  return fetch(new URL(path, process.env.REACT_APP_API_ENDPOINT).toString(), {
-    ...init,
-    headers,
-  });
+  const res = await doFetch(await getAccessToken());
+  if (res.status !== 401) {
+    return res;
+  }
+
+  // The access token may have been revoked or have expired just now.
+  const token = await refreshSession();
+  return token ? doFetch(token) : res;
 };
 
 const DevicesPage: React.FC = () => {
@@ -5,27 +5,35 @@ import { Theme } from "@rmwc/theme";
 import { Elevation } from "@rmwc/elevation";
 import Logo from "./logo-black.svg";
 import { useLocation, useHistory } from "react-router-dom";
-import Cookies from "universal-cookie";
-
-const useQuery = () => {
-  return new URLSearchParams(useLocation().search);
-};
+import { setSession } from "./session";
 
 const LoginDonePage: React.FC = (props) => {
-  const query = useQuery();
+  const location = useLocation();
   const history = useHistory();
+  const query = new URLSearchParams(location.search);
+  // The tokens are in the fragment, so they are never sent to a server.
+  const tokens = new URLSearchParams(location.hash.substring(1));
 
   const status = query.get("status");
-  const token = query.get("token");
-  const expiresUnix = Number(query.get("expires"));
-  if (status === "ok" && token && expiresUnix) {
-    const expires = new Date(expiresUnix * 1000);
-    const tokenDec = atob(token);
-    new Cookies().set("ttsess", tokenDec, {
-      path: "/",
-      expires: expires,
+  const token = tokens.get("token");
+  const expiresUnix = Number(tokens.get("expires"));
+  const refreshToken = tokens.get("refreshToken");
+  const refreshTokenExpiresUnix = Number(tokens.get("refreshTokenExpires"));
+  if (
+    status === "ok" &&
+    token &&
+    expiresUnix &&
+    refreshToken &&
+    refreshTokenExpiresUnix
+  ) {
+    setSession({
+      token: atob(token),
+      expires: new Date(expiresUnix * 1000),
+      refreshToken: atob(refreshToken),
+      refreshTokenExpires: new Date(refreshTokenExpiresUnix * 1000),
     });
-    history.push("/devices");
+    // Replace the current entry, so the tokens don't stay in the browser history.
+    history.replace("/devices");
   }
   const error = query.get("error");
 
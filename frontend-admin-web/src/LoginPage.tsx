@@ -5,13 +5,13 @@ import { Button } from "@rmwc/button";
 import { Theme } from "@rmwc/theme";
 import { Elevation } from "@rmwc/elevation";
 import Logo from "./logo-black.svg";
-import Cookies from "universal-cookie";
+import { isLoggedIn } from "./session";
 import { useHistory } from "react-router-dom";
 
 const LoginPage: React.FC = (props) => {
   const [isHovering, setIsHovering] = useState(false);
   const history = useHistory();
-  if (new Cookies().get("ttsess")) {
+  if (isLoggedIn()) {
     history.push("/devices");
   }
 
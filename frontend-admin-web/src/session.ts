@@ -0,0 +1,83 @@
+import Cookies from "universal-cookie";
+
+const accessTokenCookie = "ttsess";
+const refreshTokenCookie = "ttrefresh";
+
+export interface SessionTokens {
+  token: string;
+  expires: Date;
+  refreshToken: string;
+  refreshTokenExpires: Date;
+}
+
+export const setSession = (tokens: SessionTokens) => {
+  const cookies = new Cookies();
+  cookies.set(accessTokenCookie, tokens.token, {
+    path: "/",
+    expires: tokens.expires,
+  });
+  cookies.set(refreshTokenCookie, tokens.refreshToken, {
+    path: "/",
+    expires: tokens.refreshTokenExpires,
+  });
+};
+
+export const clearSession = () => {
+  const cookies = new Cookies();
+  cookies.remove(accessTokenCookie, { path: "/" });
+  cookies.remove(refreshTokenCookie, { path: "/" });
+};
+
+// The access token expires after a few minutes, but the session lasts as long as the refresh token.
+export const isLoggedIn = () => {
+  const cookies = new Cookies();
+  return !!(cookies.get(accessTokenCookie) || cookies.get(refreshTokenCookie));
+};
+
+const refresh = async (): Promise<string | undefined> => {
+  const refreshToken = new Cookies().get(refreshTokenCookie);
+  if (!refreshToken) {
+    return undefined;
+  }
+
+  const res = await fetch(
+    new URL("/token/refresh", process.env.REACT_APP_API_ENDPOINT).toString(),
+    {
+      method: "POST",
+      headers: { "Content-Type": "application/json" },
+      body: JSON.stringify({ refreshToken }),
+    }
+  );
+  if (!res.ok) {
+    if (res.status === 401) {
+      clearSession();
+    }
+    return undefined;
+  }
+
+  const body = await res.json();
+  setSession({
+    token: body.token,
+    expires: new Date(body.expires),
+    refreshToken: body.refreshToken,
+    refreshTokenExpires: new Date(body.refreshTokenExpires),
+  });
+  return body.token;
+};
+
+let refreshing: Promise<string | undefined> | undefined;
+
+// refreshSession exchanges the refresh token for a new access token.
+// A refresh token can only be used once (using it again revokes the session),
+// so concurrent requests share a single refresh.
+export const refreshSession = (): Promise<string | undefined> => {
+  if (!refreshing) {
+    refreshing = refresh().finally(() => {
+      refreshing = undefined;
+    });
+  }
+  return refreshing;
+};
+
+export const getAccessToken = async (): Promise<string | undefined> =>
+  new Cookies().get(accessTokenCookie) ?? (await refreshSession());
@@ -56,9 +56,24 @@ func (h *Handler) ProvisionNewDevice(ctx context.Context, id uuid.UUID) (err err
 }
 
 func (h *Handler) GenerateDeviceCredentials(ctx context.Context, id uuid.UUID) (creds []byte, err error) {
+	// Devices retrieve new credentials when they start, which is when consumers for streams which were added
+	// after the device was provisioned are created.
+	err = setUpDeviceConsumers(id, h.streamMgr)
+	if err != nil {
+		return nil, fmt.Errorf("could not set up device consumers: %w", err)
+	}
+
 	creds, err = h.mgr.GenerateDeviceCredentials(ctx, id)
 	if err != nil {
 		return nil, fmt.Errorf("could not generate credentials for device (user): %w", err)
 	}
 	return
 }
+
+func (h *Handler) RevokeDeviceCredentials(ctx context.Context, id uuid.UUID) error {
+	err := h.mgr.RevokeDeviceCredentials(ctx, id)
+	if err != nil {
+		return fmt.Errorf("could not revoke credentials for device (user): %w", err)
+	}
+	return nil
+}
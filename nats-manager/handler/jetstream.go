@@ -8,11 +8,14 @@ import (
 	"github.com/nats-io/jsm.go"
 )
 
+// setUpDeviceConsumers creates the consumers through which a device receives messages from the static streams.
+// Consumers which already exist are left alone, so it can also be used for devices which were provisioned
+// before a stream was added.
 func setUpDeviceConsumers(devID uuid.UUID, mgr *jsm.Manager) error {
 	consumerName := fmt.Sprintf("EMDEV-%s-EMDEV-RETRIEVE-NEW-NETWORKING-CONFIG", devID)
 	wantDisownTokenSubject := fmt.Sprintf("EMDEV.%s.RETRIEVE-NEW-NETWORKING-CONFIG", devID)
 
-	_, err := mgr.NewConsumer("EMDEV-RETRIEVE-NEW-NETWORKING-CONFIG",
+	_, err := mgr.LoadOrNewConsumer("EMDEV-RETRIEVE-NEW-NETWORKING-CONFIG", consumerName,
 		jsm.DurableName(consumerName),
 		jsm.FilterStreamBySubject(wantDisownTokenSubject),
 		jsm.AckWait(time.Second*30),
@@ -22,5 +25,47 @@ func setUpDeviceConsumers(devID uuid.UUID, mgr *jsm.Manager) error {
 	if err != nil {
 		return fmt.Errorf("could not set up EMDEV-RETRIEVE-NEW-NETWORKING-CONFIG consumer: %w", err)
 	}
+
+	deviceConfigConsumerName := fmt.Sprintf("EMDEV-%s-EMDEV-RETRIEVE-NEW-DEVICE-CONFIG", devID)
+	deviceConfigSubject := fmt.Sprintf("EMDEV.%s.RETRIEVE-NEW-DEVICE-CONFIG", devID)
+
+	_, err = mgr.LoadOrNewConsumer("EMDEV-RETRIEVE-NEW-DEVICE-CONFIG", deviceConfigConsumerName,
+		jsm.DurableName(deviceConfigConsumerName),
+		jsm.FilterStreamBySubject(deviceConfigSubject),
+		jsm.AckWait(time.Second*30),
+		jsm.AcknowledgeExplicit(),
+		jsm.DeliverAllAvailable(),
+	)
+	if err != nil {
+		return fmt.Errorf("could not set up EMDEV-RETRIEVE-NEW-DEVICE-CONFIG consumer: %w", err)
+	}
+
+	commandsConsumerName := fmt.Sprintf("EMDEV-%s-EMDEV-COMMANDS", devID)
+	commandsSubject := fmt.Sprintf("EMDEV.%s.COMMAND", devID)
+
+	_, err = mgr.LoadOrNewConsumer("EMDEV-COMMANDS", commandsConsumerName,
+		jsm.DurableName(commandsConsumerName),
+		jsm.FilterStreamBySubject(commandsSubject),
+		jsm.AckWait(time.Second*30),
+		jsm.AcknowledgeExplicit(),
+		jsm.DeliverAllAvailable(),
+	)
+	if err != nil {
+		return fmt.Errorf("could not set up EMDEV-COMMANDS consumer: %w", err)
+	}
+
+	announcementsConsumerName := fmt.Sprintf("EMDEV-%s-EMDEV-ANNOUNCEMENTS", devID)
+	announcementsSubject := fmt.Sprintf("EMDEV.%s.ANNOUNCEMENT", devID)
+
+	_, err = mgr.LoadOrNewConsumer("EMDEV-ANNOUNCEMENTS", announcementsConsumerName,
+		jsm.DurableName(announcementsConsumerName),
+		jsm.FilterStreamBySubject(announcementsSubject),
+		jsm.AckWait(time.Second*30),
+		jsm.AcknowledgeExplicit(),
+		jsm.DeliverAllAvailable(),
+	)
+	if err != nil {
+		return fmt.Errorf("could not set up EMDEV-ANNOUNCEMENTS consumer: %w", err)
+	}
 	return nil
 }
@@ -22,6 +22,7 @@ func newAppIndex() appIndex {
 		apps: makeAppsByUsers(map[string]appUser{
 			"backend":        {accountName: "BACKEND", userName: "backend"},
 			"backend-emdevs": {accountName: "EMDEVS", userName: "backend"},
+			"backend-sys":    {accountName: "SYS", userName: "backend"},
 		}),
 	}
 }
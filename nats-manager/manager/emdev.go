@@ -13,8 +13,16 @@ func emdevEditor(devID uuid.UUID) UserClaimsEditor {
 			fmt.Sprintf("EMDEV.%s.>", devID),
 		)
 		c.Pub.Allow.Add(
+			fmt.Sprintf("EMDEV.%s.HEARTBEAT", devID),
+			fmt.Sprintf("EMDEV.%s.COMMAND-RESULT", devID),
+			fmt.Sprintf("$JS.API.CONSUMER.MSG.NEXT.EMDEV-COMMANDS.EMDEV-%s-EMDEV-COMMANDS", devID),
+			fmt.Sprintf("$JS.ACK.EMDEV-COMMANDS.EMDEV-%s-EMDEV-COMMANDS.>", devID),
 			fmt.Sprintf("$JS.API.CONSUMER.MSG.NEXT.EMDEV-RETRIEVE-NEW-NETWORKING-CONFIG.EMDEV-%s-EMDEV-RETRIEVE-NEW-NETWORKING-CONFIG", devID),
 			fmt.Sprintf("$JS.ACK.EMDEV-RETRIEVE-NEW-NETWORKING-CONFIG.EMDEV-%s-EMDEV-RETRIEVE-NEW-NETWORKING-CONFIG.>", devID),
+			fmt.Sprintf("$JS.API.CONSUMER.MSG.NEXT.EMDEV-ANNOUNCEMENTS.EMDEV-%s-EMDEV-ANNOUNCEMENTS", devID),
+			fmt.Sprintf("$JS.ACK.EMDEV-ANNOUNCEMENTS.EMDEV-%s-EMDEV-ANNOUNCEMENTS.>", devID),
+			fmt.Sprintf("$JS.API.CONSUMER.MSG.NEXT.EMDEV-RETRIEVE-NEW-DEVICE-CONFIG.EMDEV-%s-EMDEV-RETRIEVE-NEW-DEVICE-CONFIG", devID),
+			fmt.Sprintf("$JS.ACK.EMDEV-RETRIEVE-NEW-DEVICE-CONFIG.EMDEV-%s-EMDEV-RETRIEVE-NEW-DEVICE-CONFIG.>", devID),
 		)
 	}
 }
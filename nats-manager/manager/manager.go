@@ -645,6 +645,19 @@ func (m *Manager) GenerateDeviceCredentials(ctx context.Context, id uuid.UUID) (
 	return m.GenerateUserCredentials(ctx, deviceUserName(id), "EMDEVS")
 }
 
+// RevokeDeviceCredentials revokes all credentials issued to a device with a known ID until now.
+// The revocation is added to the EMDEVS account, so the NATS server picks it up when it fetches the account JWT.
+func (m *Manager) RevokeDeviceCredentials(ctx context.Context, id uuid.UUID) error {
+	pk, err := m.dbw.GetUserSubject(ctx, deviceUserName(id), "EMDEVS", m.operator.Name)
+	if err != nil {
+		return fmt.Errorf("could not get device user subject: %w", err)
+	}
+
+	return m.UpdateAccount(ctx, "EMDEVS", m.operator.Name, func(c *jwt.AccountClaims) {
+		c.RevokeAt(pk, time.Now())
+	})
+}
+
 // GenerateUserCredentials generates new NATS credentials for a user with a known name and issuer (account).
 func (m *Manager) GenerateUserCredentials(ctx context.Context, userName, accountName string) ([]byte, error) {
 	// Get the subject for the user
@@ -29,6 +29,10 @@ func emdevsAccount() jwtmigrate.AccountRef {
 	return defaultOperator().Account("EMDEVS")
 }
 
+func sysAccount() jwtmigrate.AccountRef {
+	return defaultOperator().Account("SYS")
+}
+
 func jwtMigrations() jwtmigrate.Migrations {
 	return jwtmigrate.Migrations{
 		{
@@ -154,6 +158,147 @@ func jwtMigrations() jwtmigrate.Migrations {
 				},
 			},
 		},
+		{
+			Name:    "HEARTBEAT",
+			Version: 4,
+			CreateUsers: jwtmigrate.UserCreates{
+				// The backend listens for (dis)connect events of devices, which are only published in the system account.
+				sysAccount().User("backend"): {
+					Patches: &jwtpatch.UserClaimsPatches{
+						UserPatches: jwtpatch.UserPatches{
+							PermissionsPatches: jwtpatch.PermissionsPatches{
+								Sub: &jwtpatch.PermissionPatches{
+									Allow: jwtpatch.StringListPatches{
+										Add: []string{"$SYS.ACCOUNT.*.CONNECT", "$SYS.ACCOUNT.*.DISCONNECT"},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			UsersUp: []*jwtmigrate.UserMigration{
+				{
+					NameRegex:        `^emdev-.*$`,
+					AccountNameRegex: `^EMDEVS$`,
+					Patch: func(log logr.Logger, r jwtmigrate.UserRef, c *jwt.UserClaims) {
+						id := strings.TrimPrefix(r.Name, "emdev-")
+						uid, err := uuid.Parse(id)
+						if err != nil {
+							log.Error(err, "could not parse device ID in migration",
+								"id", id, "userName", r.Name,
+							)
+							return
+						}
+
+						c.Pub.Allow.Add(fmt.Sprintf("EMDEV.%s.HEARTBEAT", uid))
+					},
+				},
+				{
+					NameRegex:        `^backend$`,
+					AccountNameRegex: `^EMDEVS$`,
+					Patch: func(log logr.Logger, r jwtmigrate.UserRef, c *jwt.UserClaims) {
+						c.Sub.Allow.Add("EMDEV.*.HEARTBEAT")
+					},
+				},
+			},
+		},
+		{
+			Name:    "COMMANDS",
+			Version: 5,
+			UsersUp: []*jwtmigrate.UserMigration{
+				{
+					NameRegex:        `^emdev-.*$`,
+					AccountNameRegex: `^EMDEVS$`,
+					Patch: func(log logr.Logger, r jwtmigrate.UserRef, c *jwt.UserClaims) {
+						id := strings.TrimPrefix(r.Name, "emdev-")
+						uid, err := uuid.Parse(id)
+						if err != nil {
+							log.Error(err, "could not parse device ID in migration",
+								"id", id, "userName", r.Name,
+							)
+							return
+						}
+
+						c.Pub.Allow.Add(
+							fmt.Sprintf("EMDEV.%s.COMMAND-RESULT", uid),
+							fmt.Sprintf("$JS.API.CONSUMER.MSG.NEXT.EMDEV-COMMANDS.EMDEV-%s-EMDEV-COMMANDS", uid),
+							fmt.Sprintf("$JS.ACK.EMDEV-COMMANDS.EMDEV-%s-EMDEV-COMMANDS.>", uid),
+						)
+					},
+				},
+				{
+					NameRegex:        `^backend$`,
+					AccountNameRegex: `^EMDEVS$`,
+					Patch: func(log logr.Logger, r jwtmigrate.UserRef, c *jwt.UserClaims) {
+						c.Sub.Allow.Add("EMDEV.*.COMMAND-RESULT")
+					},
+				},
+			},
+		},
+		{
+			Name:    "ANNOUNCEMENTS",
+			Version: 6,
+			UsersUp: []*jwtmigrate.UserMigration{
+				{
+					NameRegex:        `^emdev-.*$`,
+					AccountNameRegex: `^EMDEVS$`,
+					Patch: func(log logr.Logger, r jwtmigrate.UserRef, c *jwt.UserClaims) {
+						id := strings.TrimPrefix(r.Name, "emdev-")
+						uid, err := uuid.Parse(id)
+						if err != nil {
+							log.Error(err, "could not parse device ID in migration",
+								"id", id, "userName", r.Name,
+							)
+							return
+						}
+
+						c.Pub.Allow.Add(
+							fmt.Sprintf("$JS.API.CONSUMER.MSG.NEXT.EMDEV-ANNOUNCEMENTS.EMDEV-%s-EMDEV-ANNOUNCEMENTS", uid),
+							fmt.Sprintf("$JS.ACK.EMDEV-ANNOUNCEMENTS.EMDEV-%s-EMDEV-ANNOUNCEMENTS.>", uid),
+						)
+					},
+				},
+			},
+		},
+		{
+			Name:    "RETRIEVE-NEW-DEVICE-CONFIG",
+			Version: 7,
+			UsersUp: []*jwtmigrate.UserMigration{
+				{
+					NameRegex:        `^emdev-.*$`,
+					AccountNameRegex: `^EMDEVS$`,
+					Patch: func(log logr.Logger, r jwtmigrate.UserRef, c *jwt.UserClaims) {
+						id := strings.TrimPrefix(r.Name, "emdev-")
+						uid, err := uuid.Parse(id)
+						if err != nil {
+							log.Error(err, "could not parse device ID in migration",
+								"id", id, "userName", r.Name,
+							)
+							return
+						}
+
+						c.Pub.Allow.Add(
+							fmt.Sprintf("$JS.API.CONSUMER.MSG.NEXT.EMDEV-RETRIEVE-NEW-DEVICE-CONFIG.EMDEV-%s-EMDEV-RETRIEVE-NEW-DEVICE-CONFIG", uid),
+							fmt.Sprintf("$JS.ACK.EMDEV-RETRIEVE-NEW-DEVICE-CONFIG.EMDEV-%s-EMDEV-RETRIEVE-NEW-DEVICE-CONFIG.>", uid),
+						)
+					},
+				},
+			},
+		},
+		{
+			Name:    "REVOKE-DEVICE-CREDENTIALS",
+			Version: 8,
+			UsersUp: []*jwtmigrate.UserMigration{
+				{
+					NameRegex:        `^backend$`,
+					AccountNameRegex: `^BACKEND$`,
+					Patch: func(log logr.Logger, r jwtmigrate.UserRef, c *jwt.UserClaims) {
+						c.Pub.Allow.Add(nmsdk.SubjectRevokeDeviceCredentials)
+					},
+				},
+			},
+		},
 	}
 }
 
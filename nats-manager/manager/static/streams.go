@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/go-logr/logr"
 	"github.com/nats-io/jsm.go"
@@ -30,6 +31,28 @@ func ConfigureStreams(ctx context.Context, log logr.Logger, mgr *manager.Manager
 					jsm.Subjects("EMDEV.*.RETRIEVE-NEW-NETWORKING-CONFIG"),
 				},
 			},
+			"EMDEV-RETRIEVE-NEW-DEVICE-CONFIG": {
+				options: []jsm.StreamOption{
+					jsm.FileStorage(),
+					jsm.Subjects("EMDEV.*.RETRIEVE-NEW-DEVICE-CONFIG"),
+				},
+			},
+			"EMDEV-COMMANDS": {
+				options: []jsm.StreamOption{
+					jsm.FileStorage(),
+					jsm.Subjects("EMDEV.*.COMMAND"),
+					// Commands expire after a week, devices which are offline for longer don't execute them.
+					jsm.MaxAge(7 * 24 * time.Hour),
+				},
+			},
+			"EMDEV-ANNOUNCEMENTS": {
+				options: []jsm.StreamOption{
+					jsm.FileStorage(),
+					jsm.Subjects("EMDEV.*.ANNOUNCEMENT"),
+					// Devices which were offline for longer retrieve the current announcements via the backend.
+					jsm.MaxAge(7 * 24 * time.Hour),
+				},
+			},
 		},
 	}
 
@@ -14,6 +14,7 @@ import (
 const (
 	SubjectProvisionNewDevice        = "NATS-MANAGER.PROVISION-NEW-DEVICE"
 	SubjectGenerateDeviceCredentials = "NATS-MANAGER.GENERATE-DEVICE-CREDENTIALS"
+	SubjectRevokeDeviceCredentials   = "NATS-MANAGER.REVOKE-DEVICE-CREDENTIALS"
 )
 
 type ManagerError struct {
@@ -76,3 +77,23 @@ func (c *Client) GenerateDeviceCredentials(ctx context.Context, id uuid.UUID) (s
 		return "", errors.New("invalid response")
 	}
 }
+
+func (c *Client) RevokeDeviceCredentials(ctx context.Context, id uuid.UUID) error {
+	var rsp rpcpb.RevokeDeviceCredentialsResponse
+
+	err := c.enc.RequestWithContext(ctx, SubjectRevokeDeviceCredentials, &rpcpb.RevokeDeviceCredentialsRequest{
+		DeviceId: id.String(),
+	}, &rsp)
+	if err != nil {
+		return err
+	}
+
+	switch data := rsp.Response.(type) {
+	case *rpcpb.RevokeDeviceCredentialsResponse_Success:
+		return nil
+	case *rpcpb.RevokeDeviceCredentialsResponse_Error:
+		return ManagerError{data.Error}
+	default:
+		return errors.New("invalid response")
+	}
+}
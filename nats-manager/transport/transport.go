@@ -53,6 +53,14 @@ func (t *Transport) Run(ctx context.Context) error {
 		return err
 	}
 
+	if _, err := t.enc.QueueSubscribe(
+		nmsdk.SubjectRevokeDeviceCredentials,
+		nmsdk.SubjectRevokeDeviceCredentials,
+		t.handleRevokeDeviceCredentials,
+	); err != nil {
+		return err
+	}
+
 	if err := t.enc.Flush(); err != nil {
 		return err
 	}
@@ -137,6 +145,34 @@ func (t *Transport) handleGenerateDeviceCredentials(
 	}
 }
 
+func (t *Transport) handleRevokeDeviceCredentials(
+	_, /* sub */
+	reply string,
+	msg *rpcpb.RevokeDeviceCredentialsRequest,
+) {
+	defer t.handlePanic()
+
+	rsp := new(rpcpb.RevokeDeviceCredentialsResponse)
+	ctx, cancel := context.WithTimeout(context.Background(), requestHandleTimeout)
+	defer cancel()
+
+	err := t.revokeDeviceCredentials(ctx, msg)
+	if err != nil {
+		rsp.Response = &rpcpb.RevokeDeviceCredentialsResponse_Error{
+			Error: &rpcpb.Error{
+				Message: err.Error(),
+			},
+		}
+	} else {
+		rsp.Response = &rpcpb.RevokeDeviceCredentialsResponse_Success{Success: &rpcpb.Empty{}}
+	}
+
+	err = t.enc.Publish(reply, rsp)
+	if err != nil {
+		t.log.Error(err, "could not publish revokeDeviceCredentials response")
+	}
+}
+
 func (t *Transport) provisionNewDevice(ctx context.Context, msg *rpcpb.ProvisionNewDeviceRequest) error {
 	devID, err := uuid.Parse(msg.GetDeviceId())
 	if err != nil {
@@ -171,3 +207,19 @@ func (t *Transport) generateDeviceCredentials(
 
 	return creds, nil
 }
+
+func (t *Transport) revokeDeviceCredentials(ctx context.Context, msg *rpcpb.RevokeDeviceCredentialsRequest) error {
+	devID, err := uuid.Parse(msg.GetDeviceId())
+	if err != nil {
+		return errors.New("invalid device ID")
+	}
+
+	err = t.h.RevokeDeviceCredentials(ctx, devID)
+	if err != nil {
+		t.log.Error(err, "could not revoke device credentials")
+
+		return fmt.Errorf("could not revoke credentials for device: %w", err)
+	}
+
+	return nil
+}
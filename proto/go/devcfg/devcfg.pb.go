@@ -1087,6 +1087,467 @@ func (x *NetworkingService) GetIsPhase_2EapBased() bool {
 	return false
 }
 
+// ProvisioningBundle contains everything a new device needs to register itself.
+// It is kept compact so it fits in a QR code.
+type ProvisioningBundle struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// The registration token and organization ID are raw (16-byte) UUIDs.
+	RegistrationToken []byte `protobuf:"bytes,1,opt,name=registration_token,json=registrationToken,proto3" json:"registration_token,omitempty"`
+	OrganizationId    []byte `protobuf:"bytes,2,opt,name=organization_id,json=organizationId,proto3" json:"organization_id,omitempty"`
+	BackendUrl        string `protobuf:"bytes,3,opt,name=backend_url,json=backendUrl,proto3" json:"backend_url,omitempty"`
+	// The passphrases and private keys of the networking services are left out, as the bundle is not encrypted.
+	// The device retrieves the complete networking services after it has registered itself.
+	NetworkingServices *NetworkingServices `protobuf:"bytes,4,opt,name=networking_services,json=networkingServices,proto3" json:"networking_services,omitempty"`
+	// Unix timestamp (in seconds) after which the registration token can't be used anymore.
+	ExpiresAt int64 `protobuf:"varint,5,opt,name=expires_at,json=expiresAt,proto3" json:"expires_at,omitempty"`
+}
+
+func (x *ProvisioningBundle) Reset() {
+	*x = ProvisioningBundle{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_devcfg_devcfg_proto_msgTypes[6]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ProvisioningBundle) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ProvisioningBundle) ProtoMessage() {}
+
+func (x *ProvisioningBundle) ProtoReflect() protoreflect.Message {
+	mi := &file_devcfg_devcfg_proto_msgTypes[6]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ProvisioningBundle.ProtoReflect.Descriptor instead.
+func (*ProvisioningBundle) Descriptor() ([]byte, []int) {
+	return file_devcfg_devcfg_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *ProvisioningBundle) GetRegistrationToken() []byte {
+	if x != nil {
+		return x.RegistrationToken
+	}
+	return nil
+}
+
+func (x *ProvisioningBundle) GetOrganizationId() []byte {
+	if x != nil {
+		return x.OrganizationId
+	}
+	return nil
+}
+
+func (x *ProvisioningBundle) GetBackendUrl() string {
+	if x != nil {
+		return x.BackendUrl
+	}
+	return ""
+}
+
+func (x *ProvisioningBundle) GetNetworkingServices() *NetworkingServices {
+	if x != nil {
+		return x.NetworkingServices
+	}
+	return nil
+}
+
+func (x *ProvisioningBundle) GetExpiresAt() int64 {
+	if x != nil {
+		return x.ExpiresAt
+	}
+	return 0
+}
+
+type SignedProvisioningBundle struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Serialized ProvisioningBundle.
+	Bundle []byte `protobuf:"bytes,1,opt,name=bundle,proto3" json:"bundle,omitempty"`
+	// Ed25519 signature of bundle.
+	Signature []byte `protobuf:"bytes,2,opt,name=signature,proto3" json:"signature,omitempty"`
+}
+
+func (x *SignedProvisioningBundle) Reset() {
+	*x = SignedProvisioningBundle{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_devcfg_devcfg_proto_msgTypes[7]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SignedProvisioningBundle) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SignedProvisioningBundle) ProtoMessage() {}
+
+func (x *SignedProvisioningBundle) ProtoReflect() protoreflect.Message {
+	mi := &file_devcfg_devcfg_proto_msgTypes[7]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SignedProvisioningBundle.ProtoReflect.Descriptor instead.
+func (*SignedProvisioningBundle) Descriptor() ([]byte, []int) {
+	return file_devcfg_devcfg_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *SignedProvisioningBundle) GetBundle() []byte {
+	if x != nil {
+		return x.Bundle
+	}
+	return nil
+}
+
+func (x *SignedProvisioningBundle) GetSignature() []byte {
+	if x != nil {
+		return x.Signature
+	}
+	return nil
+}
+
+// DeviceConfig configures the kiosk application of a device. Device config profiles can be assigned to
+// an organization, a device group and a device; the fields which are set in a more specific profile
+// replace those of less specific profiles. Fields which are not set in any profile keep their defaults.
+type DeviceConfig struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// BCP 47 language tag of the user interface, e.g. "nl-NL".
+	Language *string `protobuf:"bytes,1,opt,name=language,proto3,oneof" json:"language,omitempty"`
+	// IANA timezone in which the brightness schedule is evaluated, e.g. "Europe/Amsterdam".
+	Timezone           *string             `protobuf:"bytes,2,opt,name=timezone,proto3,oneof" json:"timezone,omitempty"`
+	BrightnessSchedule *BrightnessSchedule `protobuf:"bytes,3,opt,name=brightness_schedule,json=brightnessSchedule,proto3" json:"brightness_schedule,omitempty"`
+	// Time after which the kiosk returns to its start screen when it isn't used.
+	IdleTimeoutSeconds *uint32   `protobuf:"varint,4,opt,name=idle_timeout_seconds,json=idleTimeoutSeconds,proto3,oneof" json:"idle_timeout_seconds,omitempty"`
+	Screens            *Screens  `protobuf:"bytes,5,opt,name=screens,proto3" json:"screens,omitempty"`
+	Branding           *Branding `protobuf:"bytes,6,opt,name=branding,proto3" json:"branding,omitempty"`
+}
+
+func (x *DeviceConfig) Reset() {
+	*x = DeviceConfig{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_devcfg_devcfg_proto_msgTypes[8]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *DeviceConfig) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeviceConfig) ProtoMessage() {}
+
+func (x *DeviceConfig) ProtoReflect() protoreflect.Message {
+	mi := &file_devcfg_devcfg_proto_msgTypes[8]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeviceConfig.ProtoReflect.Descriptor instead.
+func (*DeviceConfig) Descriptor() ([]byte, []int) {
+	return file_devcfg_devcfg_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *DeviceConfig) GetLanguage() string {
+	if x != nil && x.Language != nil {
+		return *x.Language
+	}
+	return ""
+}
+
+func (x *DeviceConfig) GetTimezone() string {
+	if x != nil && x.Timezone != nil {
+		return *x.Timezone
+	}
+	return ""
+}
+
+func (x *DeviceConfig) GetBrightnessSchedule() *BrightnessSchedule {
+	if x != nil {
+		return x.BrightnessSchedule
+	}
+	return nil
+}
+
+func (x *DeviceConfig) GetIdleTimeoutSeconds() uint32 {
+	if x != nil && x.IdleTimeoutSeconds != nil {
+		return *x.IdleTimeoutSeconds
+	}
+	return 0
+}
+
+func (x *DeviceConfig) GetScreens() *Screens {
+	if x != nil {
+		return x.Screens
+	}
+	return nil
+}
+
+func (x *DeviceConfig) GetBranding() *Branding {
+	if x != nil {
+		return x.Branding
+	}
+	return nil
+}
+
+type BrightnessSchedule struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// The periods are sorted by their start. Each period lasts until the next one starts,
+	// the last period lasts until the first one starts on the next day.
+	Periods []*BrightnessPeriod `protobuf:"bytes,1,rep,name=periods,proto3" json:"periods,omitempty"`
+}
+
+func (x *BrightnessSchedule) Reset() {
+	*x = BrightnessSchedule{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_devcfg_devcfg_proto_msgTypes[9]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *BrightnessSchedule) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BrightnessSchedule) ProtoMessage() {}
+
+func (x *BrightnessSchedule) ProtoReflect() protoreflect.Message {
+	mi := &file_devcfg_devcfg_proto_msgTypes[9]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BrightnessSchedule.ProtoReflect.Descriptor instead.
+func (*BrightnessSchedule) Descriptor() ([]byte, []int) {
+	return file_devcfg_devcfg_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *BrightnessSchedule) GetPeriods() []*BrightnessPeriod {
+	if x != nil {
+		return x.Periods
+	}
+	return nil
+}
+
+type BrightnessPeriod struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Minutes after midnight at which the period starts.
+	StartMinute uint32 `protobuf:"varint,1,opt,name=start_minute,json=startMinute,proto3" json:"start_minute,omitempty"`
+	// Brightness of the display as a percentage.
+	Brightness uint32 `protobuf:"varint,2,opt,name=brightness,proto3" json:"brightness,omitempty"`
+}
+
+func (x *BrightnessPeriod) Reset() {
+	*x = BrightnessPeriod{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_devcfg_devcfg_proto_msgTypes[10]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *BrightnessPeriod) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BrightnessPeriod) ProtoMessage() {}
+
+func (x *BrightnessPeriod) ProtoReflect() protoreflect.Message {
+	mi := &file_devcfg_devcfg_proto_msgTypes[10]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BrightnessPeriod.ProtoReflect.Descriptor instead.
+func (*BrightnessPeriod) Descriptor() ([]byte, []int) {
+	return file_devcfg_devcfg_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *BrightnessPeriod) GetStartMinute() uint32 {
+	if x != nil {
+		return x.StartMinute
+	}
+	return 0
+}
+
+func (x *BrightnessPeriod) GetBrightness() uint32 {
+	if x != nil {
+		return x.Brightness
+	}
+	return 0
+}
+
+// Screens determines which screens of the kiosk application are enabled.
+type Screens struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Timetable      bool `protobuf:"varint,1,opt,name=timetable,proto3" json:"timetable,omitempty"`
+	CardEnrollment bool `protobuf:"varint,2,opt,name=card_enrollment,json=cardEnrollment,proto3" json:"card_enrollment,omitempty"`
+	Announcements  bool `protobuf:"varint,3,opt,name=announcements,proto3" json:"announcements,omitempty"`
+}
+
+func (x *Screens) Reset() {
+	*x = Screens{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_devcfg_devcfg_proto_msgTypes[11]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Screens) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Screens) ProtoMessage() {}
+
+func (x *Screens) ProtoReflect() protoreflect.Message {
+	mi := &file_devcfg_devcfg_proto_msgTypes[11]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Screens.ProtoReflect.Descriptor instead.
+func (*Screens) Descriptor() ([]byte, []int) {
+	return file_devcfg_devcfg_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *Screens) GetTimetable() bool {
+	if x != nil {
+		return x.Timetable
+	}
+	return false
+}
+
+func (x *Screens) GetCardEnrollment() bool {
+	if x != nil {
+		return x.CardEnrollment
+	}
+	return false
+}
+
+func (x *Screens) GetAnnouncements() bool {
+	if x != nil {
+		return x.Announcements
+	}
+	return false
+}
+
+type Branding struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Title shown at the top of the kiosk, the name of the organization if it is empty.
+	Title    string `protobuf:"bytes,1,opt,name=title,proto3" json:"title,omitempty"`
+	ShowLogo bool   `protobuf:"varint,2,opt,name=show_logo,json=showLogo,proto3" json:"show_logo,omitempty"`
+}
+
+func (x *Branding) Reset() {
+	*x = Branding{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_devcfg_devcfg_proto_msgTypes[12]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Branding) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Branding) ProtoMessage() {}
+
+func (x *Branding) ProtoReflect() protoreflect.Message {
+	mi := &file_devcfg_devcfg_proto_msgTypes[12]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Branding.ProtoReflect.Descriptor instead.
+func (*Branding) Descriptor() ([]byte, []int) {
+	return file_devcfg_devcfg_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *Branding) GetTitle() string {
+	if x != nil {
+		return x.Title
+	}
+	return ""
+}
+
+func (x *Branding) GetShowLogo() bool {
+	if x != nil {
+		return x.ShowLogo
+	}
+	return false
+}
+
 var File_devcfg_devcfg_proto protoreflect.FileDescriptor
 
 var file_devcfg_devcfg_proto_rawDesc = []byte{
@@ -1223,82 +1684,151 @@ var file_devcfg_devcfg_proto_rawDesc = []byte{
 	0x32, 0x12, 0x2e, 0x0a, 0x14, 0x69, 0x73, 0x5f, 0x70, 0x68, 0x61, 0x73, 0x65, 0x5f, 0x32, 0x5f,
 	0x65, 0x61, 0x70, 0x5f, 0x62, 0x61, 0x73, 0x65, 0x64, 0x18, 0x1d, 0x20, 0x01, 0x28, 0x08, 0x52,
 	0x10, 0x69, 0x73, 0x50, 0x68, 0x61, 0x73, 0x65, 0x32, 0x45, 0x61, 0x70, 0x42, 0x61, 0x73, 0x65,
-	0x64, 0x2a, 0x88, 0x01, 0x0a, 0x15, 0x4e, 0x65, 0x74, 0x77, 0x6f, 0x72, 0x6b, 0x69, 0x6e, 0x67,
-	0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x54, 0x79, 0x70, 0x65, 0x12, 0x27, 0x0a, 0x23, 0x4e,
-	0x45, 0x54, 0x57, 0x4f, 0x52, 0x4b, 0x49, 0x4e, 0x47, 0x5f, 0x53, 0x45, 0x52, 0x56, 0x49, 0x43,
-	0x45, 0x5f, 0x54, 0x59, 0x50, 0x45, 0x5f, 0x55, 0x4e, 0x53, 0x50, 0x45, 0x43, 0x49, 0x46, 0x49,
-	0x45, 0x44, 0x10, 0x00, 0x12, 0x24, 0x0a, 0x20, 0x4e, 0x45, 0x54, 0x57, 0x4f, 0x52, 0x4b, 0x49,
-	0x4e, 0x47, 0x5f, 0x53, 0x45, 0x52, 0x56, 0x49, 0x43, 0x45, 0x5f, 0x54, 0x59, 0x50, 0x45, 0x5f,
-	0x45, 0x54, 0x48, 0x45, 0x52, 0x4e, 0x45, 0x54, 0x10, 0x01, 0x12, 0x20, 0x0a, 0x1c, 0x4e, 0x45,
-	0x54, 0x57, 0x4f, 0x52, 0x4b, 0x49, 0x4e, 0x47, 0x5f, 0x53, 0x45, 0x52, 0x56, 0x49, 0x43, 0x45,
-	0x5f, 0x54, 0x59, 0x50, 0x45, 0x5f, 0x57, 0x49, 0x46, 0x49, 0x10, 0x02, 0x2a, 0x84, 0x01, 0x0a,
-	0x0e, 0x49, 0x70, 0x76, 0x34, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x54, 0x79, 0x70, 0x65, 0x12,
-	0x20, 0x0a, 0x1c, 0x49, 0x50, 0x56, 0x34, 0x5f, 0x43, 0x4f, 0x4e, 0x46, 0x49, 0x47, 0x5f, 0x54,
-	0x59, 0x50, 0x45, 0x5f, 0x55, 0x4e, 0x53, 0x50, 0x45, 0x43, 0x49, 0x46, 0x49, 0x45, 0x44, 0x10,
-	0x00, 0x12, 0x18, 0x0a, 0x14, 0x49, 0x50, 0x56, 0x34, 0x5f, 0x43, 0x4f, 0x4e, 0x46, 0x49, 0x47,
-	0x5f, 0x54, 0x59, 0x50, 0x45, 0x5f, 0x4f, 0x46, 0x46, 0x10, 0x01, 0x12, 0x19, 0x0a, 0x15, 0x49,
-	0x50, 0x56, 0x34, 0x5f, 0x43, 0x4f, 0x4e, 0x46, 0x49, 0x47, 0x5f, 0x54, 0x59, 0x50, 0x45, 0x5f,
-	0x44, 0x48, 0x43, 0x50, 0x10, 0x02, 0x12, 0x1b, 0x0a, 0x17, 0x49, 0x50, 0x56, 0x34, 0x5f, 0x43,
-	0x4f, 0x4e, 0x46, 0x49, 0x47, 0x5f, 0x54, 0x59, 0x50, 0x45, 0x5f, 0x43, 0x55, 0x53, 0x54, 0x4f,
-	0x4d, 0x10, 0x03, 0x2a, 0x84, 0x01, 0x0a, 0x0e, 0x49, 0x70, 0x76, 0x36, 0x43, 0x6f, 0x6e, 0x66,
-	0x69, 0x67, 0x54, 0x79, 0x70, 0x65, 0x12, 0x20, 0x0a, 0x1c, 0x49, 0x50, 0x56, 0x36, 0x5f, 0x43,
-	0x4f, 0x4e, 0x46, 0x49, 0x47, 0x5f, 0x54, 0x59, 0x50, 0x45, 0x5f, 0x55, 0x4e, 0x53, 0x50, 0x45,
-	0x43, 0x49, 0x46, 0x49, 0x45, 0x44, 0x10, 0x00, 0x12, 0x18, 0x0a, 0x14, 0x49, 0x50, 0x56, 0x36,
-	0x5f, 0x43, 0x4f, 0x4e, 0x46, 0x49, 0x47, 0x5f, 0x54, 0x59, 0x50, 0x45, 0x5f, 0x4f, 0x46, 0x46,
-	0x10, 0x01, 0x12, 0x19, 0x0a, 0x15, 0x49, 0x50, 0x56, 0x36, 0x5f, 0x43, 0x4f, 0x4e, 0x46, 0x49,
-	0x47, 0x5f, 0x54, 0x59, 0x50, 0x45, 0x5f, 0x41, 0x55, 0x54, 0x4f, 0x10, 0x02, 0x12, 0x1b, 0x0a,
-	0x17, 0x49, 0x50, 0x56, 0x36, 0x5f, 0x43, 0x4f, 0x4e, 0x46, 0x49, 0x47, 0x5f, 0x54, 0x59, 0x50,
-	0x45, 0x5f, 0x43, 0x55, 0x53, 0x54, 0x4f, 0x4d, 0x10, 0x03, 0x2a, 0x7c, 0x0a, 0x0b, 0x49, 0x70,
-	0x76, 0x36, 0x50, 0x72, 0x69, 0x76, 0x61, 0x63, 0x79, 0x12, 0x1c, 0x0a, 0x18, 0x49, 0x50, 0x56,
-	0x36, 0x5f, 0x50, 0x52, 0x49, 0x56, 0x41, 0x43, 0x59, 0x5f, 0x55, 0x4e, 0x53, 0x50, 0x45, 0x43,
-	0x49, 0x46, 0x49, 0x45, 0x44, 0x10, 0x00, 0x12, 0x19, 0x0a, 0x15, 0x49, 0x50, 0x56, 0x36, 0x5f,
-	0x50, 0x52, 0x49, 0x56, 0x41, 0x43, 0x59, 0x5f, 0x44, 0x49, 0x53, 0x41, 0x42, 0x4c, 0x45, 0x44,
-	0x10, 0x01, 0x12, 0x18, 0x0a, 0x14, 0x49, 0x50, 0x56, 0x36, 0x5f, 0x50, 0x52, 0x49, 0x56, 0x41,
-	0x43, 0x59, 0x5f, 0x45, 0x4e, 0x41, 0x42, 0x4c, 0x45, 0x44, 0x10, 0x02, 0x12, 0x1a, 0x0a, 0x16,
-	0x49, 0x50, 0x56, 0x36, 0x5f, 0x50, 0x52, 0x49, 0x56, 0x41, 0x43, 0x59, 0x5f, 0x50, 0x52, 0x45,
-	0x46, 0x45, 0x52, 0x52, 0x45, 0x44, 0x10, 0x03, 0x2a, 0x73, 0x0a, 0x08, 0x53, 0x65, 0x63, 0x75,
-	0x72, 0x69, 0x74, 0x79, 0x12, 0x18, 0x0a, 0x14, 0x53, 0x45, 0x43, 0x55, 0x52, 0x49, 0x54, 0x59,
-	0x5f, 0x55, 0x4e, 0x53, 0x50, 0x45, 0x43, 0x49, 0x46, 0x49, 0x45, 0x44, 0x10, 0x00, 0x12, 0x10,
-	0x0a, 0x0c, 0x53, 0x45, 0x43, 0x55, 0x52, 0x49, 0x54, 0x59, 0x5f, 0x50, 0x53, 0x4b, 0x10, 0x01,
-	0x12, 0x16, 0x0a, 0x12, 0x53, 0x45, 0x43, 0x55, 0x52, 0x49, 0x54, 0x59, 0x5f, 0x49, 0x45, 0x45,
-	0x45, 0x38, 0x30, 0x32, 0x31, 0x58, 0x10, 0x02, 0x12, 0x11, 0x0a, 0x0d, 0x53, 0x45, 0x43, 0x55,
-	0x52, 0x49, 0x54, 0x59, 0x5f, 0x4e, 0x4f, 0x4e, 0x45, 0x10, 0x03, 0x12, 0x10, 0x0a, 0x0c, 0x53,
-	0x45, 0x43, 0x55, 0x52, 0x49, 0x54, 0x59, 0x5f, 0x57, 0x45, 0x50, 0x10, 0x04, 0x2a, 0x43, 0x0a,
-	0x03, 0x45, 0x61, 0x70, 0x12, 0x13, 0x0a, 0x0f, 0x45, 0x41, 0x50, 0x5f, 0x55, 0x4e, 0x53, 0x50,
-	0x45, 0x43, 0x49, 0x46, 0x49, 0x45, 0x44, 0x10, 0x00, 0x12, 0x0b, 0x0a, 0x07, 0x45, 0x41, 0x50,
-	0x5f, 0x54, 0x4c, 0x53, 0x10, 0x01, 0x12, 0x0c, 0x0a, 0x08, 0x45, 0x41, 0x50, 0x5f, 0x54, 0x54,
-	0x4c, 0x53, 0x10, 0x02, 0x12, 0x0c, 0x0a, 0x08, 0x45, 0x41, 0x50, 0x5f, 0x50, 0x45, 0x41, 0x50,
-	0x10, 0x03, 0x2a, 0x56, 0x0a, 0x0a, 0x43, 0x61, 0x43, 0x65, 0x72, 0x74, 0x54, 0x79, 0x70, 0x65,
-	0x12, 0x1c, 0x0a, 0x18, 0x43, 0x41, 0x5f, 0x43, 0x45, 0x52, 0x54, 0x5f, 0x54, 0x59, 0x50, 0x45,
-	0x5f, 0x55, 0x4e, 0x53, 0x50, 0x45, 0x43, 0x49, 0x46, 0x49, 0x45, 0x44, 0x10, 0x00, 0x12, 0x14,
-	0x0a, 0x10, 0x43, 0x41, 0x5f, 0x43, 0x45, 0x52, 0x54, 0x5f, 0x54, 0x59, 0x50, 0x45, 0x5f, 0x50,
-	0x45, 0x4d, 0x10, 0x01, 0x12, 0x14, 0x0a, 0x10, 0x43, 0x41, 0x5f, 0x43, 0x45, 0x52, 0x54, 0x5f,
-	0x54, 0x59, 0x50, 0x45, 0x5f, 0x44, 0x45, 0x52, 0x10, 0x02, 0x2a, 0x80, 0x01, 0x0a, 0x0e, 0x50,
-	0x72, 0x69, 0x76, 0x61, 0x74, 0x65, 0x4b, 0x65, 0x79, 0x54, 0x79, 0x70, 0x65, 0x12, 0x20, 0x0a,
-	0x1c, 0x50, 0x52, 0x49, 0x56, 0x41, 0x54, 0x45, 0x5f, 0x4b, 0x45, 0x59, 0x5f, 0x54, 0x59, 0x50,
-	0x45, 0x5f, 0x55, 0x4e, 0x53, 0x50, 0x45, 0x43, 0x49, 0x46, 0x49, 0x45, 0x44, 0x10, 0x00, 0x12,
-	0x18, 0x0a, 0x14, 0x50, 0x52, 0x49, 0x56, 0x41, 0x54, 0x45, 0x5f, 0x4b, 0x45, 0x59, 0x5f, 0x54,
-	0x59, 0x50, 0x45, 0x5f, 0x50, 0x45, 0x4d, 0x10, 0x01, 0x12, 0x18, 0x0a, 0x14, 0x50, 0x52, 0x49,
-	0x56, 0x41, 0x54, 0x45, 0x5f, 0x4b, 0x45, 0x59, 0x5f, 0x54, 0x59, 0x50, 0x45, 0x5f, 0x44, 0x45,
-	0x52, 0x10, 0x02, 0x12, 0x18, 0x0a, 0x14, 0x50, 0x52, 0x49, 0x56, 0x41, 0x54, 0x45, 0x5f, 0x4b,
-	0x45, 0x59, 0x5f, 0x54, 0x59, 0x50, 0x45, 0x5f, 0x50, 0x46, 0x58, 0x10, 0x03, 0x2a, 0x6d, 0x0a,
-	0x18, 0x50, 0x72, 0x69, 0x76, 0x61, 0x74, 0x65, 0x4b, 0x65, 0x79, 0x50, 0x61, 0x73, 0x73, 0x70,
-	0x68, 0x72, 0x61, 0x73, 0x65, 0x54, 0x79, 0x70, 0x65, 0x12, 0x2b, 0x0a, 0x27, 0x50, 0x52, 0x49,
-	0x56, 0x41, 0x54, 0x45, 0x5f, 0x4b, 0x45, 0x59, 0x5f, 0x50, 0x41, 0x53, 0x53, 0x50, 0x48, 0x52,
-	0x41, 0x53, 0x45, 0x5f, 0x54, 0x59, 0x50, 0x45, 0x5f, 0x55, 0x4e, 0x53, 0x50, 0x45, 0x43, 0x49,
-	0x46, 0x49, 0x45, 0x44, 0x10, 0x00, 0x12, 0x24, 0x0a, 0x20, 0x50, 0x52, 0x49, 0x56, 0x41, 0x54,
-	0x45, 0x5f, 0x4b, 0x45, 0x59, 0x5f, 0x50, 0x41, 0x53, 0x53, 0x50, 0x48, 0x52, 0x41, 0x53, 0x45,
-	0x5f, 0x54, 0x59, 0x50, 0x45, 0x5f, 0x46, 0x53, 0x49, 0x44, 0x10, 0x01, 0x2a, 0x5b, 0x0a, 0x0a,
-	0x50, 0x68, 0x61, 0x73, 0x65, 0x32, 0x54, 0x79, 0x70, 0x65, 0x12, 0x1c, 0x0a, 0x18, 0x50, 0x48,
-	0x41, 0x53, 0x45, 0x5f, 0x32, 0x5f, 0x54, 0x59, 0x50, 0x45, 0x5f, 0x55, 0x4e, 0x53, 0x50, 0x45,
-	0x43, 0x49, 0x46, 0x49, 0x45, 0x44, 0x10, 0x00, 0x12, 0x19, 0x0a, 0x15, 0x50, 0x48, 0x41, 0x53,
-	0x45, 0x5f, 0x32, 0x5f, 0x54, 0x59, 0x50, 0x45, 0x5f, 0x4d, 0x53, 0x43, 0x48, 0x41, 0x50, 0x56,
-	0x32, 0x10, 0x01, 0x12, 0x14, 0x0a, 0x10, 0x50, 0x48, 0x41, 0x53, 0x45, 0x5f, 0x32, 0x5f, 0x54,
-	0x59, 0x50, 0x45, 0x5f, 0x47, 0x54, 0x43, 0x10, 0x02, 0x42, 0x37, 0x5a, 0x35, 0x67, 0x69, 0x74,
-	0x6c, 0x61, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x74, 0x69, 0x6d, 0x65, 0x74, 0x65, 0x72, 0x6d,
-	0x2f, 0x74, 0x69, 0x6d, 0x65, 0x74, 0x65, 0x72, 0x6d, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2f,
-	0x67, 0x6f, 0x2f, 0x64, 0x65, 0x76, 0x63, 0x66, 0x67, 0x3b, 0x64, 0x65, 0x76, 0x63, 0x66, 0x67,
-	0x70, 0x62, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+	0x64, 0x22, 0x88, 0x02, 0x0a, 0x12, 0x50, 0x72, 0x6f, 0x76, 0x69, 0x73, 0x69, 0x6f, 0x6e, 0x69,
+	0x6e, 0x67, 0x42, 0x75, 0x6e, 0x64, 0x6c, 0x65, 0x12, 0x2d, 0x0a, 0x12, 0x72, 0x65, 0x67, 0x69,
+	0x73, 0x74, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x5f, 0x74, 0x6f, 0x6b, 0x65, 0x6e, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x0c, 0x52, 0x11, 0x72, 0x65, 0x67, 0x69, 0x73, 0x74, 0x72, 0x61, 0x74, 0x69,
+	0x6f, 0x6e, 0x54, 0x6f, 0x6b, 0x65, 0x6e, 0x12, 0x27, 0x0a, 0x0f, 0x6f, 0x72, 0x67, 0x61, 0x6e,
+	0x69, 0x7a, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x5f, 0x69, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c,
+	0x52, 0x0e, 0x6f, 0x72, 0x67, 0x61, 0x6e, 0x69, 0x7a, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x49, 0x64,
+	0x12, 0x1f, 0x0a, 0x0b, 0x62, 0x61, 0x63, 0x6b, 0x65, 0x6e, 0x64, 0x5f, 0x75, 0x72, 0x6c, 0x18,
+	0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0a, 0x62, 0x61, 0x63, 0x6b, 0x65, 0x6e, 0x64, 0x55, 0x72,
+	0x6c, 0x12, 0x5a, 0x0a, 0x13, 0x6e, 0x65, 0x74, 0x77, 0x6f, 0x72, 0x6b, 0x69, 0x6e, 0x67, 0x5f,
+	0x73, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x73, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x29,
+	0x2e, 0x74, 0x69, 0x6d, 0x65, 0x74, 0x65, 0x72, 0x6d, 0x5f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e,
+	0x64, 0x65, 0x76, 0x63, 0x66, 0x67, 0x2e, 0x4e, 0x65, 0x74, 0x77, 0x6f, 0x72, 0x6b, 0x69, 0x6e,
+	0x67, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x73, 0x52, 0x12, 0x6e, 0x65, 0x74, 0x77, 0x6f,
+	0x72, 0x6b, 0x69, 0x6e, 0x67, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x73, 0x12, 0x1d, 0x0a,
+	0x0a, 0x65, 0x78, 0x70, 0x69, 0x72, 0x65, 0x73, 0x5f, 0x61, 0x74, 0x18, 0x05, 0x20, 0x01, 0x28,
+	0x03, 0x52, 0x09, 0x65, 0x78, 0x70, 0x69, 0x72, 0x65, 0x73, 0x41, 0x74, 0x22, 0x50, 0x0a, 0x18,
+	0x53, 0x69, 0x67, 0x6e, 0x65, 0x64, 0x50, 0x72, 0x6f, 0x76, 0x69, 0x73, 0x69, 0x6f, 0x6e, 0x69,
+	0x6e, 0x67, 0x42, 0x75, 0x6e, 0x64, 0x6c, 0x65, 0x12, 0x16, 0x0a, 0x06, 0x62, 0x75, 0x6e, 0x64,
+	0x6c, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x06, 0x62, 0x75, 0x6e, 0x64, 0x6c, 0x65,
+	0x12, 0x1c, 0x0a, 0x09, 0x73, 0x69, 0x67, 0x6e, 0x61, 0x74, 0x75, 0x72, 0x65, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x0c, 0x52, 0x09, 0x73, 0x69, 0x67, 0x6e, 0x61, 0x74, 0x75, 0x72, 0x65, 0x22, 0x8d,
+	0x03, 0x0a, 0x0c, 0x44, 0x65, 0x76, 0x69, 0x63, 0x65, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x12,
+	0x1f, 0x0a, 0x08, 0x6c, 0x61, 0x6e, 0x67, 0x75, 0x61, 0x67, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x09, 0x48, 0x00, 0x52, 0x08, 0x6c, 0x61, 0x6e, 0x67, 0x75, 0x61, 0x67, 0x65, 0x88, 0x01, 0x01,
+	0x12, 0x1f, 0x0a, 0x08, 0x74, 0x69, 0x6d, 0x65, 0x7a, 0x6f, 0x6e, 0x65, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x09, 0x48, 0x01, 0x52, 0x08, 0x74, 0x69, 0x6d, 0x65, 0x7a, 0x6f, 0x6e, 0x65, 0x88, 0x01,
+	0x01, 0x12, 0x5a, 0x0a, 0x13, 0x62, 0x72, 0x69, 0x67, 0x68, 0x74, 0x6e, 0x65, 0x73, 0x73, 0x5f,
+	0x73, 0x63, 0x68, 0x65, 0x64, 0x75, 0x6c, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x29,
+	0x2e, 0x74, 0x69, 0x6d, 0x65, 0x74, 0x65, 0x72, 0x6d, 0x5f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e,
+	0x64, 0x65, 0x76, 0x63, 0x66, 0x67, 0x2e, 0x42, 0x72, 0x69, 0x67, 0x68, 0x74, 0x6e, 0x65, 0x73,
+	0x73, 0x53, 0x63, 0x68, 0x65, 0x64, 0x75, 0x6c, 0x65, 0x52, 0x12, 0x62, 0x72, 0x69, 0x67, 0x68,
+	0x74, 0x6e, 0x65, 0x73, 0x73, 0x53, 0x63, 0x68, 0x65, 0x64, 0x75, 0x6c, 0x65, 0x12, 0x35, 0x0a,
+	0x14, 0x69, 0x64, 0x6c, 0x65, 0x5f, 0x74, 0x69, 0x6d, 0x65, 0x6f, 0x75, 0x74, 0x5f, 0x73, 0x65,
+	0x63, 0x6f, 0x6e, 0x64, 0x73, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0d, 0x48, 0x02, 0x52, 0x12, 0x69,
+	0x64, 0x6c, 0x65, 0x54, 0x69, 0x6d, 0x65, 0x6f, 0x75, 0x74, 0x53, 0x65, 0x63, 0x6f, 0x6e, 0x64,
+	0x73, 0x88, 0x01, 0x01, 0x12, 0x38, 0x0a, 0x07, 0x73, 0x63, 0x72, 0x65, 0x65, 0x6e, 0x73, 0x18,
+	0x05, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1e, 0x2e, 0x74, 0x69, 0x6d, 0x65, 0x74, 0x65, 0x72, 0x6d,
+	0x5f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x64, 0x65, 0x76, 0x63, 0x66, 0x67, 0x2e, 0x53, 0x63,
+	0x72, 0x65, 0x65, 0x6e, 0x73, 0x52, 0x07, 0x73, 0x63, 0x72, 0x65, 0x65, 0x6e, 0x73, 0x12, 0x3b,
+	0x0a, 0x08, 0x62, 0x72, 0x61, 0x6e, 0x64, 0x69, 0x6e, 0x67, 0x18, 0x06, 0x20, 0x01, 0x28, 0x0b,
+	0x32, 0x1f, 0x2e, 0x74, 0x69, 0x6d, 0x65, 0x74, 0x65, 0x72, 0x6d, 0x5f, 0x70, 0x72, 0x6f, 0x74,
+	0x6f, 0x2e, 0x64, 0x65, 0x76, 0x63, 0x66, 0x67, 0x2e, 0x42, 0x72, 0x61, 0x6e, 0x64, 0x69, 0x6e,
+	0x67, 0x52, 0x08, 0x62, 0x72, 0x61, 0x6e, 0x64, 0x69, 0x6e, 0x67, 0x42, 0x0b, 0x0a, 0x09, 0x5f,
+	0x6c, 0x61, 0x6e, 0x67, 0x75, 0x61, 0x67, 0x65, 0x42, 0x0b, 0x0a, 0x09, 0x5f, 0x74, 0x69, 0x6d,
+	0x65, 0x7a, 0x6f, 0x6e, 0x65, 0x42, 0x17, 0x0a, 0x15, 0x5f, 0x69, 0x64, 0x6c, 0x65, 0x5f, 0x74,
+	0x69, 0x6d, 0x65, 0x6f, 0x75, 0x74, 0x5f, 0x73, 0x65, 0x63, 0x6f, 0x6e, 0x64, 0x73, 0x22, 0x57,
+	0x0a, 0x12, 0x42, 0x72, 0x69, 0x67, 0x68, 0x74, 0x6e, 0x65, 0x73, 0x73, 0x53, 0x63, 0x68, 0x65,
+	0x64, 0x75, 0x6c, 0x65, 0x12, 0x41, 0x0a, 0x07, 0x70, 0x65, 0x72, 0x69, 0x6f, 0x64, 0x73, 0x18,
+	0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x27, 0x2e, 0x74, 0x69, 0x6d, 0x65, 0x74, 0x65, 0x72, 0x6d,
+	0x5f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x64, 0x65, 0x76, 0x63, 0x66, 0x67, 0x2e, 0x42, 0x72,
+	0x69, 0x67, 0x68, 0x74, 0x6e, 0x65, 0x73, 0x73, 0x50, 0x65, 0x72, 0x69, 0x6f, 0x64, 0x52, 0x07,
+	0x70, 0x65, 0x72, 0x69, 0x6f, 0x64, 0x73, 0x22, 0x55, 0x0a, 0x10, 0x42, 0x72, 0x69, 0x67, 0x68,
+	0x74, 0x6e, 0x65, 0x73, 0x73, 0x50, 0x65, 0x72, 0x69, 0x6f, 0x64, 0x12, 0x21, 0x0a, 0x0c, 0x73,
+	0x74, 0x61, 0x72, 0x74, 0x5f, 0x6d, 0x69, 0x6e, 0x75, 0x74, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x0d, 0x52, 0x0b, 0x73, 0x74, 0x61, 0x72, 0x74, 0x4d, 0x69, 0x6e, 0x75, 0x74, 0x65, 0x12, 0x1e,
+	0x0a, 0x0a, 0x62, 0x72, 0x69, 0x67, 0x68, 0x74, 0x6e, 0x65, 0x73, 0x73, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x0d, 0x52, 0x0a, 0x62, 0x72, 0x69, 0x67, 0x68, 0x74, 0x6e, 0x65, 0x73, 0x73, 0x22, 0x76,
+	0x0a, 0x07, 0x53, 0x63, 0x72, 0x65, 0x65, 0x6e, 0x73, 0x12, 0x1c, 0x0a, 0x09, 0x74, 0x69, 0x6d,
+	0x65, 0x74, 0x61, 0x62, 0x6c, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x08, 0x52, 0x09, 0x74, 0x69,
+	0x6d, 0x65, 0x74, 0x61, 0x62, 0x6c, 0x65, 0x12, 0x27, 0x0a, 0x0f, 0x63, 0x61, 0x72, 0x64, 0x5f,
+	0x65, 0x6e, 0x72, 0x6f, 0x6c, 0x6c, 0x6d, 0x65, 0x6e, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x08,
+	0x52, 0x0e, 0x63, 0x61, 0x72, 0x64, 0x45, 0x6e, 0x72, 0x6f, 0x6c, 0x6c, 0x6d, 0x65, 0x6e, 0x74,
+	0x12, 0x24, 0x0a, 0x0d, 0x61, 0x6e, 0x6e, 0x6f, 0x75, 0x6e, 0x63, 0x65, 0x6d, 0x65, 0x6e, 0x74,
+	0x73, 0x18, 0x03, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0d, 0x61, 0x6e, 0x6e, 0x6f, 0x75, 0x6e, 0x63,
+	0x65, 0x6d, 0x65, 0x6e, 0x74, 0x73, 0x22, 0x3d, 0x0a, 0x08, 0x42, 0x72, 0x61, 0x6e, 0x64, 0x69,
+	0x6e, 0x67, 0x12, 0x14, 0x0a, 0x05, 0x74, 0x69, 0x74, 0x6c, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x05, 0x74, 0x69, 0x74, 0x6c, 0x65, 0x12, 0x1b, 0x0a, 0x09, 0x73, 0x68, 0x6f, 0x77,
+	0x5f, 0x6c, 0x6f, 0x67, 0x6f, 0x18, 0x02, 0x20, 0x01, 0x28, 0x08, 0x52, 0x08, 0x73, 0x68, 0x6f,
+	0x77, 0x4c, 0x6f, 0x67, 0x6f, 0x2a, 0x88, 0x01, 0x0a, 0x15, 0x4e, 0x65, 0x74, 0x77, 0x6f, 0x72,
+	0x6b, 0x69, 0x6e, 0x67, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x54, 0x79, 0x70, 0x65, 0x12,
+	0x27, 0x0a, 0x23, 0x4e, 0x45, 0x54, 0x57, 0x4f, 0x52, 0x4b, 0x49, 0x4e, 0x47, 0x5f, 0x53, 0x45,
+	0x52, 0x56, 0x49, 0x43, 0x45, 0x5f, 0x54, 0x59, 0x50, 0x45, 0x5f, 0x55, 0x4e, 0x53, 0x50, 0x45,
+	0x43, 0x49, 0x46, 0x49, 0x45, 0x44, 0x10, 0x00, 0x12, 0x24, 0x0a, 0x20, 0x4e, 0x45, 0x54, 0x57,
+	0x4f, 0x52, 0x4b, 0x49, 0x4e, 0x47, 0x5f, 0x53, 0x45, 0x52, 0x56, 0x49, 0x43, 0x45, 0x5f, 0x54,
+	0x59, 0x50, 0x45, 0x5f, 0x45, 0x54, 0x48, 0x45, 0x52, 0x4e, 0x45, 0x54, 0x10, 0x01, 0x12, 0x20,
+	0x0a, 0x1c, 0x4e, 0x45, 0x54, 0x57, 0x4f, 0x52, 0x4b, 0x49, 0x4e, 0x47, 0x5f, 0x53, 0x45, 0x52,
+	0x56, 0x49, 0x43, 0x45, 0x5f, 0x54, 0x59, 0x50, 0x45, 0x5f, 0x57, 0x49, 0x46, 0x49, 0x10, 0x02,
+	0x2a, 0x84, 0x01, 0x0a, 0x0e, 0x49, 0x70, 0x76, 0x34, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x54,
+	0x79, 0x70, 0x65, 0x12, 0x20, 0x0a, 0x1c, 0x49, 0x50, 0x56, 0x34, 0x5f, 0x43, 0x4f, 0x4e, 0x46,
+	0x49, 0x47, 0x5f, 0x54, 0x59, 0x50, 0x45, 0x5f, 0x55, 0x4e, 0x53, 0x50, 0x45, 0x43, 0x49, 0x46,
+	0x49, 0x45, 0x44, 0x10, 0x00, 0x12, 0x18, 0x0a, 0x14, 0x49, 0x50, 0x56, 0x34, 0x5f, 0x43, 0x4f,
+	0x4e, 0x46, 0x49, 0x47, 0x5f, 0x54, 0x59, 0x50, 0x45, 0x5f, 0x4f, 0x46, 0x46, 0x10, 0x01, 0x12,
+	0x19, 0x0a, 0x15, 0x49, 0x50, 0x56, 0x34, 0x5f, 0x43, 0x4f, 0x4e, 0x46, 0x49, 0x47, 0x5f, 0x54,
+	0x59, 0x50, 0x45, 0x5f, 0x44, 0x48, 0x43, 0x50, 0x10, 0x02, 0x12, 0x1b, 0x0a, 0x17, 0x49, 0x50,
+	0x56, 0x34, 0x5f, 0x43, 0x4f, 0x4e, 0x46, 0x49, 0x47, 0x5f, 0x54, 0x59, 0x50, 0x45, 0x5f, 0x43,
+	0x55, 0x53, 0x54, 0x4f, 0x4d, 0x10, 0x03, 0x2a, 0x84, 0x01, 0x0a, 0x0e, 0x49, 0x70, 0x76, 0x36,
+	0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x54, 0x79, 0x70, 0x65, 0x12, 0x20, 0x0a, 0x1c, 0x49, 0x50,
+	0x56, 0x36, 0x5f, 0x43, 0x4f, 0x4e, 0x46, 0x49, 0x47, 0x5f, 0x54, 0x59, 0x50, 0x45, 0x5f, 0x55,
+	0x4e, 0x53, 0x50, 0x45, 0x43, 0x49, 0x46, 0x49, 0x45, 0x44, 0x10, 0x00, 0x12, 0x18, 0x0a, 0x14,
+	0x49, 0x50, 0x56, 0x36, 0x5f, 0x43, 0x4f, 0x4e, 0x46, 0x49, 0x47, 0x5f, 0x54, 0x59, 0x50, 0x45,
+	0x5f, 0x4f, 0x46, 0x46, 0x10, 0x01, 0x12, 0x19, 0x0a, 0x15, 0x49, 0x50, 0x56, 0x36, 0x5f, 0x43,
+	0x4f, 0x4e, 0x46, 0x49, 0x47, 0x5f, 0x54, 0x59, 0x50, 0x45, 0x5f, 0x41, 0x55, 0x54, 0x4f, 0x10,
+	0x02, 0x12, 0x1b, 0x0a, 0x17, 0x49, 0x50, 0x56, 0x36, 0x5f, 0x43, 0x4f, 0x4e, 0x46, 0x49, 0x47,
+	0x5f, 0x54, 0x59, 0x50, 0x45, 0x5f, 0x43, 0x55, 0x53, 0x54, 0x4f, 0x4d, 0x10, 0x03, 0x2a, 0x7c,
+	0x0a, 0x0b, 0x49, 0x70, 0x76, 0x36, 0x50, 0x72, 0x69, 0x76, 0x61, 0x63, 0x79, 0x12, 0x1c, 0x0a,
+	0x18, 0x49, 0x50, 0x56, 0x36, 0x5f, 0x50, 0x52, 0x49, 0x56, 0x41, 0x43, 0x59, 0x5f, 0x55, 0x4e,
+	0x53, 0x50, 0x45, 0x43, 0x49, 0x46, 0x49, 0x45, 0x44, 0x10, 0x00, 0x12, 0x19, 0x0a, 0x15, 0x49,
+	0x50, 0x56, 0x36, 0x5f, 0x50, 0x52, 0x49, 0x56, 0x41, 0x43, 0x59, 0x5f, 0x44, 0x49, 0x53, 0x41,
+	0x42, 0x4c, 0x45, 0x44, 0x10, 0x01, 0x12, 0x18, 0x0a, 0x14, 0x49, 0x50, 0x56, 0x36, 0x5f, 0x50,
+	0x52, 0x49, 0x56, 0x41, 0x43, 0x59, 0x5f, 0x45, 0x4e, 0x41, 0x42, 0x4c, 0x45, 0x44, 0x10, 0x02,
+	0x12, 0x1a, 0x0a, 0x16, 0x49, 0x50, 0x56, 0x36, 0x5f, 0x50, 0x52, 0x49, 0x56, 0x41, 0x43, 0x59,
+	0x5f, 0x50, 0x52, 0x45, 0x46, 0x45, 0x52, 0x52, 0x45, 0x44, 0x10, 0x03, 0x2a, 0x73, 0x0a, 0x08,
+	0x53, 0x65, 0x63, 0x75, 0x72, 0x69, 0x74, 0x79, 0x12, 0x18, 0x0a, 0x14, 0x53, 0x45, 0x43, 0x55,
+	0x52, 0x49, 0x54, 0x59, 0x5f, 0x55, 0x4e, 0x53, 0x50, 0x45, 0x43, 0x49, 0x46, 0x49, 0x45, 0x44,
+	0x10, 0x00, 0x12, 0x10, 0x0a, 0x0c, 0x53, 0x45, 0x43, 0x55, 0x52, 0x49, 0x54, 0x59, 0x5f, 0x50,
+	0x53, 0x4b, 0x10, 0x01, 0x12, 0x16, 0x0a, 0x12, 0x53, 0x45, 0x43, 0x55, 0x52, 0x49, 0x54, 0x59,
+	0x5f, 0x49, 0x45, 0x45, 0x45, 0x38, 0x30, 0x32, 0x31, 0x58, 0x10, 0x02, 0x12, 0x11, 0x0a, 0x0d,
+	0x53, 0x45, 0x43, 0x55, 0x52, 0x49, 0x54, 0x59, 0x5f, 0x4e, 0x4f, 0x4e, 0x45, 0x10, 0x03, 0x12,
+	0x10, 0x0a, 0x0c, 0x53, 0x45, 0x43, 0x55, 0x52, 0x49, 0x54, 0x59, 0x5f, 0x57, 0x45, 0x50, 0x10,
+	0x04, 0x2a, 0x43, 0x0a, 0x03, 0x45, 0x61, 0x70, 0x12, 0x13, 0x0a, 0x0f, 0x45, 0x41, 0x50, 0x5f,
+	0x55, 0x4e, 0x53, 0x50, 0x45, 0x43, 0x49, 0x46, 0x49, 0x45, 0x44, 0x10, 0x00, 0x12, 0x0b, 0x0a,
+	0x07, 0x45, 0x41, 0x50, 0x5f, 0x54, 0x4c, 0x53, 0x10, 0x01, 0x12, 0x0c, 0x0a, 0x08, 0x45, 0x41,
+	0x50, 0x5f, 0x54, 0x54, 0x4c, 0x53, 0x10, 0x02, 0x12, 0x0c, 0x0a, 0x08, 0x45, 0x41, 0x50, 0x5f,
+	0x50, 0x45, 0x41, 0x50, 0x10, 0x03, 0x2a, 0x56, 0x0a, 0x0a, 0x43, 0x61, 0x43, 0x65, 0x72, 0x74,
+	0x54, 0x79, 0x70, 0x65, 0x12, 0x1c, 0x0a, 0x18, 0x43, 0x41, 0x5f, 0x43, 0x45, 0x52, 0x54, 0x5f,
+	0x54, 0x59, 0x50, 0x45, 0x5f, 0x55, 0x4e, 0x53, 0x50, 0x45, 0x43, 0x49, 0x46, 0x49, 0x45, 0x44,
+	0x10, 0x00, 0x12, 0x14, 0x0a, 0x10, 0x43, 0x41, 0x5f, 0x43, 0x45, 0x52, 0x54, 0x5f, 0x54, 0x59,
+	0x50, 0x45, 0x5f, 0x50, 0x45, 0x4d, 0x10, 0x01, 0x12, 0x14, 0x0a, 0x10, 0x43, 0x41, 0x5f, 0x43,
+	0x45, 0x52, 0x54, 0x5f, 0x54, 0x59, 0x50, 0x45, 0x5f, 0x44, 0x45, 0x52, 0x10, 0x02, 0x2a, 0x80,
+	0x01, 0x0a, 0x0e, 0x50, 0x72, 0x69, 0x76, 0x61, 0x74, 0x65, 0x4b, 0x65, 0x79, 0x54, 0x79, 0x70,
+	0x65, 0x12, 0x20, 0x0a, 0x1c, 0x50, 0x52, 0x49, 0x56, 0x41, 0x54, 0x45, 0x5f, 0x4b, 0x45, 0x59,
+	0x5f, 0x54, 0x59, 0x50, 0x45, 0x5f, 0x55, 0x4e, 0x53, 0x50, 0x45, 0x43, 0x49, 0x46, 0x49, 0x45,
+	0x44, 0x10, 0x00, 0x12, 0x18, 0x0a, 0x14, 0x50, 0x52, 0x49, 0x56, 0x41, 0x54, 0x45, 0x5f, 0x4b,
+	0x45, 0x59, 0x5f, 0x54, 0x59, 0x50, 0x45, 0x5f, 0x50, 0x45, 0x4d, 0x10, 0x01, 0x12, 0x18, 0x0a,
+	0x14, 0x50, 0x52, 0x49, 0x56, 0x41, 0x54, 0x45, 0x5f, 0x4b, 0x45, 0x59, 0x5f, 0x54, 0x59, 0x50,
+	0x45, 0x5f, 0x44, 0x45, 0x52, 0x10, 0x02, 0x12, 0x18, 0x0a, 0x14, 0x50, 0x52, 0x49, 0x56, 0x41,
+	0x54, 0x45, 0x5f, 0x4b, 0x45, 0x59, 0x5f, 0x54, 0x59, 0x50, 0x45, 0x5f, 0x50, 0x46, 0x58, 0x10,
+	0x03, 0x2a, 0x6d, 0x0a, 0x18, 0x50, 0x72, 0x69, 0x76, 0x61, 0x74, 0x65, 0x4b, 0x65, 0x79, 0x50,
+	0x61, 0x73, 0x73, 0x70, 0x68, 0x72, 0x61, 0x73, 0x65, 0x54, 0x79, 0x70, 0x65, 0x12, 0x2b, 0x0a,
+	0x27, 0x50, 0x52, 0x49, 0x56, 0x41, 0x54, 0x45, 0x5f, 0x4b, 0x45, 0x59, 0x5f, 0x50, 0x41, 0x53,
+	0x53, 0x50, 0x48, 0x52, 0x41, 0x53, 0x45, 0x5f, 0x54, 0x59, 0x50, 0x45, 0x5f, 0x55, 0x4e, 0x53,
+	0x50, 0x45, 0x43, 0x49, 0x46, 0x49, 0x45, 0x44, 0x10, 0x00, 0x12, 0x24, 0x0a, 0x20, 0x50, 0x52,
+	0x49, 0x56, 0x41, 0x54, 0x45, 0x5f, 0x4b, 0x45, 0x59, 0x5f, 0x50, 0x41, 0x53, 0x53, 0x50, 0x48,
+	0x52, 0x41, 0x53, 0x45, 0x5f, 0x54, 0x59, 0x50, 0x45, 0x5f, 0x46, 0x53, 0x49, 0x44, 0x10, 0x01,
+	0x2a, 0x5b, 0x0a, 0x0a, 0x50, 0x68, 0x61, 0x73, 0x65, 0x32, 0x54, 0x79, 0x70, 0x65, 0x12, 0x1c,
+	0x0a, 0x18, 0x50, 0x48, 0x41, 0x53, 0x45, 0x5f, 0x32, 0x5f, 0x54, 0x59, 0x50, 0x45, 0x5f, 0x55,
+	0x4e, 0x53, 0x50, 0x45, 0x43, 0x49, 0x46, 0x49, 0x45, 0x44, 0x10, 0x00, 0x12, 0x19, 0x0a, 0x15,
+	0x50, 0x48, 0x41, 0x53, 0x45, 0x5f, 0x32, 0x5f, 0x54, 0x59, 0x50, 0x45, 0x5f, 0x4d, 0x53, 0x43,
+	0x48, 0x41, 0x50, 0x56, 0x32, 0x10, 0x01, 0x12, 0x14, 0x0a, 0x10, 0x50, 0x48, 0x41, 0x53, 0x45,
+	0x5f, 0x32, 0x5f, 0x54, 0x59, 0x50, 0x45, 0x5f, 0x47, 0x54, 0x43, 0x10, 0x02, 0x42, 0x37, 0x5a,
+	0x35, 0x67, 0x69, 0x74, 0x6c, 0x61, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x74, 0x69, 0x6d, 0x65,
+	0x74, 0x65, 0x72, 0x6d, 0x2f, 0x74, 0x69, 0x6d, 0x65, 0x74, 0x65, 0x72, 0x6d, 0x2f, 0x70, 0x72,
+	0x6f, 0x74, 0x6f, 0x2f, 0x67, 0x6f, 0x2f, 0x64, 0x65, 0x76, 0x63, 0x66, 0x67, 0x3b, 0x64, 0x65,
+	0x76, 0x63, 0x66, 0x67, 0x70, 0x62, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
 }
 
 var (
@@ -1314,28 +1844,35 @@ func file_devcfg_devcfg_proto_rawDescGZIP() []byte {
 }
 
 var file_devcfg_devcfg_proto_enumTypes = make([]protoimpl.EnumInfo, 10)
-var file_devcfg_devcfg_proto_msgTypes = make([]protoimpl.MessageInfo, 7)
+var file_devcfg_devcfg_proto_msgTypes = make([]protoimpl.MessageInfo, 14)
 var file_devcfg_devcfg_proto_goTypes = []interface{}{
-	(NetworkingServiceType)(0),    // 0: timeterm_proto.devcfg.NetworkingServiceType
-	(Ipv4ConfigType)(0),           // 1: timeterm_proto.devcfg.Ipv4ConfigType
-	(Ipv6ConfigType)(0),           // 2: timeterm_proto.devcfg.Ipv6ConfigType
-	(Ipv6Privacy)(0),              // 3: timeterm_proto.devcfg.Ipv6Privacy
-	(Security)(0),                 // 4: timeterm_proto.devcfg.Security
-	(Eap)(0),                      // 5: timeterm_proto.devcfg.Eap
-	(CaCertType)(0),               // 6: timeterm_proto.devcfg.CaCertType
-	(PrivateKeyType)(0),           // 7: timeterm_proto.devcfg.PrivateKeyType
-	(PrivateKeyPassphraseType)(0), // 8: timeterm_proto.devcfg.PrivateKeyPassphraseType
-	(Phase2Type)(0),               // 9: timeterm_proto.devcfg.Phase2Type
-	(*NetworkingServices)(nil),    // 10: timeterm_proto.devcfg.NetworkingServices
-	(*Ipv4ConfigSettings)(nil),    // 11: timeterm_proto.devcfg.Ipv4ConfigSettings
-	(*Ipv4Config)(nil),            // 12: timeterm_proto.devcfg.Ipv4Config
-	(*Ipv6ConfigSettings)(nil),    // 13: timeterm_proto.devcfg.Ipv6ConfigSettings
-	(*Ipv6Config)(nil),            // 14: timeterm_proto.devcfg.Ipv6Config
-	(*NetworkingService)(nil),     // 15: timeterm_proto.devcfg.NetworkingService
-	nil,                           // 16: timeterm_proto.devcfg.NetworkingServices.ServicesEntry
+	(NetworkingServiceType)(0),       // 0: timeterm_proto.devcfg.NetworkingServiceType
+	(Ipv4ConfigType)(0),              // 1: timeterm_proto.devcfg.Ipv4ConfigType
+	(Ipv6ConfigType)(0),              // 2: timeterm_proto.devcfg.Ipv6ConfigType
+	(Ipv6Privacy)(0),                 // 3: timeterm_proto.devcfg.Ipv6Privacy
+	(Security)(0),                    // 4: timeterm_proto.devcfg.Security
+	(Eap)(0),                         // 5: timeterm_proto.devcfg.Eap
+	(CaCertType)(0),                  // 6: timeterm_proto.devcfg.CaCertType
+	(PrivateKeyType)(0),              // 7: timeterm_proto.devcfg.PrivateKeyType
+	(PrivateKeyPassphraseType)(0),    // 8: timeterm_proto.devcfg.PrivateKeyPassphraseType
+	(Phase2Type)(0),                  // 9: timeterm_proto.devcfg.Phase2Type
+	(*NetworkingServices)(nil),       // 10: timeterm_proto.devcfg.NetworkingServices
+	(*Ipv4ConfigSettings)(nil),       // 11: timeterm_proto.devcfg.Ipv4ConfigSettings
+	(*Ipv4Config)(nil),               // 12: timeterm_proto.devcfg.Ipv4Config
+	(*Ipv6ConfigSettings)(nil),       // 13: timeterm_proto.devcfg.Ipv6ConfigSettings
+	(*Ipv6Config)(nil),               // 14: timeterm_proto.devcfg.Ipv6Config
+	(*NetworkingService)(nil),        // 15: timeterm_proto.devcfg.NetworkingService
+	(*ProvisioningBundle)(nil),       // 16: timeterm_proto.devcfg.ProvisioningBundle
+	(*SignedProvisioningBundle)(nil), // 17: timeterm_proto.devcfg.SignedProvisioningBundle
+	(*DeviceConfig)(nil),             // 18: timeterm_proto.devcfg.DeviceConfig
+	(*BrightnessSchedule)(nil),       // 19: timeterm_proto.devcfg.BrightnessSchedule
+	(*BrightnessPeriod)(nil),         // 20: timeterm_proto.devcfg.BrightnessPeriod
+	(*Screens)(nil),                  // 21: timeterm_proto.devcfg.Screens
+	(*Branding)(nil),                 // 22: timeterm_proto.devcfg.Branding
+	nil,                              // 23: timeterm_proto.devcfg.NetworkingServices.ServicesEntry
 }
 var file_devcfg_devcfg_proto_depIdxs = []int32{
-	16, // 0: timeterm_proto.devcfg.NetworkingServices.services:type_name -> timeterm_proto.devcfg.NetworkingServices.ServicesEntry
+	23, // 0: timeterm_proto.devcfg.NetworkingServices.services:type_name -> timeterm_proto.devcfg.NetworkingServices.ServicesEntry
 	1,  // 1: timeterm_proto.devcfg.Ipv4Config.type:type_name -> timeterm_proto.devcfg.Ipv4ConfigType
 	11, // 2: timeterm_proto.devcfg.Ipv4Config.settings:type_name -> timeterm_proto.devcfg.Ipv4ConfigSettings
 	2,  // 3: timeterm_proto.devcfg.Ipv6Config.type:type_name -> timeterm_proto.devcfg.Ipv6ConfigType
@@ -1350,12 +1887,17 @@ var file_devcfg_devcfg_proto_depIdxs = []int32{
 	7,  // 12: timeterm_proto.devcfg.NetworkingService.private_key_type:type_name -> timeterm_proto.devcfg.PrivateKeyType
 	8,  // 13: timeterm_proto.devcfg.NetworkingService.private_key_passphrase_type:type_name -> timeterm_proto.devcfg.PrivateKeyPassphraseType
 	9,  // 14: timeterm_proto.devcfg.NetworkingService.phase_2:type_name -> timeterm_proto.devcfg.Phase2Type
-	15, // 15: timeterm_proto.devcfg.NetworkingServices.ServicesEntry.value:type_name -> timeterm_proto.devcfg.NetworkingService
-	16, // [16:16] is the sub-list for method output_type
-	16, // [16:16] is the sub-list for method input_type
-	16, // [16:16] is the sub-list for extension type_name
-	16, // [16:16] is the sub-list for extension extendee
-	0,  // [0:16] is the sub-list for field type_name
+	10, // 15: timeterm_proto.devcfg.ProvisioningBundle.networking_services:type_name -> timeterm_proto.devcfg.NetworkingServices
+	19, // 16: timeterm_proto.devcfg.DeviceConfig.brightness_schedule:type_name -> timeterm_proto.devcfg.BrightnessSchedule
+	21, // 17: timeterm_proto.devcfg.DeviceConfig.screens:type_name -> timeterm_proto.devcfg.Screens
+	22, // 18: timeterm_proto.devcfg.DeviceConfig.branding:type_name -> timeterm_proto.devcfg.Branding
+	20, // 19: timeterm_proto.devcfg.BrightnessSchedule.periods:type_name -> timeterm_proto.devcfg.BrightnessPeriod
+	15, // 20: timeterm_proto.devcfg.NetworkingServices.ServicesEntry.value:type_name -> timeterm_proto.devcfg.NetworkingService
+	21, // [21:21] is the sub-list for method output_type
+	21, // [21:21] is the sub-list for method input_type
+	21, // [21:21] is the sub-list for extension type_name
+	21, // [21:21] is the sub-list for extension extendee
+	0,  // [0:21] is the sub-list for field type_name
 }
 
 func init() { file_devcfg_devcfg_proto_init() }
@@ -1436,14 +1978,99 @@ func file_devcfg_devcfg_proto_init() {
 				return nil
 			}
 		}
+		file_devcfg_devcfg_proto_msgTypes[6].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ProvisioningBundle); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_devcfg_devcfg_proto_msgTypes[7].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SignedProvisioningBundle); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_devcfg_devcfg_proto_msgTypes[8].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*DeviceConfig); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_devcfg_devcfg_proto_msgTypes[9].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*BrightnessSchedule); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_devcfg_devcfg_proto_msgTypes[10].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*BrightnessPeriod); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_devcfg_devcfg_proto_msgTypes[11].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Screens); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_devcfg_devcfg_proto_msgTypes[12].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Branding); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
 	}
+	file_devcfg_devcfg_proto_msgTypes[8].OneofWrappers = []interface{}{}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: file_devcfg_devcfg_proto_rawDesc,
 			NumEnums:      10,
-			NumMessages:   7,
+			NumMessages:   14,
 			NumExtensions: 0,
 			NumServices:   0,
 		},
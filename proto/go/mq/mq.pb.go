@@ -25,29 +25,815 @@ const (
 // of the legacy proto package is being used.
 const _ = proto.ProtoPackageIsVersion4
 
+type DeviceCapability int32
+
+const (
+	DeviceCapability_DEVICE_CAPABILITY_UNSPECIFIED DeviceCapability = 0
+	// The device can execute SetScreenPowerMessages.
+	DeviceCapability_DEVICE_CAPABILITY_SCREEN_POWER DeviceCapability = 1
+)
+
+// Enum value maps for DeviceCapability.
+var (
+	DeviceCapability_name = map[int32]string{
+		0: "DEVICE_CAPABILITY_UNSPECIFIED",
+		1: "DEVICE_CAPABILITY_SCREEN_POWER",
+	}
+	DeviceCapability_value = map[string]int32{
+		"DEVICE_CAPABILITY_UNSPECIFIED":  0,
+		"DEVICE_CAPABILITY_SCREEN_POWER": 1,
+	}
+)
+
+func (x DeviceCapability) Enum() *DeviceCapability {
+	p := new(DeviceCapability)
+	*p = x
+	return p
+}
+
+func (x DeviceCapability) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (DeviceCapability) Descriptor() protoreflect.EnumDescriptor {
+	return file_mq_mq_proto_enumTypes[0].Descriptor()
+}
+
+func (DeviceCapability) Type() protoreflect.EnumType {
+	return &file_mq_mq_proto_enumTypes[0]
+}
+
+func (x DeviceCapability) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use DeviceCapability.Descriptor instead.
+func (DeviceCapability) EnumDescriptor() ([]byte, []int) {
+	return file_mq_mq_proto_rawDescGZIP(), []int{0}
+}
+
+type CardReaderStatus int32
+
+const (
+	CardReaderStatus_CARD_READER_STATUS_UNSPECIFIED   CardReaderStatus = 0
+	CardReaderStatus_CARD_READER_STATUS_OK            CardReaderStatus = 1
+	CardReaderStatus_CARD_READER_STATUS_NOT_CONNECTED CardReaderStatus = 2
+	CardReaderStatus_CARD_READER_STATUS_ERROR         CardReaderStatus = 3
+)
+
+// Enum value maps for CardReaderStatus.
+var (
+	CardReaderStatus_name = map[int32]string{
+		0: "CARD_READER_STATUS_UNSPECIFIED",
+		1: "CARD_READER_STATUS_OK",
+		2: "CARD_READER_STATUS_NOT_CONNECTED",
+		3: "CARD_READER_STATUS_ERROR",
+	}
+	CardReaderStatus_value = map[string]int32{
+		"CARD_READER_STATUS_UNSPECIFIED":   0,
+		"CARD_READER_STATUS_OK":            1,
+		"CARD_READER_STATUS_NOT_CONNECTED": 2,
+		"CARD_READER_STATUS_ERROR":         3,
+	}
+)
+
+func (x CardReaderStatus) Enum() *CardReaderStatus {
+	p := new(CardReaderStatus)
+	*p = x
+	return p
+}
+
+func (x CardReaderStatus) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (CardReaderStatus) Descriptor() protoreflect.EnumDescriptor {
+	return file_mq_mq_proto_enumTypes[1].Descriptor()
+}
+
+func (CardReaderStatus) Type() protoreflect.EnumType {
+	return &file_mq_mq_proto_enumTypes[1]
+}
+
+func (x CardReaderStatus) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use CardReaderStatus.Descriptor instead.
+func (CardReaderStatus) EnumDescriptor() ([]byte, []int) {
+	return file_mq_mq_proto_rawDescGZIP(), []int{1}
+}
+
+type CommandStatus int32
+
+const (
+	CommandStatus_COMMAND_STATUS_UNSPECIFIED CommandStatus = 0
+	// The device has received the command and will execute it.
+	CommandStatus_COMMAND_STATUS_ACKED     CommandStatus = 1
+	CommandStatus_COMMAND_STATUS_SUCCEEDED CommandStatus = 2
+	CommandStatus_COMMAND_STATUS_FAILED    CommandStatus = 3
+)
+
+// Enum value maps for CommandStatus.
+var (
+	CommandStatus_name = map[int32]string{
+		0: "COMMAND_STATUS_UNSPECIFIED",
+		1: "COMMAND_STATUS_ACKED",
+		2: "COMMAND_STATUS_SUCCEEDED",
+		3: "COMMAND_STATUS_FAILED",
+	}
+	CommandStatus_value = map[string]int32{
+		"COMMAND_STATUS_UNSPECIFIED": 0,
+		"COMMAND_STATUS_ACKED":       1,
+		"COMMAND_STATUS_SUCCEEDED":   2,
+		"COMMAND_STATUS_FAILED":      3,
+	}
+)
+
+func (x CommandStatus) Enum() *CommandStatus {
+	p := new(CommandStatus)
+	*p = x
+	return p
+}
+
+func (x CommandStatus) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (CommandStatus) Descriptor() protoreflect.EnumDescriptor {
+	return file_mq_mq_proto_enumTypes[2].Descriptor()
+}
+
+func (CommandStatus) Type() protoreflect.EnumType {
+	return &file_mq_mq_proto_enumTypes[2]
+}
+
+func (x CommandStatus) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use CommandStatus.Descriptor instead.
+func (CommandStatus) EnumDescriptor() ([]byte, []int) {
+	return file_mq_mq_proto_rawDescGZIP(), []int{2}
+}
+
+type AnnouncementSeverity int32
+
+const (
+	AnnouncementSeverity_ANNOUNCEMENT_SEVERITY_UNSPECIFIED AnnouncementSeverity = 0
+	AnnouncementSeverity_ANNOUNCEMENT_SEVERITY_INFO        AnnouncementSeverity = 1
+	AnnouncementSeverity_ANNOUNCEMENT_SEVERITY_WARNING     AnnouncementSeverity = 2
+	AnnouncementSeverity_ANNOUNCEMENT_SEVERITY_CRITICAL    AnnouncementSeverity = 3
+)
+
+// Enum value maps for AnnouncementSeverity.
+var (
+	AnnouncementSeverity_name = map[int32]string{
+		0: "ANNOUNCEMENT_SEVERITY_UNSPECIFIED",
+		1: "ANNOUNCEMENT_SEVERITY_INFO",
+		2: "ANNOUNCEMENT_SEVERITY_WARNING",
+		3: "ANNOUNCEMENT_SEVERITY_CRITICAL",
+	}
+	AnnouncementSeverity_value = map[string]int32{
+		"ANNOUNCEMENT_SEVERITY_UNSPECIFIED": 0,
+		"ANNOUNCEMENT_SEVERITY_INFO":        1,
+		"ANNOUNCEMENT_SEVERITY_WARNING":     2,
+		"ANNOUNCEMENT_SEVERITY_CRITICAL":    3,
+	}
+)
+
+func (x AnnouncementSeverity) Enum() *AnnouncementSeverity {
+	p := new(AnnouncementSeverity)
+	*p = x
+	return p
+}
+
+func (x AnnouncementSeverity) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (AnnouncementSeverity) Descriptor() protoreflect.EnumDescriptor {
+	return file_mq_mq_proto_enumTypes[3].Descriptor()
+}
+
+func (AnnouncementSeverity) Type() protoreflect.EnumType {
+	return &file_mq_mq_proto_enumTypes[3]
+}
+
+func (x AnnouncementSeverity) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use AnnouncementSeverity.Descriptor instead.
+func (AnnouncementSeverity) EnumDescriptor() ([]byte, []int) {
+	return file_mq_mq_proto_rawDescGZIP(), []int{3}
+}
+
 type RetrieveNewNetworkingConfigMessage struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 }
 
-func (x *RetrieveNewNetworkingConfigMessage) Reset() {
-	*x = RetrieveNewNetworkingConfigMessage{}
+func (x *RetrieveNewNetworkingConfigMessage) Reset() {
+	*x = RetrieveNewNetworkingConfigMessage{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_mq_mq_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *RetrieveNewNetworkingConfigMessage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RetrieveNewNetworkingConfigMessage) ProtoMessage() {}
+
+func (x *RetrieveNewNetworkingConfigMessage) ProtoReflect() protoreflect.Message {
+	mi := &file_mq_mq_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RetrieveNewNetworkingConfigMessage.ProtoReflect.Descriptor instead.
+func (*RetrieveNewNetworkingConfigMessage) Descriptor() ([]byte, []int) {
+	return file_mq_mq_proto_rawDescGZIP(), []int{0}
+}
+
+// RetrieveNewDeviceConfigMessage is delivered to devices through the EMDEV-RETRIEVE-NEW-DEVICE-CONFIG
+// JetStream stream (on EMDEV.{id}.RETRIEVE-NEW-DEVICE-CONFIG) when their device config has changed.
+type RetrieveNewDeviceConfigMessage struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *RetrieveNewDeviceConfigMessage) Reset() {
+	*x = RetrieveNewDeviceConfigMessage{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_mq_mq_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *RetrieveNewDeviceConfigMessage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RetrieveNewDeviceConfigMessage) ProtoMessage() {}
+
+func (x *RetrieveNewDeviceConfigMessage) ProtoReflect() protoreflect.Message {
+	mi := &file_mq_mq_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RetrieveNewDeviceConfigMessage.ProtoReflect.Descriptor instead.
+func (*RetrieveNewDeviceConfigMessage) Descriptor() ([]byte, []int) {
+	return file_mq_mq_proto_rawDescGZIP(), []int{1}
+}
+
+type RebootMessage struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *RebootMessage) Reset() {
+	*x = RebootMessage{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_mq_mq_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *RebootMessage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RebootMessage) ProtoMessage() {}
+
+func (x *RebootMessage) ProtoReflect() protoreflect.Message {
+	mi := &file_mq_mq_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RebootMessage.ProtoReflect.Descriptor instead.
+func (*RebootMessage) Descriptor() ([]byte, []int) {
+	return file_mq_mq_proto_rawDescGZIP(), []int{2}
+}
+
+// UploadLogsMessage requests the device to upload a gzip-compressed tarball of its logs,
+// by doing a PUT request to /device/{id}/log-bundle/{command_id} on the backend.
+type UploadLogsMessage struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *UploadLogsMessage) Reset() {
+	*x = UploadLogsMessage{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_mq_mq_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *UploadLogsMessage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UploadLogsMessage) ProtoMessage() {}
+
+func (x *UploadLogsMessage) ProtoReflect() protoreflect.Message {
+	mi := &file_mq_mq_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UploadLogsMessage.ProtoReflect.Descriptor instead.
+func (*UploadLogsMessage) Descriptor() ([]byte, []int) {
+	return file_mq_mq_proto_rawDescGZIP(), []int{3}
+}
+
+// TakeScreenshotMessage requests the device to take a PNG screenshot of its display and upload it
+// by doing a PUT request to /device/{id}/screenshot-upload/{command_id} on the backend.
+type TakeScreenshotMessage struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *TakeScreenshotMessage) Reset() {
+	*x = TakeScreenshotMessage{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_mq_mq_proto_msgTypes[4]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *TakeScreenshotMessage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TakeScreenshotMessage) ProtoMessage() {}
+
+func (x *TakeScreenshotMessage) ProtoReflect() protoreflect.Message {
+	mi := &file_mq_mq_proto_msgTypes[4]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TakeScreenshotMessage.ProtoReflect.Descriptor instead.
+func (*TakeScreenshotMessage) Descriptor() ([]byte, []int) {
+	return file_mq_mq_proto_rawDescGZIP(), []int{4}
+}
+
+// SetScreenPowerMessage turns the screen of a device on or off, e.g. to put it in standby outside school hours.
+type SetScreenPowerMessage struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	On bool `protobuf:"varint,1,opt,name=on,proto3" json:"on,omitempty"`
+}
+
+func (x *SetScreenPowerMessage) Reset() {
+	*x = SetScreenPowerMessage{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_mq_mq_proto_msgTypes[5]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SetScreenPowerMessage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetScreenPowerMessage) ProtoMessage() {}
+
+func (x *SetScreenPowerMessage) ProtoReflect() protoreflect.Message {
+	mi := &file_mq_mq_proto_msgTypes[5]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetScreenPowerMessage.ProtoReflect.Descriptor instead.
+func (*SetScreenPowerMessage) Descriptor() ([]byte, []int) {
+	return file_mq_mq_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *SetScreenPowerMessage) GetOn() bool {
+	if x != nil {
+		return x.On
+	}
+	return false
+}
+
+// StartCardEnrollmentMessage asks the device to bind the next card that is tapped to a student.
+// The device reports the UID of the card to the backend, mentioning the session ID.
+type StartCardEnrollmentMessage struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	SessionId string `protobuf:"bytes,1,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
+	// Unix timestamp (in seconds) after which the session can no longer be completed.
+	ExpiresAt int64 `protobuf:"varint,2,opt,name=expires_at,json=expiresAt,proto3" json:"expires_at,omitempty"`
+}
+
+func (x *StartCardEnrollmentMessage) Reset() {
+	*x = StartCardEnrollmentMessage{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_mq_mq_proto_msgTypes[6]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *StartCardEnrollmentMessage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StartCardEnrollmentMessage) ProtoMessage() {}
+
+func (x *StartCardEnrollmentMessage) ProtoReflect() protoreflect.Message {
+	mi := &file_mq_mq_proto_msgTypes[6]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StartCardEnrollmentMessage.ProtoReflect.Descriptor instead.
+func (*StartCardEnrollmentMessage) Descriptor() ([]byte, []int) {
+	return file_mq_mq_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *StartCardEnrollmentMessage) GetSessionId() string {
+	if x != nil {
+		return x.SessionId
+	}
+	return ""
+}
+
+func (x *StartCardEnrollmentMessage) GetExpiresAt() int64 {
+	if x != nil {
+		return x.ExpiresAt
+	}
+	return 0
+}
+
+type CancelCardEnrollmentMessage struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	SessionId string `protobuf:"bytes,1,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
+}
+
+func (x *CancelCardEnrollmentMessage) Reset() {
+	*x = CancelCardEnrollmentMessage{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_mq_mq_proto_msgTypes[7]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *CancelCardEnrollmentMessage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CancelCardEnrollmentMessage) ProtoMessage() {}
+
+func (x *CancelCardEnrollmentMessage) ProtoReflect() protoreflect.Message {
+	mi := &file_mq_mq_proto_msgTypes[7]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CancelCardEnrollmentMessage.ProtoReflect.Descriptor instead.
+func (*CancelCardEnrollmentMessage) Descriptor() ([]byte, []int) {
+	return file_mq_mq_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *CancelCardEnrollmentMessage) GetSessionId() string {
+	if x != nil {
+		return x.SessionId
+	}
+	return ""
+}
+
+// HeartbeatMessage is published by devices on EMDEV.{id}.HEARTBEAT to let the backend know that they are online.
+// Devices can report their status with it, in which case only the fields which are set are stored.
+type HeartbeatMessage struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Status *DeviceStatus `protobuf:"bytes,1,opt,name=status,proto3" json:"status,omitempty"`
+	// Capabilities replace the capabilities of the device when set, which devices should do after they have connected.
+	Capabilities *DeviceCapabilities `protobuf:"bytes,2,opt,name=capabilities,proto3" json:"capabilities,omitempty"`
+}
+
+func (x *HeartbeatMessage) Reset() {
+	*x = HeartbeatMessage{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_mq_mq_proto_msgTypes[8]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *HeartbeatMessage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HeartbeatMessage) ProtoMessage() {}
+
+func (x *HeartbeatMessage) ProtoReflect() protoreflect.Message {
+	mi := &file_mq_mq_proto_msgTypes[8]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HeartbeatMessage.ProtoReflect.Descriptor instead.
+func (*HeartbeatMessage) Descriptor() ([]byte, []int) {
+	return file_mq_mq_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *HeartbeatMessage) GetStatus() *DeviceStatus {
+	if x != nil {
+		return x.Status
+	}
+	return nil
+}
+
+func (x *HeartbeatMessage) GetCapabilities() *DeviceCapabilities {
+	if x != nil {
+		return x.Capabilities
+	}
+	return nil
+}
+
+// DeviceCapabilities are the optional features which a device supports.
+// Commands which require a capability are only sent to devices which have reported it.
+type DeviceCapabilities struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Capabilities []DeviceCapability `protobuf:"varint,1,rep,packed,name=capabilities,proto3,enum=timeterm_proto.mq.DeviceCapability" json:"capabilities,omitempty"`
+}
+
+func (x *DeviceCapabilities) Reset() {
+	*x = DeviceCapabilities{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_mq_mq_proto_msgTypes[9]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *DeviceCapabilities) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeviceCapabilities) ProtoMessage() {}
+
+func (x *DeviceCapabilities) ProtoReflect() protoreflect.Message {
+	mi := &file_mq_mq_proto_msgTypes[9]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeviceCapabilities.ProtoReflect.Descriptor instead.
+func (*DeviceCapabilities) Descriptor() ([]byte, []int) {
+	return file_mq_mq_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *DeviceCapabilities) GetCapabilities() []DeviceCapability {
+	if x != nil {
+		return x.Capabilities
+	}
+	return nil
+}
+
+type DeviceStatus struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	OsVersion     *string `protobuf:"bytes,1,opt,name=os_version,json=osVersion,proto3,oneof" json:"os_version,omitempty"`
+	AppVersion    *string `protobuf:"bytes,2,opt,name=app_version,json=appVersion,proto3,oneof" json:"app_version,omitempty"`
+	UptimeSeconds *int64  `protobuf:"varint,3,opt,name=uptime_seconds,json=uptimeSeconds,proto3,oneof" json:"uptime_seconds,omitempty"`
+	IpAddress     *string `protobuf:"bytes,4,opt,name=ip_address,json=ipAddress,proto3,oneof" json:"ip_address,omitempty"`
+	Ssid          *string `protobuf:"bytes,5,opt,name=ssid,proto3,oneof" json:"ssid,omitempty"`
+	// Strength of the Wi-Fi signal, as a percentage.
+	SignalStrength   *int32            `protobuf:"varint,6,opt,name=signal_strength,json=signalStrength,proto3,oneof" json:"signal_strength,omitempty"`
+	CardReaderStatus *CardReaderStatus `protobuf:"varint,7,opt,name=card_reader_status,json=cardReaderStatus,proto3,enum=timeterm_proto.mq.CardReaderStatus,oneof" json:"card_reader_status,omitempty"`
+	FreeDiskBytes    *int64            `protobuf:"varint,8,opt,name=free_disk_bytes,json=freeDiskBytes,proto3,oneof" json:"free_disk_bytes,omitempty"`
+	LastError        *string           `protobuf:"bytes,9,opt,name=last_error,json=lastError,proto3,oneof" json:"last_error,omitempty"`
+}
+
+func (x *DeviceStatus) Reset() {
+	*x = DeviceStatus{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_mq_mq_proto_msgTypes[10]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *DeviceStatus) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeviceStatus) ProtoMessage() {}
+
+func (x *DeviceStatus) ProtoReflect() protoreflect.Message {
+	mi := &file_mq_mq_proto_msgTypes[10]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeviceStatus.ProtoReflect.Descriptor instead.
+func (*DeviceStatus) Descriptor() ([]byte, []int) {
+	return file_mq_mq_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *DeviceStatus) GetOsVersion() string {
+	if x != nil && x.OsVersion != nil {
+		return *x.OsVersion
+	}
+	return ""
+}
+
+func (x *DeviceStatus) GetAppVersion() string {
+	if x != nil && x.AppVersion != nil {
+		return *x.AppVersion
+	}
+	return ""
+}
+
+func (x *DeviceStatus) GetUptimeSeconds() int64 {
+	if x != nil && x.UptimeSeconds != nil {
+		return *x.UptimeSeconds
+	}
+	return 0
+}
+
+func (x *DeviceStatus) GetIpAddress() string {
+	if x != nil && x.IpAddress != nil {
+		return *x.IpAddress
+	}
+	return ""
+}
+
+func (x *DeviceStatus) GetSsid() string {
+	if x != nil && x.Ssid != nil {
+		return *x.Ssid
+	}
+	return ""
+}
+
+func (x *DeviceStatus) GetSignalStrength() int32 {
+	if x != nil && x.SignalStrength != nil {
+		return *x.SignalStrength
+	}
+	return 0
+}
+
+func (x *DeviceStatus) GetCardReaderStatus() CardReaderStatus {
+	if x != nil && x.CardReaderStatus != nil {
+		return *x.CardReaderStatus
+	}
+	return CardReaderStatus_CARD_READER_STATUS_UNSPECIFIED
+}
+
+func (x *DeviceStatus) GetFreeDiskBytes() int64 {
+	if x != nil && x.FreeDiskBytes != nil {
+		return *x.FreeDiskBytes
+	}
+	return 0
+}
+
+func (x *DeviceStatus) GetLastError() string {
+	if x != nil && x.LastError != nil {
+		return *x.LastError
+	}
+	return ""
+}
+
+// CommandMessage is delivered to devices through the EMDEV-COMMANDS JetStream stream (on EMDEV.{id}.COMMAND).
+// The device acknowledges the JetStream message when it has received the command and reports its progress
+// by publishing CommandResultMessages on EMDEV.{id}.COMMAND-RESULT.
+type CommandMessage struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	CommandId string `protobuf:"bytes,1,opt,name=command_id,json=commandId,proto3" json:"command_id,omitempty"`
+	// Unix timestamp (in seconds) after which the device should no longer execute the command.
+	ExpiresAt int64 `protobuf:"varint,2,opt,name=expires_at,json=expiresAt,proto3" json:"expires_at,omitempty"`
+	// Types that are assignable to Command:
+	//	*CommandMessage_Reboot
+	//	*CommandMessage_RetrieveNewNetworkingConfig
+	//	*CommandMessage_SetScreenPower
+	//	*CommandMessage_UploadLogs
+	//	*CommandMessage_TakeScreenshot
+	Command isCommandMessage_Command `protobuf_oneof:"command"`
+}
+
+func (x *CommandMessage) Reset() {
+	*x = CommandMessage{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_mq_mq_proto_msgTypes[0]
+		mi := &file_mq_mq_proto_msgTypes[11]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *RetrieveNewNetworkingConfigMessage) String() string {
+func (x *CommandMessage) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*RetrieveNewNetworkingConfigMessage) ProtoMessage() {}
+func (*CommandMessage) ProtoMessage() {}
 
-func (x *RetrieveNewNetworkingConfigMessage) ProtoReflect() protoreflect.Message {
-	mi := &file_mq_mq_proto_msgTypes[0]
+func (x *CommandMessage) ProtoReflect() protoreflect.Message {
+	mi := &file_mq_mq_proto_msgTypes[11]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -58,34 +844,213 @@ func (x *RetrieveNewNetworkingConfigMessage) ProtoReflect() protoreflect.Message
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use RetrieveNewNetworkingConfigMessage.ProtoReflect.Descriptor instead.
-func (*RetrieveNewNetworkingConfigMessage) Descriptor() ([]byte, []int) {
-	return file_mq_mq_proto_rawDescGZIP(), []int{0}
+// Deprecated: Use CommandMessage.ProtoReflect.Descriptor instead.
+func (*CommandMessage) Descriptor() ([]byte, []int) {
+	return file_mq_mq_proto_rawDescGZIP(), []int{11}
 }
 
-type RebootMessage struct {
+func (x *CommandMessage) GetCommandId() string {
+	if x != nil {
+		return x.CommandId
+	}
+	return ""
+}
+
+func (x *CommandMessage) GetExpiresAt() int64 {
+	if x != nil {
+		return x.ExpiresAt
+	}
+	return 0
+}
+
+func (m *CommandMessage) GetCommand() isCommandMessage_Command {
+	if m != nil {
+		return m.Command
+	}
+	return nil
+}
+
+func (x *CommandMessage) GetReboot() *RebootMessage {
+	if x, ok := x.GetCommand().(*CommandMessage_Reboot); ok {
+		return x.Reboot
+	}
+	return nil
+}
+
+func (x *CommandMessage) GetRetrieveNewNetworkingConfig() *RetrieveNewNetworkingConfigMessage {
+	if x, ok := x.GetCommand().(*CommandMessage_RetrieveNewNetworkingConfig); ok {
+		return x.RetrieveNewNetworkingConfig
+	}
+	return nil
+}
+
+func (x *CommandMessage) GetSetScreenPower() *SetScreenPowerMessage {
+	if x, ok := x.GetCommand().(*CommandMessage_SetScreenPower); ok {
+		return x.SetScreenPower
+	}
+	return nil
+}
+
+func (x *CommandMessage) GetUploadLogs() *UploadLogsMessage {
+	if x, ok := x.GetCommand().(*CommandMessage_UploadLogs); ok {
+		return x.UploadLogs
+	}
+	return nil
+}
+
+func (x *CommandMessage) GetTakeScreenshot() *TakeScreenshotMessage {
+	if x, ok := x.GetCommand().(*CommandMessage_TakeScreenshot); ok {
+		return x.TakeScreenshot
+	}
+	return nil
+}
+
+type isCommandMessage_Command interface {
+	isCommandMessage_Command()
+}
+
+type CommandMessage_Reboot struct {
+	Reboot *RebootMessage `protobuf:"bytes,3,opt,name=reboot,proto3,oneof"`
+}
+
+type CommandMessage_RetrieveNewNetworkingConfig struct {
+	RetrieveNewNetworkingConfig *RetrieveNewNetworkingConfigMessage `protobuf:"bytes,4,opt,name=retrieve_new_networking_config,json=retrieveNewNetworkingConfig,proto3,oneof"`
+}
+
+type CommandMessage_SetScreenPower struct {
+	SetScreenPower *SetScreenPowerMessage `protobuf:"bytes,5,opt,name=set_screen_power,json=setScreenPower,proto3,oneof"`
+}
+
+type CommandMessage_UploadLogs struct {
+	UploadLogs *UploadLogsMessage `protobuf:"bytes,6,opt,name=upload_logs,json=uploadLogs,proto3,oneof"`
+}
+
+type CommandMessage_TakeScreenshot struct {
+	TakeScreenshot *TakeScreenshotMessage `protobuf:"bytes,7,opt,name=take_screenshot,json=takeScreenshot,proto3,oneof"`
+}
+
+func (*CommandMessage_Reboot) isCommandMessage_Command() {}
+
+func (*CommandMessage_RetrieveNewNetworkingConfig) isCommandMessage_Command() {}
+
+func (*CommandMessage_SetScreenPower) isCommandMessage_Command() {}
+
+func (*CommandMessage_UploadLogs) isCommandMessage_Command() {}
+
+func (*CommandMessage_TakeScreenshot) isCommandMessage_Command() {}
+
+type CommandResultMessage struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	CommandId string        `protobuf:"bytes,1,opt,name=command_id,json=commandId,proto3" json:"command_id,omitempty"`
+	Status    CommandStatus `protobuf:"varint,2,opt,name=status,proto3,enum=timeterm_proto.mq.CommandStatus" json:"status,omitempty"`
+	// Error message if the command has failed.
+	Error string `protobuf:"bytes,3,opt,name=error,proto3" json:"error,omitempty"`
+	// Output of the command, if any.
+	Output string `protobuf:"bytes,4,opt,name=output,proto3" json:"output,omitempty"`
+}
+
+func (x *CommandResultMessage) Reset() {
+	*x = CommandResultMessage{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_mq_mq_proto_msgTypes[12]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *CommandResultMessage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CommandResultMessage) ProtoMessage() {}
+
+func (x *CommandResultMessage) ProtoReflect() protoreflect.Message {
+	mi := &file_mq_mq_proto_msgTypes[12]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CommandResultMessage.ProtoReflect.Descriptor instead.
+func (*CommandResultMessage) Descriptor() ([]byte, []int) {
+	return file_mq_mq_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *CommandResultMessage) GetCommandId() string {
+	if x != nil {
+		return x.CommandId
+	}
+	return ""
+}
+
+func (x *CommandResultMessage) GetStatus() CommandStatus {
+	if x != nil {
+		return x.Status
+	}
+	return CommandStatus_COMMAND_STATUS_UNSPECIFIED
+}
+
+func (x *CommandResultMessage) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+func (x *CommandResultMessage) GetOutput() string {
+	if x != nil {
+		return x.Output
+	}
+	return ""
+}
+
+// AnnouncementMessage is delivered to devices through the EMDEV-ANNOUNCEMENTS JetStream stream
+// (on EMDEV.{id}.ANNOUNCEMENT) when an announcement for the device is created, changed or removed.
+// Devices which have been offline for a longer time retrieve the current announcements from the backend.
+type AnnouncementMessage struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
+
+	AnnouncementId string `protobuf:"bytes,1,opt,name=announcement_id,json=announcementId,proto3" json:"announcement_id,omitempty"`
+	// Set if the announcement has been deleted or is no longer meant for the device,
+	// in which case the other fields are not set.
+	Removed  bool                 `protobuf:"varint,2,opt,name=removed,proto3" json:"removed,omitempty"`
+	Text     string               `protobuf:"bytes,3,opt,name=text,proto3" json:"text,omitempty"`
+	Severity AnnouncementSeverity `protobuf:"varint,4,opt,name=severity,proto3,enum=timeterm_proto.mq.AnnouncementSeverity" json:"severity,omitempty"`
+	// Unix timestamp (in seconds) from which the announcement should be shown.
+	StartsAt int64 `protobuf:"varint,5,opt,name=starts_at,json=startsAt,proto3" json:"starts_at,omitempty"`
+	// Unix timestamp (in seconds) until which the announcement should be shown, 0 if it has no end.
+	EndsAt int64 `protobuf:"varint,6,opt,name=ends_at,json=endsAt,proto3" json:"ends_at,omitempty"`
+	// Set if the announcement has an image, which can be retrieved from the backend.
+	HasImage bool `protobuf:"varint,7,opt,name=has_image,json=hasImage,proto3" json:"has_image,omitempty"`
 }
 
-func (x *RebootMessage) Reset() {
-	*x = RebootMessage{}
+func (x *AnnouncementMessage) Reset() {
+	*x = AnnouncementMessage{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_mq_mq_proto_msgTypes[1]
+		mi := &file_mq_mq_proto_msgTypes[13]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *RebootMessage) String() string {
+func (x *AnnouncementMessage) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*RebootMessage) ProtoMessage() {}
+func (*AnnouncementMessage) ProtoMessage() {}
 
-func (x *RebootMessage) ProtoReflect() protoreflect.Message {
-	mi := &file_mq_mq_proto_msgTypes[1]
+func (x *AnnouncementMessage) ProtoReflect() protoreflect.Message {
+	mi := &file_mq_mq_proto_msgTypes[13]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -96,9 +1061,58 @@ func (x *RebootMessage) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use RebootMessage.ProtoReflect.Descriptor instead.
-func (*RebootMessage) Descriptor() ([]byte, []int) {
-	return file_mq_mq_proto_rawDescGZIP(), []int{1}
+// Deprecated: Use AnnouncementMessage.ProtoReflect.Descriptor instead.
+func (*AnnouncementMessage) Descriptor() ([]byte, []int) {
+	return file_mq_mq_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *AnnouncementMessage) GetAnnouncementId() string {
+	if x != nil {
+		return x.AnnouncementId
+	}
+	return ""
+}
+
+func (x *AnnouncementMessage) GetRemoved() bool {
+	if x != nil {
+		return x.Removed
+	}
+	return false
+}
+
+func (x *AnnouncementMessage) GetText() string {
+	if x != nil {
+		return x.Text
+	}
+	return ""
+}
+
+func (x *AnnouncementMessage) GetSeverity() AnnouncementSeverity {
+	if x != nil {
+		return x.Severity
+	}
+	return AnnouncementSeverity_ANNOUNCEMENT_SEVERITY_UNSPECIFIED
+}
+
+func (x *AnnouncementMessage) GetStartsAt() int64 {
+	if x != nil {
+		return x.StartsAt
+	}
+	return 0
+}
+
+func (x *AnnouncementMessage) GetEndsAt() int64 {
+	if x != nil {
+		return x.EndsAt
+	}
+	return 0
+}
+
+func (x *AnnouncementMessage) GetHasImage() bool {
+	if x != nil {
+		return x.HasImage
+	}
+	return false
 }
 
 var File_mq_mq_proto protoreflect.FileDescriptor
@@ -108,11 +1122,172 @@ var file_mq_mq_proto_rawDesc = []byte{
 	0x69, 0x6d, 0x65, 0x74, 0x65, 0x72, 0x6d, 0x5f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x6d, 0x71,
 	0x22, 0x24, 0x0a, 0x22, 0x52, 0x65, 0x74, 0x72, 0x69, 0x65, 0x76, 0x65, 0x4e, 0x65, 0x77, 0x4e,
 	0x65, 0x74, 0x77, 0x6f, 0x72, 0x6b, 0x69, 0x6e, 0x67, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x4d,
-	0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x22, 0x0f, 0x0a, 0x0d, 0x52, 0x65, 0x62, 0x6f, 0x6f, 0x74,
-	0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x42, 0x2f, 0x5a, 0x2d, 0x67, 0x69, 0x74, 0x6c, 0x61,
-	0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x74, 0x69, 0x6d, 0x65, 0x74, 0x65, 0x72, 0x6d, 0x2f, 0x74,
-	0x69, 0x6d, 0x65, 0x74, 0x65, 0x72, 0x6d, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2f, 0x67, 0x6f,
-	0x2f, 0x6d, 0x71, 0x3b, 0x6d, 0x71, 0x70, 0x62, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+	0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x22, 0x20, 0x0a, 0x1e, 0x52, 0x65, 0x74, 0x72, 0x69, 0x65,
+	0x76, 0x65, 0x4e, 0x65, 0x77, 0x44, 0x65, 0x76, 0x69, 0x63, 0x65, 0x43, 0x6f, 0x6e, 0x66, 0x69,
+	0x67, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x22, 0x0f, 0x0a, 0x0d, 0x52, 0x65, 0x62, 0x6f,
+	0x6f, 0x74, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x22, 0x13, 0x0a, 0x11, 0x55, 0x70, 0x6c,
+	0x6f, 0x61, 0x64, 0x4c, 0x6f, 0x67, 0x73, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x22, 0x17,
+	0x0a, 0x15, 0x54, 0x61, 0x6b, 0x65, 0x53, 0x63, 0x72, 0x65, 0x65, 0x6e, 0x73, 0x68, 0x6f, 0x74,
+	0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x22, 0x27, 0x0a, 0x15, 0x53, 0x65, 0x74, 0x53, 0x63,
+	0x72, 0x65, 0x65, 0x6e, 0x50, 0x6f, 0x77, 0x65, 0x72, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65,
+	0x12, 0x0e, 0x0a, 0x02, 0x6f, 0x6e, 0x18, 0x01, 0x20, 0x01, 0x28, 0x08, 0x52, 0x02, 0x6f, 0x6e,
+	0x22, 0x5a, 0x0a, 0x1a, 0x53, 0x74, 0x61, 0x72, 0x74, 0x43, 0x61, 0x72, 0x64, 0x45, 0x6e, 0x72,
+	0x6f, 0x6c, 0x6c, 0x6d, 0x65, 0x6e, 0x74, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x12, 0x1d,
+	0x0a, 0x0a, 0x73, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x09, 0x73, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x49, 0x64, 0x12, 0x1d, 0x0a,
+	0x0a, 0x65, 0x78, 0x70, 0x69, 0x72, 0x65, 0x73, 0x5f, 0x61, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x03, 0x52, 0x09, 0x65, 0x78, 0x70, 0x69, 0x72, 0x65, 0x73, 0x41, 0x74, 0x22, 0x3c, 0x0a, 0x1b,
+	0x43, 0x61, 0x6e, 0x63, 0x65, 0x6c, 0x43, 0x61, 0x72, 0x64, 0x45, 0x6e, 0x72, 0x6f, 0x6c, 0x6c,
+	0x6d, 0x65, 0x6e, 0x74, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x12, 0x1d, 0x0a, 0x0a, 0x73,
+	0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x09, 0x73, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x49, 0x64, 0x22, 0x96, 0x01, 0x0a, 0x10, 0x48,
+	0x65, 0x61, 0x72, 0x74, 0x62, 0x65, 0x61, 0x74, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x12,
+	0x37, 0x0a, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32,
+	0x1f, 0x2e, 0x74, 0x69, 0x6d, 0x65, 0x74, 0x65, 0x72, 0x6d, 0x5f, 0x70, 0x72, 0x6f, 0x74, 0x6f,
+	0x2e, 0x6d, 0x71, 0x2e, 0x44, 0x65, 0x76, 0x69, 0x63, 0x65, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73,
+	0x52, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x49, 0x0a, 0x0c, 0x63, 0x61, 0x70, 0x61,
+	0x62, 0x69, 0x6c, 0x69, 0x74, 0x69, 0x65, 0x73, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x25,
+	0x2e, 0x74, 0x69, 0x6d, 0x65, 0x74, 0x65, 0x72, 0x6d, 0x5f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e,
+	0x6d, 0x71, 0x2e, 0x44, 0x65, 0x76, 0x69, 0x63, 0x65, 0x43, 0x61, 0x70, 0x61, 0x62, 0x69, 0x6c,
+	0x69, 0x74, 0x69, 0x65, 0x73, 0x52, 0x0c, 0x63, 0x61, 0x70, 0x61, 0x62, 0x69, 0x6c, 0x69, 0x74,
+	0x69, 0x65, 0x73, 0x22, 0x5d, 0x0a, 0x12, 0x44, 0x65, 0x76, 0x69, 0x63, 0x65, 0x43, 0x61, 0x70,
+	0x61, 0x62, 0x69, 0x6c, 0x69, 0x74, 0x69, 0x65, 0x73, 0x12, 0x47, 0x0a, 0x0c, 0x63, 0x61, 0x70,
+	0x61, 0x62, 0x69, 0x6c, 0x69, 0x74, 0x69, 0x65, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0e, 0x32,
+	0x23, 0x2e, 0x74, 0x69, 0x6d, 0x65, 0x74, 0x65, 0x72, 0x6d, 0x5f, 0x70, 0x72, 0x6f, 0x74, 0x6f,
+	0x2e, 0x6d, 0x71, 0x2e, 0x44, 0x65, 0x76, 0x69, 0x63, 0x65, 0x43, 0x61, 0x70, 0x61, 0x62, 0x69,
+	0x6c, 0x69, 0x74, 0x79, 0x52, 0x0c, 0x63, 0x61, 0x70, 0x61, 0x62, 0x69, 0x6c, 0x69, 0x74, 0x69,
+	0x65, 0x73, 0x22, 0xb0, 0x04, 0x0a, 0x0c, 0x44, 0x65, 0x76, 0x69, 0x63, 0x65, 0x53, 0x74, 0x61,
+	0x74, 0x75, 0x73, 0x12, 0x22, 0x0a, 0x0a, 0x6f, 0x73, 0x5f, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f,
+	0x6e, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x48, 0x00, 0x52, 0x09, 0x6f, 0x73, 0x56, 0x65, 0x72,
+	0x73, 0x69, 0x6f, 0x6e, 0x88, 0x01, 0x01, 0x12, 0x24, 0x0a, 0x0b, 0x61, 0x70, 0x70, 0x5f, 0x76,
+	0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x48, 0x01, 0x52, 0x0a,
+	0x61, 0x70, 0x70, 0x56, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x88, 0x01, 0x01, 0x12, 0x2a, 0x0a,
+	0x0e, 0x75, 0x70, 0x74, 0x69, 0x6d, 0x65, 0x5f, 0x73, 0x65, 0x63, 0x6f, 0x6e, 0x64, 0x73, 0x18,
+	0x03, 0x20, 0x01, 0x28, 0x03, 0x48, 0x02, 0x52, 0x0d, 0x75, 0x70, 0x74, 0x69, 0x6d, 0x65, 0x53,
+	0x65, 0x63, 0x6f, 0x6e, 0x64, 0x73, 0x88, 0x01, 0x01, 0x12, 0x22, 0x0a, 0x0a, 0x69, 0x70, 0x5f,
+	0x61, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x48, 0x03, 0x52,
+	0x09, 0x69, 0x70, 0x41, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x88, 0x01, 0x01, 0x12, 0x17, 0x0a,
+	0x04, 0x73, 0x73, 0x69, 0x64, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x48, 0x04, 0x52, 0x04, 0x73,
+	0x73, 0x69, 0x64, 0x88, 0x01, 0x01, 0x12, 0x2c, 0x0a, 0x0f, 0x73, 0x69, 0x67, 0x6e, 0x61, 0x6c,
+	0x5f, 0x73, 0x74, 0x72, 0x65, 0x6e, 0x67, 0x74, 0x68, 0x18, 0x06, 0x20, 0x01, 0x28, 0x05, 0x48,
+	0x05, 0x52, 0x0e, 0x73, 0x69, 0x67, 0x6e, 0x61, 0x6c, 0x53, 0x74, 0x72, 0x65, 0x6e, 0x67, 0x74,
+	0x68, 0x88, 0x01, 0x01, 0x12, 0x56, 0x0a, 0x12, 0x63, 0x61, 0x72, 0x64, 0x5f, 0x72, 0x65, 0x61,
+	0x64, 0x65, 0x72, 0x5f, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x18, 0x07, 0x20, 0x01, 0x28, 0x0e,
+	0x32, 0x23, 0x2e, 0x74, 0x69, 0x6d, 0x65, 0x74, 0x65, 0x72, 0x6d, 0x5f, 0x70, 0x72, 0x6f, 0x74,
+	0x6f, 0x2e, 0x6d, 0x71, 0x2e, 0x43, 0x61, 0x72, 0x64, 0x52, 0x65, 0x61, 0x64, 0x65, 0x72, 0x53,
+	0x74, 0x61, 0x74, 0x75, 0x73, 0x48, 0x06, 0x52, 0x10, 0x63, 0x61, 0x72, 0x64, 0x52, 0x65, 0x61,
+	0x64, 0x65, 0x72, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x88, 0x01, 0x01, 0x12, 0x2b, 0x0a, 0x0f,
+	0x66, 0x72, 0x65, 0x65, 0x5f, 0x64, 0x69, 0x73, 0x6b, 0x5f, 0x62, 0x79, 0x74, 0x65, 0x73, 0x18,
+	0x08, 0x20, 0x01, 0x28, 0x03, 0x48, 0x07, 0x52, 0x0d, 0x66, 0x72, 0x65, 0x65, 0x44, 0x69, 0x73,
+	0x6b, 0x42, 0x79, 0x74, 0x65, 0x73, 0x88, 0x01, 0x01, 0x12, 0x22, 0x0a, 0x0a, 0x6c, 0x61, 0x73,
+	0x74, 0x5f, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x18, 0x09, 0x20, 0x01, 0x28, 0x09, 0x48, 0x08, 0x52,
+	0x09, 0x6c, 0x61, 0x73, 0x74, 0x45, 0x72, 0x72, 0x6f, 0x72, 0x88, 0x01, 0x01, 0x42, 0x0d, 0x0a,
+	0x0b, 0x5f, 0x6f, 0x73, 0x5f, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x42, 0x0e, 0x0a, 0x0c,
+	0x5f, 0x61, 0x70, 0x70, 0x5f, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x42, 0x11, 0x0a, 0x0f,
+	0x5f, 0x75, 0x70, 0x74, 0x69, 0x6d, 0x65, 0x5f, 0x73, 0x65, 0x63, 0x6f, 0x6e, 0x64, 0x73, 0x42,
+	0x0d, 0x0a, 0x0b, 0x5f, 0x69, 0x70, 0x5f, 0x61, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x42, 0x07,
+	0x0a, 0x05, 0x5f, 0x73, 0x73, 0x69, 0x64, 0x42, 0x12, 0x0a, 0x10, 0x5f, 0x73, 0x69, 0x67, 0x6e,
+	0x61, 0x6c, 0x5f, 0x73, 0x74, 0x72, 0x65, 0x6e, 0x67, 0x74, 0x68, 0x42, 0x15, 0x0a, 0x13, 0x5f,
+	0x63, 0x61, 0x72, 0x64, 0x5f, 0x72, 0x65, 0x61, 0x64, 0x65, 0x72, 0x5f, 0x73, 0x74, 0x61, 0x74,
+	0x75, 0x73, 0x42, 0x12, 0x0a, 0x10, 0x5f, 0x66, 0x72, 0x65, 0x65, 0x5f, 0x64, 0x69, 0x73, 0x6b,
+	0x5f, 0x62, 0x79, 0x74, 0x65, 0x73, 0x42, 0x0d, 0x0a, 0x0b, 0x5f, 0x6c, 0x61, 0x73, 0x74, 0x5f,
+	0x65, 0x72, 0x72, 0x6f, 0x72, 0x22, 0x87, 0x04, 0x0a, 0x0e, 0x43, 0x6f, 0x6d, 0x6d, 0x61, 0x6e,
+	0x64, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x12, 0x1d, 0x0a, 0x0a, 0x63, 0x6f, 0x6d, 0x6d,
+	0x61, 0x6e, 0x64, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x63, 0x6f,
+	0x6d, 0x6d, 0x61, 0x6e, 0x64, 0x49, 0x64, 0x12, 0x1d, 0x0a, 0x0a, 0x65, 0x78, 0x70, 0x69, 0x72,
+	0x65, 0x73, 0x5f, 0x61, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x09, 0x65, 0x78, 0x70,
+	0x69, 0x72, 0x65, 0x73, 0x41, 0x74, 0x12, 0x3a, 0x0a, 0x06, 0x72, 0x65, 0x62, 0x6f, 0x6f, 0x74,
+	0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x20, 0x2e, 0x74, 0x69, 0x6d, 0x65, 0x74, 0x65, 0x72,
+	0x6d, 0x5f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x6d, 0x71, 0x2e, 0x52, 0x65, 0x62, 0x6f, 0x6f,
+	0x74, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x48, 0x00, 0x52, 0x06, 0x72, 0x65, 0x62, 0x6f,
+	0x6f, 0x74, 0x12, 0x7c, 0x0a, 0x1e, 0x72, 0x65, 0x74, 0x72, 0x69, 0x65, 0x76, 0x65, 0x5f, 0x6e,
+	0x65, 0x77, 0x5f, 0x6e, 0x65, 0x74, 0x77, 0x6f, 0x72, 0x6b, 0x69, 0x6e, 0x67, 0x5f, 0x63, 0x6f,
+	0x6e, 0x66, 0x69, 0x67, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x35, 0x2e, 0x74, 0x69, 0x6d,
+	0x65, 0x74, 0x65, 0x72, 0x6d, 0x5f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x6d, 0x71, 0x2e, 0x52,
+	0x65, 0x74, 0x72, 0x69, 0x65, 0x76, 0x65, 0x4e, 0x65, 0x77, 0x4e, 0x65, 0x74, 0x77, 0x6f, 0x72,
+	0x6b, 0x69, 0x6e, 0x67, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67,
+	0x65, 0x48, 0x00, 0x52, 0x1b, 0x72, 0x65, 0x74, 0x72, 0x69, 0x65, 0x76, 0x65, 0x4e, 0x65, 0x77,
+	0x4e, 0x65, 0x74, 0x77, 0x6f, 0x72, 0x6b, 0x69, 0x6e, 0x67, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67,
+	0x12, 0x54, 0x0a, 0x10, 0x73, 0x65, 0x74, 0x5f, 0x73, 0x63, 0x72, 0x65, 0x65, 0x6e, 0x5f, 0x70,
+	0x6f, 0x77, 0x65, 0x72, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x28, 0x2e, 0x74, 0x69, 0x6d,
+	0x65, 0x74, 0x65, 0x72, 0x6d, 0x5f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x6d, 0x71, 0x2e, 0x53,
+	0x65, 0x74, 0x53, 0x63, 0x72, 0x65, 0x65, 0x6e, 0x50, 0x6f, 0x77, 0x65, 0x72, 0x4d, 0x65, 0x73,
+	0x73, 0x61, 0x67, 0x65, 0x48, 0x00, 0x52, 0x0e, 0x73, 0x65, 0x74, 0x53, 0x63, 0x72, 0x65, 0x65,
+	0x6e, 0x50, 0x6f, 0x77, 0x65, 0x72, 0x12, 0x47, 0x0a, 0x0b, 0x75, 0x70, 0x6c, 0x6f, 0x61, 0x64,
+	0x5f, 0x6c, 0x6f, 0x67, 0x73, 0x18, 0x06, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x24, 0x2e, 0x74, 0x69,
+	0x6d, 0x65, 0x74, 0x65, 0x72, 0x6d, 0x5f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x6d, 0x71, 0x2e,
+	0x55, 0x70, 0x6c, 0x6f, 0x61, 0x64, 0x4c, 0x6f, 0x67, 0x73, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67,
+	0x65, 0x48, 0x00, 0x52, 0x0a, 0x75, 0x70, 0x6c, 0x6f, 0x61, 0x64, 0x4c, 0x6f, 0x67, 0x73, 0x12,
+	0x53, 0x0a, 0x0f, 0x74, 0x61, 0x6b, 0x65, 0x5f, 0x73, 0x63, 0x72, 0x65, 0x65, 0x6e, 0x73, 0x68,
+	0x6f, 0x74, 0x18, 0x07, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x28, 0x2e, 0x74, 0x69, 0x6d, 0x65, 0x74,
+	0x65, 0x72, 0x6d, 0x5f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x6d, 0x71, 0x2e, 0x54, 0x61, 0x6b,
+	0x65, 0x53, 0x63, 0x72, 0x65, 0x65, 0x6e, 0x73, 0x68, 0x6f, 0x74, 0x4d, 0x65, 0x73, 0x73, 0x61,
+	0x67, 0x65, 0x48, 0x00, 0x52, 0x0e, 0x74, 0x61, 0x6b, 0x65, 0x53, 0x63, 0x72, 0x65, 0x65, 0x6e,
+	0x73, 0x68, 0x6f, 0x74, 0x42, 0x09, 0x0a, 0x07, 0x63, 0x6f, 0x6d, 0x6d, 0x61, 0x6e, 0x64, 0x22,
+	0x9d, 0x01, 0x0a, 0x14, 0x43, 0x6f, 0x6d, 0x6d, 0x61, 0x6e, 0x64, 0x52, 0x65, 0x73, 0x75, 0x6c,
+	0x74, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x12, 0x1d, 0x0a, 0x0a, 0x63, 0x6f, 0x6d, 0x6d,
+	0x61, 0x6e, 0x64, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x63, 0x6f,
+	0x6d, 0x6d, 0x61, 0x6e, 0x64, 0x49, 0x64, 0x12, 0x38, 0x0a, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75,
+	0x73, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x20, 0x2e, 0x74, 0x69, 0x6d, 0x65, 0x74, 0x65,
+	0x72, 0x6d, 0x5f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x6d, 0x71, 0x2e, 0x43, 0x6f, 0x6d, 0x6d,
+	0x61, 0x6e, 0x64, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x52, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75,
+	0x73, 0x12, 0x14, 0x0a, 0x05, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x05, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x12, 0x16, 0x0a, 0x06, 0x6f, 0x75, 0x74, 0x70, 0x75,
+	0x74, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x6f, 0x75, 0x74, 0x70, 0x75, 0x74, 0x22,
+	0x84, 0x02, 0x0a, 0x13, 0x41, 0x6e, 0x6e, 0x6f, 0x75, 0x6e, 0x63, 0x65, 0x6d, 0x65, 0x6e, 0x74,
+	0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x12, 0x27, 0x0a, 0x0f, 0x61, 0x6e, 0x6e, 0x6f, 0x75,
+	0x6e, 0x63, 0x65, 0x6d, 0x65, 0x6e, 0x74, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x0e, 0x61, 0x6e, 0x6e, 0x6f, 0x75, 0x6e, 0x63, 0x65, 0x6d, 0x65, 0x6e, 0x74, 0x49, 0x64,
+	0x12, 0x18, 0x0a, 0x07, 0x72, 0x65, 0x6d, 0x6f, 0x76, 0x65, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x08, 0x52, 0x07, 0x72, 0x65, 0x6d, 0x6f, 0x76, 0x65, 0x64, 0x12, 0x12, 0x0a, 0x04, 0x74, 0x65,
+	0x78, 0x74, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x74, 0x65, 0x78, 0x74, 0x12, 0x43,
+	0x0a, 0x08, 0x73, 0x65, 0x76, 0x65, 0x72, 0x69, 0x74, 0x79, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0e,
+	0x32, 0x27, 0x2e, 0x74, 0x69, 0x6d, 0x65, 0x74, 0x65, 0x72, 0x6d, 0x5f, 0x70, 0x72, 0x6f, 0x74,
+	0x6f, 0x2e, 0x6d, 0x71, 0x2e, 0x41, 0x6e, 0x6e, 0x6f, 0x75, 0x6e, 0x63, 0x65, 0x6d, 0x65, 0x6e,
+	0x74, 0x53, 0x65, 0x76, 0x65, 0x72, 0x69, 0x74, 0x79, 0x52, 0x08, 0x73, 0x65, 0x76, 0x65, 0x72,
+	0x69, 0x74, 0x79, 0x12, 0x1b, 0x0a, 0x09, 0x73, 0x74, 0x61, 0x72, 0x74, 0x73, 0x5f, 0x61, 0x74,
+	0x18, 0x05, 0x20, 0x01, 0x28, 0x03, 0x52, 0x08, 0x73, 0x74, 0x61, 0x72, 0x74, 0x73, 0x41, 0x74,
+	0x12, 0x17, 0x0a, 0x07, 0x65, 0x6e, 0x64, 0x73, 0x5f, 0x61, 0x74, 0x18, 0x06, 0x20, 0x01, 0x28,
+	0x03, 0x52, 0x06, 0x65, 0x6e, 0x64, 0x73, 0x41, 0x74, 0x12, 0x1b, 0x0a, 0x09, 0x68, 0x61, 0x73,
+	0x5f, 0x69, 0x6d, 0x61, 0x67, 0x65, 0x18, 0x07, 0x20, 0x01, 0x28, 0x08, 0x52, 0x08, 0x68, 0x61,
+	0x73, 0x49, 0x6d, 0x61, 0x67, 0x65, 0x2a, 0x59, 0x0a, 0x10, 0x44, 0x65, 0x76, 0x69, 0x63, 0x65,
+	0x43, 0x61, 0x70, 0x61, 0x62, 0x69, 0x6c, 0x69, 0x74, 0x79, 0x12, 0x21, 0x0a, 0x1d, 0x44, 0x45,
+	0x56, 0x49, 0x43, 0x45, 0x5f, 0x43, 0x41, 0x50, 0x41, 0x42, 0x49, 0x4c, 0x49, 0x54, 0x59, 0x5f,
+	0x55, 0x4e, 0x53, 0x50, 0x45, 0x43, 0x49, 0x46, 0x49, 0x45, 0x44, 0x10, 0x00, 0x12, 0x22, 0x0a,
+	0x1e, 0x44, 0x45, 0x56, 0x49, 0x43, 0x45, 0x5f, 0x43, 0x41, 0x50, 0x41, 0x42, 0x49, 0x4c, 0x49,
+	0x54, 0x59, 0x5f, 0x53, 0x43, 0x52, 0x45, 0x45, 0x4e, 0x5f, 0x50, 0x4f, 0x57, 0x45, 0x52, 0x10,
+	0x01, 0x2a, 0x95, 0x01, 0x0a, 0x10, 0x43, 0x61, 0x72, 0x64, 0x52, 0x65, 0x61, 0x64, 0x65, 0x72,
+	0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x22, 0x0a, 0x1e, 0x43, 0x41, 0x52, 0x44, 0x5f, 0x52,
+	0x45, 0x41, 0x44, 0x45, 0x52, 0x5f, 0x53, 0x54, 0x41, 0x54, 0x55, 0x53, 0x5f, 0x55, 0x4e, 0x53,
+	0x50, 0x45, 0x43, 0x49, 0x46, 0x49, 0x45, 0x44, 0x10, 0x00, 0x12, 0x19, 0x0a, 0x15, 0x43, 0x41,
+	0x52, 0x44, 0x5f, 0x52, 0x45, 0x41, 0x44, 0x45, 0x52, 0x5f, 0x53, 0x54, 0x41, 0x54, 0x55, 0x53,
+	0x5f, 0x4f, 0x4b, 0x10, 0x01, 0x12, 0x24, 0x0a, 0x20, 0x43, 0x41, 0x52, 0x44, 0x5f, 0x52, 0x45,
+	0x41, 0x44, 0x45, 0x52, 0x5f, 0x53, 0x54, 0x41, 0x54, 0x55, 0x53, 0x5f, 0x4e, 0x4f, 0x54, 0x5f,
+	0x43, 0x4f, 0x4e, 0x4e, 0x45, 0x43, 0x54, 0x45, 0x44, 0x10, 0x02, 0x12, 0x1c, 0x0a, 0x18, 0x43,
+	0x41, 0x52, 0x44, 0x5f, 0x52, 0x45, 0x41, 0x44, 0x45, 0x52, 0x5f, 0x53, 0x54, 0x41, 0x54, 0x55,
+	0x53, 0x5f, 0x45, 0x52, 0x52, 0x4f, 0x52, 0x10, 0x03, 0x2a, 0x82, 0x01, 0x0a, 0x0d, 0x43, 0x6f,
+	0x6d, 0x6d, 0x61, 0x6e, 0x64, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x1e, 0x0a, 0x1a, 0x43,
+	0x4f, 0x4d, 0x4d, 0x41, 0x4e, 0x44, 0x5f, 0x53, 0x54, 0x41, 0x54, 0x55, 0x53, 0x5f, 0x55, 0x4e,
+	0x53, 0x50, 0x45, 0x43, 0x49, 0x46, 0x49, 0x45, 0x44, 0x10, 0x00, 0x12, 0x18, 0x0a, 0x14, 0x43,
+	0x4f, 0x4d, 0x4d, 0x41, 0x4e, 0x44, 0x5f, 0x53, 0x54, 0x41, 0x54, 0x55, 0x53, 0x5f, 0x41, 0x43,
+	0x4b, 0x45, 0x44, 0x10, 0x01, 0x12, 0x1c, 0x0a, 0x18, 0x43, 0x4f, 0x4d, 0x4d, 0x41, 0x4e, 0x44,
+	0x5f, 0x53, 0x54, 0x41, 0x54, 0x55, 0x53, 0x5f, 0x53, 0x55, 0x43, 0x43, 0x45, 0x45, 0x44, 0x45,
+	0x44, 0x10, 0x02, 0x12, 0x19, 0x0a, 0x15, 0x43, 0x4f, 0x4d, 0x4d, 0x41, 0x4e, 0x44, 0x5f, 0x53,
+	0x54, 0x41, 0x54, 0x55, 0x53, 0x5f, 0x46, 0x41, 0x49, 0x4c, 0x45, 0x44, 0x10, 0x03, 0x2a, 0xa4,
+	0x01, 0x0a, 0x14, 0x41, 0x6e, 0x6e, 0x6f, 0x75, 0x6e, 0x63, 0x65, 0x6d, 0x65, 0x6e, 0x74, 0x53,
+	0x65, 0x76, 0x65, 0x72, 0x69, 0x74, 0x79, 0x12, 0x25, 0x0a, 0x21, 0x41, 0x4e, 0x4e, 0x4f, 0x55,
+	0x4e, 0x43, 0x45, 0x4d, 0x45, 0x4e, 0x54, 0x5f, 0x53, 0x45, 0x56, 0x45, 0x52, 0x49, 0x54, 0x59,
+	0x5f, 0x55, 0x4e, 0x53, 0x50, 0x45, 0x43, 0x49, 0x46, 0x49, 0x45, 0x44, 0x10, 0x00, 0x12, 0x1e,
+	0x0a, 0x1a, 0x41, 0x4e, 0x4e, 0x4f, 0x55, 0x4e, 0x43, 0x45, 0x4d, 0x45, 0x4e, 0x54, 0x5f, 0x53,
+	0x45, 0x56, 0x45, 0x52, 0x49, 0x54, 0x59, 0x5f, 0x49, 0x4e, 0x46, 0x4f, 0x10, 0x01, 0x12, 0x21,
+	0x0a, 0x1d, 0x41, 0x4e, 0x4e, 0x4f, 0x55, 0x4e, 0x43, 0x45, 0x4d, 0x45, 0x4e, 0x54, 0x5f, 0x53,
+	0x45, 0x56, 0x45, 0x52, 0x49, 0x54, 0x59, 0x5f, 0x57, 0x41, 0x52, 0x4e, 0x49, 0x4e, 0x47, 0x10,
+	0x02, 0x12, 0x22, 0x0a, 0x1e, 0x41, 0x4e, 0x4e, 0x4f, 0x55, 0x4e, 0x43, 0x45, 0x4d, 0x45, 0x4e,
+	0x54, 0x5f, 0x53, 0x45, 0x56, 0x45, 0x52, 0x49, 0x54, 0x59, 0x5f, 0x43, 0x52, 0x49, 0x54, 0x49,
+	0x43, 0x41, 0x4c, 0x10, 0x03, 0x42, 0x2f, 0x5a, 0x2d, 0x67, 0x69, 0x74, 0x6c, 0x61, 0x62, 0x2e,
+	0x63, 0x6f, 0x6d, 0x2f, 0x74, 0x69, 0x6d, 0x65, 0x74, 0x65, 0x72, 0x6d, 0x2f, 0x74, 0x69, 0x6d,
+	0x65, 0x74, 0x65, 0x72, 0x6d, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2f, 0x67, 0x6f, 0x2f, 0x6d,
+	0x71, 0x3b, 0x6d, 0x71, 0x70, 0x62, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
 }
 
 var (
@@ -127,17 +1302,45 @@ func file_mq_mq_proto_rawDescGZIP() []byte {
 	return file_mq_mq_proto_rawDescData
 }
 
-var file_mq_mq_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
+var file_mq_mq_proto_enumTypes = make([]protoimpl.EnumInfo, 4)
+var file_mq_mq_proto_msgTypes = make([]protoimpl.MessageInfo, 14)
 var file_mq_mq_proto_goTypes = []interface{}{
-	(*RetrieveNewNetworkingConfigMessage)(nil), // 0: timeterm_proto.mq.RetrieveNewNetworkingConfigMessage
-	(*RebootMessage)(nil),                      // 1: timeterm_proto.mq.RebootMessage
+	(DeviceCapability)(0),                      // 0: timeterm_proto.mq.DeviceCapability
+	(CardReaderStatus)(0),                      // 1: timeterm_proto.mq.CardReaderStatus
+	(CommandStatus)(0),                         // 2: timeterm_proto.mq.CommandStatus
+	(AnnouncementSeverity)(0),                  // 3: timeterm_proto.mq.AnnouncementSeverity
+	(*RetrieveNewNetworkingConfigMessage)(nil), // 4: timeterm_proto.mq.RetrieveNewNetworkingConfigMessage
+	(*RetrieveNewDeviceConfigMessage)(nil),     // 5: timeterm_proto.mq.RetrieveNewDeviceConfigMessage
+	(*RebootMessage)(nil),                      // 6: timeterm_proto.mq.RebootMessage
+	(*UploadLogsMessage)(nil),                  // 7: timeterm_proto.mq.UploadLogsMessage
+	(*TakeScreenshotMessage)(nil),              // 8: timeterm_proto.mq.TakeScreenshotMessage
+	(*SetScreenPowerMessage)(nil),              // 9: timeterm_proto.mq.SetScreenPowerMessage
+	(*StartCardEnrollmentMessage)(nil),         // 10: timeterm_proto.mq.StartCardEnrollmentMessage
+	(*CancelCardEnrollmentMessage)(nil),        // 11: timeterm_proto.mq.CancelCardEnrollmentMessage
+	(*HeartbeatMessage)(nil),                   // 12: timeterm_proto.mq.HeartbeatMessage
+	(*DeviceCapabilities)(nil),                 // 13: timeterm_proto.mq.DeviceCapabilities
+	(*DeviceStatus)(nil),                       // 14: timeterm_proto.mq.DeviceStatus
+	(*CommandMessage)(nil),                     // 15: timeterm_proto.mq.CommandMessage
+	(*CommandResultMessage)(nil),               // 16: timeterm_proto.mq.CommandResultMessage
+	(*AnnouncementMessage)(nil),                // 17: timeterm_proto.mq.AnnouncementMessage
 }
 var file_mq_mq_proto_depIdxs = []int32{
-	0, // [0:0] is the sub-list for method output_type
-	0, // [0:0] is the sub-list for method input_type
-	0, // [0:0] is the sub-list for extension type_name
-	0, // [0:0] is the sub-list for extension extendee
-	0, // [0:0] is the sub-list for field type_name
+	14, // 0: timeterm_proto.mq.HeartbeatMessage.status:type_name -> timeterm_proto.mq.DeviceStatus
+	13, // 1: timeterm_proto.mq.HeartbeatMessage.capabilities:type_name -> timeterm_proto.mq.DeviceCapabilities
+	0,  // 2: timeterm_proto.mq.DeviceCapabilities.capabilities:type_name -> timeterm_proto.mq.DeviceCapability
+	1,  // 3: timeterm_proto.mq.DeviceStatus.card_reader_status:type_name -> timeterm_proto.mq.CardReaderStatus
+	6,  // 4: timeterm_proto.mq.CommandMessage.reboot:type_name -> timeterm_proto.mq.RebootMessage
+	4,  // 5: timeterm_proto.mq.CommandMessage.retrieve_new_networking_config:type_name -> timeterm_proto.mq.RetrieveNewNetworkingConfigMessage
+	9,  // 6: timeterm_proto.mq.CommandMessage.set_screen_power:type_name -> timeterm_proto.mq.SetScreenPowerMessage
+	7,  // 7: timeterm_proto.mq.CommandMessage.upload_logs:type_name -> timeterm_proto.mq.UploadLogsMessage
+	8,  // 8: timeterm_proto.mq.CommandMessage.take_screenshot:type_name -> timeterm_proto.mq.TakeScreenshotMessage
+	2,  // 9: timeterm_proto.mq.CommandResultMessage.status:type_name -> timeterm_proto.mq.CommandStatus
+	3,  // 10: timeterm_proto.mq.AnnouncementMessage.severity:type_name -> timeterm_proto.mq.AnnouncementSeverity
+	11, // [11:11] is the sub-list for method output_type
+	11, // [11:11] is the sub-list for method input_type
+	11, // [11:11] is the sub-list for extension type_name
+	11, // [11:11] is the sub-list for extension extendee
+	0,  // [0:11] is the sub-list for field type_name
 }
 
 func init() { file_mq_mq_proto_init() }
@@ -159,6 +1362,18 @@ func file_mq_mq_proto_init() {
 			}
 		}
 		file_mq_mq_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*RetrieveNewDeviceConfigMessage); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_mq_mq_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*RebootMessage); i {
 			case 0:
 				return &v.state
@@ -170,19 +1385,160 @@ func file_mq_mq_proto_init() {
 				return nil
 			}
 		}
+		file_mq_mq_proto_msgTypes[3].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*UploadLogsMessage); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_mq_mq_proto_msgTypes[4].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*TakeScreenshotMessage); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_mq_mq_proto_msgTypes[5].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SetScreenPowerMessage); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_mq_mq_proto_msgTypes[6].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*StartCardEnrollmentMessage); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_mq_mq_proto_msgTypes[7].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*CancelCardEnrollmentMessage); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_mq_mq_proto_msgTypes[8].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*HeartbeatMessage); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_mq_mq_proto_msgTypes[9].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*DeviceCapabilities); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_mq_mq_proto_msgTypes[10].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*DeviceStatus); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_mq_mq_proto_msgTypes[11].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*CommandMessage); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_mq_mq_proto_msgTypes[12].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*CommandResultMessage); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_mq_mq_proto_msgTypes[13].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*AnnouncementMessage); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	file_mq_mq_proto_msgTypes[10].OneofWrappers = []interface{}{}
+	file_mq_mq_proto_msgTypes[11].OneofWrappers = []interface{}{
+		(*CommandMessage_Reboot)(nil),
+		(*CommandMessage_RetrieveNewNetworkingConfig)(nil),
+		(*CommandMessage_SetScreenPower)(nil),
+		(*CommandMessage_UploadLogs)(nil),
+		(*CommandMessage_TakeScreenshot)(nil),
 	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: file_mq_mq_proto_rawDesc,
-			NumEnums:      0,
-			NumMessages:   2,
+			NumEnums:      4,
+			NumMessages:   14,
 			NumExtensions: 0,
 			NumServices:   0,
 		},
 		GoTypes:           file_mq_mq_proto_goTypes,
 		DependencyIndexes: file_mq_mq_proto_depIdxs,
+		EnumInfos:         file_mq_mq_proto_enumTypes,
 		MessageInfos:      file_mq_mq_proto_msgTypes,
 	}.Build()
 	File_mq_mq_proto = out.File
@@ -327,6 +327,133 @@ func (x *DeviceCredentials) GetNatsCreds() string {
 	return ""
 }
 
+type RevokeDeviceCredentialsRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	DeviceId string `protobuf:"bytes,1,opt,name=device_id,json=deviceId,proto3" json:"device_id,omitempty"`
+}
+
+func (x *RevokeDeviceCredentialsRequest) Reset() {
+	*x = RevokeDeviceCredentialsRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_rpc_rpc_proto_msgTypes[5]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *RevokeDeviceCredentialsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RevokeDeviceCredentialsRequest) ProtoMessage() {}
+
+func (x *RevokeDeviceCredentialsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_rpc_rpc_proto_msgTypes[5]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RevokeDeviceCredentialsRequest.ProtoReflect.Descriptor instead.
+func (*RevokeDeviceCredentialsRequest) Descriptor() ([]byte, []int) {
+	return file_rpc_rpc_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *RevokeDeviceCredentialsRequest) GetDeviceId() string {
+	if x != nil {
+		return x.DeviceId
+	}
+	return ""
+}
+
+type RevokeDeviceCredentialsResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Types that are assignable to Response:
+	//	*RevokeDeviceCredentialsResponse_Error
+	//	*RevokeDeviceCredentialsResponse_Success
+	Response isRevokeDeviceCredentialsResponse_Response `protobuf_oneof:"response"`
+}
+
+func (x *RevokeDeviceCredentialsResponse) Reset() {
+	*x = RevokeDeviceCredentialsResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_rpc_rpc_proto_msgTypes[6]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *RevokeDeviceCredentialsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RevokeDeviceCredentialsResponse) ProtoMessage() {}
+
+func (x *RevokeDeviceCredentialsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_rpc_rpc_proto_msgTypes[6]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RevokeDeviceCredentialsResponse.ProtoReflect.Descriptor instead.
+func (*RevokeDeviceCredentialsResponse) Descriptor() ([]byte, []int) {
+	return file_rpc_rpc_proto_rawDescGZIP(), []int{6}
+}
+
+func (m *RevokeDeviceCredentialsResponse) GetResponse() isRevokeDeviceCredentialsResponse_Response {
+	if m != nil {
+		return m.Response
+	}
+	return nil
+}
+
+func (x *RevokeDeviceCredentialsResponse) GetError() *Error {
+	if x, ok := x.GetResponse().(*RevokeDeviceCredentialsResponse_Error); ok {
+		return x.Error
+	}
+	return nil
+}
+
+func (x *RevokeDeviceCredentialsResponse) GetSuccess() *Empty {
+	if x, ok := x.GetResponse().(*RevokeDeviceCredentialsResponse_Success); ok {
+		return x.Success
+	}
+	return nil
+}
+
+type isRevokeDeviceCredentialsResponse_Response interface {
+	isRevokeDeviceCredentialsResponse_Response()
+}
+
+type RevokeDeviceCredentialsResponse_Error struct {
+	Error *Error `protobuf:"bytes,1,opt,name=error,proto3,oneof"`
+}
+
+type RevokeDeviceCredentialsResponse_Success struct {
+	Success *Empty `protobuf:"bytes,2,opt,name=success,proto3,oneof"`
+}
+
+func (*RevokeDeviceCredentialsResponse_Error) isRevokeDeviceCredentialsResponse_Response() {}
+
+func (*RevokeDeviceCredentialsResponse_Success) isRevokeDeviceCredentialsResponse_Response() {}
+
 type Error struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -338,7 +465,7 @@ type Error struct {
 func (x *Error) Reset() {
 	*x = Error{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_rpc_rpc_proto_msgTypes[5]
+		mi := &file_rpc_rpc_proto_msgTypes[7]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -351,7 +478,7 @@ func (x *Error) String() string {
 func (*Error) ProtoMessage() {}
 
 func (x *Error) ProtoReflect() protoreflect.Message {
-	mi := &file_rpc_rpc_proto_msgTypes[5]
+	mi := &file_rpc_rpc_proto_msgTypes[7]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -364,7 +491,7 @@ func (x *Error) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Error.ProtoReflect.Descriptor instead.
 func (*Error) Descriptor() ([]byte, []int) {
-	return file_rpc_rpc_proto_rawDescGZIP(), []int{5}
+	return file_rpc_rpc_proto_rawDescGZIP(), []int{7}
 }
 
 func (x *Error) GetMessage() string {
@@ -383,7 +510,7 @@ type Empty struct {
 func (x *Empty) Reset() {
 	*x = Empty{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_rpc_rpc_proto_msgTypes[6]
+		mi := &file_rpc_rpc_proto_msgTypes[8]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -396,7 +523,7 @@ func (x *Empty) String() string {
 func (*Empty) ProtoMessage() {}
 
 func (x *Empty) ProtoReflect() protoreflect.Message {
-	mi := &file_rpc_rpc_proto_msgTypes[6]
+	mi := &file_rpc_rpc_proto_msgTypes[8]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -409,7 +536,7 @@ func (x *Empty) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Empty.ProtoReflect.Descriptor instead.
 func (*Empty) Descriptor() ([]byte, []int) {
-	return file_rpc_rpc_proto_rawDescGZIP(), []int{6}
+	return file_rpc_rpc_proto_rawDescGZIP(), []int{8}
 }
 
 var File_rpc_rpc_proto protoreflect.FileDescriptor
@@ -447,14 +574,27 @@ var file_rpc_rpc_proto_rawDesc = []byte{
 	0x08, 0x72, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x32, 0x0a, 0x11, 0x44, 0x65, 0x76,
 	0x69, 0x63, 0x65, 0x43, 0x72, 0x65, 0x64, 0x65, 0x6e, 0x74, 0x69, 0x61, 0x6c, 0x73, 0x12, 0x1d,
 	0x0a, 0x0a, 0x6e, 0x61, 0x74, 0x73, 0x5f, 0x63, 0x72, 0x65, 0x64, 0x73, 0x18, 0x01, 0x20, 0x01,
-	0x28, 0x09, 0x52, 0x09, 0x6e, 0x61, 0x74, 0x73, 0x43, 0x72, 0x65, 0x64, 0x73, 0x22, 0x21, 0x0a,
-	0x05, 0x45, 0x72, 0x72, 0x6f, 0x72, 0x12, 0x18, 0x0a, 0x07, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67,
-	0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65,
-	0x22, 0x07, 0x0a, 0x05, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x42, 0x31, 0x5a, 0x2f, 0x67, 0x69, 0x74,
-	0x6c, 0x61, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x74, 0x69, 0x6d, 0x65, 0x74, 0x65, 0x72, 0x6d,
-	0x2f, 0x74, 0x69, 0x6d, 0x65, 0x74, 0x65, 0x72, 0x6d, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2f,
-	0x67, 0x6f, 0x2f, 0x72, 0x70, 0x63, 0x3b, 0x72, 0x70, 0x63, 0x70, 0x62, 0x62, 0x06, 0x70, 0x72,
-	0x6f, 0x74, 0x6f, 0x33,
+	0x28, 0x09, 0x52, 0x09, 0x6e, 0x61, 0x74, 0x73, 0x43, 0x72, 0x65, 0x64, 0x73, 0x22, 0x3d, 0x0a,
+	0x1e, 0x52, 0x65, 0x76, 0x6f, 0x6b, 0x65, 0x44, 0x65, 0x76, 0x69, 0x63, 0x65, 0x43, 0x72, 0x65,
+	0x64, 0x65, 0x6e, 0x74, 0x69, 0x61, 0x6c, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12,
+	0x1b, 0x0a, 0x09, 0x64, 0x65, 0x76, 0x69, 0x63, 0x65, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x08, 0x64, 0x65, 0x76, 0x69, 0x63, 0x65, 0x49, 0x64, 0x22, 0x97, 0x01, 0x0a,
+	0x1f, 0x52, 0x65, 0x76, 0x6f, 0x6b, 0x65, 0x44, 0x65, 0x76, 0x69, 0x63, 0x65, 0x43, 0x72, 0x65,
+	0x64, 0x65, 0x6e, 0x74, 0x69, 0x61, 0x6c, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65,
+	0x12, 0x31, 0x0a, 0x05, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32,
+	0x19, 0x2e, 0x74, 0x69, 0x6d, 0x65, 0x74, 0x65, 0x72, 0x6d, 0x5f, 0x70, 0x72, 0x6f, 0x74, 0x6f,
+	0x2e, 0x72, 0x70, 0x63, 0x2e, 0x45, 0x72, 0x72, 0x6f, 0x72, 0x48, 0x00, 0x52, 0x05, 0x65, 0x72,
+	0x72, 0x6f, 0x72, 0x12, 0x35, 0x0a, 0x07, 0x73, 0x75, 0x63, 0x63, 0x65, 0x73, 0x73, 0x18, 0x02,
+	0x20, 0x01, 0x28, 0x0b, 0x32, 0x19, 0x2e, 0x74, 0x69, 0x6d, 0x65, 0x74, 0x65, 0x72, 0x6d, 0x5f,
+	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x72, 0x70, 0x63, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x48,
+	0x00, 0x52, 0x07, 0x73, 0x75, 0x63, 0x63, 0x65, 0x73, 0x73, 0x42, 0x0a, 0x0a, 0x08, 0x72, 0x65,
+	0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x21, 0x0a, 0x05, 0x45, 0x72, 0x72, 0x6f, 0x72, 0x12,
+	0x18, 0x0a, 0x07, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x07, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x22, 0x07, 0x0a, 0x05, 0x45, 0x6d, 0x70,
+	0x74, 0x79, 0x42, 0x31, 0x5a, 0x2f, 0x67, 0x69, 0x74, 0x6c, 0x61, 0x62, 0x2e, 0x63, 0x6f, 0x6d,
+	0x2f, 0x74, 0x69, 0x6d, 0x65, 0x74, 0x65, 0x72, 0x6d, 0x2f, 0x74, 0x69, 0x6d, 0x65, 0x74, 0x65,
+	0x72, 0x6d, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2f, 0x67, 0x6f, 0x2f, 0x72, 0x70, 0x63, 0x3b,
+	0x72, 0x70, 0x63, 0x70, 0x62, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
 }
 
 var (
@@ -469,26 +609,30 @@ func file_rpc_rpc_proto_rawDescGZIP() []byte {
 	return file_rpc_rpc_proto_rawDescData
 }
 
-var file_rpc_rpc_proto_msgTypes = make([]protoimpl.MessageInfo, 7)
+var file_rpc_rpc_proto_msgTypes = make([]protoimpl.MessageInfo, 9)
 var file_rpc_rpc_proto_goTypes = []interface{}{
 	(*ProvisionNewDeviceRequest)(nil),         // 0: timeterm_proto.rpc.ProvisionNewDeviceRequest
 	(*ProvisionNewDeviceResponse)(nil),        // 1: timeterm_proto.rpc.ProvisionNewDeviceResponse
 	(*GenerateDeviceCredentialsRequest)(nil),  // 2: timeterm_proto.rpc.GenerateDeviceCredentialsRequest
 	(*GenerateDeviceCredentialsResponse)(nil), // 3: timeterm_proto.rpc.GenerateDeviceCredentialsResponse
 	(*DeviceCredentials)(nil),                 // 4: timeterm_proto.rpc.DeviceCredentials
-	(*Error)(nil),                             // 5: timeterm_proto.rpc.Error
-	(*Empty)(nil),                             // 6: timeterm_proto.rpc.Empty
+	(*RevokeDeviceCredentialsRequest)(nil),    // 5: timeterm_proto.rpc.RevokeDeviceCredentialsRequest
+	(*RevokeDeviceCredentialsResponse)(nil),   // 6: timeterm_proto.rpc.RevokeDeviceCredentialsResponse
+	(*Error)(nil),                             // 7: timeterm_proto.rpc.Error
+	(*Empty)(nil),                             // 8: timeterm_proto.rpc.Empty
 }
 var file_rpc_rpc_proto_depIdxs = []int32{
-	5, // 0: timeterm_proto.rpc.ProvisionNewDeviceResponse.error:type_name -> timeterm_proto.rpc.Error
-	6, // 1: timeterm_proto.rpc.ProvisionNewDeviceResponse.success:type_name -> timeterm_proto.rpc.Empty
-	5, // 2: timeterm_proto.rpc.GenerateDeviceCredentialsResponse.error:type_name -> timeterm_proto.rpc.Error
+	7, // 0: timeterm_proto.rpc.ProvisionNewDeviceResponse.error:type_name -> timeterm_proto.rpc.Error
+	8, // 1: timeterm_proto.rpc.ProvisionNewDeviceResponse.success:type_name -> timeterm_proto.rpc.Empty
+	7, // 2: timeterm_proto.rpc.GenerateDeviceCredentialsResponse.error:type_name -> timeterm_proto.rpc.Error
 	4, // 3: timeterm_proto.rpc.GenerateDeviceCredentialsResponse.sucess:type_name -> timeterm_proto.rpc.DeviceCredentials
-	4, // [4:4] is the sub-list for method output_type
-	4, // [4:4] is the sub-list for method input_type
-	4, // [4:4] is the sub-list for extension type_name
-	4, // [4:4] is the sub-list for extension extendee
-	0, // [0:4] is the sub-list for field type_name
+	7, // 4: timeterm_proto.rpc.RevokeDeviceCredentialsResponse.error:type_name -> timeterm_proto.rpc.Error
+	8, // 5: timeterm_proto.rpc.RevokeDeviceCredentialsResponse.success:type_name -> timeterm_proto.rpc.Empty
+	6, // [6:6] is the sub-list for method output_type
+	6, // [6:6] is the sub-list for method input_type
+	6, // [6:6] is the sub-list for extension type_name
+	6, // [6:6] is the sub-list for extension extendee
+	0, // [0:6] is the sub-list for field type_name
 }
 
 func init() { file_rpc_rpc_proto_init() }
@@ -558,7 +702,7 @@ func file_rpc_rpc_proto_init() {
 			}
 		}
 		file_rpc_rpc_proto_msgTypes[5].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*Error); i {
+			switch v := v.(*RevokeDeviceCredentialsRequest); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -570,6 +714,30 @@ func file_rpc_rpc_proto_init() {
 			}
 		}
 		file_rpc_rpc_proto_msgTypes[6].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*RevokeDeviceCredentialsResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_rpc_rpc_proto_msgTypes[7].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Error); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_rpc_rpc_proto_msgTypes[8].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*Empty); i {
 			case 0:
 				return &v.state
@@ -590,13 +758,17 @@ func file_rpc_rpc_proto_init() {
 		(*GenerateDeviceCredentialsResponse_Error)(nil),
 		(*GenerateDeviceCredentialsResponse_Sucess)(nil),
 	}
+	file_rpc_rpc_proto_msgTypes[6].OneofWrappers = []interface{}{
+		(*RevokeDeviceCredentialsResponse_Error)(nil),
+		(*RevokeDeviceCredentialsResponse_Success)(nil),
+	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: file_rpc_rpc_proto_rawDesc,
 			NumEnums:      0,
-			NumMessages:   7,
+			NumMessages:   9,
 			NumExtensions: 0,
 			NumServices:   0,
 		},